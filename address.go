@@ -6,18 +6,6 @@ import (
 	"net/http"
 )
 
-// Error codes
-const (
-	ErrInvalidParams    = 1001
-	ErrNetworkError     = 1002
-	ErrInvalidResponse  = 1003
-	ErrAPIError         = 1004
-	ErrInvalidAddress   = 1005
-	ErrInvalidSignature = 1006
-	ErrInvalidBlock     = 1007
-	ErrInvalidMethod    = 1008
-)
-
 // DetectAddressResponse represents the response from the /detectAddress endpoint
 type DetectAddressResponse struct {
 	OK     bool `json:"ok"`
@@ -38,10 +26,10 @@ type DetectAddressResponse struct {
 }
 
 // DetectAddress detects the type of a TON address
-func (c *Client) DetectAddress(address string) (*DetectAddressResponse, error) {
+func (h *HTTPBackend) DetectAddress(address string) (*DetectAddressResponse, error) {
 	endpoint := fmt.Sprintf("/detectAddress?address=%s", address)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -84,10 +72,10 @@ type EstimateFeeResponse struct {
 }
 
 // EstimateFee estimates the fee for a transaction
-func (c *Client) EstimateFee(req EstimateFeeRequest) (*EstimateFeeResponse, error) {
+func (h *HTTPBackend) EstimateFee(req EstimateFeeRequest) (*EstimateFeeResponse, error) {
 	endpoint := "/estimateFee"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -111,10 +99,10 @@ type GetAddressBalanceResponse struct {
 }
 
 // GetAddressBalance gets the balance of a TON address
-func (c *Client) GetAddressBalance(address string) (*GetAddressBalanceResponse, error) {
+func (h *HTTPBackend) GetAddressBalance(address string) (*GetAddressBalanceResponse, error) {
 	endpoint := fmt.Sprintf("/getAddressBalance?address=%s", address)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -153,10 +141,10 @@ type GetAddressInformationResponse struct {
 }
 
 // GetAddressInformation gets detailed information about a TON address
-func (c *Client) GetAddressInformation(address string) (*GetAddressInformationResponse, error) {
+func (h *HTTPBackend) GetAddressInformation(address string) (*GetAddressInformationResponse, error) {
 	endpoint := fmt.Sprintf("/getAddressInformation?address=%s", address)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -180,10 +168,10 @@ type GetAddressStateResponse struct {
 }
 
 // GetAddressState gets the state of a TON address
-func (c *Client) GetAddressState(address string) (*GetAddressStateResponse, error) {
+func (h *HTTPBackend) GetAddressState(address string) (*GetAddressStateResponse, error) {
 	endpoint := fmt.Sprintf("/getAddressState?address=%s", address)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
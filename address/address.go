@@ -0,0 +1,168 @@
+// Package address parses and formats TON account addresses.
+package address
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// User-friendly address flags
+const (
+	flagBounceable    = 0x11
+	flagNonBounceable = 0x51
+	flagTestnet       = 0x80
+)
+
+// ErrInvalidAddress is returned for strings that are not valid addresses
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Address is a standard internal address (addr_std without anycast)
+type Address struct {
+	Workchain int32
+	Hash      [32]byte
+	
+	// Bounceable and Testnet are the flags of the user-friendly form
+	Bounceable bool
+	Testnet    bool
+}
+
+// New creates a bounceable address
+func New(workchain int32, hash []byte) *Address {
+	a := &Address{Workchain: workchain, Bounceable: true}
+	copy(a.Hash[:], hash)
+	return a
+}
+
+// Parse parses a raw ("0:<hex>") or user-friendly (base64 or base64url) address
+func Parse(s string) (*Address, error) {
+	if strings.Contains(s, ":") {
+		return ParseRaw(s)
+	}
+	
+	if len(s) != 48 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, s)
+	}
+	data, err := base64.URLEncoding.DecodeString(s)
+	if err != nil {
+		data, err = base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAddress, err)
+		}
+	}
+	
+	if binary.BigEndian.Uint16(data[34:]) != crc16(data[:34]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidAddress)
+	}
+	
+	flags := data[0]
+	a := &Address{
+		Workchain: int32(int8(data[1])),
+		Testnet:   flags&flagTestnet != 0,
+	}
+	switch flags &^ flagTestnet {
+	case flagBounceable:
+		a.Bounceable = true
+	case flagNonBounceable:
+	default:
+		return nil, fmt.Errorf("%w: unknown flags %#x", ErrInvalidAddress, flags)
+	}
+	copy(a.Hash[:], data[2:34])
+	return a, nil
+}
+
+// ParseRaw parses an address in the raw "workchain:hex" form
+func ParseRaw(s string) (*Address, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, s)
+	}
+	
+	wc, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: workchain %q", ErrInvalidAddress, parts[0])
+	}
+	hash, err := hex.DecodeString(parts[1])
+	if err != nil || len(hash) != 32 {
+		return nil, fmt.Errorf("%w: hash %q", ErrInvalidAddress, parts[1])
+	}
+	return New(int32(wc), hash), nil
+}
+
+// MustParse is like Parse but panics on error
+func MustParse(s string) *Address {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// String returns the user-friendly url-safe form using the address flags
+func (a *Address) String() string {
+	return a.Format(a.Bounceable, a.Testnet)
+}
+
+// Format returns the user-friendly url-safe form with the given flags
+func (a *Address) Format(bounceable, testnet bool) string {
+	data := make([]byte, 36)
+	data[0] = flagNonBounceable
+	if bounceable {
+		data[0] = flagBounceable
+	}
+	if testnet {
+		data[0] |= flagTestnet
+	}
+	data[1] = byte(int8(a.Workchain))
+	copy(data[2:], a.Hash[:])
+	binary.BigEndian.PutUint16(data[34:], crc16(data[:34]))
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+// Raw returns the raw "workchain:hex" form
+func (a *Address) Raw() string {
+	return fmt.Sprintf("%d:%s", a.Workchain, hex.EncodeToString(a.Hash[:]))
+}
+
+// Equal reports whether two addresses refer to the same account, ignoring flags
+func (a *Address) Equal(b *Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Workchain == b.Workchain && a.Hash == b.Hash
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (a *Address) UnmarshalText(text []byte) error {
+	p, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*a = *p
+	return nil
+}
+
+// crc16 computes CRC-16/XMODEM
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,330 @@
+package toncenterzp
+
+// Backend executes the typed operations of a Client. HTTPBackend, the default, calls
+// the HTTP API; other implementations can serve the same operations from a fake, a
+// cache, a liteserver connection (see package liteclient) or several providers.
+// Set Client.Backend to swap the implementation without changing calling code.
+//
+// Implementations that only support some operations can embed UnimplementedBackend
+// or another Backend to provide the rest.
+type Backend interface {
+	DetectAddress(address string) (*DetectAddressResponse, error)
+	EstimateFee(req EstimateFeeRequest) (*EstimateFeeResponse, error)
+	GetAddressBalance(address string) (*GetAddressBalanceResponse, error)
+	GetAddressInformation(address string) (*GetAddressInformationResponse, error)
+	GetAddressState(address string) (*GetAddressStateResponse, error)
+	GetBlockHeader(req GetBlockHeaderRequest) (*GetBlockHeaderResponse, error)
+	GetBlockTransactions(req GetBlockTransactionsRequest) (*GetBlockTransactionsResponse, error)
+	GetConsensusBlock(req *GetConsensusBlockRequest) (*GetConsensusBlockResponse, error)
+	GetExtendedAddressInformation(address string) (*GetExtendedAddressInformationResponse, error)
+	GetMasterchainBlockSignatures(req GetMasterchainBlockSignaturesRequest) (*GetMasterchainBlockSignaturesResponse, error)
+	GetMasterchainInfo() (*GetMasterchainInfoResponse, error)
+	GetShardBlockProof(req GetShardBlockProofRequest) (*GetShardBlockProofResponse, error)
+	GetTokenData(address string) (*GetTokenDataResponse, error)
+	GetTransactions(req GetTransactionsRequest) (*GetTransactionsResponse, error)
+	GetWalletInformation(address string) (*GetWalletInformationResponse, error)
+	LookupBlock(req LookupBlockRequest) (*LookupBlockResponse, error)
+	PackAddress(address string) (*PackAddressResponse, error)
+	RunGetMethod(req RunGetMethodRequest) (*RunGetMethodResponse, error)
+	SendBoc(req SendBocRequest) (*SendBocResponse, error)
+	SendBocReturnHash(req SendBocReturnHashRequest) (*SendBocReturnHashResponse, error)
+	SendQuery(req SendQueryRequest) (*SendQueryResponse, error)
+	Shards(seqNo int) (*ShardsResponse, error)
+	TryLocateResultTx(req TryLocateResultTxRequest) (*TryLocateResultTxResponse, error)
+	TryLocateSourceTx(req TryLocateSourceTxRequest) (*TryLocateSourceTxResponse, error)
+	TryLocateTx(req TryLocateTxRequest) (*TryLocateTxResponse, error)
+	UnpackAddress(address string) (*UnpackAddressResponse, error)
+}
+
+// backend returns the configured backend or the HTTP API
+func (c *Client) backend() Backend {
+	if c.Backend != nil {
+		return c.Backend
+	}
+	return &HTTPBackend{client: c}
+}
+
+// HTTPBackend executes operations through the HTTP API of a Client, using its base URL,
+// API key, cache, request coalescing and transport mode
+type HTTPBackend struct {
+	client *Client
+}
+
+// NewHTTPBackend returns the HTTP backend of a client. It ignores Client.Backend, so it
+// can serve as the fallback of another backend installed on the same client.
+func NewHTTPBackend(c *Client) *HTTPBackend {
+	return &HTTPBackend{client: c}
+}
+
+// DetectAddress detects the type of a TON address
+func (c *Client) DetectAddress(address string) (*DetectAddressResponse, error) {
+	return c.backend().DetectAddress(address)
+}
+
+// EstimateFee estimates the fee for a transaction
+func (c *Client) EstimateFee(req EstimateFeeRequest) (*EstimateFeeResponse, error) {
+	return c.backend().EstimateFee(req)
+}
+
+// GetAddressBalance gets the balance of a TON address
+func (c *Client) GetAddressBalance(address string) (*GetAddressBalanceResponse, error) {
+	return c.backend().GetAddressBalance(address)
+}
+
+// GetAddressInformation gets detailed information about a TON address
+func (c *Client) GetAddressInformation(address string) (*GetAddressInformationResponse, error) {
+	return c.backend().GetAddressInformation(address)
+}
+
+// GetAddressState gets the state of a TON address
+func (c *Client) GetAddressState(address string) (*GetAddressStateResponse, error) {
+	return c.backend().GetAddressState(address)
+}
+
+// GetBlockHeader gets the header of a block
+func (c *Client) GetBlockHeader(req GetBlockHeaderRequest) (*GetBlockHeaderResponse, error) {
+	return c.backend().GetBlockHeader(req)
+}
+
+// GetBlockTransactions gets the transactions in a block
+func (c *Client) GetBlockTransactions(req GetBlockTransactionsRequest) (*GetBlockTransactionsResponse, error) {
+	return c.backend().GetBlockTransactions(req)
+}
+
+// GetConsensusBlock gets the consensus block
+func (c *Client) GetConsensusBlock(req *GetConsensusBlockRequest) (*GetConsensusBlockResponse, error) {
+	return c.backend().GetConsensusBlock(req)
+}
+
+// GetExtendedAddressInformation gets extended information about a TON address
+func (c *Client) GetExtendedAddressInformation(address string) (*GetExtendedAddressInformationResponse, error) {
+	return c.backend().GetExtendedAddressInformation(address)
+}
+
+// GetMasterchainBlockSignatures gets the signatures of a masterchain block
+func (c *Client) GetMasterchainBlockSignatures(req GetMasterchainBlockSignaturesRequest) (*GetMasterchainBlockSignaturesResponse, error) {
+	return c.backend().GetMasterchainBlockSignatures(req)
+}
+
+// GetMasterchainInfo gets information about the masterchain
+func (c *Client) GetMasterchainInfo() (*GetMasterchainInfoResponse, error) {
+	return c.backend().GetMasterchainInfo()
+}
+
+// GetShardBlockProof gets the proof of a shard block
+func (c *Client) GetShardBlockProof(req GetShardBlockProofRequest) (*GetShardBlockProofResponse, error) {
+	return c.backend().GetShardBlockProof(req)
+}
+
+// GetTokenData gets data about a token
+func (c *Client) GetTokenData(address string) (*GetTokenDataResponse, error) {
+	return c.backend().GetTokenData(address)
+}
+
+// GetTransactions gets transactions for a TON address
+func (c *Client) GetTransactions(req GetTransactionsRequest) (*GetTransactionsResponse, error) {
+	resp, err := c.backend().GetTransactions(req)
+	if err != nil {
+		return nil, err
+	}
+	c.decodeBodies(resp.Result.Transactions)
+	return resp, nil
+}
+
+// GetWalletInformation gets information about a TON wallet
+func (c *Client) GetWalletInformation(address string) (*GetWalletInformationResponse, error) {
+	return c.backend().GetWalletInformation(address)
+}
+
+// LookupBlock looks up a block by various criteria
+func (c *Client) LookupBlock(req LookupBlockRequest) (*LookupBlockResponse, error) {
+	return c.backend().LookupBlock(req)
+}
+
+// PackAddress packs a TON address
+func (c *Client) PackAddress(address string) (*PackAddressResponse, error) {
+	return c.backend().PackAddress(address)
+}
+
+// RunGetMethod runs a get method on a TON contract
+func (c *Client) RunGetMethod(req RunGetMethodRequest) (*RunGetMethodResponse, error) {
+	return c.backend().RunGetMethod(req)
+}
+
+// SendBoc sends a bag of cells to the TON network
+func (c *Client) SendBoc(req SendBocRequest) (*SendBocResponse, error) {
+	return c.backend().SendBoc(req)
+}
+
+// SendBocReturnHash sends a bag of cells to the TON network and returns the hash
+func (c *Client) SendBocReturnHash(req SendBocReturnHashRequest) (*SendBocReturnHashResponse, error) {
+	return c.backend().SendBocReturnHash(req)
+}
+
+// SendQuery sends a query to the TON network
+func (c *Client) SendQuery(req SendQueryRequest) (*SendQueryResponse, error) {
+	return c.backend().SendQuery(req)
+}
+
+// Shards gets the list of shards
+func (c *Client) Shards(seqNo int) (*ShardsResponse, error) {
+	return c.backend().Shards(seqNo)
+}
+
+// TryLocateResultTx tries to locate a result transaction
+func (c *Client) TryLocateResultTx(req TryLocateResultTxRequest) (*TryLocateResultTxResponse, error) {
+	return c.backend().TryLocateResultTx(req)
+}
+
+// TryLocateSourceTx tries to locate a source transaction
+func (c *Client) TryLocateSourceTx(req TryLocateSourceTxRequest) (*TryLocateSourceTxResponse, error) {
+	return c.backend().TryLocateSourceTx(req)
+}
+
+// TryLocateTx tries to locate a transaction by hash
+func (c *Client) TryLocateTx(req TryLocateTxRequest) (*TryLocateTxResponse, error) {
+	return c.backend().TryLocateTx(req)
+}
+
+// UnpackAddress unpacks a TON address
+func (c *Client) UnpackAddress(address string) (*UnpackAddressResponse, error) {
+	return c.backend().UnpackAddress(address)
+}
+
+// UnimplementedBackend returns an error with code ErrInvalidMethod from every method
+type UnimplementedBackend struct{}
+
+// errUnsupported returns the error for an operation a backend does not support
+func errUnsupported(method string) error {
+	return NewError(ErrInvalidMethod, method+" is not supported by this backend", nil)
+}
+
+// DetectAddress returns an unsupported method error
+func (UnimplementedBackend) DetectAddress(address string) (*DetectAddressResponse, error) {
+	return nil, errUnsupported("DetectAddress")
+}
+
+// EstimateFee returns an unsupported method error
+func (UnimplementedBackend) EstimateFee(req EstimateFeeRequest) (*EstimateFeeResponse, error) {
+	return nil, errUnsupported("EstimateFee")
+}
+
+// GetAddressBalance returns an unsupported method error
+func (UnimplementedBackend) GetAddressBalance(address string) (*GetAddressBalanceResponse, error) {
+	return nil, errUnsupported("GetAddressBalance")
+}
+
+// GetAddressInformation returns an unsupported method error
+func (UnimplementedBackend) GetAddressInformation(address string) (*GetAddressInformationResponse, error) {
+	return nil, errUnsupported("GetAddressInformation")
+}
+
+// GetAddressState returns an unsupported method error
+func (UnimplementedBackend) GetAddressState(address string) (*GetAddressStateResponse, error) {
+	return nil, errUnsupported("GetAddressState")
+}
+
+// GetBlockHeader returns an unsupported method error
+func (UnimplementedBackend) GetBlockHeader(req GetBlockHeaderRequest) (*GetBlockHeaderResponse, error) {
+	return nil, errUnsupported("GetBlockHeader")
+}
+
+// GetBlockTransactions returns an unsupported method error
+func (UnimplementedBackend) GetBlockTransactions(req GetBlockTransactionsRequest) (*GetBlockTransactionsResponse, error) {
+	return nil, errUnsupported("GetBlockTransactions")
+}
+
+// GetConsensusBlock returns an unsupported method error
+func (UnimplementedBackend) GetConsensusBlock(req *GetConsensusBlockRequest) (*GetConsensusBlockResponse, error) {
+	return nil, errUnsupported("GetConsensusBlock")
+}
+
+// GetExtendedAddressInformation returns an unsupported method error
+func (UnimplementedBackend) GetExtendedAddressInformation(address string) (*GetExtendedAddressInformationResponse, error) {
+	return nil, errUnsupported("GetExtendedAddressInformation")
+}
+
+// GetMasterchainBlockSignatures returns an unsupported method error
+func (UnimplementedBackend) GetMasterchainBlockSignatures(req GetMasterchainBlockSignaturesRequest) (*GetMasterchainBlockSignaturesResponse, error) {
+	return nil, errUnsupported("GetMasterchainBlockSignatures")
+}
+
+// GetMasterchainInfo returns an unsupported method error
+func (UnimplementedBackend) GetMasterchainInfo() (*GetMasterchainInfoResponse, error) {
+	return nil, errUnsupported("GetMasterchainInfo")
+}
+
+// GetShardBlockProof returns an unsupported method error
+func (UnimplementedBackend) GetShardBlockProof(req GetShardBlockProofRequest) (*GetShardBlockProofResponse, error) {
+	return nil, errUnsupported("GetShardBlockProof")
+}
+
+// GetTokenData returns an unsupported method error
+func (UnimplementedBackend) GetTokenData(address string) (*GetTokenDataResponse, error) {
+	return nil, errUnsupported("GetTokenData")
+}
+
+// GetTransactions returns an unsupported method error
+func (UnimplementedBackend) GetTransactions(req GetTransactionsRequest) (*GetTransactionsResponse, error) {
+	return nil, errUnsupported("GetTransactions")
+}
+
+// GetWalletInformation returns an unsupported method error
+func (UnimplementedBackend) GetWalletInformation(address string) (*GetWalletInformationResponse, error) {
+	return nil, errUnsupported("GetWalletInformation")
+}
+
+// LookupBlock returns an unsupported method error
+func (UnimplementedBackend) LookupBlock(req LookupBlockRequest) (*LookupBlockResponse, error) {
+	return nil, errUnsupported("LookupBlock")
+}
+
+// PackAddress returns an unsupported method error
+func (UnimplementedBackend) PackAddress(address string) (*PackAddressResponse, error) {
+	return nil, errUnsupported("PackAddress")
+}
+
+// RunGetMethod returns an unsupported method error
+func (UnimplementedBackend) RunGetMethod(req RunGetMethodRequest) (*RunGetMethodResponse, error) {
+	return nil, errUnsupported("RunGetMethod")
+}
+
+// SendBoc returns an unsupported method error
+func (UnimplementedBackend) SendBoc(req SendBocRequest) (*SendBocResponse, error) {
+	return nil, errUnsupported("SendBoc")
+}
+
+// SendBocReturnHash returns an unsupported method error
+func (UnimplementedBackend) SendBocReturnHash(req SendBocReturnHashRequest) (*SendBocReturnHashResponse, error) {
+	return nil, errUnsupported("SendBocReturnHash")
+}
+
+// SendQuery returns an unsupported method error
+func (UnimplementedBackend) SendQuery(req SendQueryRequest) (*SendQueryResponse, error) {
+	return nil, errUnsupported("SendQuery")
+}
+
+// Shards returns an unsupported method error
+func (UnimplementedBackend) Shards(seqNo int) (*ShardsResponse, error) {
+	return nil, errUnsupported("Shards")
+}
+
+// TryLocateResultTx returns an unsupported method error
+func (UnimplementedBackend) TryLocateResultTx(req TryLocateResultTxRequest) (*TryLocateResultTxResponse, error) {
+	return nil, errUnsupported("TryLocateResultTx")
+}
+
+// TryLocateSourceTx returns an unsupported method error
+func (UnimplementedBackend) TryLocateSourceTx(req TryLocateSourceTxRequest) (*TryLocateSourceTxResponse, error) {
+	return nil, errUnsupported("TryLocateSourceTx")
+}
+
+// TryLocateTx returns an unsupported method error
+func (UnimplementedBackend) TryLocateTx(req TryLocateTxRequest) (*TryLocateTxResponse, error) {
+	return nil, errUnsupported("TryLocateTx")
+}
+
+// UnpackAddress returns an unsupported method error
+func (UnimplementedBackend) UnpackAddress(address string) (*UnpackAddressResponse, error) {
+	return nil, errUnsupported("UnpackAddress")
+}
@@ -0,0 +1,47 @@
+package toncenterzp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeBackend serves balances from a map and leaves the other operations unsupported
+type fakeBackend struct {
+	UnimplementedBackend
+	balances map[string]string
+}
+
+func (f *fakeBackend) GetAddressBalance(address string) (*GetAddressBalanceResponse, error) {
+	b, ok := f.balances[address]
+	if !ok {
+		return nil, NewError(ErrInvalidAddress, "unknown address", nil)
+	}
+	return &GetAddressBalanceResponse{OK: true, Result: b}, nil
+}
+
+func TestBackend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":"999"}`))
+	}))
+	defer srv.Close()
+	c := NewClientWithOptions("", srv.URL, DefaultTimeout)
+	c.Backend = &fakeBackend{balances: map[string]string{"EQabc": "100"}}
+	
+	if b, err := c.GetAddressBalance("EQabc"); err != nil || b.Result != "100" {
+		t.Errorf("balance from the backend %+v, %v", b, err)
+	}
+	
+	// Operations the backend does not implement fail with ErrInvalidMethod
+	_, err := c.GetMasterchainInfo()
+	var coded *ErrorWithCode
+	if !errors.As(err, &coded) || coded.Code != ErrInvalidMethod {
+		t.Errorf("unimplemented operation: error %v, want code ErrInvalidMethod", err)
+	}
+	
+	// The HTTP backend of the client bypasses the installed one
+	if b, err := NewHTTPBackend(c).GetAddressBalance("EQabc"); err != nil || b.Result != "999" {
+		t.Errorf("balance from the HTTP API %+v, %v", b, err)
+	}
+}
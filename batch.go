@@ -0,0 +1,193 @@
+package toncenterzp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// JSONRPCCall describes a single call of a JSON-RPC batch
+type JSONRPCCall struct {
+	Method string
+	Params interface{}
+}
+
+// JSONRPCBatch sends several JSON-RPC requests in one POST to /jsonRPC.
+// Every call gets a unique ID and the responses are returned in the order of calls,
+// regardless of the order the server answered in. A call that failed, including one
+// answered with "ok": false or not answered at all, has its Error set; the returned
+// error is only non-nil when the batch as a whole failed.
+func (c *Client) JSONRPCBatch(calls []JSONRPCCall) ([]*JSONRPCResponse, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	
+	reqs := make([]JSONRPCRequest, len(calls))
+	index := make(map[int]int, len(calls))
+	for i, call := range calls {
+		reqs[i] = JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  call.Method,
+			Params:  call.Params,
+			ID:      nextJSONRPCID(),
+		}
+		index[reqs[i].ID] = i
+	}
+	
+	respBody, err := c.doRequest(http.MethodPost, EndpointJSONRPC, reqs)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Entries are decoded one by one so that a call reporting its error in the
+	// toncenter form does not fail the whole batch
+	var entries []json.RawMessage
+	if err := json.Unmarshal(respBody, &entries); err != nil {
+		// Some providers answer a rejected batch with a single error object
+		var single jsonRPCEnvelope
+		if json.Unmarshal(respBody, &single) == nil {
+			if rpcErr := single.rpcError(); rpcErr != nil {
+				return nil, fmt.Errorf("JSON-RPC error: %s (code: %d)", rpcErr.Message, rpcErr.Code)
+			}
+		}
+		return nil, fmt.Errorf("error unmarshaling response: %w (code: %d)", err, ErrInvalidResponse)
+	}
+	
+	results := make([]*JSONRPCResponse, len(calls))
+	for _, entry := range entries {
+		var env jsonRPCEnvelope
+		if json.Unmarshal(entry, &env) != nil || env.ID == nil {
+			continue
+		}
+		pos, ok := index[*env.ID]
+		if !ok || results[pos] != nil {
+			continue
+		}
+		
+		resp := &JSONRPCResponse{JSONRPC: "2.0", ID: *env.ID, Error: env.rpcError()}
+		if resp.Error == nil && env.OK != nil && !*env.OK {
+			resp.Error = &JSONRPCError{Code: ErrAPIError, Message: "API returned non-OK status"}
+		}
+		if resp.Error == nil {
+			resp.Result = env.Result
+		}
+		results[pos] = resp
+	}
+	
+	for i, result := range results {
+		if result == nil {
+			results[i] = &JSONRPCResponse{
+				JSONRPC: "2.0",
+				ID:      reqs[i].ID,
+				Error: &JSONRPCError{
+					Code:    ErrInvalidResponse,
+					Message: fmt.Sprintf("no response for request id %d", reqs[i].ID),
+				},
+			}
+		}
+	}
+	
+	return results, nil
+}
+
+// BatchAddressBalance is the result of one address in BatchGetAddressBalance
+type BatchAddressBalance struct {
+	Address string
+	Balance string
+	Err     error
+}
+
+// BatchGetAddressBalance fetches the balances of many addresses in a single round trip
+func (c *Client) BatchGetAddressBalance(addresses []string) ([]BatchAddressBalance, error) {
+	responses, err := c.JSONRPCBatch(addressCalls("getAddressBalance", addresses))
+	if err != nil {
+		return nil, err
+	}
+	
+	results := make([]BatchAddressBalance, len(addresses))
+	for i, resp := range responses {
+		results[i].Address = addresses[i]
+		results[i].Err = decodeBatchResult(resp, &results[i].Balance)
+	}
+	
+	return results, nil
+}
+
+// BatchAddressInformation is the result of one address in BatchGetAddressInformation
+type BatchAddressInformation struct {
+	Address     string
+	Information *GetAddressInformationResponse
+	Err         error
+}
+
+// BatchGetAddressInformation fetches the information of many addresses in a single round trip
+func (c *Client) BatchGetAddressInformation(addresses []string) ([]BatchAddressInformation, error) {
+	responses, err := c.JSONRPCBatch(addressCalls("getAddressInformation", addresses))
+	if err != nil {
+		return nil, err
+	}
+	
+	results := make([]BatchAddressInformation, len(addresses))
+	for i, resp := range responses {
+		results[i].Address = addresses[i]
+		info := &GetAddressInformationResponse{OK: true}
+		if err := decodeBatchResult(resp, &info.Result); err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Information = info
+	}
+	
+	return results, nil
+}
+
+// BatchWalletInformation is the result of one address in BatchGetWalletInformation
+type BatchWalletInformation struct {
+	Address     string
+	Information *GetWalletInformationResponse
+	Err         error
+}
+
+// BatchGetWalletInformation fetches the wallet information of many addresses in a single round trip
+func (c *Client) BatchGetWalletInformation(addresses []string) ([]BatchWalletInformation, error) {
+	responses, err := c.JSONRPCBatch(addressCalls("getWalletInformation", addresses))
+	if err != nil {
+		return nil, err
+	}
+	
+	results := make([]BatchWalletInformation, len(addresses))
+	for i, resp := range responses {
+		results[i].Address = addresses[i]
+		info := &GetWalletInformationResponse{OK: true}
+		if err := decodeBatchResult(resp, &info.Result); err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Information = info
+	}
+	
+	return results, nil
+}
+
+// addressCalls builds one JSON-RPC call per address for a method taking a single address parameter
+func addressCalls(method string, addresses []string) []JSONRPCCall {
+	calls := make([]JSONRPCCall, len(addresses))
+	for i, address := range addresses {
+		calls[i] = JSONRPCCall{
+			Method: method,
+			Params: map[string]interface{}{"address": address},
+		}
+	}
+	return calls
+}
+
+// decodeBatchResult converts a batch response into either a decoded result or an error
+func decodeBatchResult(resp *JSONRPCResponse, out interface{}) error {
+	if resp.Error != nil {
+		return fmt.Errorf("JSON-RPC error: %s (code: %d)", resp.Error.Message, resp.Error.Code)
+	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		return fmt.Errorf("error unmarshaling response: %w (code: %d)", err, ErrInvalidResponse)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package toncenterzp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// batchServer answers every JSON-RPC batch with the entries built by answer from the
+// request IDs
+func batchServer(t *testing.T, answer func(ids []int) []string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []JSONRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			t.Errorf("decoding batch: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ids := make([]int, len(reqs))
+		for i, req := range reqs {
+			ids[i] = req.ID
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(answer(ids), ","))
+	}))
+	t.Cleanup(srv.Close)
+	return NewClientWithOptions("", srv.URL, DefaultTimeout)
+}
+
+func TestBatchGetAddressBalanceOutOfOrder(t *testing.T) {
+	c := batchServer(t, func(ids []int) []string {
+		return []string{
+			fmt.Sprintf(`{"ok":true,"result":"300","id":%d}`, ids[2]),
+			fmt.Sprintf(`{"ok":true,"result":"100","id":%d}`, ids[0]),
+			fmt.Sprintf(`{"ok":true,"result":"200","id":%d}`, ids[1]),
+		}
+	})
+	
+	results, err := c.BatchGetAddressBalance([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"100", "200", "300"} {
+		if results[i].Err != nil {
+			t.Fatalf("result %d: %v", i, results[i].Err)
+		}
+		if results[i].Balance != want {
+			t.Errorf("result %d: balance %q, want %q", i, results[i].Balance, want)
+		}
+	}
+}
+
+func TestBatchGetAddressBalanceMissingID(t *testing.T) {
+	c := batchServer(t, func(ids []int) []string {
+		return []string{
+			fmt.Sprintf(`{"ok":true,"result":"100","id":%d}`, ids[0]),
+			`{"ok":true,"result":"200"}`,
+		}
+	})
+	
+	results, err := c.BatchGetAddressBalance([]string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err != nil || results[0].Balance != "100" {
+		t.Errorf("result 0: %q, %v", results[0].Balance, results[0].Err)
+	}
+	if results[1].Err == nil {
+		t.Errorf("result 1: expected an error for the unanswered call, got balance %q", results[1].Balance)
+	}
+}
+
+func TestBatchGetAddressBalanceStringError(t *testing.T) {
+	c := batchServer(t, func(ids []int) []string {
+		return []string{
+			fmt.Sprintf(`{"ok":false,"error":"LITE_SERVER_UNKNOWN: cannot load account","code":500,"id":%d}`, ids[0]),
+			fmt.Sprintf(`{"ok":true,"result":"200","id":%d}`, ids[1]),
+			fmt.Sprintf(`{"ok":false,"id":%d}`, ids[2]),
+		}
+	})
+	
+	results, err := c.BatchGetAddressBalance([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "cannot load account") || !strings.Contains(results[0].Err.Error(), "500") {
+		t.Errorf("result 0: error %v, want the string error with code 500", results[0].Err)
+	}
+	if results[1].Err != nil || results[1].Balance != "200" {
+		t.Errorf("result 1: %q, %v", results[1].Balance, results[1].Err)
+	}
+	if results[2].Err == nil {
+		t.Error("result 2: expected an error for ok:false")
+	}
+}
+
+func TestJSONRPCBatchObjectError(t *testing.T) {
+	c := batchServer(t, func(ids []int) []string {
+		return []string{fmt.Sprintf(`{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found"},"id":%d}`, ids[0])}
+	})
+	
+	responses, err := c.JSONRPCBatch([]JSONRPCCall{{Method: "nope"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := responses[0].Error; e == nil || e.Code != -32601 || e.Message != "method not found" {
+		t.Errorf("error %+v, want code -32601", e)
+	}
+}
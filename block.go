@@ -38,10 +38,10 @@ type GetBlockHeaderResponse struct {
 }
 
 // GetBlockHeader gets the header of a block
-func (c *Client) GetBlockHeader(req GetBlockHeaderRequest) (*GetBlockHeaderResponse, error) {
+func (h *HTTPBackend) GetBlockHeader(req GetBlockHeaderRequest) (*GetBlockHeaderResponse, error) {
 	endpoint := "/getBlockHeader"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -91,10 +91,10 @@ type GetBlockTransactionsResponse struct {
 }
 
 // GetBlockTransactions gets the transactions in a block
-func (c *Client) GetBlockTransactions(req GetBlockTransactionsRequest) (*GetBlockTransactionsResponse, error) {
+func (h *HTTPBackend) GetBlockTransactions(req GetBlockTransactionsRequest) (*GetBlockTransactionsResponse, error) {
 	endpoint := "/getBlockTransactions"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -136,10 +136,10 @@ type GetConsensusBlockResponse struct {
 }
 
 // GetConsensusBlock gets the consensus block
-func (c *Client) GetConsensusBlock(req *GetConsensusBlockRequest) (*GetConsensusBlockResponse, error) {
+func (h *HTTPBackend) GetConsensusBlock(req *GetConsensusBlockRequest) (*GetConsensusBlockResponse, error) {
 	endpoint := "/getConsensusBlock"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -194,10 +194,10 @@ type GetExtendedAddressInformationResponse struct {
 }
 
 // GetExtendedAddressInformation gets extended information about a TON address
-func (c *Client) GetExtendedAddressInformation(address string) (*GetExtendedAddressInformationResponse, error) {
+func (h *HTTPBackend) GetExtendedAddressInformation(address string) (*GetExtendedAddressInformationResponse, error) {
 	endpoint := fmt.Sprintf("/getExtendedAddressInformation?address=%s", address)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
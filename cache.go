@@ -0,0 +1,175 @@
+package toncenterzp
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// CacheForever is the TTL for responses that never change once the API returned them
+	CacheForever time.Duration = 0
+	
+	// DefaultCacheMaxEntries is the default entry limit of the in-memory LRU cache
+	DefaultCacheMaxEntries = 10000
+	
+	// DefaultCacheMaxBytes is the default size limit of the in-memory LRU cache
+	DefaultCacheMaxBytes = 64 << 20
+)
+
+// Cache stores raw API responses keyed by endpoint and request parameters.
+// Implementations must be safe for concurrent use; a Redis or memcached
+// backed implementation only needs to provide these two methods.
+type Cache interface {
+	// Get returns the cached value for key, if present and not expired
+	Get(key string) ([]byte, bool)
+	
+	// Set stores value under key. A ttl of CacheForever means the entry never expires.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// DefaultCachePolicies returns the per-endpoint TTLs used when Client.CachePolicies is nil.
+// Only immutable data is cached: block headers, block transactions, located
+// transactions and shard lists of existing masterchain blocks.
+func DefaultCachePolicies() map[string]time.Duration {
+	return map[string]time.Duration{
+		EndpointGetBlockHeader:       CacheForever,
+		EndpointGetBlockTransactions: CacheForever,
+		EndpointTryLocateTx:          CacheForever,
+		EndpointShards:               CacheForever,
+	}
+}
+
+// uncacheable lists the endpoints that change the network state; they are never
+// cached, even when listed in Client.CachePolicies
+var uncacheable = map[string]bool{
+	EndpointSendBoc:           true,
+	EndpointSendBocReturnHash: true,
+	EndpointSendQuery:         true,
+}
+
+// cachePolicy returns the cache key and TTL for a request, or false if the request must not be cached
+func (c *Client) cachePolicy(method, endpoint string, jsonBody []byte) (string, time.Duration, bool) {
+	if c.Cache == nil || uncacheable[endpointPath(endpoint)] {
+		return "", 0, false
+	}
+	
+	policies := c.CachePolicies
+	if policies == nil {
+		policies = DefaultCachePolicies()
+	}
+	
+	ttl, ok := policies[endpointPath(endpoint)]
+	if !ok {
+		return "", 0, false
+	}
+	
+	return method + " " + endpoint + " " + string(jsonBody), ttl, true
+}
+
+// isOKResponse reports whether a response body carries "ok": true
+func isOKResponse(respBody []byte) bool {
+	var okResp struct {
+		OK bool `json:"ok"`
+	}
+	return json.Unmarshal(respBody, &okResp) == nil && okResp.OK
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used entries
+// once either the entry limit or the size limit is exceeded
+type LRUCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	size       int64
+	order      *list.List
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUCache creates an LRU cache limited to maxEntries entries and maxBytes bytes of values.
+// A non-positive limit falls back to the corresponding default.
+func NewLRUCache(maxEntries int, maxBytes int64) *LRUCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultCacheMaxBytes
+	}
+	
+	return &LRUCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get returns the cached value for key
+func (l *LRUCache) Get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	
+	entry := elem.Value.(*lruEntry)
+	if !entry.expires.IsZero() && l.now().After(entry.expires) {
+		l.remove(elem)
+		return nil, false
+	}
+	
+	l.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set stores value under key, evicting old entries as needed
+func (l *LRUCache) Set(key string, value []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	if int64(len(value)) > l.maxBytes {
+		return
+	}
+	
+	var expires time.Time
+	if ttl > 0 {
+		expires = l.now().Add(ttl)
+	}
+	
+	if elem, ok := l.entries[key]; ok {
+		l.remove(elem)
+	}
+	
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	l.size += int64(len(value))
+	
+	for l.order.Len() > l.maxEntries || l.size > l.maxBytes {
+		l.remove(l.order.Back())
+	}
+}
+
+// Len returns the number of cached entries
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// remove deletes an element from the cache; the caller must hold the lock
+func (l *LRUCache) remove(elem *list.Element) {
+	entry := elem.Value.(*lruEntry)
+	l.order.Remove(elem)
+	delete(l.entries, entry.key)
+	l.size -= int64(len(entry.value))
+}
@@ -0,0 +1,198 @@
+package toncenterzp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	l := NewLRUCache(2, 0)
+	l.Set("a", []byte("1"), CacheForever)
+	l.Set("b", []byte("2"), CacheForever)
+	
+	// Reading a makes b the least recently used entry
+	if v, ok := l.Get("a"); !ok || string(v) != "1" {
+		t.Fatalf("get a: %q, %v", v, ok)
+	}
+	l.Set("c", []byte("3"), CacheForever)
+	if _, ok := l.Get("b"); ok {
+		t.Error("b not evicted")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := l.Get(k); !ok {
+			t.Errorf("%s evicted", k)
+		}
+	}
+	if l.Len() != 2 {
+		t.Errorf("%d entries, want 2", l.Len())
+	}
+}
+
+func TestLRUCacheMaxBytes(t *testing.T) {
+	l := NewLRUCache(10, 8)
+	l.Set("a", []byte("1234"), CacheForever)
+	l.Set("b", []byte("5678"), CacheForever)
+	l.Set("a", []byte("12"), CacheForever) // replacing an entry frees its size
+	l.Set("c", []byte("90"), CacheForever)
+	if l.Len() != 3 {
+		t.Fatalf("%d entries, want 3", l.Len())
+	}
+	
+	// Going over the size limit evicts from the least recently used end
+	l.Set("d", []byte("xyz"), CacheForever)
+	if _, ok := l.Get("b"); ok {
+		t.Error("b not evicted")
+	}
+	for _, k := range []string{"a", "c", "d"} {
+		if _, ok := l.Get(k); !ok {
+			t.Errorf("%s evicted", k)
+		}
+	}
+	
+	// Values over the limit are not stored at all
+	l.Set("big", []byte("123456789"), CacheForever)
+	if _, ok := l.Get("big"); ok || l.Len() != 3 {
+		t.Errorf("oversized value cached, %d entries", l.Len())
+	}
+}
+
+func TestLRUCacheTTL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	l := NewLRUCache(0, 0)
+	l.now = func() time.Time { return now }
+	
+	l.Set("short", []byte("1"), time.Minute)
+	l.Set("forever", []byte("2"), CacheForever)
+	
+	now = now.Add(time.Minute)
+	if _, ok := l.Get("short"); !ok {
+		t.Error("entry expired before its TTL")
+	}
+	now = now.Add(time.Second)
+	if _, ok := l.Get("short"); ok {
+		t.Error("entry not expired after its TTL")
+	}
+	if l.Len() != 1 {
+		t.Errorf("expired entry kept, %d entries", l.Len())
+	}
+	
+	now = now.Add(365 * 24 * time.Hour)
+	if _, ok := l.Get("forever"); !ok {
+		t.Error("CacheForever entry expired")
+	}
+}
+
+// countingServer counts the requests per path and answers them with a successful
+// response, or with an ok:false error if failing is set
+func countingServer(t *testing.T, failing bool) (*Client, map[string]*int32) {
+	t.Helper()
+	bodies := map[string]string{
+		EndpointGetBlockHeader:    `{"ok":true,"result":{}}`,
+		EndpointSendBoc:           `{"ok":true,"result":{}}`,
+		EndpointGetAddressBalance: `{"ok":true,"result":"1500"}`,
+		EndpointShards:            `{"ok":true,"result":{"shards":[]}}`,
+	}
+	counts := map[string]*int32{}
+	for p := range bodies {
+		counts[p] = new(int32)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(counts[r.URL.Path], 1)
+		if failing {
+			w.Write([]byte(`{"ok":false,"error":"not found","code":404}`))
+			return
+		}
+		w.Write([]byte(bodies[r.URL.Path]))
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClientWithOptions("", srv.URL, DefaultTimeout)
+	c.Cache = NewLRUCache(0, 0)
+	return c, counts
+}
+
+func TestCachePolicies(t *testing.T) {
+	c, counts := countingServer(t, false)
+	
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetBlockHeader(GetBlockHeaderRequest{Workchain: -1, Shard: "-9223372036854775808", SeqNo: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.SendBoc(SendBocRequest{Boc: "te6cc"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.GetAddressBalance("EQabc"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(counts[EndpointGetBlockHeader]); n != 1 {
+		t.Errorf("getBlockHeader sent %d times, want 1", n)
+	}
+	if n := atomic.LoadInt32(counts[EndpointSendBoc]); n != 3 {
+		t.Errorf("sendBoc sent %d times, want 3", n)
+	}
+	if n := atomic.LoadInt32(counts[EndpointGetAddressBalance]); n != 3 {
+		t.Errorf("getAddressBalance sent %d times without a policy, want 3", n)
+	}
+	
+	// The key includes the parameters: another block is a new request
+	if _, err := c.GetBlockHeader(GetBlockHeaderRequest{Workchain: -1, Shard: "-9223372036854775808", SeqNo: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Shards(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Shards(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Shards(1); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(counts[EndpointGetBlockHeader]); n != 2 {
+		t.Errorf("getBlockHeader sent %d times, want 2", n)
+	}
+	if n := atomic.LoadInt32(counts[EndpointShards]); n != 2 {
+		t.Errorf("shards sent %d times, want 2", n)
+	}
+}
+
+func TestCachePoliciesCustom(t *testing.T) {
+	c, counts := countingServer(t, false)
+	c.CachePolicies = map[string]time.Duration{
+		EndpointGetAddressBalance: time.Minute,
+		EndpointSendBoc:           time.Minute, // ignored: sendBoc is never cached
+	}
+	
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetAddressBalance("EQabc"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.SendBoc(SendBocRequest{Boc: "te6cc"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.GetBlockHeader(GetBlockHeaderRequest{SeqNo: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(counts[EndpointGetAddressBalance]); n != 1 {
+		t.Errorf("getAddressBalance sent %d times, want 1", n)
+	}
+	if n := atomic.LoadInt32(counts[EndpointSendBoc]); n != 2 {
+		t.Errorf("sendBoc sent %d times, want 2", n)
+	}
+	if n := atomic.LoadInt32(counts[EndpointGetBlockHeader]); n != 2 {
+		t.Errorf("getBlockHeader sent %d times without a policy, want 2", n)
+	}
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	c, counts := countingServer(t, true)
+	for i := 0; i < 2; i++ {
+		c.GetBlockHeader(GetBlockHeaderRequest{SeqNo: 1})
+	}
+	if n := atomic.LoadInt32(counts[EndpointGetBlockHeader]); n != 2 {
+		t.Errorf("failed getBlockHeader sent %d times, want 2", n)
+	}
+}
@@ -0,0 +1,55 @@
+package cell
+
+import (
+	"fmt"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+)
+
+// StoreAddress stores a MsgAddress: addr_none for nil, otherwise addr_std
+func (b *Builder) StoreAddress(a *address.Address) *Builder {
+	if a == nil {
+		return b.StoreUInt(0, 2)
+	}
+	return b.StoreUInt(0b100, 3).StoreInt(int64(a.Workchain), 8).StoreBytes(a.Hash[:])
+}
+
+// LoadAddress reads a MsgAddress. It returns nil for addr_none and external
+// addresses; anycast prefixes are skipped.
+func (s *Slice) LoadAddress() *address.Address {
+	switch s.LoadUInt(2) {
+	case 0b00:
+		return nil
+	case 0b01:
+		s.Skip(int(s.LoadUInt(9)))
+		return nil
+	case 0b10:
+		s.skipAnycast()
+		wc := s.LoadInt(8)
+		hash := s.LoadBytes(32)
+		if s.err != nil {
+			return nil
+		}
+		return address.New(int32(wc), hash)
+	default:
+		s.skipAnycast()
+		n := int(s.LoadUInt(9))
+		wc := s.LoadInt(32)
+		hash := s.LoadBits(n)
+		if s.err != nil {
+			return nil
+		}
+		if n != 256 {
+			s.fail(fmt.Errorf("unsupported addr_var length %d", n))
+			return nil
+		}
+		return address.New(int32(wc), hash)
+	}
+}
+
+// skipAnycast skips a Maybe Anycast field
+func (s *Slice) skipAnycast() {
+	if s.LoadBit() {
+		s.Skip(int(s.LoadUInt(5)))
+	}
+}
@@ -0,0 +1,277 @@
+package cell
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Augmentation describes the extra values (Y) of an augmented dictionary
+type Augmentation struct {
+	// Skip reads past an extra value; it is required to read the dictionary
+	Skip func(s *Slice)
+	
+	// Combine returns the extra value of a fork from those of its children and
+	// Empty is the extra value of an empty HashmapAugE; both are only needed to
+	// serialize a modified dictionary
+	Combine func(left, right *Slice) (*Cell, error)
+	Empty   *Cell
+}
+
+// augEntry is a value of an augmented dictionary with its extra value
+type augEntry struct {
+	value *Cell
+	extra *Cell
+}
+
+// AugDict is an augmented dictionary (HashmapAug n X Y), in which every leaf and
+// fork also stores an extra value, e.g. the total balance of the accounts below it.
+// Keys work as in Dict.
+type AugDict struct {
+	keyLen  int
+	aug     *Augmentation
+	root    *Cell
+	extra   *Slice
+	entries map[string]augEntry // nil until the dictionary is modified
+}
+
+// NewAugDict returns an empty augmented dictionary with keys of keyLen bits
+func NewAugDict(keyLen int, aug *Augmentation) *AugDict {
+	return &AugDict{keyLen: keyLen, aug: aug, entries: map[string]augEntry{}}
+}
+
+// LoadAugDict wraps the root cell of a HashmapAug with keys of keyLen bits; a nil
+// root is an empty dictionary
+func LoadAugDict(root *Cell, keyLen int, aug *Augmentation) *AugDict {
+	return &AugDict{keyLen: keyLen, aug: aug, root: root}
+}
+
+// LoadAugDict reads a HashmapAugE with keys of keyLen bits
+func (s *Slice) LoadAugDict(keyLen int, aug *Augmentation) *AugDict {
+	d := LoadAugDict(s.LoadMaybeRef(), keyLen, aug)
+	start := s.Copy()
+	aug.Skip(s)
+	d.extra = prefixOf(start, s)
+	return d
+}
+
+// StoreAugDict stores an augmented dictionary as a HashmapAugE
+func (b *Builder) StoreAugDict(d *AugDict) *Builder {
+	root, extra, err := d.ToCell()
+	if err != nil {
+		return b.fail(err)
+	}
+	return b.StoreMaybeRef(root).StoreSlice(extra.BeginParse())
+}
+
+// KeyLen returns the key length in bits
+func (d *AugDict) KeyLen() int {
+	return d.keyLen
+}
+
+// IsEmpty reports whether the dictionary has no entries
+func (d *AugDict) IsEmpty() bool {
+	if d.entries != nil {
+		return len(d.entries) == 0
+	}
+	return d.root == nil
+}
+
+// Extra returns the extra value of the whole dictionary as read by LoadAugDict or
+// computed for its entries
+func (d *AugDict) Extra() (*Slice, error) {
+	if d.entries == nil && d.extra != nil {
+		return d.extra.Copy(), nil
+	}
+	_, extra, err := d.ToCell()
+	if err != nil {
+		return nil, err
+	}
+	return extra.BeginParse(), nil
+}
+
+// Get returns the value of key and its extra value, nil if the key is absent
+func (d *AugDict) Get(key []byte) (value, extra *Slice, err error) {
+	k, err := normKey(key, d.keyLen)
+	if err != nil {
+		return nil, nil, err
+	}
+	if d.entries != nil {
+		e, ok := d.entries[string(k)]
+		if !ok {
+			return nil, nil, nil
+		}
+		return e.value.BeginParse(), e.extra.BeginParse(), nil
+	}
+	if d.root == nil {
+		return nil, nil, nil
+	}
+	s, err := dictLookup(d.root, k, d.keyLen)
+	if err != nil || s == nil {
+		return nil, nil, err
+	}
+	return d.split(s)
+}
+
+// ForEach calls fn for every entry in ascending key order
+func (d *AugDict) ForEach(fn func(key []byte, value, extra *Slice) error) error {
+	if d.entries == nil {
+		if d.root == nil {
+			return nil
+		}
+		return dictForEach(d.root, d.keyLen, func(key []byte, s *Slice) error {
+			value, extra, err := d.split(s)
+			if err != nil {
+				return err
+			}
+			return fn(key, value, extra)
+		})
+	}
+	for _, k := range d.sortedKeys() {
+		e := d.entries[k]
+		if err := fn([]byte(k), e.value.BeginParse(), e.extra.BeginParse()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set sets the value of key and its extra value
+func (d *AugDict) Set(key []byte, value, extra *Cell) error {
+	k, err := normKey(key, d.keyLen)
+	if err != nil {
+		return err
+	}
+	if value == nil || extra == nil {
+		return fmt.Errorf("cell: nil dictionary value")
+	}
+	if err := d.load(); err != nil {
+		return err
+	}
+	d.entries[string(k)] = augEntry{value: value, extra: extra}
+	return nil
+}
+
+// Delete removes key and reports whether it was present
+func (d *AugDict) Delete(key []byte) (bool, error) {
+	k, err := normKey(key, d.keyLen)
+	if err != nil {
+		return false, err
+	}
+	if err := d.load(); err != nil {
+		return false, err
+	}
+	_, ok := d.entries[string(k)]
+	delete(d.entries, string(k))
+	return ok, nil
+}
+
+// ToCell returns the root cell of the HashmapAug, nil for an empty dictionary, and
+// the extra value of the whole dictionary
+func (d *AugDict) ToCell() (root, extra *Cell, err error) {
+	if d.entries == nil {
+		if d.root == nil {
+			return d.emptyExtra()
+		}
+		if d.extra != nil {
+			extra, err = d.extra.Copy().ToCell()
+			return d.root, extra, err
+		}
+		extra, err = d.rootExtra()
+		return d.root, extra, err
+	}
+	if len(d.entries) == 0 {
+		return d.emptyExtra()
+	}
+	if d.aug.Combine == nil {
+		return nil, nil, fmt.Errorf("cell: augmentation without Combine")
+	}
+	return dictBuild(d.sortedKeys(), d.keyLen, func(key string, b *Builder) (*Cell, error) {
+		// ahmn_leaf extra:Y value:X
+		e := d.entries[key]
+		b.StoreSlice(e.extra.BeginParse()).StoreSlice(e.value.BeginParse())
+		return e.extra, nil
+	}, d.aug.Combine)
+}
+
+// rootExtra reads the extra value stored in the root node
+func (d *AugDict) rootExtra() (*Cell, error) {
+	s, err := parseNode(d.root)
+	if err != nil {
+		return nil, err
+	}
+	_, n := loadLabel(s, d.keyLen)
+	if n < d.keyLen {
+		// ahmn_fork left:^(HashmapAug n X Y) right:^(HashmapAug n X Y) extra:Y
+		s.LoadRef()
+		s.LoadRef()
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	_, extra, err := d.split(s)
+	if err != nil {
+		return nil, err
+	}
+	return extra.ToCell()
+}
+
+// emptyExtra returns the extra value of an empty dictionary
+func (d *AugDict) emptyExtra() (*Cell, *Cell, error) {
+	if d.aug.Empty == nil {
+		return nil, nil, fmt.Errorf("cell: augmentation without Empty")
+	}
+	return nil, d.aug.Empty, nil
+}
+
+// split separates a leaf after its label into the extra value and the value
+func (d *AugDict) split(s *Slice) (value, extra *Slice, err error) {
+	start := s.Copy()
+	d.aug.Skip(s)
+	if err := s.Err(); err != nil {
+		return nil, nil, err
+	}
+	return s, prefixOf(start, s), nil
+}
+
+// load reads all entries of a loaded dictionary before its first modification
+func (d *AugDict) load() error {
+	if d.entries != nil {
+		return nil
+	}
+	entries := map[string]augEntry{}
+	err := d.ForEach(func(key []byte, value, extra *Slice) error {
+		v, err := value.ToCell()
+		if err != nil {
+			return err
+		}
+		x, err := extra.ToCell()
+		entries[string(key)] = augEntry{value: v, extra: x}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	d.entries = entries
+	return nil
+}
+
+// sortedKeys returns the keys in ascending order
+func (d *AugDict) sortedKeys() []string {
+	keys := make([]string, 0, len(d.entries))
+	for k := range d.entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// prefixOf returns the part of s that was read to get end, a copy of s read further
+func prefixOf(s, end *Slice) *Slice {
+	cell := &Cell{
+		data: s.cell.data,
+		bits: end.bitPos,
+		refs: s.cell.refs[:end.refPos],
+		typ:  Ordinary,
+	}
+	return &Slice{cell: cell, bitPos: s.bitPos, refPos: s.refPos, err: end.err}
+}
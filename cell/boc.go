@@ -0,0 +1,318 @@
+package cell
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/bits"
+)
+
+// BOC magic prefixes
+const (
+	bocGeneric    = 0xb5ee9c72
+	bocIndexed    = 0x68ff65f3
+	bocIndexedCRC = 0xacc3a728
+)
+
+// ErrInvalidBOC is returned for malformed bags of cells
+var ErrInvalidBOC = errors.New("invalid BOC")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// FromBOC parses a bag of cells with a single root
+func FromBOC(data []byte) (*Cell, error) {
+	roots, err := FromBOCMultiRoot(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) != 1 {
+		return nil, fmt.Errorf("%w: expected 1 root, got %d", ErrInvalidBOC, len(roots))
+	}
+	return roots[0], nil
+}
+
+// FromBOCBase64 parses a base64 encoded bag of cells with a single root
+func FromBOCBase64(s string) (*Cell, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		if data, err = base64.URLEncoding.DecodeString(s); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBOC, err)
+		}
+	}
+	return FromBOC(data)
+}
+
+// bocReader reads the fields of a serialized BOC
+type bocReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// uint reads an n byte big-endian integer
+func (r *bocReader) uint(n int) int {
+	if r.err != nil {
+		return 0
+	}
+	if n > 8 || r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("%w: unexpected end of data", ErrInvalidBOC)
+		return 0
+	}
+	var v uint64
+	for i := 0; i < n; i++ {
+		v = v<<8 | uint64(r.data[r.pos+i])
+	}
+	r.pos += n
+	if v > uint64(len(r.data))*8 {
+		r.err = fmt.Errorf("%w: value %d out of range", ErrInvalidBOC, v)
+		return 0
+	}
+	return int(v)
+}
+
+// bytes reads n bytes
+func (r *bocReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("%w: unexpected end of data", ErrInvalidBOC)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// FromBOCMultiRoot parses a bag of cells and returns all of its roots
+func FromBOCMultiRoot(data []byte) ([]*Cell, error) {
+	if len(data) < 6 {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidBOC)
+	}
+	
+	r := &bocReader{data: data}
+	magic := binary.BigEndian.Uint32(r.bytes(4))
+	
+	var hasIndex, hasCRC bool
+	var size int
+	flags := int(r.bytes(1)[0])
+	switch magic {
+	case bocGeneric:
+		hasIndex = flags&0x80 != 0
+		hasCRC = flags&0x40 != 0
+		size = flags & 7
+	case bocIndexed, bocIndexedCRC:
+		hasIndex = true
+		hasCRC = magic == bocIndexedCRC
+		size = flags
+	default:
+		return nil, fmt.Errorf("%w: unknown magic %08x", ErrInvalidBOC, magic)
+	}
+	if size < 1 || size > 4 {
+		return nil, fmt.Errorf("%w: invalid reference size %d", ErrInvalidBOC, size)
+	}
+	
+	if hasCRC {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("%w: too short", ErrInvalidBOC)
+		}
+		body := data[:len(data)-4]
+		if crc32.Checksum(body, castagnoli) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+			return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidBOC)
+		}
+		r.data = body
+	}
+	
+	offBytes := int(r.bytes(1)[0])
+	cellsNum := r.uint(size)
+	rootsNum := r.uint(size)
+	r.uint(size) // absent cells
+	totalSize := r.uint(offBytes)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if rootsNum > cellsNum {
+		return nil, fmt.Errorf("%w: more roots than cells", ErrInvalidBOC)
+	}
+	
+	rootIdx := make([]int, rootsNum)
+	if magic == bocGeneric {
+		for i := range rootIdx {
+			rootIdx[i] = r.uint(size)
+		}
+	}
+	if hasIndex {
+		r.bytes(cellsNum * offBytes)
+	}
+	
+	cellData := &bocReader{data: r.bytes(totalSize)}
+	if r.err != nil {
+		return nil, r.err
+	}
+	
+	type rawCell struct {
+		typ  Type
+		data []byte
+		bits int
+		refs []int
+	}
+	raws := make([]rawCell, cellsNum)
+	for i := 0; i < cellsNum; i++ {
+		hdr := cellData.bytes(2)
+		if cellData.err != nil {
+			return nil, cellData.err
+		}
+		d1, d2 := hdr[0], hdr[1]
+		
+		refsNum := int(d1 & 7)
+		if refsNum > MaxRefs {
+			return nil, fmt.Errorf("%w: cell %d has %d references", ErrInvalidBOC, i, refsNum)
+		}
+		if d1&16 != 0 {
+			hashes := bits.OnesCount8(d1>>5) + 1
+			cellData.bytes(hashes * (32 + 2))
+		}
+		
+		n := int(d2+1) / 2
+		payload := append([]byte(nil), cellData.bytes(n)...)
+		bitLen := n * 8
+		if d2%2 == 1 && cellData.err == nil {
+			last := payload[n-1]
+			if last == 0 {
+				return nil, fmt.Errorf("%w: cell %d has no completion tag", ErrInvalidBOC, i)
+			}
+			tz := bits.TrailingZeros8(last)
+			bitLen -= tz + 1
+			payload[n-1] &^= 1 << uint(tz)
+		}
+		
+		raw := rawCell{typ: Ordinary, data: payload, bits: bitLen}
+		if d1&8 != 0 {
+			if bitLen < 8 {
+				return nil, fmt.Errorf("%w: exotic cell %d without type", ErrInvalidBOC, i)
+			}
+			raw.typ = Type(payload[0])
+		}
+		for j := 0; j < refsNum; j++ {
+			idx := cellData.uint(size)
+			if cellData.err == nil && (idx <= i || idx >= cellsNum) {
+				return nil, fmt.Errorf("%w: cell %d has invalid reference %d", ErrInvalidBOC, i, idx)
+			}
+			raw.refs = append(raw.refs, idx)
+		}
+		if cellData.err != nil {
+			return nil, cellData.err
+		}
+		raws[i] = raw
+	}
+	
+	// References always point forward, so build the cells from the end
+	cells := make([]*Cell, cellsNum)
+	for i := cellsNum - 1; i >= 0; i-- {
+		refs := make([]*Cell, len(raws[i].refs))
+		for j, idx := range raws[i].refs {
+			refs[j] = cells[idx]
+		}
+		c, err := newCell(raws[i].typ, raws[i].data, raws[i].bits, refs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cell %d: %v", ErrInvalidBOC, i, err)
+		}
+		cells[i] = c
+	}
+	
+	if magic != bocGeneric {
+		for i := range rootIdx {
+			rootIdx[i] = i
+		}
+	}
+	roots := make([]*Cell, rootsNum)
+	for i, idx := range rootIdx {
+		if idx >= cellsNum {
+			return nil, fmt.Errorf("%w: invalid root index %d", ErrInvalidBOC, idx)
+		}
+		roots[i] = cells[idx]
+	}
+	return roots, nil
+}
+
+// ToBOC serializes the cell as a bag of cells with a CRC32C checksum
+func (c *Cell) ToBOC() []byte {
+	return ToBOCMultiRoot(c)
+}
+
+// ToBOCBase64 serializes the cell as a base64 encoded bag of cells
+func (c *Cell) ToBOCBase64() string {
+	return base64.StdEncoding.EncodeToString(c.ToBOC())
+}
+
+// ToBOCMultiRoot serializes several roots into one bag of cells with a CRC32C checksum
+func ToBOCMultiRoot(roots ...*Cell) []byte {
+	// Reverse post-order puts every cell before the cells it references
+	index := map[string]int{}
+	var order []*Cell
+	var visit func(c *Cell)
+	visit = func(c *Cell) {
+		key := string(c.Hash())
+		if _, ok := index[key]; ok {
+			return
+		}
+		index[key] = -1
+		for _, r := range c.refs {
+			visit(r)
+		}
+		order = append(order, c)
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	for i, c := range order {
+		index[string(c.Hash())] = i
+	}
+	
+	size := bytesFor(len(order))
+	
+	var body []byte
+	for _, c := range order {
+		body = append(body, c.d1(c.levelMask), c.d2())
+		body = append(body, c.paddedData()...)
+		for _, r := range c.refs {
+			body = appendUint(body, index[string(r.Hash())], size)
+		}
+	}
+	offBytes := bytesFor(len(body))
+	
+	out := make([]byte, 0, 16+len(body))
+	out = binary.BigEndian.AppendUint32(out, bocGeneric)
+	out = append(out, byte(0x40|size), byte(offBytes))
+	out = appendUint(out, len(order), size)
+	out = appendUint(out, len(roots), size)
+	out = appendUint(out, 0, size)
+	out = appendUint(out, len(body), offBytes)
+	for _, root := range roots {
+		out = appendUint(out, index[string(root.Hash())], size)
+	}
+	out = append(out, body...)
+	return binary.LittleEndian.AppendUint32(out, crc32.Checksum(out, castagnoli))
+}
+
+// bytesFor returns the number of bytes needed to store n
+func bytesFor(n int) int {
+	b := (bits.Len(uint(n)) + 7) / 8
+	if b == 0 {
+		return 1
+	}
+	return b
+}
+
+// appendUint appends v as an n byte big-endian integer
+func appendUint(out []byte, v, n int) []byte {
+	for i := n - 1; i >= 0; i-- {
+		out = append(out, byte(v>>(8*uint(i))))
+	}
+	return out
+}
@@ -0,0 +1,298 @@
+package cell
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Builder creates cells
+type Builder struct {
+	data []byte
+	bits int
+	refs []*Cell
+	err  error
+}
+
+// BeginCell returns an empty Builder
+func BeginCell() *Builder {
+	return &Builder{data: make([]byte, 0, 128)}
+}
+
+// Err returns the first error that occurred while building
+func (b *Builder) Err() error {
+	return b.err
+}
+
+// BitsUsed returns the number of stored bits
+func (b *Builder) BitsUsed() int {
+	return b.bits
+}
+
+// BitsLeft returns the number of bits that can still be stored
+func (b *Builder) BitsLeft() int {
+	return MaxBits - b.bits
+}
+
+// RefsLeft returns the number of references that can still be stored
+func (b *Builder) RefsLeft() int {
+	return MaxRefs - len(b.refs)
+}
+
+// EndCell returns the built ordinary cell
+func (b *Builder) EndCell() (*Cell, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return newCell(Ordinary, append([]byte(nil), b.data...), b.bits, append([]*Cell(nil), b.refs...))
+}
+
+// EndExoticCell returns the built data as an exotic cell; the first byte is its type
+func (b *Builder) EndExoticCell() (*Cell, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.bits < 8 {
+		return nil, fmt.Errorf("%w: exotic cell without type", ErrInvalidCell)
+	}
+	return newCell(Type(b.data[0]), append([]byte(nil), b.data...), b.bits, append([]*Cell(nil), b.refs...))
+}
+
+// fail records the first error
+func (b *Builder) fail(err error) *Builder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// storeBit appends a single bit without checks
+func (b *Builder) storeBit(bit bool) {
+	if b.bits%8 == 0 {
+		b.data = append(b.data, 0)
+	}
+	if bit {
+		b.data[b.bits/8] |= 1 << (7 - b.bits%8)
+	}
+	b.bits++
+}
+
+// reserve checks that n more bits fit
+func (b *Builder) reserve(n int) bool {
+	if b.err != nil {
+		return false
+	}
+	if n < 0 || b.bits+n > MaxBits {
+		b.fail(ErrOutOfSpace)
+		return false
+	}
+	return true
+}
+
+// StoreBit stores a single bit
+func (b *Builder) StoreBit(bit bool) *Builder {
+	if b.reserve(1) {
+		b.storeBit(bit)
+	}
+	return b
+}
+
+// StoreUInt stores an unsigned integer in n bits (n <= 64)
+func (b *Builder) StoreUInt(v uint64, n int) *Builder {
+	if n > 64 {
+		return b.StoreBigUInt(new(big.Int).SetUint64(v), n)
+	}
+	if n < 64 && v>>uint(n) != 0 {
+		return b.fail(fmt.Errorf("value %d does not fit in %d bits", v, n))
+	}
+	if b.reserve(n) {
+		for i := n - 1; i >= 0; i-- {
+			b.storeBit(v>>uint(i)&1 == 1)
+		}
+	}
+	return b
+}
+
+// StoreInt stores a signed integer in n bits (n <= 64)
+func (b *Builder) StoreInt(v int64, n int) *Builder {
+	if n > 64 {
+		return b.StoreBigInt(big.NewInt(v), n)
+	}
+	if n < 64 && (v >= 1<<uint(n-1) || v < -(1<<uint(n-1))) {
+		return b.fail(fmt.Errorf("value %d does not fit in %d signed bits", v, n))
+	}
+	if n < 64 {
+		return b.StoreUInt(uint64(v)&(1<<uint(n)-1), n)
+	}
+	return b.StoreUInt(uint64(v), n)
+}
+
+// StoreBigUInt stores a non-negative big integer in n bits
+func (b *Builder) StoreBigUInt(v *big.Int, n int) *Builder {
+	if v.Sign() < 0 || v.BitLen() > n {
+		return b.fail(fmt.Errorf("value %s does not fit in %d bits", v, n))
+	}
+	if b.reserve(n) {
+		for i := n - 1; i >= 0; i-- {
+			b.storeBit(v.Bit(i) == 1)
+		}
+	}
+	return b
+}
+
+// StoreBigInt stores a big integer in two's complement in n bits
+func (b *Builder) StoreBigInt(v *big.Int, n int) *Builder {
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(n-1))
+	if v.Cmp(limit) >= 0 || v.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return b.fail(fmt.Errorf("value %s does not fit in %d signed bits", v, n))
+	}
+	if v.Sign() < 0 {
+		v = new(big.Int).Add(v, new(big.Int).Lsh(limit, 1))
+	}
+	return b.StoreBigUInt(v, n)
+}
+
+// StoreBool stores a boolean as one bit
+func (b *Builder) StoreBool(v bool) *Builder {
+	return b.StoreBit(v)
+}
+
+// StoreBits stores the first n bits of data
+func (b *Builder) StoreBits(data []byte, n int) *Builder {
+	if n > len(data)*8 {
+		return b.fail(fmt.Errorf("%d bits requested from %d bytes", n, len(data)))
+	}
+	if b.reserve(n) {
+		if b.bits%8 == 0 && n%8 == 0 {
+			b.data = append(b.data, data[:n/8]...)
+			b.bits += n
+			return b
+		}
+		for i := 0; i < n; i++ {
+			b.storeBit(data[i/8]>>(7-i%8)&1 == 1)
+		}
+	}
+	return b
+}
+
+// StoreBytes stores whole bytes
+func (b *Builder) StoreBytes(data []byte) *Builder {
+	return b.StoreBits(data, len(data)*8)
+}
+
+// StoreVarUInt stores a VarUInteger whose byte length is encoded in lenBits bits
+func (b *Builder) StoreVarUInt(v *big.Int, lenBits int) *Builder {
+	if v == nil {
+		v = new(big.Int)
+	}
+	n := (v.BitLen() + 7) / 8
+	if n >= 1<<uint(lenBits) {
+		return b.fail(fmt.Errorf("value %s is too large for VarUInteger with %d length bits", v, lenBits))
+	}
+	return b.StoreUInt(uint64(n), lenBits).StoreBigUInt(v, n*8)
+}
+
+// StoreCoins stores an amount of nanotons (Grams)
+func (b *Builder) StoreCoins(v *big.Int) *Builder {
+	return b.StoreVarUInt(v, 4)
+}
+
+// StoreRef stores a reference
+func (b *Builder) StoreRef(c *Cell) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if c == nil {
+		return b.fail(fmt.Errorf("nil reference"))
+	}
+	if len(b.refs) >= MaxRefs {
+		return b.fail(ErrOutOfSpace)
+	}
+	b.refs = append(b.refs, c)
+	return b
+}
+
+// StoreMaybeRef stores a Maybe ^Cell: a 0 bit for nil, otherwise a 1 bit and the reference
+func (b *Builder) StoreMaybeRef(c *Cell) *Builder {
+	if c == nil {
+		return b.StoreBit(false)
+	}
+	return b.StoreBit(true).StoreRef(c)
+}
+
+// StoreSlice stores the remaining bits and references of a slice
+func (b *Builder) StoreSlice(s *Slice) *Builder {
+	if s.err != nil {
+		return b.fail(s.err)
+	}
+	data, n := s.remainingBits()
+	b.StoreBits(data, n)
+	for i := s.refPos; i < len(s.cell.refs); i++ {
+		b.StoreRef(s.cell.refs[i])
+	}
+	return b
+}
+
+// StoreBuilder appends the contents of another builder
+func (b *Builder) StoreBuilder(o *Builder) *Builder {
+	if o.err != nil {
+		return b.fail(o.err)
+	}
+	b.StoreBits(o.data, o.bits)
+	for _, r := range o.refs {
+		b.StoreRef(r)
+	}
+	return b
+}
+
+// StoreStringSnake stores a string in this cell, continuing in a chain of references
+// when it does not fit
+func (b *Builder) StoreStringSnake(s string) *Builder {
+	return b.StoreBytesSnake([]byte(s))
+}
+
+// StoreBytesSnake stores bytes in this cell, continuing in a chain of references
+// when they do not fit
+func (b *Builder) StoreBytesSnake(data []byte) *Builder {
+	if b.err != nil {
+		return b
+	}
+	
+	n := b.BitsLeft() / 8
+	if len(data) <= n {
+		return b.StoreBytes(data)
+	}
+	
+	tail, err := snakeCell(data[n:])
+	if err != nil {
+		return b.fail(err)
+	}
+	return b.StoreBytes(data[:n]).StoreRef(tail)
+}
+
+// snakeCell builds a chain of cells holding data
+func snakeCell(data []byte) (*Cell, error) {
+	const chunk = MaxBits / 8
+	
+	var next *Cell
+	for end := len(data); end > 0 || next == nil; {
+		start := ((end - 1) / chunk) * chunk
+		if end == 0 {
+			start = 0
+		}
+		
+		cb := BeginCell().StoreBytes(data[start:end])
+		if next != nil {
+			cb.StoreRef(next)
+		}
+		c, err := cb.EndCell()
+		if err != nil {
+			return nil, err
+		}
+		next = c
+		if start == 0 {
+			break
+		}
+		end = start
+	}
+	return next, nil
+}
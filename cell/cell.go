@@ -0,0 +1,337 @@
+// Package cell implements TVM cells and the bag-of-cells (BOC) serialization format.
+//
+// Cells are immutable once built; use a Builder to create them and a Slice to read
+// them. Builder and Slice keep the first error that occurs (out of space, out of
+// data, value too large) and turn later calls into no-ops, so a sequence of stores or
+// loads only needs a single Err check at the end.
+package cell
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// Cell limits
+const (
+	MaxBits = 1023
+	MaxRefs = 4
+	
+	// MaxLevel is the highest level a cell hash can have
+	MaxLevel = 3
+)
+
+// Type is the type of a cell
+type Type uint8
+
+// Cell types. Exotic cells store their type in the first data byte.
+const (
+	Ordinary     Type = 0xff
+	PrunedBranch Type = 1
+	Library      Type = 2
+	MerkleProof  Type = 3
+	MerkleUpdate Type = 4
+)
+
+// String returns the name of the cell type
+func (t Type) String() string {
+	switch t {
+	case Ordinary:
+		return "ordinary"
+	case PrunedBranch:
+		return "pruned branch"
+	case Library:
+		return "library"
+	case MerkleProof:
+		return "merkle proof"
+	case MerkleUpdate:
+		return "merkle update"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(t))
+}
+
+var (
+	// ErrOutOfData is returned when a Slice is read past its end
+	ErrOutOfData = errors.New("not enough data in cell")
+	
+	// ErrOutOfSpace is returned when a Builder exceeds MaxBits or MaxRefs
+	ErrOutOfSpace = errors.New("not enough space in cell")
+	
+	// ErrInvalidCell is returned for malformed exotic cells
+	ErrInvalidCell = errors.New("invalid cell")
+)
+
+// Cell is an immutable TVM cell: up to 1023 bits of data and up to 4 references
+type Cell struct {
+	data []byte
+	bits int
+	refs []*Cell
+	typ  Type
+	
+	levelMask uint8
+	hashes    [][32]byte
+	depths    []uint16
+}
+
+// newCell creates a cell and computes its hashes
+func newCell(typ Type, data []byte, bitLen int, refs []*Cell) (*Cell, error) {
+	c := &Cell{
+		data: data,
+		bits: bitLen,
+		refs: refs,
+		typ:  typ,
+	}
+	if err := c.finalize(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Type returns the cell type
+func (c *Cell) Type() Type {
+	return c.typ
+}
+
+// IsExotic reports whether the cell is not an ordinary cell
+func (c *Cell) IsExotic() bool {
+	return c.typ != Ordinary
+}
+
+// BitsSize returns the number of data bits
+func (c *Cell) BitsSize() int {
+	return c.bits
+}
+
+// RefsNum returns the number of references
+func (c *Cell) RefsNum() int {
+	return len(c.refs)
+}
+
+// Ref returns the i-th reference or nil if there is none
+func (c *Cell) Ref(i int) *Cell {
+	if i < 0 || i >= len(c.refs) {
+		return nil
+	}
+	return c.refs[i]
+}
+
+// Data returns a copy of the data bits, left aligned and zero padded to whole bytes
+func (c *Cell) Data() []byte {
+	return append([]byte(nil), c.data...)
+}
+
+// Level returns the level of the cell
+func (c *Cell) Level() int {
+	return levelOf(c.levelMask)
+}
+
+// LevelMask returns the level mask of the cell
+func (c *Cell) LevelMask() uint8 {
+	return c.levelMask
+}
+
+// Hash returns the representation hash of the cell
+func (c *Cell) Hash() []byte {
+	return c.HashAt(MaxLevel)
+}
+
+// HashAt returns the hash of the cell at the given level. For cells inside a Merkle
+// proof, HashAt(0) is the hash of the original cell the pruned subtree stood for.
+func (c *Cell) HashAt(level int) []byte {
+	h := c.hashes[c.hashIndex(level)]
+	return h[:]
+}
+
+// Depth returns the depth of the cell
+func (c *Cell) Depth() int {
+	return c.DepthAt(MaxLevel)
+}
+
+// DepthAt returns the depth of the cell at the given level
+func (c *Cell) DepthAt(level int) int {
+	return int(c.depths[c.hashIndex(level)])
+}
+
+// BeginParse returns a Slice reading the cell from the start
+func (c *Cell) BeginParse() *Slice {
+	return &Slice{cell: c}
+}
+
+// String returns a short description of the cell for debugging
+func (c *Cell) String() string {
+	s := fmt.Sprintf("%d[%X]", c.bits, c.data)
+	if c.typ != Ordinary {
+		s = c.typ.String() + " " + s
+	}
+	if len(c.refs) > 0 {
+		s += fmt.Sprintf(" -> %d refs", len(c.refs))
+	}
+	return s
+}
+
+// hashIndex maps a level to an index in the hashes of the cell
+func (c *Cell) hashIndex(level int) int {
+	if level > MaxLevel {
+		level = MaxLevel
+	}
+	if level < 0 {
+		level = 0
+	}
+	
+	// Pruned branches keep the hashes of the lower levels before their own
+	// representation hash, so the same indexing applies to every cell type.
+	return bits.OnesCount8(c.levelMask & (1<<level - 1))
+}
+
+// levelOf returns the level encoded by a level mask
+func levelOf(mask uint8) int {
+	return bits.Len8(mask)
+}
+
+// finalize validates exotic cells and computes the level mask, hashes and depths
+func (c *Cell) finalize() error {
+	if c.bits > MaxBits || len(c.refs) > MaxRefs {
+		return ErrOutOfSpace
+	}
+	
+	switch c.typ {
+	case Ordinary:
+		for _, r := range c.refs {
+			c.levelMask |= r.levelMask
+		}
+	case PrunedBranch:
+		if c.bits < 16 || len(c.refs) != 0 {
+			return fmt.Errorf("%w: pruned branch layout", ErrInvalidCell)
+		}
+		c.levelMask = c.data[1]
+		n := bits.OnesCount8(c.levelMask)
+		if c.levelMask == 0 || c.levelMask > 7 || c.bits != 16+n*(256+16) {
+			return fmt.Errorf("%w: pruned branch level mask", ErrInvalidCell)
+		}
+	case Library:
+		if c.bits != 8+256 || len(c.refs) != 0 {
+			return fmt.Errorf("%w: library cell layout", ErrInvalidCell)
+		}
+	case MerkleProof:
+		if c.bits != 8+256+16 || len(c.refs) != 1 {
+			return fmt.Errorf("%w: merkle proof layout", ErrInvalidCell)
+		}
+		c.levelMask = c.refs[0].levelMask >> 1
+	case MerkleUpdate:
+		if c.bits != 8+2*(256+16) || len(c.refs) != 2 {
+			return fmt.Errorf("%w: merkle update layout", ErrInvalidCell)
+		}
+		c.levelMask = (c.refs[0].levelMask | c.refs[1].levelMask) >> 1
+	default:
+		return fmt.Errorf("%w: unknown exotic type %d", ErrInvalidCell, c.typ)
+	}
+	
+	c.computeHashes()
+	
+	if c.typ == MerkleProof || c.typ == MerkleUpdate {
+		for i, r := range c.refs {
+			if string(c.data[1+32*i:1+32*i+32]) != string(r.HashAt(0)) {
+				return fmt.Errorf("%w: merkle hash mismatch", ErrInvalidCell)
+			}
+			off := 1 + 32*len(c.refs) + 2*i
+			if int(binary.BigEndian.Uint16(c.data[off:])) != r.DepthAt(0) {
+				return fmt.Errorf("%w: merkle depth mismatch", ErrInvalidCell)
+			}
+		}
+	}
+	return nil
+}
+
+// computeHashes computes the hash and depth of every significant level
+func (c *Cell) computeHashes() {
+	level := levelOf(c.levelMask)
+	
+	// A pruned branch only hashes its own representation; the lower level
+	// hashes are taken from its data.
+	first := 0
+	if c.typ == PrunedBranch {
+		n := bits.OnesCount8(c.levelMask)
+		for i := 0; i < n; i++ {
+			var h [32]byte
+			copy(h[:], c.data[2+32*i:])
+			c.hashes = append(c.hashes, h)
+			c.depths = append(c.depths, binary.BigEndian.Uint16(c.data[2+32*n+2*i:]))
+		}
+		first = level
+	}
+	
+	var prev []byte
+	for l := 0; l <= level; l++ {
+		if l > 0 && c.levelMask&(1<<(l-1)) == 0 {
+			continue
+		}
+		if l < first {
+			continue
+		}
+		
+		childLevel := l
+		if c.typ == MerkleProof || c.typ == MerkleUpdate {
+			childLevel = l + 1
+		}
+		
+		var depth uint16
+		for _, r := range c.refs {
+			if d := uint16(r.DepthAt(childLevel)); d+1 > depth {
+				depth = d + 1
+			}
+		}
+		
+		h := sha256.New()
+		mask := c.levelMask & (1<<l - 1)
+		h.Write([]byte{c.d1(mask)})
+		if prev == nil {
+			h.Write([]byte{c.d2()})
+			h.Write(c.paddedData())
+		} else {
+			// Higher levels hash the previous level hash instead of the data
+			h.Write([]byte{64})
+			h.Write(prev)
+		}
+		for _, r := range c.refs {
+			var d [2]byte
+			binary.BigEndian.PutUint16(d[:], uint16(r.DepthAt(childLevel)))
+			h.Write(d[:])
+		}
+		for _, r := range c.refs {
+			h.Write(r.HashAt(childLevel))
+		}
+		
+		var sum [32]byte
+		copy(sum[:], h.Sum(nil))
+		c.hashes = append(c.hashes, sum)
+		c.depths = append(c.depths, depth)
+		prev = sum[:]
+	}
+}
+
+// d1 returns the refs descriptor byte for the given level mask
+func (c *Cell) d1(mask uint8) byte {
+	d := byte(len(c.refs)) + mask<<5
+	if c.typ != Ordinary {
+		d += 8
+	}
+	return d
+}
+
+// d2 returns the bits descriptor byte
+func (c *Cell) d2() byte {
+	return byte(c.bits/8 + (c.bits+7)/8)
+}
+
+// paddedData returns the data with the completion tag appended if it is not byte aligned
+func (c *Cell) paddedData() []byte {
+	n := (c.bits + 7) / 8
+	out := make([]byte, n)
+	copy(out, c.data)
+	if c.bits%8 != 0 {
+		out[n-1] |= 1 << (7 - c.bits%8)
+	}
+	return out
+}
@@ -0,0 +1,216 @@
+package cell
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+)
+
+// highloadV3Code is the code of highload wallet v3, whose hash is published as
+// 11acad7955844090f283bf238bc1449871f783e7cc0979408d3f4859483e8525
+const highloadV3Code = "b5ee9c7241021001000228000114ff00f4a413f4bcf2c80b01020120020d02014803040078d020d74bc00101c060b0915be101d0d3030171b0915be0fa4030f828c705b39130e0d31f018210ae42e5a4ba9d8040d721d74cf82a01ed55fb04e030020120050a02027306070011adce76a2686b85ffc00201200809001aabb6ed44d0810122d721d70b3f0018aa3bed44d08307d721d70b1f0201200b0c001bb9a6eed44d0810162d721d70b15800e5b8bf2eda2edfb21ab09028409b0ed44d0810120d721f404f404d33fd315d1058e1bf82325a15210b99f326df82305aa0015a112b992306dde923033e2923033e25230800df40f6fa19ed021d721d70a00955f037fdb31e09130e259800df40f6fa19cd001d721d70a00937fdb31e0915be270801f6f2d48308d718d121f900ed44d0d3ffd31ff404f404d33fd315d1f82321a15220b98e12336df82324aa00a112b9926d32de58f82301de541675f910f2a106d0d31fd4d307d30cd309d33fd315d15168baf2a2515abaf2a6f8232aa15250bcf2a304f823bbf2a35304800df40f6fa199d024d721d70a00f2649130e20e01fe5309800df40f6fa18e13d05004d718d20001f264c858cf16cf8301cf168e1030c824cf40cf8384095005a1a514cf40e2f800c94039800df41704c8cbff13cb1ff40012f40012cb3f12cb15c9ed54f80f21d0d30001f265d3020171b0925f03e0fa4001d70b01c000f2a5fa4031fa0031f401fa0031fa00318060d721d300010f0020f265d2000193d431d19130e272b1fb00b585bf03"
+
+// mustCell panics on builder errors, which only happen on a broken test
+func mustCell(c *Cell, err error) *Cell {
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func TestEmptyCellHash(t *testing.T) {
+	c := mustCell(BeginCell().EndCell())
+	if got := hex.EncodeToString(c.Hash()); got != "96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7" {
+		t.Errorf("hash %s", got)
+	}
+}
+
+func TestBOCKnownCode(t *testing.T) {
+	data, _ := hex.DecodeString(highloadV3Code)
+	c, err := FromBOC(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(c.Hash()); got != "11acad7955844090f283bf238bc1449871f783e7cc0979408d3f4859483e8525" {
+		t.Fatalf("hash %s", got)
+	}
+	
+	// Serializing and parsing again keeps the tree
+	again, err := FromBOC(c.ToBOC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Hash(), c.Hash()) {
+		t.Error("hash changed after a BOC round trip")
+	}
+	if again.Depth() != c.Depth() {
+		t.Errorf("depth %d, want %d", again.Depth(), c.Depth())
+	}
+}
+
+func TestBOCMultiRoot(t *testing.T) {
+	shared := mustCell(BeginCell().StoreUInt(0xabc, 12).EndCell())
+	a := mustCell(BeginCell().StoreUInt(1, 8).StoreRef(shared).EndCell())
+	b := mustCell(BeginCell().StoreUInt(2, 8).StoreRef(shared).StoreRef(shared).EndCell())
+	
+	roots, err := FromBOCMultiRoot(ToBOCMultiRoot(a, b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 2 || !bytes.Equal(roots[0].Hash(), a.Hash()) || !bytes.Equal(roots[1].Hash(), b.Hash()) {
+		t.Fatal("roots differ after a round trip")
+	}
+	if _, err := FromBOC(ToBOCMultiRoot(a, b)); err == nil {
+		t.Error("FromBOC accepted a BOC with two roots")
+	}
+}
+
+func TestBOCRejectsCorruptData(t *testing.T) {
+	data := mustCell(BeginCell().StoreUInt(7, 32).StoreRef(mustCell(BeginCell().EndCell())).EndCell()).ToBOC()
+	
+	if _, err := FromBOC(data[:len(data)-3]); err == nil {
+		t.Error("truncated BOC accepted")
+	}
+	bad := append([]byte(nil), data...)
+	bad[0] ^= 0xff
+	if _, err := FromBOC(bad); err == nil {
+		t.Error("BOC with a bad magic accepted")
+	}
+	if _, err := FromBOCBase64("not base64!"); err == nil {
+		t.Error("invalid base64 accepted")
+	}
+}
+
+func TestBuilderSliceRoundTrip(t *testing.T) {
+	addr := address.MustParse("0:" + "83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	coins, _ := new(big.Int).SetString("123456789012345678901", 10)
+	neg := big.NewInt(-5)
+	ref := mustCell(BeginCell().StoreUInt(9, 4).EndCell())
+	
+	c := mustCell(BeginCell().
+		StoreBit(true).
+		StoreUInt(0x1234, 13).
+		StoreInt(-77, 9).
+		StoreBigInt(neg, 257).
+		StoreCoins(coins).
+		StoreAddress(addr).
+		StoreAddress(nil).
+		StoreMaybeRef(nil).
+		StoreMaybeRef(ref).
+		StoreRef(ref).
+		EndCell())
+	
+	s := c.BeginParse()
+	if !s.LoadBit() {
+		t.Error("bit")
+	}
+	if v := s.LoadUInt(13); v != 0x1234 {
+		t.Errorf("uint %x", v)
+	}
+	if v := s.LoadInt(9); v != -77 {
+		t.Errorf("int %d", v)
+	}
+	if v := s.LoadBigInt(257); v.Cmp(neg) != 0 {
+		t.Errorf("big int %s", v)
+	}
+	if v := s.LoadCoins(); v.Cmp(coins) != 0 {
+		t.Errorf("coins %s", v)
+	}
+	if a := s.LoadAddress(); a == nil || !a.Equal(addr) {
+		t.Errorf("address %v", a)
+	}
+	if a := s.LoadAddress(); a != nil {
+		t.Errorf("none address loaded as %v", a)
+	}
+	if r := s.LoadMaybeRef(); r != nil {
+		t.Error("empty maybe ref")
+	}
+	if r := s.LoadMaybeRef(); r == nil || !bytes.Equal(r.Hash(), ref.Hash()) {
+		t.Error("maybe ref")
+	}
+	if r := s.LoadRef(); r == nil || !bytes.Equal(r.Hash(), ref.Hash()) {
+		t.Error("ref")
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if s.BitsLeft() != 0 || s.RefsLeft() != 0 {
+		t.Errorf("%d bits and %d refs left", s.BitsLeft(), s.RefsLeft())
+	}
+	
+	s.LoadUInt(1)
+	if s.Err() == nil {
+		t.Error("reading past the end did not fail")
+	}
+}
+
+func TestBuilderLimits(t *testing.T) {
+	if _, err := BeginCell().StoreBits(make([]byte, 128), 1023).EndCell(); err != nil {
+		t.Errorf("1023 bits: %v", err)
+	}
+	if _, err := BeginCell().StoreBits(make([]byte, 128), 1024).EndCell(); err == nil {
+		t.Error("1024 bits accepted")
+	}
+	
+	empty := mustCell(BeginCell().EndCell())
+	b := BeginCell()
+	for i := 0; i < 4; i++ {
+		b.StoreRef(empty)
+	}
+	if _, err := b.EndCell(); err != nil {
+		t.Errorf("4 refs: %v", err)
+	}
+	if _, err := b.StoreRef(empty).EndCell(); err == nil {
+		t.Error("5 refs accepted")
+	}
+	if _, err := BeginCell().StoreUInt(256, 8).EndCell(); err == nil {
+		t.Error("value wider than its bit length accepted")
+	}
+}
+
+func TestSnakeData(t *testing.T) {
+	text := string(bytes.Repeat([]byte("snake data spanning several cells "), 20))
+	c := mustCell(BeginCell().StoreStringSnake(text).EndCell())
+	if c.RefsNum() == 0 {
+		t.Fatal("long string stored in a single cell")
+	}
+	s := c.BeginParse()
+	if got := s.LoadStringSnake(); got != text || s.Err() != nil {
+		t.Errorf("got %q, %v", got, s.Err())
+	}
+}
+
+func TestPrunedBranchKeepsHash(t *testing.T) {
+	leaf := mustCell(BeginCell().StoreUInt(0xdeadbeef, 32).EndCell())
+	pruned := mustCell(BeginCell().
+		StoreUInt(uint64(PrunedBranch), 8).
+		StoreUInt(1, 8).
+		StoreBytes(leaf.Hash()).
+		StoreUInt(uint64(leaf.Depth()), 16).
+		EndExoticCell())
+	if pruned.Type() != PrunedBranch || pruned.Level() != 1 {
+		t.Fatalf("type %s level %d", pruned.Type(), pruned.Level())
+	}
+	
+	full := mustCell(BeginCell().StoreUInt(1, 8).StoreRef(leaf).EndCell())
+	partial := mustCell(BeginCell().StoreUInt(1, 8).StoreRef(pruned).EndCell())
+	if !bytes.Equal(partial.HashAt(0), full.Hash()) {
+		t.Error("tree with a pruned branch has a different level 0 hash")
+	}
+	
+	proof := mustCell(BeginCell().
+		StoreUInt(uint64(MerkleProof), 8).
+		StoreBytes(partial.HashAt(0)).
+		StoreUInt(uint64(partial.DepthAt(0)), 16).
+		StoreRef(partial).
+		EndExoticCell())
+	if proof.Level() != 0 {
+		t.Errorf("merkle proof level %d", proof.Level())
+	}
+	if _, err := BeginCell().StoreUInt(uint64(MerkleProof), 8).StoreBytes(make([]byte, 32)).StoreUInt(0, 16).StoreRef(partial).EndExoticCell(); err == nil {
+		t.Error("merkle proof with a wrong hash accepted")
+	}
+}
@@ -0,0 +1,456 @@
+package cell
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sort"
+)
+
+// ErrPruned is returned when a dictionary walk reaches a pruned branch, e.g. in a
+// Merkle proof that does not cover the requested key
+var ErrPruned = errors.New("dictionary cell is pruned")
+
+// Dict is a dictionary (Hashmap n X) with keys of a fixed number of bits.
+//
+// Keys are bit strings, left aligned in byte slices; UintKey, BigKey and KeyUint
+// convert them from and to integers. A Dict loaded from a cell is read lazily: Get
+// and ForEach walk the cell tree, and the first Set or Delete reads all entries.
+type Dict struct {
+	keyLen  int
+	root    *Cell
+	entries map[string]*Cell // nil until the dictionary is modified
+}
+
+// NewDict returns an empty dictionary with keys of keyLen bits
+func NewDict(keyLen int) *Dict {
+	return &Dict{keyLen: keyLen, entries: map[string]*Cell{}}
+}
+
+// LoadDict wraps the root cell of a Hashmap with keys of keyLen bits; a nil root is
+// an empty dictionary
+func LoadDict(root *Cell, keyLen int) *Dict {
+	return &Dict{keyLen: keyLen, root: root}
+}
+
+// LoadDict reads a HashmapE with keys of keyLen bits
+func (s *Slice) LoadDict(keyLen int) *Dict {
+	return LoadDict(s.LoadMaybeRef(), keyLen)
+}
+
+// StoreDict stores a dictionary as a HashmapE
+func (b *Builder) StoreDict(d *Dict) *Builder {
+	root, err := d.ToCell()
+	if err != nil {
+		return b.fail(err)
+	}
+	return b.StoreMaybeRef(root)
+}
+
+// KeyLen returns the key length in bits
+func (d *Dict) KeyLen() int {
+	return d.keyLen
+}
+
+// IsEmpty reports whether the dictionary has no entries
+func (d *Dict) IsEmpty() bool {
+	if d.entries != nil {
+		return len(d.entries) == 0
+	}
+	return d.root == nil
+}
+
+// Get returns the value of key, nil if the key is absent
+func (d *Dict) Get(key []byte) (*Slice, error) {
+	k, err := normKey(key, d.keyLen)
+	if err != nil {
+		return nil, err
+	}
+	if d.entries != nil {
+		if v := d.entries[string(k)]; v != nil {
+			return v.BeginParse(), nil
+		}
+		return nil, nil
+	}
+	if d.root == nil {
+		return nil, nil
+	}
+	return dictLookup(d.root, k, d.keyLen)
+}
+
+// ForEach calls fn for every entry in ascending key order
+func (d *Dict) ForEach(fn func(key []byte, value *Slice) error) error {
+	if d.entries == nil {
+		if d.root == nil {
+			return nil
+		}
+		return dictForEach(d.root, d.keyLen, fn)
+	}
+	for _, k := range sortedKeys(d.entries) {
+		if err := fn([]byte(k), d.entries[k].BeginParse()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Set sets the value of key. The value is stored inline in the leaf, after the key
+// label, so it must leave room for it.
+func (d *Dict) Set(key []byte, value *Cell) error {
+	k, err := normKey(key, d.keyLen)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("cell: nil dictionary value")
+	}
+	if err := d.load(); err != nil {
+		return err
+	}
+	d.entries[string(k)] = value
+	return nil
+}
+
+// Delete removes key and reports whether it was present
+func (d *Dict) Delete(key []byte) (bool, error) {
+	k, err := normKey(key, d.keyLen)
+	if err != nil {
+		return false, err
+	}
+	if err := d.load(); err != nil {
+		return false, err
+	}
+	_, ok := d.entries[string(k)]
+	delete(d.entries, string(k))
+	return ok, nil
+}
+
+// ToCell returns the root cell of the Hashmap, nil for an empty dictionary
+func (d *Dict) ToCell() (*Cell, error) {
+	if d.entries == nil {
+		return d.root, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, nil
+	}
+	root, _, err := dictBuild(sortedKeys(d.entries), d.keyLen, func(key string, b *Builder) (*Cell, error) {
+		b.StoreSlice(d.entries[key].BeginParse())
+		return nil, nil
+	}, nil)
+	return root, err
+}
+
+// load reads all entries of a loaded dictionary before its first modification
+func (d *Dict) load() error {
+	if d.entries != nil {
+		return nil
+	}
+	entries := map[string]*Cell{}
+	err := d.ForEach(func(key []byte, value *Slice) error {
+		c, err := value.ToCell()
+		entries[string(key)] = c
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	d.entries = entries
+	return nil
+}
+
+// UintKey returns v as a key of n <= 64 bits
+func UintKey(v uint64, n int) []byte {
+	key := make([]byte, (n+7)/8)
+	for i := 0; i < n; i++ {
+		if v>>(n-1-i)&1 == 1 {
+			setBit(key, i)
+		}
+	}
+	return key
+}
+
+// BigKey returns v as a key of n bits, in two's complement if v is negative
+func BigKey(v *big.Int, n int) []byte {
+	u := new(big.Int).Set(v)
+	if u.Sign() < 0 {
+		u.Add(u, new(big.Int).Lsh(big.NewInt(1), uint(n)))
+	}
+	key := make([]byte, (n+7)/8)
+	for i := 0; i < n; i++ {
+		if u.Bit(n-1-i) == 1 {
+			setBit(key, i)
+		}
+	}
+	return key
+}
+
+// KeyUint returns a key of n <= 64 bits as an unsigned integer
+func KeyUint(key []byte, n int) uint64 {
+	var v uint64
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if bitAt(key, i) {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// normKey copies the first n bits of key, clearing the unused bits of the last byte
+func normKey(key []byte, n int) ([]byte, error) {
+	if len(key)*8 < n {
+		return nil, fmt.Errorf("cell: dictionary key of %d bytes is shorter than %d bits", len(key), n)
+	}
+	k := append([]byte(nil), key[:(n+7)/8]...)
+	if n%8 != 0 {
+		k[len(k)-1] &= 0xff << uint(8-n%8)
+	}
+	return k, nil
+}
+
+// sortedKeys returns the keys of a map in ascending order, which for left aligned
+// keys of equal length is the order of their bits
+func sortedKeys(m map[string]*Cell) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// bitAt returns the i-th bit of left aligned data
+func bitAt(data []byte, i int) bool {
+	return data[i/8]>>(7-i%8)&1 == 1
+}
+
+// setBit sets the i-th bit of left aligned data
+func setBit(data []byte, i int) {
+	data[i/8] |= 1 << (7 - i%8)
+}
+
+// subBits returns n bits of data starting at bit from, left aligned
+func subBits(data []byte, from, n int) []byte {
+	out := make([]byte, (n+7)/8)
+	for i := 0; i < n; i++ {
+		if bitAt(data, from+i) {
+			setBit(out, i)
+		}
+	}
+	return out
+}
+
+// commonBits returns how many bits a and b share starting at bit from, at most max
+func commonBits(a, b []byte, from, max int) int {
+	n := 0
+	for n < max && bitAt(a, from+n) == bitAt(b, from+n) {
+		n++
+	}
+	return n
+}
+
+// loadLabel reads a HmLabel for a remaining key length of m bits and returns the
+// label bits left aligned and their count
+func loadLabel(s *Slice, m int) ([]byte, int) {
+	lenBits := bits.Len(uint(m))
+	if !s.LoadBit() {
+		// hml_short$0 len:(Unary ~n) s:(n * Bit)
+		n := 0
+		for s.LoadBit() {
+			n++
+		}
+		return s.LoadBits(n), n
+	}
+	if !s.LoadBit() {
+		// hml_long$10 n:(#<= m) s:(n * Bit)
+		n := int(s.LoadUInt(lenBits))
+		return s.LoadBits(n), n
+	}
+	
+	// hml_same$11 v:Bit n:(#<= m)
+	v := s.LoadBit()
+	n := int(s.LoadUInt(lenBits))
+	out := make([]byte, (n+7)/8)
+	if v {
+		for i := 0; i < n; i++ {
+			setBit(out, i)
+		}
+	}
+	return out, n
+}
+
+// storeLabel writes the shortest HmLabel of l bits for a remaining key length of m bits
+func storeLabel(b *Builder, label []byte, l, m int) {
+	lenBits := bits.Len(uint(m))
+	short := 2*l + 2
+	long := 2 + lenBits + l
+	same := -1
+	if l > 1 {
+		ones := 0
+		for i := 0; i < l; i++ {
+			if bitAt(label, i) {
+				ones++
+			}
+		}
+		if ones == 0 || ones == l {
+			same = 3 + lenBits
+		}
+	}
+	
+	switch {
+	case same >= 0 && same < short && same < long:
+		b.StoreUInt(0b11, 2).StoreBit(bitAt(label, 0)).StoreUInt(uint64(l), lenBits)
+	case short <= long:
+		b.StoreBit(false)
+		for i := 0; i < l; i++ {
+			b.StoreBit(true)
+		}
+		b.StoreBit(false).StoreBits(label, l)
+	default:
+		b.StoreUInt(0b10, 2).StoreUInt(uint64(l), lenBits).StoreBits(label, l)
+	}
+}
+
+// parseNode begins parsing a dictionary node, failing for pruned branches
+func parseNode(c *Cell) (*Slice, error) {
+	if c.Type() == PrunedBranch {
+		return nil, ErrPruned
+	}
+	return c.BeginParse(), nil
+}
+
+// dictLookup walks the Hashmap rooted at root to key and returns the rest of its leaf
+// after the label, nil if the key is absent
+func dictLookup(root *Cell, key []byte, keyLen int) (*Slice, error) {
+	pos := 0
+	for c := root; ; {
+		s, err := parseNode(c)
+		if err != nil {
+			return nil, err
+		}
+		label, n := loadLabel(s, keyLen-pos)
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		if pos+n > keyLen {
+			return nil, fmt.Errorf("%w: dictionary label is longer than the key", ErrInvalidCell)
+		}
+		for i := 0; i < n; i++ {
+			if bitAt(label, i) != bitAt(key, pos+i) {
+				return nil, nil
+			}
+		}
+		pos += n
+		
+		if pos == keyLen {
+			return s, nil
+		}
+		left, right := s.LoadRef(), s.LoadRef()
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		c = left
+		if bitAt(key, pos) {
+			c = right
+		}
+		pos++
+	}
+}
+
+// dictForEach calls fn for the rest of every leaf of the Hashmap rooted at root in
+// key order
+func dictForEach(root *Cell, keyLen int, fn func(key []byte, value *Slice) error) error {
+	key := make([]byte, (keyLen+7)/8)
+	var walk func(c *Cell, pos int) error
+	walk = func(c *Cell, pos int) error {
+		s, err := parseNode(c)
+		if err != nil {
+			return err
+		}
+		label, n := loadLabel(s, keyLen-pos)
+		if err := s.Err(); err != nil {
+			return err
+		}
+		if pos+n > keyLen {
+			return fmt.Errorf("%w: dictionary label is longer than the key", ErrInvalidCell)
+		}
+		for i := 0; i < n; i++ {
+			setKeyBit(key, pos+i, bitAt(label, i))
+		}
+		pos += n
+		
+		if pos == keyLen {
+			return fn(append([]byte(nil), key...), s)
+		}
+		left, right := s.LoadRef(), s.LoadRef()
+		if err := s.Err(); err != nil {
+			return err
+		}
+		for i, child := range []*Cell{left, right} {
+			setKeyBit(key, pos, i == 1)
+			if err := walk(child, pos+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(root, 0)
+}
+
+// setKeyBit sets or clears the i-th bit of a key
+func setKeyBit(key []byte, i int, v bool) {
+	if v {
+		setBit(key, i)
+	} else {
+		key[i/8] &^= 1 << (7 - i%8)
+	}
+}
+
+// dictBuild builds a Hashmap from different keys in ascending order. leaf stores the
+// node of a key after its label and returns its extra value; combine, if not nil,
+// computes the extra value of every fork, as in an augmented dictionary. dictBuild
+// returns the root and its extra value.
+func dictBuild(keys []string, keyLen int, leaf func(key string, b *Builder) (*Cell, error), combine func(left, right *Slice) (*Cell, error)) (*Cell, *Cell, error) {
+	var build func(keys []string, pos int) (*Cell, *Cell, error)
+	build = func(keys []string, pos int) (*Cell, *Cell, error) {
+		// the label is the prefix shared by the first and the last key
+		first, last := []byte(keys[0]), []byte(keys[len(keys)-1])
+		m := keyLen - pos
+		l := commonBits(first, last, pos, m)
+		
+		b := BeginCell()
+		storeLabel(b, subBits(first, pos, l), l, m)
+		if l == m {
+			extra, err := leaf(keys[0], b)
+			if err != nil {
+				return nil, nil, err
+			}
+			c, err := b.EndCell()
+			return c, extra, err
+		}
+		
+		split := sort.Search(len(keys), func(i int) bool { return bitAt([]byte(keys[i]), pos+l) })
+		var extras [2]*Cell
+		for i, part := range [][]string{keys[:split], keys[split:]} {
+			child, extra, err := build(part, pos+l+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			b.StoreRef(child)
+			extras[i] = extra
+		}
+		if combine == nil {
+			c, err := b.EndCell()
+			return c, nil, err
+		}
+		extra, err := combine(extras[0].BeginParse(), extras[1].BeginParse())
+		if err != nil {
+			return nil, nil, err
+		}
+		b.StoreSlice(extra.BeginParse())
+		c, err := b.EndCell()
+		return c, extra, err
+	}
+	return build(keys, 0)
+}
@@ -0,0 +1,259 @@
+package cell
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func uintValue(v uint64) *Cell {
+	return mustCell(BeginCell().StoreUInt(v, 32).EndCell())
+}
+
+func TestDictLabels(t *testing.T) {
+	// One key: a single leaf with an hml_long label, 2+4+8 bits being shorter than
+	// hml_short's 2*8+2
+	d := NewDict(8)
+	d.Set(UintKey(0x0f, 8), uintValue(1))
+	want := mustCell(BeginCell().StoreUInt(0b10, 2).StoreUInt(8, 4).StoreUInt(0x0f, 8).StoreUInt(1, 32).EndCell())
+	if got := mustCell(d.ToCell()); !bytes.Equal(got.Hash(), want.Hash()) {
+		t.Errorf("single key dictionary %s, want %s", got, want)
+	}
+	
+	// Keys 0 and 255 fork at the root with an empty hml_short label; the leaves have
+	// hml_same labels of seven zeros and seven ones
+	d = NewDict(8)
+	d.Set(UintKey(0, 8), uintValue(1))
+	d.Set(UintKey(255, 8), uintValue(2))
+	left := mustCell(BeginCell().StoreUInt(0b110, 3).StoreUInt(7, 3).StoreUInt(1, 32).EndCell())
+	right := mustCell(BeginCell().StoreUInt(0b111, 3).StoreUInt(7, 3).StoreUInt(2, 32).EndCell())
+	want = mustCell(BeginCell().StoreUInt(0b00, 2).StoreRef(left).StoreRef(right).EndCell())
+	if got := mustCell(d.ToCell()); !bytes.Equal(got.Hash(), want.Hash()) {
+		t.Errorf("two key dictionary %s, want %s", got, want)
+	}
+	
+	if c := mustCell(BeginCell().StoreDict(NewDict(8)).EndCell()); c.BitsSize() != 1 || c.RefsNum() != 0 {
+		t.Errorf("empty dictionary stored as %s", c)
+	}
+}
+
+func TestDictRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, keyLen := range []int{1, 3, 8, 15, 32, 64} {
+		for iter := 0; iter < 20; iter++ {
+			want := map[uint64]uint64{}
+			d := NewDict(keyLen)
+			for i := r.Intn(40) + 1; i > 0; i-- {
+				k := r.Uint64()
+				if keyLen < 64 {
+					k &= 1<<keyLen - 1
+				}
+				want[k] = r.Uint64() >> 32
+				if err := d.Set(UintKey(k, keyLen), uintValue(want[k])); err != nil {
+					t.Fatal(err)
+				}
+			}
+			
+			root := mustCell(FromBOC(mustCell(d.ToCell()).ToBOC()))
+			loaded := LoadDict(root, keyLen)
+			var keys []uint64
+			err := loaded.ForEach(func(key []byte, v *Slice) error {
+				k := KeyUint(key, keyLen)
+				if got := v.LoadUInt(32); got != want[k] {
+					return fmt.Errorf("key %d: value %d, want %d", k, got, want[k])
+				}
+				keys = append(keys, k)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) != len(want) || !sort.SliceIsSorted(keys, func(i, j int) bool { return keys[i] < keys[j] }) {
+				t.Fatalf("%d bit keys: ForEach visited %v of %d keys", keyLen, keys, len(want))
+			}
+			for k, v := range want {
+				s, err := loaded.Get(UintKey(k, keyLen))
+				if err != nil || s == nil || s.LoadUInt(32) != v {
+					t.Fatalf("%d bit keys: Get(%d) = %v, %v", keyLen, k, s, err)
+				}
+			}
+			
+			// Deleting from a loaded dictionary gives the tree built from scratch
+			// without the key
+			k := keys[r.Intn(len(keys))]
+			if ok, err := loaded.Delete(UintKey(k, keyLen)); !ok || err != nil {
+				t.Fatalf("Delete(%d) = %v, %v", k, ok, err)
+			}
+			if s, _ := loaded.Get(UintKey(k, keyLen)); s != nil {
+				t.Fatalf("key %d found after Delete", k)
+			}
+			fresh := NewDict(keyLen)
+			for key, v := range want {
+				if key != k {
+					fresh.Set(UintKey(key, keyLen), uintValue(v))
+				}
+			}
+			a := mustCell(BeginCell().StoreDict(loaded).EndCell())
+			b := mustCell(BeginCell().StoreDict(fresh).EndCell())
+			if !bytes.Equal(a.Hash(), b.Hash()) {
+				t.Fatalf("%d bit keys: dictionary after Delete differs from a new one", keyLen)
+			}
+		}
+	}
+}
+
+func TestDictLongKeys(t *testing.T) {
+	// Keys of 267 bits, the size of an address
+	r := rand.New(rand.NewSource(2))
+	d := NewDict(267)
+	want := map[string]uint64{}
+	for i := 0; i < 100; i++ {
+		key := make([]byte, 34)
+		r.Read(key)
+		key[33] &= 0xe0
+		want[string(key)] = uint64(i)
+		d.Set(key, uintValue(uint64(i)))
+	}
+	
+	loaded := LoadDict(mustCell(d.ToCell()), 267)
+	var prev []byte
+	n := 0
+	err := loaded.ForEach(func(key []byte, v *Slice) error {
+		if prev != nil && bytes.Compare(prev, key) >= 0 {
+			return fmt.Errorf("key %x after %x", key, prev)
+		}
+		prev = key
+		if got := v.LoadUInt(32); got != want[string(key)] {
+			return fmt.Errorf("key %x: value %d", key, got)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(want) {
+		t.Errorf("%d keys, want %d", n, len(want))
+	}
+	if v, _ := loaded.Get(make([]byte, 34)); v != nil {
+		t.Error("missing key found")
+	}
+}
+
+func TestDictKeyLength(t *testing.T) {
+	if err := NewDict(16).Set([]byte{1}, uintValue(1)); err == nil {
+		t.Error("8 bit key accepted in a 16 bit dictionary")
+	}
+	
+	// Keys are the first keyLen bits of the given bytes
+	d := NewDict(12)
+	d.Set([]byte{0xab, 0xcf}, uintValue(1))
+	if v, _ := d.Get([]byte{0xab, 0xc0}); v == nil || v.LoadUInt(32) != 1 {
+		t.Error("bits after the key length are not ignored")
+	}
+}
+
+// sumAug is an augmentation summing 32 bit extras
+func sumAug() *Augmentation {
+	return &Augmentation{
+		Skip: func(s *Slice) { s.Skip(32) },
+		Combine: func(l, r *Slice) (*Cell, error) {
+			return BeginCell().StoreUInt(l.LoadUInt(32)+r.LoadUInt(32), 32).EndCell()
+		},
+		Empty: uintValue(0),
+	}
+}
+
+func TestAugDict(t *testing.T) {
+	d := NewAugDict(16, sumAug())
+	var total uint64
+	for i := uint64(0); i < 50; i++ {
+		k := i * 977 % 65536
+		value := mustCell(BeginCell().StoreUInt(k, 16).StoreRef(uintValue(i)).EndCell())
+		if err := d.Set(UintKey(k, 16), value, uintValue(i)); err != nil {
+			t.Fatal(err)
+		}
+		total += i
+	}
+	
+	c := mustCell(BeginCell().StoreAugDict(d).StoreUInt(0x77, 8).EndCell())
+	s := c.BeginParse()
+	loaded := s.LoadAugDict(16, sumAug())
+	if s.LoadUInt(8) != 0x77 || s.Err() != nil {
+		t.Fatal("data after the dictionary misread")
+	}
+	if extra, err := loaded.Extra(); err != nil || extra.LoadUInt(32) != total {
+		t.Fatalf("root extra %v, %v; want %d", extra, err, total)
+	}
+	
+	v, x, err := loaded.Get(UintKey(977*3, 16))
+	if err != nil || v == nil {
+		t.Fatalf("Get = %v, %v", v, err)
+	}
+	if v.LoadUInt(16) != 977*3 || v.LoadRef() == nil || x.LoadUInt(32) != 3 || x.BitsLeft() != 0 {
+		t.Error("value or extra misread")
+	}
+	
+	if ok, err := loaded.Delete(UintKey(977*3, 16)); !ok || err != nil {
+		t.Fatalf("Delete = %v, %v", ok, err)
+	}
+	if extra, _ := loaded.Extra(); extra.LoadUInt(32) != total-3 {
+		t.Error("root extra not updated after Delete")
+	}
+	n := 0
+	loaded.ForEach(func(key []byte, v, x *Slice) error { n++; return nil })
+	if n != 49 {
+		t.Errorf("%d entries after Delete, want 49", n)
+	}
+	
+	// An empty HashmapAugE is a zero bit and the empty extra
+	if c := mustCell(BeginCell().StoreAugDict(NewAugDict(16, sumAug())).EndCell()); c.BitsSize() != 33 {
+		t.Errorf("empty augmented dictionary of %d bits", c.BitsSize())
+	}
+}
+
+func TestPfxDict(t *testing.T) {
+	keys := []struct {
+		bits uint64
+		n    int
+	}{{0b0, 1}, {0b10, 2}, {0b110, 3}, {0b1110000, 7}, {0b1111, 4}, {0b11100011, 8}}
+	d := NewPfxDict(16)
+	for i, k := range keys {
+		if err := d.Set(UintKey(k.bits, k.n), k.n, uintValue(uint64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	
+	loaded := mustCell(BeginCell().StorePfxDict(d).EndCell()).BeginParse().LoadPfxDict(16)
+	for i, k := range keys {
+		v, err := loaded.Get(UintKey(k.bits, k.n), k.n)
+		if err != nil || v == nil || v.LoadUInt(32) != uint64(i) {
+			t.Errorf("Get(%0*b) = %v, %v", k.n, k.bits, v, err)
+		}
+	}
+	// Prefixes of keys are not keys
+	for _, k := range []struct {
+		bits uint64
+		n    int
+	}{{0b11, 2}, {0b111000, 6}} {
+		if v, _ := loaded.Get(UintKey(k.bits, k.n), k.n); v != nil {
+			t.Errorf("prefix %0*b found", k.n, k.bits)
+		}
+	}
+	
+	var got []string
+	loaded.ForEach(func(key []byte, n int, v *Slice) error {
+		got = append(got, fmt.Sprintf("%0*b", n, KeyUint(key, n)))
+		return nil
+	})
+	if fmt.Sprint(got) != "[0 10 110 1110000 11100011 1111]" {
+		t.Errorf("ForEach visited %v", got)
+	}
+	
+	loaded.Set(UintKey(0b11, 2), 2, uintValue(9))
+	if _, err := loaded.ToCell(); err == nil {
+		t.Error("key that is a prefix of another accepted")
+	}
+}
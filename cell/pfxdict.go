@@ -0,0 +1,291 @@
+package cell
+
+import (
+	"fmt"
+	"sort"
+)
+
+// pfxKey is a key of a prefix dictionary: left aligned bits and their count
+type pfxKey struct {
+	data string
+	n    int
+}
+
+// PfxDict is a prefix code dictionary (PfxHashmap n X): keys have up to n bits and
+// none of them is a prefix of another. Keys are left aligned bits with an explicit
+// length.
+type PfxDict struct {
+	maxLen  int
+	root    *Cell
+	entries map[pfxKey]*Cell // nil until the dictionary is modified
+}
+
+// NewPfxDict returns an empty prefix dictionary with keys of up to maxLen bits
+func NewPfxDict(maxLen int) *PfxDict {
+	return &PfxDict{maxLen: maxLen, entries: map[pfxKey]*Cell{}}
+}
+
+// LoadPfxDict wraps the root cell of a PfxHashmap with keys of up to maxLen bits; a
+// nil root is an empty dictionary
+func LoadPfxDict(root *Cell, maxLen int) *PfxDict {
+	return &PfxDict{maxLen: maxLen, root: root}
+}
+
+// LoadPfxDict reads a PfxHashmapE with keys of up to maxLen bits
+func (s *Slice) LoadPfxDict(maxLen int) *PfxDict {
+	return LoadPfxDict(s.LoadMaybeRef(), maxLen)
+}
+
+// StorePfxDict stores a prefix dictionary as a PfxHashmapE
+func (b *Builder) StorePfxDict(d *PfxDict) *Builder {
+	root, err := d.ToCell()
+	if err != nil {
+		return b.fail(err)
+	}
+	return b.StoreMaybeRef(root)
+}
+
+// MaxKeyLen returns the maximum key length in bits
+func (d *PfxDict) MaxKeyLen() int {
+	return d.maxLen
+}
+
+// IsEmpty reports whether the dictionary has no entries
+func (d *PfxDict) IsEmpty() bool {
+	if d.entries != nil {
+		return len(d.entries) == 0
+	}
+	return d.root == nil
+}
+
+// Get returns the value of the n-bit key, nil if the key is absent
+func (d *PfxDict) Get(key []byte, n int) (*Slice, error) {
+	k, err := d.normKey(key, n)
+	if err != nil {
+		return nil, err
+	}
+	if d.entries != nil {
+		if v := d.entries[pfxKey{string(k), n}]; v != nil {
+			return v.BeginParse(), nil
+		}
+		return nil, nil
+	}
+	
+	pos := 0
+	for c := d.root; c != nil; {
+		s, err := parseNode(c)
+		if err != nil {
+			return nil, err
+		}
+		label, l := loadLabel(s, d.maxLen-pos)
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		if pos+l > n {
+			return nil, nil
+		}
+		for i := 0; i < l; i++ {
+			if bitAt(label, i) != bitAt(k, pos+i) {
+				return nil, nil
+			}
+		}
+		pos += l
+		
+		// phmn_leaf$0 value:X | phmn_fork$1 left:^(PfxHashmap n1 X) right:^(PfxHashmap n1 X)
+		if !s.LoadBit() {
+			if err := s.Err(); err != nil || pos != n {
+				return nil, err
+			}
+			return s, nil
+		}
+		left, right := s.LoadRef(), s.LoadRef()
+		if err := s.Err(); err != nil || pos == n {
+			return nil, err
+		}
+		c = left
+		if bitAt(k, pos) {
+			c = right
+		}
+		pos++
+	}
+	return nil, nil
+}
+
+// ForEach calls fn for every entry in ascending key order
+func (d *PfxDict) ForEach(fn func(key []byte, n int, value *Slice) error) error {
+	if d.entries != nil {
+		for _, k := range d.sortedKeys() {
+			if err := fn([]byte(k.data), k.n, d.entries[k].BeginParse()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if d.root == nil {
+		return nil
+	}
+	
+	key := make([]byte, (d.maxLen+7)/8)
+	var walk func(c *Cell, pos int) error
+	walk = func(c *Cell, pos int) error {
+		s, err := parseNode(c)
+		if err != nil {
+			return err
+		}
+		label, l := loadLabel(s, d.maxLen-pos)
+		if err := s.Err(); err != nil {
+			return err
+		}
+		if pos+l > d.maxLen {
+			return fmt.Errorf("%w: dictionary label is longer than the key", ErrInvalidCell)
+		}
+		for i := 0; i < l; i++ {
+			setKeyBit(key, pos+i, bitAt(label, i))
+		}
+		pos += l
+		
+		if !s.LoadBit() {
+			if err := s.Err(); err != nil {
+				return err
+			}
+			k, _ := normKey(key, pos)
+			return fn(k, pos, s)
+		}
+		left, right := s.LoadRef(), s.LoadRef()
+		if err := s.Err(); err != nil {
+			return err
+		}
+		if pos == d.maxLen {
+			return fmt.Errorf("%w: dictionary fork below the maximum key length", ErrInvalidCell)
+		}
+		for i, child := range []*Cell{left, right} {
+			setKeyBit(key, pos, i == 1)
+			if err := walk(child, pos+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(d.root, 0)
+}
+
+// Set sets the value of the n-bit key. Keys that are prefixes of one another are
+// reported by ToCell.
+func (d *PfxDict) Set(key []byte, n int, value *Cell) error {
+	k, err := d.normKey(key, n)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("cell: nil dictionary value")
+	}
+	if err := d.load(); err != nil {
+		return err
+	}
+	d.entries[pfxKey{string(k), n}] = value
+	return nil
+}
+
+// Delete removes the n-bit key and reports whether it was present
+func (d *PfxDict) Delete(key []byte, n int) (bool, error) {
+	k, err := d.normKey(key, n)
+	if err != nil {
+		return false, err
+	}
+	if err := d.load(); err != nil {
+		return false, err
+	}
+	_, ok := d.entries[pfxKey{string(k), n}]
+	delete(d.entries, pfxKey{string(k), n})
+	return ok, nil
+}
+
+// ToCell returns the root cell of the PfxHashmap, nil for an empty dictionary
+func (d *PfxDict) ToCell() (*Cell, error) {
+	if d.entries == nil {
+		return d.root, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, nil
+	}
+	
+	var build func(keys []pfxKey, pos int) (*Cell, error)
+	build = func(keys []pfxKey, pos int) (*Cell, error) {
+		first, last := []byte(keys[0].data), []byte(keys[len(keys)-1].data)
+		m := d.maxLen - pos
+		
+		b := BeginCell()
+		if len(keys) == 1 {
+			l := keys[0].n - pos
+			storeLabel(b, subBits(first, pos, l), l, m)
+			b.StoreBit(false).StoreSlice(d.entries[keys[0]].BeginParse())
+			return b.EndCell()
+		}
+		
+		l := keys[0].n
+		if keys[len(keys)-1].n < l {
+			l = keys[len(keys)-1].n
+		}
+		l = commonBits(first, last, pos, l-pos)
+		for _, k := range keys {
+			if k.n <= pos+l {
+				return nil, fmt.Errorf("cell: prefix dictionary key %x/%d is a prefix of another key", k.data, k.n)
+			}
+		}
+		storeLabel(b, subBits(first, pos, l), l, m)
+		b.StoreBit(true)
+		
+		split := sort.Search(len(keys), func(i int) bool { return bitAt([]byte(keys[i].data), pos+l) })
+		for _, part := range [][]pfxKey{keys[:split], keys[split:]} {
+			child, err := build(part, pos+l+1)
+			if err != nil {
+				return nil, err
+			}
+			b.StoreRef(child)
+		}
+		return b.EndCell()
+	}
+	return build(d.sortedKeys(), 0)
+}
+
+// normKey checks the key length and copies the key
+func (d *PfxDict) normKey(key []byte, n int) ([]byte, error) {
+	if n < 0 || n > d.maxLen {
+		return nil, fmt.Errorf("cell: prefix dictionary key of %d bits, at most %d allowed", n, d.maxLen)
+	}
+	return normKey(key, n)
+}
+
+// load reads all entries of a loaded dictionary before its first modification
+func (d *PfxDict) load() error {
+	if d.entries != nil {
+		return nil
+	}
+	entries := map[pfxKey]*Cell{}
+	err := d.ForEach(func(key []byte, n int, value *Slice) error {
+		c, err := value.ToCell()
+		entries[pfxKey{string(key), n}] = c
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	d.entries = entries
+	return nil
+}
+
+// sortedKeys returns the keys in bit order; a key sorts before the longer keys it is
+// a prefix of
+func (d *PfxDict) sortedKeys() []pfxKey {
+	keys := make([]pfxKey, 0, len(d.entries))
+	for k := range d.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].data != keys[j].data {
+			return keys[i].data < keys[j].data
+		}
+		return keys[i].n < keys[j].n
+	})
+	return keys
+}
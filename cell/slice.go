@@ -0,0 +1,240 @@
+package cell
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Slice reads the bits and references of a cell sequentially
+type Slice struct {
+	cell   *Cell
+	bitPos int
+	refPos int
+	err    error
+}
+
+// Err returns the first error that occurred while reading
+func (s *Slice) Err() error {
+	return s.err
+}
+
+// BitsLeft returns the number of unread bits
+func (s *Slice) BitsLeft() int {
+	return s.cell.bits - s.bitPos
+}
+
+// RefsLeft returns the number of unread references
+func (s *Slice) RefsLeft() int {
+	return len(s.cell.refs) - s.refPos
+}
+
+// Copy returns an independent slice at the same position
+func (s *Slice) Copy() *Slice {
+	cp := *s
+	return &cp
+}
+
+// ToCell returns a cell with the unread bits and references
+func (s *Slice) ToCell() (*Cell, error) {
+	return BeginCell().StoreSlice(s).EndCell()
+}
+
+// fail records the first error
+func (s *Slice) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// need checks that n more bits can be read
+func (s *Slice) need(n int) bool {
+	if s.err != nil {
+		return false
+	}
+	if n < 0 || s.bitPos+n > s.cell.bits {
+		s.fail(fmt.Errorf("%w: %d bits requested, %d left", ErrOutOfData, n, s.BitsLeft()))
+		return false
+	}
+	return true
+}
+
+// bit returns the bit at the current position without checks and advances
+func (s *Slice) bit() bool {
+	v := s.cell.data[s.bitPos/8]>>(7-s.bitPos%8)&1 == 1
+	s.bitPos++
+	return v
+}
+
+// LoadBit reads one bit
+func (s *Slice) LoadBit() bool {
+	if !s.need(1) {
+		return false
+	}
+	return s.bit()
+}
+
+// LoadBool reads one bit as a boolean
+func (s *Slice) LoadBool() bool {
+	return s.LoadBit()
+}
+
+// LoadUInt reads an unsigned integer of n bits (n <= 64)
+func (s *Slice) LoadUInt(n int) uint64 {
+	if n > 64 {
+		s.fail(fmt.Errorf("LoadUInt supports at most 64 bits, got %d", n))
+		return 0
+	}
+	if !s.need(n) {
+		return 0
+	}
+	var v uint64
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if s.bit() {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// PreloadUInt reads an unsigned integer of n bits without advancing
+func (s *Slice) PreloadUInt(n int) uint64 {
+	cp := *s
+	return cp.LoadUInt(n)
+}
+
+// LoadInt reads a signed integer of n bits (n <= 64)
+func (s *Slice) LoadInt(n int) int64 {
+	v := s.LoadUInt(n)
+	if n > 0 && n < 64 && v>>(uint(n)-1) == 1 {
+		return int64(v) - int64(1)<<uint(n)
+	}
+	return int64(v)
+}
+
+// LoadBigUInt reads an unsigned integer of n bits
+func (s *Slice) LoadBigUInt(n int) *big.Int {
+	data := s.LoadBits(n)
+	if s.err != nil {
+		return new(big.Int)
+	}
+	v := new(big.Int).SetBytes(data)
+	if n%8 != 0 {
+		v.Rsh(v, uint(8-n%8))
+	}
+	return v
+}
+
+// LoadBigInt reads a two's complement integer of n bits
+func (s *Slice) LoadBigInt(n int) *big.Int {
+	v := s.LoadBigUInt(n)
+	if n > 0 && v.Bit(n-1) == 1 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(n)))
+	}
+	return v
+}
+
+// LoadBits reads n bits, left aligned in the returned bytes
+func (s *Slice) LoadBits(n int) []byte {
+	if !s.need(n) {
+		return nil
+	}
+	out := make([]byte, (n+7)/8)
+	if s.bitPos%8 == 0 {
+		copy(out, s.cell.data[s.bitPos/8:])
+		if n%8 != 0 {
+			out[len(out)-1] &= 0xff << uint(8-n%8)
+		}
+		s.bitPos += n
+		return out
+	}
+	for i := 0; i < n; i++ {
+		if s.bit() {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+// LoadBytes reads n whole bytes
+func (s *Slice) LoadBytes(n int) []byte {
+	return s.LoadBits(n * 8)
+}
+
+// LoadVarUInt reads a VarUInteger whose byte length is encoded in lenBits bits
+func (s *Slice) LoadVarUInt(lenBits int) *big.Int {
+	n := s.LoadUInt(lenBits)
+	return s.LoadBigUInt(int(n) * 8)
+}
+
+// LoadCoins reads an amount of nanotons (Grams)
+func (s *Slice) LoadCoins() *big.Int {
+	return s.LoadVarUInt(4)
+}
+
+// LoadRef reads the next reference
+func (s *Slice) LoadRef() *Cell {
+	if s.err != nil {
+		return nil
+	}
+	if s.refPos >= len(s.cell.refs) {
+		s.fail(fmt.Errorf("%w: no references left", ErrOutOfData))
+		return nil
+	}
+	r := s.cell.refs[s.refPos]
+	s.refPos++
+	return r
+}
+
+// LoadMaybeRef reads a Maybe ^Cell, returning nil for nothing
+func (s *Slice) LoadMaybeRef() *Cell {
+	if !s.LoadBit() {
+		return nil
+	}
+	return s.LoadRef()
+}
+
+// LoadRefSlice reads the next reference and begins parsing it
+func (s *Slice) LoadRefSlice() *Slice {
+	r := s.LoadRef()
+	if r == nil {
+		return &Slice{cell: &Cell{typ: Ordinary}, err: s.err}
+	}
+	return r.BeginParse()
+}
+
+// Skip skips n bits
+func (s *Slice) Skip(n int) {
+	if s.need(n) {
+		s.bitPos += n
+	}
+}
+
+// LoadBytesSnake reads the remaining whole bytes of the slice and of its chain of
+// first references
+func (s *Slice) LoadBytesSnake() []byte {
+	var out []byte
+	for cur := s; ; {
+		out = append(out, cur.LoadBytes(cur.BitsLeft()/8)...)
+		if cur.err != nil {
+			s.fail(cur.err)
+			return nil
+		}
+		if cur.RefsLeft() == 0 {
+			return out
+		}
+		cur = cur.LoadRefSlice()
+	}
+}
+
+// LoadStringSnake reads a snake encoded string
+func (s *Slice) LoadStringSnake() string {
+	return string(s.LoadBytesSnake())
+}
+
+// remainingBits returns the unread bits, left aligned
+func (s *Slice) remainingBits() ([]byte, int) {
+	cp := *s
+	n := cp.BitsLeft()
+	return cp.LoadBits(n), n
+}
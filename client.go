@@ -2,11 +2,16 @@ package toncenterzp
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
+	
+	"github.com/zhaopeng331/toncenterzp/payload"
 )
 
 const (
@@ -30,6 +35,43 @@ type Client struct {
 	BaseURL    string
 	APIKey     string
 	HTTPClient *http.Client
+	
+	// Logger receives request diagnostics: every request at debug level,
+	// failed and non-OK responses at warn level. A nil Logger disables logging.
+	Logger *slog.Logger
+	
+	// Cache stores successful responses of the endpoints listed in CachePolicies.
+	// A nil Cache disables caching.
+	Cache Cache
+	
+	// CachePolicies maps endpoints (e.g. EndpointGetBlockHeader) to the TTL of their
+	// cached responses. Endpoints that are not listed are never cached; a nil map
+	// means DefaultCachePolicies. Slow-changing data such as EndpointGetMasterchainInfo
+	// or EndpointGetAddressBalance can be opted in with a short TTL.
+	CachePolicies map[string]time.Duration
+	
+	// CoalesceEndpoints lists the endpoints whose identical concurrent requests
+	// share a single HTTP call; every waiter receives the same response.
+	// A nil map disables coalescing, see DefaultCoalesceEndpoints.
+	CoalesceEndpoints map[string]bool
+	
+	// Transport selects whether typed methods use their REST endpoints or /jsonRPC
+	Transport TransportMode
+	
+	// Backend, if set, serves the typed API methods instead of the HTTP API,
+	// e.g. a liteserver connection from package liteclient. A nil Backend
+	// means HTTPBackend.
+	Backend Backend
+	
+	// Emulator executes the messages passed to DryRun, e.g. a v3.Client or
+	// Emulators listing it before a local emulator. A nil Emulator disables DryRun.
+	Emulator Emulator
+	
+	// Decoder decodes the message bodies of GetTransactions results into
+	// Message.Decoded. A nil Decoder means payload.DefaultRegistry.
+	Decoder *payload.Registry
+	
+	flights flightGroup
 }
 
 // NewClient creates a new TON API client with the given API key
@@ -52,17 +94,80 @@ func NewClientWithOptions(apiKey, baseURL string, timeout time.Duration) *Client
 
 // doRequest performs an HTTP request to the TON API
 func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, error) {
-	var reqBody io.Reader
+	var jsonBody []byte
+	
+	if body != nil {
+		var err error
+		jsonBody, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling request body: %w", err)
+		}
+	}
+	
+	cacheKey, cacheTTL, cacheable := c.cachePolicy(method, endpoint, jsonBody)
+	if cacheable {
+		if respBody, ok := c.Cache.Get(cacheKey); ok {
+			c.log(slog.LevelDebug, "cache hit",
+				slog.String("method", method),
+				slog.String("endpoint", c.redact(endpoint)),
+				slog.Int("bytes", len(respBody)),
+			)
+			return respBody, nil
+		}
+	}
+	
+	var respBody []byte
+	var err error
+	if c.shouldCoalesce(endpoint) {
+		var shared bool
+		respBody, err, shared = c.flights.do(method+" "+endpoint+" "+string(jsonBody), func() ([]byte, error) {
+			return c.execute(method, endpoint, jsonBody)
+		})
+		if shared {
+			c.log(slog.LevelDebug, "coalesced request",
+				slog.String("method", method),
+				slog.String("endpoint", c.redact(endpoint)),
+			)
+		}
+	} else {
+		respBody, err = c.execute(method, endpoint, jsonBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	if cacheable && isOKResponse(respBody) {
+		c.Cache.Set(cacheKey, respBody, cacheTTL)
+	}
 	
+	return respBody, nil
+}
+
+// RawRequest sends an authenticated request to baseURL+endpoint through the client's
+// HTTP client and logger and returns the raw response body. It lets
+// sub-clients for other API versions of the same provider (see package v3) share
+// the transport of a Client; caching, coalescing and the transport mode do not apply.
+func (c *Client) RawRequest(method, baseURL, endpoint string, body interface{}) ([]byte, error) {
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("error marshaling request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
 	}
 	
-	url := c.BaseURL + endpoint
+	return c.send(method, baseURL, endpoint, jsonBody)
+}
+
+// send performs a single HTTP round trip and returns the response body
+func (c *Client) send(method, baseURL, endpoint string, jsonBody []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if jsonBody != nil {
+		reqBody = bytes.NewReader(jsonBody)
+	}
+	
+	url := baseURL + endpoint
 	req, err := http.NewRequest(method, url, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
@@ -71,8 +176,15 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 	req.Header.Set("x-api-key", c.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 	
+	start := time.Now()
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
+		c.log(slog.LevelWarn, "request failed",
+			slog.String("method", method),
+			slog.String("endpoint", c.redact(endpoint)),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("error", c.redact(err.Error())),
+		)
 		return nil, fmt.Errorf("error sending request: %w", err)
 	}
 	defer resp.Body.Close()
@@ -82,7 +194,22 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
 	
+	c.log(slog.LevelDebug, "request completed",
+		slog.String("method", method),
+		slog.String("endpoint", c.redact(endpoint)),
+		slog.Duration("duration", time.Since(start)),
+		slog.Int("status", resp.StatusCode),
+		slog.Int("bytes", len(respBody)),
+	)
+	
 	if resp.StatusCode != http.StatusOK {
+		c.log(slog.LevelWarn, "non-OK response",
+			slog.String("method", method),
+			slog.String("endpoint", c.redact(endpoint)),
+			slog.Int("status", resp.StatusCode),
+			slog.String("body", c.redact(string(respBody))),
+		)
+		
 		var errResp ErrorResponse
 		if err := json.Unmarshal(respBody, &errResp); err != nil {
 			return nil, fmt.Errorf("error response with status code %d: %s", resp.StatusCode, string(respBody))
@@ -91,5 +218,34 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 		return nil, fmt.Errorf("API error: %s (code: %d, status: %d)", errResp.Error, errResp.Code, errResp.Status)
 	}
 	
+	if c.Logger != nil {
+		var okResp struct {
+			OK *bool `json:"ok"`
+		}
+		if json.Unmarshal(respBody, &okResp) == nil && okResp.OK != nil && !*okResp.OK {
+			c.log(slog.LevelWarn, "non-OK response",
+				slog.String("method", method),
+				slog.String("endpoint", c.redact(endpoint)),
+				slog.Int("status", resp.StatusCode),
+			)
+		}
+	}
+	
 	return respBody, nil
 }
+
+// log writes a record to the client logger if one is configured
+func (c *Client) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	if c.Logger == nil {
+		return
+	}
+	c.Logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// redact removes the API key from strings that end up in log records
+func (c *Client) redact(s string) string {
+	if c.APIKey == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, c.APIKey, "REDACTED")
+}
@@ -0,0 +1,112 @@
+package toncenterzp
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testAPIKey = "secret-api-key-123"
+
+// logRecords decodes the records written by a JSON slog handler
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("decoding log record %q: %v", line, err)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestLogging(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != testAPIKey {
+			t.Errorf("x-api-key header %q", r.Header.Get("x-api-key"))
+		}
+		if r.URL.Path == "/getAddressBalance" {
+			w.Write([]byte(`{"ok":true,"result":"1500"}`))
+			return
+		}
+		// Error bodies may echo the request, key included
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"ok":false,"error":"invalid key ` + r.URL.Query().Get("api_key") + `","code":401}`))
+	}))
+	defer srv.Close()
+	
+	var buf bytes.Buffer
+	c := NewClientWithOptions(testAPIKey, srv.URL, DefaultTimeout)
+	c.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	
+	if _, err := c.GetAddressBalance("EQabc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.doRequest(http.MethodGet, "/getMasterchainInfo?api_key="+testAPIKey, nil); err == nil {
+		t.Fatal("no error for a 401 response")
+	}
+	
+	if strings.Contains(buf.String(), testAPIKey) {
+		t.Fatalf("API key in the log:\n%s", buf.String())
+	}
+	
+	recs := logRecords(t, &buf)
+	if len(recs) != 3 {
+		t.Fatalf("%d log records, want 3:\n%s", len(recs), buf.String())
+	}
+	ok := recs[0]
+	if ok["level"] != "DEBUG" || ok["msg"] != "request completed" || ok["method"] != http.MethodGet ||
+		ok["endpoint"] != "/getAddressBalance?address=EQabc" || ok["status"] != float64(200) ||
+		ok["bytes"] != float64(len(`{"ok":true,"result":"1500"}`)) || ok["duration"] == nil {
+		t.Errorf("request record %v", ok)
+	}
+	if recs[1]["msg"] != "request completed" || recs[1]["endpoint"] != "/getMasterchainInfo?api_key=REDACTED" {
+		t.Errorf("request record %v", recs[1])
+	}
+	failed := recs[2]
+	if failed["level"] != "WARN" || failed["msg"] != "non-OK response" || failed["status"] != float64(401) ||
+		!strings.Contains(failed["body"].(string), "invalid key REDACTED") {
+		t.Errorf("non-OK record %v", failed)
+	}
+}
+
+func TestLoggingNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	
+	var buf bytes.Buffer
+	c := NewClientWithOptions(testAPIKey, url, DefaultTimeout)
+	c.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	
+	if _, err := c.doRequest(http.MethodGet, "/getMasterchainInfo?api_key="+testAPIKey, nil); err == nil {
+		t.Fatal("no error from a closed server")
+	}
+	if strings.Contains(buf.String(), testAPIKey) {
+		t.Fatalf("API key in the log:\n%s", buf.String())
+	}
+	recs := logRecords(t, &buf)
+	if len(recs) != 1 || recs[0]["level"] != "WARN" || recs[0]["msg"] != "request failed" ||
+		!strings.Contains(recs[0]["error"].(string), "api_key=REDACTED") {
+		t.Errorf("records %v", recs)
+	}
+}
+
+func TestNoLogger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"error":"boom","code":500}`))
+	}))
+	defer srv.Close()
+	
+	// A nil Logger disables logging, including the non-OK check of 200 responses
+	c := NewClientWithOptions(testAPIKey, srv.URL, DefaultTimeout)
+	if _, err := c.doRequest(http.MethodGet, "/getMasterchainInfo", nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,81 @@
+// Command tlbgen generates Go types from TL-B schemas.
+//
+// Usage:
+//
+//	tlbgen [-pkg name] [-out file] schema.tlb...
+//
+// The schemas are parsed together, so their types may refer to each other. The
+// package defaults to $GOPACKAGE, set by go generate, and the output file to the
+// name of the first schema with a _tlb.go suffix:
+//
+//	//go:generate go run github.com/zhaopeng331/toncenterzp/cmd/tlbgen messages.tlb
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	
+	"github.com/zhaopeng331/toncenterzp/tlb"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run generates the output file and returns the exit code
+func run(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("tlbgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "package of the generated file")
+	out := fs.String("out", "", "output file, \"-\" for stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: tlbgen [-pkg name] [-out file] schema.tlb...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 || *pkg == "" {
+		fs.Usage()
+		return 2
+	}
+	
+	var src strings.Builder
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(stderr, "tlbgen:", err)
+			return 1
+		}
+		src.Write(data)
+		src.WriteString("\n")
+	}
+	
+	schema, err := tlb.Parse(src.String())
+	if err != nil {
+		fmt.Fprintln(stderr, "tlbgen:", err)
+		return 1
+	}
+	code, err := tlb.Generate(schema, *pkg)
+	if err != nil {
+		fmt.Fprintln(stderr, "tlbgen:", err)
+		return 1
+	}
+	
+	if *out == "-" {
+		_, err = os.Stdout.Write(code)
+	} else {
+		if *out == "" {
+			*out = strings.TrimSuffix(fs.Arg(0), ".tlb") + "_tlb.go"
+		}
+		err = os.WriteFile(*out, code, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "tlbgen:", err)
+		return 1
+	}
+	return 0
+}
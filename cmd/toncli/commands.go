@@ -0,0 +1,536 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/config"
+	"github.com/zhaopeng331/toncenterzp/export"
+)
+
+// commands lists every toncli subcommand
+var commands = []command{
+	{
+		name: "detect", usage: "<address>", summary: "detect the forms of an address",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.DetectAddress(args[0])
+		}),
+	},
+	{
+		name: "fee", usage: "<address> [--body b64] [--init-code b64] [--init-data b64]", summary: "estimate the fees of an external message",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *flag.FlagSet) runFunc {
+			body := fs.String("body", "", "message body BOC in base64")
+			initCode := fs.String("init-code", "", "init code BOC in base64")
+			initData := fs.String("init-data", "", "init data BOC in base64")
+			ignoreChksig := fs.Bool("ignore-chksig", true, "ignore signature checks")
+			return func(c *toncenterzp.Client, args []string) (interface{}, error) {
+				return c.EstimateFee(toncenterzp.EstimateFeeRequest{
+					Address:      args[0],
+					Body:         *body,
+					InitCode:     *initCode,
+					InitData:     *initData,
+					IgnoreChksig: *ignoreChksig,
+				})
+			}
+		},
+	},
+	{
+		name: "balance", usage: "<address>", summary: "get the balance of an address",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.GetAddressBalance(args[0])
+		}),
+	},
+	{
+		name: "info", usage: "<address>", summary: "get the information of an address",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.GetAddressInformation(args[0])
+		}),
+	},
+	{
+		name: "state", usage: "<address>", summary: "get the state of an address",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.GetAddressState(args[0])
+		}),
+	},
+	{
+		name: "extinfo", usage: "<address>", summary: "get the extended information of an address",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.GetExtendedAddressInformation(args[0])
+		}),
+	},
+	{
+		name: "wallet", usage: "<address>", summary: "get the wallet information of an address",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.GetWalletInformation(args[0])
+		}),
+	},
+	{
+		name: "token", usage: "<address>", summary: "get jetton or NFT data",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.GetTokenData(args[0])
+		}),
+	},
+	{
+		name: "block", usage: "<workchain> <shard> <seqno>", summary: "get a block header",
+		minArgs: 3, maxArgs: 3,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			wc, seqno, err := parseBlockArgs(args)
+			if err != nil {
+				return nil, err
+			}
+			return c.GetBlockHeader(toncenterzp.GetBlockHeaderRequest{Workchain: wc, Shard: args[1], SeqNo: seqno})
+		}),
+	},
+	{
+		name: "blocktxs", usage: "<workchain> <shard> <seqno> [--count n] [--after-lt lt --after-hash hash]", summary: "list the transactions of a block",
+		minArgs: 3, maxArgs: 3,
+		setup: func(fs *flag.FlagSet) runFunc {
+			count := fs.Int("count", 0, "maximum number of transactions")
+			afterLt := fs.String("after-lt", "", "continue after this logical time")
+			afterHash := fs.String("after-hash", "", "continue after this transaction hash")
+			return func(c *toncenterzp.Client, args []string) (interface{}, error) {
+				wc, seqno, err := parseBlockArgs(args)
+				if err != nil {
+					return nil, err
+				}
+				return c.GetBlockTransactions(toncenterzp.GetBlockTransactionsRequest{
+					Workchain: wc,
+					Shard:     args[1],
+					SeqNo:     seqno,
+					Count:     *count,
+					AfterLt:   *afterLt,
+					AfterHash: *afterHash,
+				})
+			}
+		},
+	},
+	{
+		name: "consensus", usage: "", summary: "get the consensus block",
+		maxArgs: 0,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.GetConsensusBlock(&toncenterzp.GetConsensusBlockRequest{})
+		}),
+	},
+	{
+		name: "lookup", usage: "<workchain> [--shard s] [--seqno n | --lt lt | --unixtime t]", summary: "look up a block",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *flag.FlagSet) runFunc {
+			shard := fs.String("shard", "", "shard ID")
+			seqno := fs.Int("seqno", 0, "block seqno")
+			lt := fs.String("lt", "", "logical time inside the block")
+			unixtime := fs.Int("unixtime", 0, "unix time inside the block")
+			return func(c *toncenterzp.Client, args []string) (interface{}, error) {
+				wc, err := strconv.Atoi(args[0])
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid workchain %q", errUsage, args[0])
+				}
+				return c.LookupBlock(toncenterzp.LookupBlockRequest{
+					Workchain: wc,
+					Shard:     *shard,
+					SeqNo:     *seqno,
+					Lt:        *lt,
+					UnixTime:  *unixtime,
+				})
+			}
+		},
+	},
+	{
+		name: "masterchain", usage: "", summary: "get the latest masterchain block",
+		maxArgs: 0,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.GetMasterchainInfo()
+		}),
+	},
+	{
+		name: "signatures", usage: "<seqno>", summary: "get the signatures of a masterchain block",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			seqno, err := strconv.Atoi(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid seqno %q", errUsage, args[0])
+			}
+			return c.GetMasterchainBlockSignatures(toncenterzp.GetMasterchainBlockSignaturesRequest{SeqNo: seqno})
+		}),
+	},
+	{
+		name: "shardproof", usage: "<workchain> <shard> <seqno> [--from seqno]", summary: "get the proof of a shard block",
+		minArgs: 3, maxArgs: 3,
+		setup: func(fs *flag.FlagSet) runFunc {
+			from := fs.Int("from", 0, "masterchain seqno to build the proof from")
+			return func(c *toncenterzp.Client, args []string) (interface{}, error) {
+				wc, seqno, err := parseBlockArgs(args)
+				if err != nil {
+					return nil, err
+				}
+				return c.GetShardBlockProof(toncenterzp.GetShardBlockProofRequest{Workchain: wc, Shard: args[1], SeqNo: seqno, FromSeqNo: *from})
+			}
+		},
+	},
+	{
+		name: "shards", usage: "<seqno>", summary: "list the shards of a masterchain block",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			seqno, err := strconv.Atoi(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid seqno %q", errUsage, args[0])
+			}
+			return c.Shards(seqno)
+		}),
+	},
+	{
+		name: "config", usage: "<param|all> [--raw]", summary: "get a blockchain config param, decoded when its layout is known",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *flag.FlagSet) runFunc {
+			raw := fs.Bool("raw", false, "print the param cell instead of decoding it")
+			return func(c *toncenterzp.Client, args []string) (interface{}, error) {
+				if args[0] == "all" {
+					return c.GetConfigAll()
+				}
+				n, err := strconv.Atoi(args[0])
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid param %q", errUsage, args[0])
+				}
+				resp, err := c.GetConfigParam(n)
+				if err != nil || *raw {
+					return resp, err
+				}
+				return decodeConfigParam(n, resp.Result.Config.Bytes)
+			}
+		},
+	},
+	{
+		name: "txs", usage: "<address> [--limit n] [--lt lt --hash hash] [--to-lt lt] [--archive]", summary: "list the transactions of an address",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *flag.FlagSet) runFunc {
+			limit := fs.Int("limit", 10, "maximum number of transactions")
+			lt := fs.String("lt", "", "start from this logical time")
+			hash := fs.String("hash", "", "start from this transaction hash")
+			toLt := fs.String("to-lt", "", "stop at this logical time")
+			archive := fs.Bool("archive", false, "only query archive nodes")
+			return func(c *toncenterzp.Client, args []string) (interface{}, error) {
+				return c.GetTransactions(toncenterzp.GetTransactionsRequest{
+					Address:     args[0],
+					Limit:       *limit,
+					Lt:          *lt,
+					Hash:        *hash,
+					ToLt:        *toLt,
+					ArchiveOnly: *archive,
+				})
+			}
+		},
+		table: transactionsTable,
+	},
+	{
+		name: "export", usage: "<address> [--format csv|jsonl] [--from time] [--to time] [--from-lt lt] [--to-lt lt] [--file path]", summary: "export the transaction history of an address",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *flag.FlagSet) runFunc {
+			format := fs.String("format", "csv", "export format: csv or jsonl")
+			from := fs.String("from", "", "oldest transaction time (RFC 3339 or unix seconds)")
+			to := fs.String("to", "", "newest transaction time (RFC 3339 or unix seconds)")
+			fromLt := fs.Uint64("from-lt", 0, "oldest logical time")
+			toLt := fs.Uint64("to-lt", 0, "newest logical time")
+			file := fs.String("file", "-", "output file, '-' for stdout")
+			pageSize := fs.Int("page-size", export.DefaultPageSize, "transactions per request")
+			return func(c *toncenterzp.Client, args []string) (interface{}, error) {
+				opts := export.Options{Address: args[0], FromLt: *fromLt, ToLt: *toLt, PageSize: *pageSize}
+				var err error
+				if opts.Format, err = export.ParseFormat(*format); err != nil {
+					return nil, fmt.Errorf("%w: %v", errUsage, err)
+				}
+				if opts.FromTime, err = parseTime(*from); err != nil {
+					return nil, err
+				}
+				if opts.ToTime, err = parseTime(*to); err != nil {
+					return nil, err
+				}
+				
+				out := io.Writer(os.Stdout)
+				if *file != "-" {
+					f, err := os.Create(*file)
+					if err != nil {
+						return nil, err
+					}
+					defer f.Close()
+					out = f
+				}
+				
+				count, err := export.New(c).Export(out, opts)
+				if err != nil {
+					return nil, err
+				}
+				if *file == "-" {
+					return nil, errNoOutput
+				}
+				return map[string]interface{}{"file": *file, "transactions": count}, nil
+			}
+		},
+	},
+	{
+		name: "locate", usage: "<hash>", summary: "locate a transaction by hash",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.TryLocateTx(toncenterzp.TryLocateTxRequest{Hash: args[0]})
+		}),
+	},
+	{
+		name: "locate-result", usage: "<source> <destination> <created_lt>", summary: "locate the transaction that received a message",
+		minArgs: 3, maxArgs: 3,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.TryLocateResultTx(toncenterzp.TryLocateResultTxRequest{Source: args[0], Destination: args[1], CreatedLt: args[2]})
+		}),
+	},
+	{
+		name: "locate-source", usage: "<source> <destination> <created_lt>", summary: "locate the transaction that sent a message",
+		minArgs: 3, maxArgs: 3,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.TryLocateSourceTx(toncenterzp.TryLocateSourceTxRequest{Source: args[0], Destination: args[1], CreatedLt: args[2]})
+		}),
+	},
+	{
+		name: "runget", usage: "<address> <method> [args...]", summary: "run a get-method; args are numbers, cell:<b64> or slice:<b64>",
+		minArgs: 2, maxArgs: -1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			stack := []interface{}{}
+			for _, arg := range args[2:] {
+				entry, err := parseStackArg(arg)
+				if err != nil {
+					return nil, err
+				}
+				stack = append(stack, entry)
+			}
+			return c.RunGetMethod(toncenterzp.RunGetMethodRequest{Address: args[0], Method: args[1], Stack: stack})
+		}),
+	},
+	{
+		name: "rpc", usage: "<method> [json-params]", summary: "call a raw JSON-RPC method",
+		minArgs: 1, maxArgs: 2,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			params := map[string]interface{}{}
+			if len(args) == 2 {
+				if err := json.Unmarshal([]byte(args[1]), &params); err != nil {
+					return nil, fmt.Errorf("%w: invalid JSON params: %v", errUsage, err)
+				}
+			}
+			resp, err := c.JSONRPC(args[0], params)
+			if err != nil {
+				return nil, err
+			}
+			return resp.Result, nil
+		}),
+	},
+	{
+		name: "send", usage: "<boc-file> [--return-hash]", summary: "broadcast a BOC read from a file ('-' for stdin)",
+		minArgs: 1, maxArgs: 1,
+		setup: func(fs *flag.FlagSet) runFunc {
+			returnHash := fs.Bool("return-hash", false, "return the message hash")
+			return func(c *toncenterzp.Client, args []string) (interface{}, error) {
+				boc, err := readBoc(args[0])
+				if err != nil {
+					return nil, err
+				}
+				if *returnHash {
+					return c.SendBocReturnHash(toncenterzp.SendBocReturnHashRequest{Boc: boc})
+				}
+				return c.SendBoc(toncenterzp.SendBocRequest{Boc: boc})
+			}
+		},
+	},
+	{
+		name: "sendquery", usage: "<address> <body-b64> [--init b64]", summary: "send an external message to an address",
+		minArgs: 2, maxArgs: 2,
+		setup: func(fs *flag.FlagSet) runFunc {
+			init := fs.String("init", "", "state init BOC in base64")
+			return func(c *toncenterzp.Client, args []string) (interface{}, error) {
+				return c.SendQuery(toncenterzp.SendQueryRequest{Address: args[0], Body: args[1], Init: *init})
+			}
+		},
+	},
+	{
+		name: "pack", usage: "<address>", summary: "convert a raw address to user-friendly form",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.PackAddress(args[0])
+		}),
+	},
+	{
+		name: "unpack", usage: "<address>", summary: "convert a user-friendly address to raw form",
+		minArgs: 1, maxArgs: 1,
+		setup: noFlags(func(c *toncenterzp.Client, args []string) (interface{}, error) {
+			return c.UnpackAddress(args[0])
+		}),
+	},
+}
+
+// transactionsTable renders GetTransactions results one transaction per row
+func transactionsTable(result interface{}) ([]string, [][]string) {
+	header := []string{"TIME", "LT", "HASH", "FROM", "TO", "VALUE", "OUT", "FEE"}
+	resp, ok := result.(*toncenterzp.GetTransactionsResponse)
+	if !ok {
+		return header, nil
+	}
+	
+	rows := make([][]string, 0, len(resp.Result.Transactions))
+	for _, tx := range resp.Result.Transactions {
+		rows = append(rows, []string{
+			time.Unix(int64(tx.Now), 0).UTC().Format(time.RFC3339),
+			tx.Lt,
+			tx.Hash,
+			tx.InMsg.Source,
+			tx.InMsg.Destination,
+			tx.InMsg.Value,
+			strconv.Itoa(len(tx.OutMsgs)),
+			tx.Fee,
+		})
+	}
+	return header, rows
+}
+
+// parseTime parses an RFC 3339 time or unix seconds; an empty string is the zero time
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid time %q", errUsage, s)
+	}
+	return t, nil
+}
+
+// noFlags adapts a run function for commands without own flags
+func noFlags(run runFunc) func(fs *flag.FlagSet) runFunc {
+	return func(fs *flag.FlagSet) runFunc {
+		return run
+	}
+}
+
+// parseBlockArgs parses the workchain and seqno of a <workchain> <shard> <seqno> argument list
+func parseBlockArgs(args []string) (int, int, error) {
+	wc, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid workchain %q", errUsage, args[0])
+	}
+	seqno, err := strconv.Atoi(args[2])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid seqno %q", errUsage, args[2])
+	}
+	return wc, seqno, nil
+}
+
+// parseStackArg converts a get-method argument into a stack entry
+func parseStackArg(arg string) ([]string, error) {
+	switch {
+	case strings.HasPrefix(arg, "cell:"):
+		return []string{"tvm.Cell", strings.TrimPrefix(arg, "cell:")}, nil
+	case strings.HasPrefix(arg, "slice:"):
+		return []string{"tvm.Slice", strings.TrimPrefix(arg, "slice:")}, nil
+	}
+	
+	n, ok := new(big.Int).SetString(arg, 0)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid stack argument %q", errUsage, arg)
+	}
+	return []string{"num", "0x" + n.Text(16)}, nil
+}
+
+// readBoc reads a BOC file and returns it base64 encoded. The file may hold raw
+// bytes, base64 or hex.
+func readBoc(path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading BOC: %w", err)
+	}
+	
+	text := strings.TrimSpace(string(data))
+	if isPrintable(text) {
+		if raw, err := base64.StdEncoding.DecodeString(text); err == nil {
+			return base64.StdEncoding.EncodeToString(raw), nil
+		}
+		if raw, err := base64.URLEncoding.DecodeString(text); err == nil {
+			return base64.StdEncoding.EncodeToString(raw), nil
+		}
+		if raw, ok := decodeHex(text); ok {
+			return base64.StdEncoding.EncodeToString(raw), nil
+		}
+	}
+	
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// isPrintable reports whether s consists of printable ASCII only
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// decodeHex decodes a hex string, accepting an optional 0x prefix
+func decodeHex(s string) ([]byte, bool) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	return raw, err == nil
+}
+
+// decodeConfigParam decodes a config param cell given as a base64 BOC; params without
+// a decoder are returned as the BOC
+func decodeConfigParam(n int, boc string) (interface{}, error) {
+	c, err := cell.FromBOCBase64(boc)
+	if err != nil {
+		return nil, err
+	}
+	switch n {
+	case config.ParamConfigAddress, config.ParamElectorAddress:
+		a, err := config.ParseAddress(c)
+		if err != nil {
+			return nil, err
+		}
+		return a.String(), nil
+	case config.ParamWorkchains:
+		return config.ParseWorkchains(c)
+	case config.ParamElectionTimings:
+		return config.ParseElectionTimings(c)
+	case config.ParamStakeLimits:
+		return config.ParseStakeLimits(c)
+	case config.ParamStoragePrices:
+		return config.ParseStoragePrices(c)
+	case config.ParamMasterchainGasPrices, config.ParamGasPrices:
+		return config.ParseGasPrices(c)
+	case config.ParamMasterchainMsgForwardPrices, config.ParamMsgForwardPrices:
+		return config.ParseMsgForwardPrices(c)
+	case config.ParamPrevValidators, config.ParamPrevTempValidators, config.ParamValidators,
+		config.ParamTempValidators, config.ParamNextValidators, config.ParamNextTempValidators:
+		return config.ParseValidatorSet(c)
+	}
+	return boc, nil
+}
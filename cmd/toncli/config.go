@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zhaopeng331/toncenterzp"
+)
+
+// Profile holds the connection settings of one named profile in the config file
+type Profile struct {
+	APIKey    string `json:"api_key"`
+	BaseURL   string `json:"base_url"`
+	Timeout   string `json:"timeout,omitempty"`
+	Transport string `json:"transport,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+// ConfigFile is the layout of the toncli profile file
+type ConfigFile struct {
+	DefaultProfile string             `json:"default_profile,omitempty"`
+	Profiles       map[string]Profile `json:"profiles"`
+}
+
+// globalOptions are the settings shared by every subcommand.
+// Precedence: command-line flags, then environment variables, then the profile file.
+type globalOptions struct {
+	configPath string
+	profile    string
+	apiKey     string
+	baseURL    string
+	timeout    time.Duration
+	transport  string
+	output     string
+	verbose    bool
+}
+
+// register adds the global flags to a subcommand flag set
+func (o *globalOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.configPath, "config", o.configPath, "path of the profile file")
+	fs.StringVar(&o.profile, "profile", o.profile, "profile to use from the profile file")
+	fs.StringVar(&o.apiKey, "api-key", o.apiKey, "API key (env TONCLI_API_KEY)")
+	fs.StringVar(&o.baseURL, "base-url", o.baseURL, "API base URL (env TONCLI_BASE_URL)")
+	fs.DurationVar(&o.timeout, "timeout", o.timeout, "HTTP timeout")
+	fs.StringVar(&o.transport, "transport", o.transport, "transport: rest or jsonrpc")
+	fs.StringVar(&o.output, "output", o.output, "output format: json, table or yaml")
+	fs.StringVar(&o.output, "o", o.output, "shorthand for --output")
+	fs.BoolVar(&o.verbose, "v", o.verbose, "log requests to stderr")
+}
+
+// defaultConfigPath returns the location of the profile file
+func defaultConfigPath() string {
+	if path := os.Getenv("TONCLI_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "toncli", "config.json")
+}
+
+// loadConfig reads the profile file; a missing file yields an empty config
+func loadConfig(path string) (*ConfigFile, error) {
+	cfg := &ConfigFile{Profiles: map[string]Profile{}}
+	if path == "" {
+		return cfg, nil
+	}
+	
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %w", err)
+	}
+	
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	
+	return cfg, nil
+}
+
+// resolve fills the options that were not set on the command line from the
+// environment and the selected profile
+func (o *globalOptions) resolve(set map[string]bool) error {
+	if !set["profile"] && os.Getenv("TONCLI_PROFILE") != "" {
+		o.profile = os.Getenv("TONCLI_PROFILE")
+	}
+	
+	cfg, err := loadConfig(o.configPath)
+	if err != nil {
+		return err
+	}
+	
+	name := o.profile
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+	if name == "" {
+		name = "default"
+	}
+	
+	profile, ok := cfg.Profiles[name]
+	if !ok && o.profile != "" {
+		return fmt.Errorf("profile %q not found in %s", o.profile, o.configPath)
+	}
+	
+	pick := func(flagName, flagValue, envName, profileValue string) string {
+		if set[flagName] {
+			return flagValue
+		}
+		if v := os.Getenv(envName); v != "" {
+			return v
+		}
+		if profileValue != "" {
+			return profileValue
+		}
+		return flagValue
+	}
+	
+	set["output"] = set["output"] || set["o"]
+	o.apiKey = pick("api-key", o.apiKey, "TONCLI_API_KEY", profile.APIKey)
+	o.baseURL = pick("base-url", o.baseURL, "TONCLI_BASE_URL", profile.BaseURL)
+	o.transport = pick("transport", o.transport, "TONCLI_TRANSPORT", profile.Transport)
+	o.output = pick("output", o.output, "TONCLI_OUTPUT", profile.Output)
+	
+	timeout := pick("timeout", "", "TONCLI_TIMEOUT", profile.Timeout)
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", timeout, err)
+		}
+		o.timeout = d
+	}
+	
+	return nil
+}
+
+// newClient builds an API client from the resolved options
+func (o *globalOptions) newClient() (*toncenterzp.Client, error) {
+	client := toncenterzp.NewClientWithOptions(o.apiKey, o.baseURL, o.timeout)
+	if o.verbose {
+		client.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	
+	switch o.transport {
+	case "", "rest":
+		client.Transport = toncenterzp.TransportREST
+	case "jsonrpc":
+		client.Transport = toncenterzp.TransportJSONRPC
+	default:
+		return nil, fmt.Errorf("unknown transport %q", o.transport)
+	}
+	
+	return client, nil
+}
@@ -0,0 +1,202 @@
+// Command toncli is a command-line client for the TON HTTP API built on toncenterzp.
+//
+// Usage:
+//
+//	toncli [flags] <command> [arguments] [flags]
+//
+// The API key and base URL are taken from --api-key/--base-url, the
+// TONCLI_API_KEY/TONCLI_BASE_URL environment variables or a profile in the
+// profile file (see --config and --profile), in that order.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/zhaopeng331/toncenterzp"
+)
+
+// command is a toncli subcommand
+type command struct {
+	name    string
+	usage   string
+	summary string
+	minArgs int
+	maxArgs int
+	setup   func(fs *flag.FlagSet) runFunc
+	
+	// table optionally renders the result as header and rows for --output table;
+	// commands without it get a generic flattened table
+	table func(result interface{}) ([]string, [][]string)
+}
+
+// runFunc executes a subcommand and returns the value to print
+type runFunc func(client *toncenterzp.Client, args []string) (interface{}, error)
+
+// errUsage signals that a command was invoked with the wrong arguments
+var errUsage = errors.New("usage error")
+
+// errNoOutput signals that a command already wrote its output itself
+var errNoOutput = errors.New("no output")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes toncli with the given arguments and returns the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	opts := &globalOptions{
+		configPath: defaultConfigPath(),
+		baseURL:    toncenterzp.DefaultBaseURL,
+		timeout:    toncenterzp.DefaultTimeout,
+		output:     "json",
+	}
+	
+	// Global flags may precede the command name
+	global := flag.NewFlagSet("toncli", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	opts.register(global)
+	global.Usage = func() { printUsage(stderr) }
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	set := map[string]bool{}
+	global.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	
+	if global.NArg() == 0 {
+		printUsage(stderr)
+		return 2
+	}
+	
+	name := global.Arg(0)
+	if name == "help" {
+		printUsage(stdout)
+		return 0
+	}
+	if name == "version" {
+		fmt.Fprintf(stdout, "toncli (toncenterzp %s)\n", toncenterzp.Version)
+		return 0
+	}
+	
+	cmd, ok := commandByName(name)
+	if !ok {
+		fmt.Fprintf(stderr, "toncli: unknown command %q\n\n", name)
+		printUsage(stderr)
+		return 2
+	}
+	
+	fs := flag.NewFlagSet("toncli "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts.register(fs)
+	runCmd := cmd.setup(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: toncli %s %s\n\n%s\n\nflags:\n", cmd.name, cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	
+	positional, err := parseInterspersed(fs, global.Args()[1:])
+	if err != nil {
+		return 2
+	}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	
+	if len(positional) < cmd.minArgs || (cmd.maxArgs >= 0 && len(positional) > cmd.maxArgs) {
+		fs.Usage()
+		return 2
+	}
+	
+	if err := opts.resolve(set); err != nil {
+		fmt.Fprintf(stderr, "toncli: %v\n", err)
+		return 1
+	}
+	
+	client, err := opts.newClient()
+	if err != nil {
+		fmt.Fprintf(stderr, "toncli: %v\n", err)
+		return 1
+	}
+	
+	result, err := runCmd(client, positional)
+	if errors.Is(err, errNoOutput) {
+		return 0
+	}
+	if errors.Is(err, errUsage) {
+		fmt.Fprintf(stderr, "toncli %s: %v\n", cmd.name, err)
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "toncli %s: %v\n", cmd.name, err)
+		return 1
+	}
+	
+	if opts.output == "table" && cmd.table != nil {
+		header, rows := cmd.table(result)
+		err = writeRows(stdout, header, rows)
+	} else {
+		err = writeOutput(stdout, opts.output, result)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "toncli: %v\n", err)
+		return 1
+	}
+	
+	return 0
+}
+
+// parseInterspersed parses flags that may appear before, between or after positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// commandByName looks up a subcommand
+func commandByName(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// printUsage writes the list of commands
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: toncli [flags] <command> [arguments] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	
+	sorted := append([]command(nil), commands...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	for _, cmd := range sorted {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "global flags:")
+	fs := flag.NewFlagSet("toncli", flag.ContinueOnError)
+	fs.SetOutput(w)
+	(&globalOptions{}).register(fs)
+	fs.PrintDefaults()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'toncli <command> -h' for the arguments of a command.")
+	fmt.Fprintf(w, "Profiles are read from %s.\n", strings.TrimSpace(defaultConfigPath()))
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// balanceServer answers /getAddressBalance and records the API keys it receives
+func balanceServer(t *testing.T, keys *[]string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/getAddressBalance" {
+			http.NotFound(w, r)
+			return
+		}
+		*keys = append(*keys, r.Header.Get("x-api-key"))
+		w.Write([]byte(`{"ok":true,"result":"1500"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+// runCLI runs toncli without the environment and profile file of the user
+func runCLI(t *testing.T, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	for _, name := range []string{"TONCLI_API_KEY", "TONCLI_BASE_URL", "TONCLI_PROFILE", "TONCLI_TRANSPORT", "TONCLI_OUTPUT", "TONCLI_TIMEOUT"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("TONCLI_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	var out, errOut bytes.Buffer
+	code = run(args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRunBalance(t *testing.T) {
+	var keys []string
+	url := balanceServer(t, &keys)
+	
+	// Flags may follow the positional arguments
+	code, out, errOut := runCLI(t, "balance", "EQabc", "--base-url", url, "--api-key", "k1")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, `"1500"`) {
+		t.Errorf("output %q", out)
+	}
+	if len(keys) != 1 || keys[0] != "k1" {
+		t.Errorf("API keys %v", keys)
+	}
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"nosuchcommand"},
+		{"balance"},
+		{"balance", "a", "b"},
+		{"balance", "a", "--nosuchflag"},
+	} {
+		if code, _, _ := runCLI(t, args...); code != 2 {
+			t.Errorf("%q: exit code %d, want 2", args, code)
+		}
+	}
+	if code, out, _ := runCLI(t, "help"); code != 0 || !strings.Contains(out, "balance") {
+		t.Errorf("help: exit code %d, output %q", code, out)
+	}
+}
+
+func TestRunProfile(t *testing.T) {
+	var keys []string
+	url := balanceServer(t, &keys)
+	config := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(config, []byte(`{
+		"default_profile": "main",
+		"profiles": {
+			"main": {"api_key": "profile-key", "base_url": "`+url+`"},
+			"other": {"api_key": "other-key", "base_url": "`+url+`"}
+		}
+	}`), 0o600)
+	
+	if code, _, errOut := runCLI(t, "--config", config, "balance", "EQabc"); code != 0 {
+		t.Fatalf("default profile: exit code %d: %s", code, errOut)
+	}
+	if code, _, errOut := runCLI(t, "--config", config, "--profile", "other", "balance", "EQabc"); code != 0 {
+		t.Fatalf("selected profile: exit code %d: %s", code, errOut)
+	}
+	// A flag wins over the profile
+	if code, _, errOut := runCLI(t, "--config", config, "balance", "EQabc", "--api-key", "flag-key"); code != 0 {
+		t.Fatalf("flag over profile: exit code %d: %s", code, errOut)
+	}
+	if strings.Join(keys, " ") != "profile-key other-key flag-key" {
+		t.Errorf("API keys %v", keys)
+	}
+	
+	if code, _, _ := runCLI(t, "--config", config, "--profile", "missing", "balance", "EQabc"); code != 1 {
+		t.Errorf("missing profile: exit code %d, want 1", code)
+	}
+}
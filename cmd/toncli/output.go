@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// writeOutput prints a command result in the requested format
+func writeOutput(w io.Writer, format string, result interface{}) error {
+	switch format {
+	case "", "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	case "yaml":
+		value, err := toGeneric(result)
+		if err != nil {
+			return err
+		}
+		writeYAML(w, value, 0)
+		return nil
+	case "table":
+		value, err := toGeneric(result)
+		if err != nil {
+			return err
+		}
+		return writeTable(w, value)
+	default:
+		return fmt.Errorf("unknown output format %q (want json, table or yaml)", format)
+	}
+}
+
+// toGeneric converts a result into maps, slices and scalars via its JSON form
+func toGeneric(result interface{}) (interface{}, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding result: %w", err)
+	}
+	
+	var value interface{}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("error encoding result: %w", err)
+	}
+	return value, nil
+}
+
+// writeYAML writes a generic value as block-style YAML
+func writeYAML(w io.Writer, value interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			fmt.Fprintf(w, "%s{}\n", pad)
+			return
+		}
+		for _, key := range sortedKeys(v) {
+			child := v[key]
+			if isCollection(child) {
+				fmt.Fprintf(w, "%s%s:\n", pad, yamlScalar(key))
+				writeYAML(w, child, indent+1)
+			} else {
+				fmt.Fprintf(w, "%s%s: %s\n", pad, yamlScalar(key), yamlScalar(child))
+			}
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			fmt.Fprintf(w, "%s[]\n", pad)
+			return
+		}
+		for _, item := range v {
+			if isCollection(item) {
+				fmt.Fprintf(w, "%s-\n", pad)
+				writeYAML(w, item, indent+1)
+			} else {
+				fmt.Fprintf(w, "%s- %s\n", pad, yamlScalar(item))
+			}
+		}
+	default:
+		fmt.Fprintf(w, "%s%s\n", pad, yamlScalar(v))
+	}
+}
+
+// isCollection reports whether a generic value is a non-empty map or slice
+func isCollection(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	}
+	return false
+}
+
+// yamlScalar formats a scalar, quoting strings that YAML would otherwise reinterpret
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case map[string]interface{}:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	case string:
+		if v == "" || needsQuoting(v) {
+			return strconv.Quote(v)
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// needsQuoting reports whether a YAML plain scalar would be misread
+func needsQuoting(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(s, ":#{}[],&*!|>'\"%@`\n") || strings.TrimSpace(s) != s {
+		return true
+	}
+	return strings.HasPrefix(s, "-") || strings.HasPrefix(s, "?")
+}
+
+// writeTable prints the "result" of an API response as a table. A list of objects
+// becomes one row per object, anything else becomes KEY/VALUE rows.
+func writeTable(w io.Writer, value interface{}) error {
+	if m, ok := value.(map[string]interface{}); ok {
+		if result, ok := m["result"]; ok {
+			value = result
+		}
+	}
+	if m, ok := value.(map[string]interface{}); ok && len(m) == 1 {
+		for _, child := range m {
+			if isObjectList(child) {
+				value = child
+			}
+		}
+	}
+	
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	
+	switch v := value.(type) {
+	case []interface{}:
+		if !isObjectList(v) {
+			for _, item := range v {
+				fmt.Fprintln(tw, cellText(item))
+			}
+			break
+		}
+		
+		rows := make([]map[string]string, len(v))
+		columns := map[string]bool{}
+		for i, item := range v {
+			rows[i] = map[string]string{}
+			flatten(item, "", rows[i])
+			for key := range rows[i] {
+				columns[key] = true
+			}
+		}
+		header := make([]string, 0, len(columns))
+		for key := range columns {
+			header = append(header, key)
+		}
+		sort.Strings(header)
+		
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+		for _, row := range rows {
+			cells := make([]string, len(header))
+			for i, key := range header {
+				cells[i] = row[key]
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+	case map[string]interface{}:
+		flat := map[string]string{}
+		flatten(v, "", flat)
+		keys := make([]string, 0, len(flat))
+		for key := range flat {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		
+		fmt.Fprintln(tw, "KEY\tVALUE")
+		for _, key := range keys {
+			fmt.Fprintf(tw, "%s\t%s\n", key, flat[key])
+		}
+	default:
+		fmt.Fprintln(tw, cellText(v))
+	}
+	
+	return tw.Flush()
+}
+
+// writeRows prints a table with the given header
+func writeRows(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// isObjectList reports whether value is a non-empty list of objects
+func isObjectList(value interface{}) bool {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// flatten writes nested objects into dotted keys
+func flatten(value interface{}, prefix string, out map[string]string) {
+	m, ok := value.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		out[prefix] = cellText(value)
+		return
+	}
+	for key, child := range m {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		flatten(child, key, out)
+	}
+}
+
+// cellText formats a value for a single table cell
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sortedKeys returns the keys of a map in lexical order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+package toncenterzp
+
+import (
+	"sync"
+)
+
+// DefaultCoalesceEndpoints returns every read-only endpoint, suitable for Client.CoalesceEndpoints.
+// Endpoints that broadcast messages are excluded so that duplicate sends stay visible to the caller.
+func DefaultCoalesceEndpoints() map[string]bool {
+	return map[string]bool{
+		EndpointDetectAddress:                 true,
+		EndpointEstimateFee:                   true,
+		EndpointGetAddressBalance:             true,
+		EndpointGetAddressInformation:         true,
+		EndpointGetAddressState:               true,
+		EndpointGetBlockHeader:                true,
+		EndpointGetBlockTransactions:          true,
+		EndpointGetConsensusBlock:             true,
+		EndpointGetExtendedAddressInformation: true,
+		EndpointGetMasterchainBlockSignatures: true,
+		EndpointGetMasterchainInfo:            true,
+		EndpointGetShardBlockProof:            true,
+		EndpointGetTokenData:                  true,
+		EndpointGetTransactions:               true,
+		EndpointGetWalletInformation:          true,
+		EndpointLookupBlock:                   true,
+		EndpointPackAddress:                   true,
+		EndpointRunGetMethod:                  true,
+		EndpointShards:                        true,
+		EndpointTryLocateResultTx:             true,
+		EndpointTryLocateSourceTx:             true,
+		EndpointTryLocateTx:                   true,
+		EndpointUnpackAddress:                 true,
+	}
+}
+
+// shouldCoalesce reports whether identical in-flight requests to endpoint may share one HTTP call
+func (c *Client) shouldCoalesce(endpoint string) bool {
+	if c.CoalesceEndpoints == nil {
+		return false
+	}
+	return c.CoalesceEndpoints[endpointPath(endpoint)]
+}
+
+// flightGroup deduplicates concurrent calls that share a key
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// flightCall is an in-flight or completed call of a flightGroup
+type flightCall struct {
+	wg  sync.WaitGroup
+	val []byte
+	err error
+}
+
+// do executes fn once for all concurrent callers with the same key and hands every
+// caller the same result. shared reports whether the result was produced for another caller.
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) (val []byte, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.val, call.err, true
+	}
+	
+	call := &flightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+	
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		call.wg.Done()
+	}()
+	
+	call.val, call.err = fn()
+	return call.val, call.err, false
+}
@@ -0,0 +1,126 @@
+package toncenterzp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingServer counts requests and holds every response until release is closed.
+// A request to an address starting with "fail" gets a 500 response.
+func blockingServer(t *testing.T) (*Client, *int32, chan struct{}) {
+	t.Helper()
+	var count int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		<-release
+		if addr := r.URL.Query().Get("address"); len(addr) >= 4 && addr[:4] == "fail" {
+			http.Error(w, `{"ok":false,"error":"internal error","code":500}`, http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":"1500"}`))
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClientWithOptions("", srv.URL, DefaultTimeout)
+	c.CoalesceEndpoints = DefaultCoalesceEndpoints()
+	return c, &count, release
+}
+
+// concurrently runs n calls of fn at once, releases the server once they are all in
+// flight and returns their errors
+func concurrently(n int, release chan struct{}, fn func(i int) error) []error {
+	errs := make([]error, n)
+	var started, done sync.WaitGroup
+	started.Add(n)
+	done.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			errs[i] = fn(i)
+		}(i)
+	}
+	started.Wait()
+	// Give the goroutines time to reach the flight group before the first response
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	done.Wait()
+	return errs
+}
+
+func TestCoalesceIdenticalCalls(t *testing.T) {
+	c, count, release := blockingServer(t)
+	results := make([]string, 10)
+	errs := concurrently(10, release, func(i int) error {
+		resp, err := c.GetAddressBalance("EQabc")
+		if err == nil {
+			results[i] = resp.Result
+		}
+		return err
+	})
+	for i, err := range errs {
+		if err != nil || results[i] != "1500" {
+			t.Errorf("call %d: %q, %v", i, results[i], err)
+		}
+	}
+	if n := atomic.LoadInt32(count); n != 1 {
+		t.Errorf("%d HTTP requests for 10 identical calls, want 1", n)
+	}
+}
+
+func TestCoalesceDifferentParams(t *testing.T) {
+	c, count, release := blockingServer(t)
+	addrs := []string{"EQa", "EQb", "EQc", "EQd"}
+	errs := concurrently(len(addrs), release, func(i int) error {
+		_, err := c.GetAddressBalance(addrs[i])
+		return err
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("call %d: %v", i, err)
+		}
+	}
+	if n := atomic.LoadInt32(count); n != int32(len(addrs)) {
+		t.Errorf("%d HTTP requests for %d addresses", n, len(addrs))
+	}
+}
+
+func TestCoalesceNotConfigured(t *testing.T) {
+	c, count, release := blockingServer(t)
+	c.CoalesceEndpoints = map[string]bool{EndpointGetAddressInformation: true}
+	concurrently(5, release, func(int) error {
+		_, err := c.GetAddressBalance("EQabc")
+		return err
+	})
+	if n := atomic.LoadInt32(count); n != 5 {
+		t.Errorf("%d HTTP requests for an endpoint that is not coalesced, want 5", n)
+	}
+}
+
+func TestCoalesceError(t *testing.T) {
+	c, count, release := blockingServer(t)
+	errs := concurrently(5, release, func(int) error {
+		_, err := c.GetAddressBalance("failing")
+		return err
+	})
+	for i, err := range errs {
+		if err == nil {
+			t.Errorf("call %d: no error", i)
+		}
+	}
+	if n := atomic.LoadInt32(count); n != 1 {
+		t.Errorf("%d HTTP requests for 5 identical calls, want 1", n)
+	}
+	
+	// The error is not kept once the call completed: the next call goes out again
+	if _, err := c.GetAddressBalance("failing"); err == nil {
+		t.Error("no error")
+	}
+	if n := atomic.LoadInt32(count); n != 2 {
+		t.Errorf("%d HTTP requests after the failed flight, want 2", n)
+	}
+}
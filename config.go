@@ -0,0 +1,53 @@
+package toncenterzp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ConfigInfo is the result of the getConfigParam and getConfigAll JSON-RPC methods
+type ConfigInfo struct {
+	Type   string `json:"@type"`
+	Config struct {
+		Type  string `json:"@type"`
+		Bytes string `json:"bytes"`
+	} `json:"config"`
+	Extra string `json:"@extra,omitempty"`
+}
+
+// GetConfigParamResponse represents the response of the getConfigParam JSON-RPC method
+type GetConfigParamResponse struct {
+	OK     bool       `json:"ok"`
+	Result ConfigInfo `json:"result"`
+}
+
+// GetConfigParam gets config param n of the last masterchain block. Result.Config.Bytes
+// is the BOC of the param cell, see package config for decoders.
+func (c *Client) GetConfigParam(n int) (*GetConfigParamResponse, error) {
+	return c.getConfig("getConfigParam", map[string]interface{}{"config_id": n})
+}
+
+// GetConfigAll gets the whole config of the last masterchain block. Result.Config.Bytes
+// is the BOC of the dictionary of all params.
+func (c *Client) GetConfigAll() (*GetConfigParamResponse, error) {
+	return c.getConfig("getConfigAll", map[string]interface{}{})
+}
+
+// getConfig calls a config JSON-RPC method
+func (c *Client) getConfig(method string, params interface{}) (*GetConfigParamResponse, error) {
+	rpcResp, err := c.JSONRPC(method, params)
+	if err != nil {
+		return nil, err
+	}
+	
+	response := GetConfigParamResponse{OK: true}
+	if err := json.Unmarshal(rpcResp.Result, &response.Result); err != nil {
+		return nil, fmt.Errorf("error unmarshaling response: %w (code: %d)", err, ErrInvalidResponse)
+	}
+	
+	if response.Result.Config.Bytes == "" {
+		return nil, fmt.Errorf("API returned no config cell (code: %d)", ErrAPIError)
+	}
+	
+	return &response, nil
+}
@@ -0,0 +1,150 @@
+// Package config decodes blockchain configuration parameters.
+//
+// The configuration is a dictionary of cells keyed by parameter number, read from
+// a key block or a masterchain state. The decoders turn parameter cells into typed
+// values; they fail with ErrInvalidParam for cells of an unexpected layout.
+package config
+
+import (
+	"errors"
+	"fmt"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// ErrInvalidParam is returned for config parameter cells that cannot be decoded
+var ErrInvalidParam = errors.New("config: invalid parameter")
+
+// ErrParamNotFound is returned for params missing from a config
+var ErrParamNotFound = errors.New("config: parameter not found")
+
+// Config is a blockchain config, the dictionary of params (Hashmap 32 ^Cell)
+type Config struct {
+	root *cell.Cell
+}
+
+// New wraps the root cell of a config dictionary, e.g. the cell returned by
+// GetConfigAll or read from a key block
+func New(root *cell.Cell) *Config {
+	return &Config{root: root}
+}
+
+// Param returns the cell of param n
+func (c *Config) Param(n int32) (*cell.Cell, error) {
+	v, err := dictLookup(c.root, uint64(uint32(n)), 32)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, fmt.Errorf("%w: %d", ErrParamNotFound, n)
+	}
+	p := v.LoadRef()
+	return p, v.Err()
+}
+
+// ConfigAddress returns the address of the config contract (param 0)
+func (c *Config) ConfigAddress() (*address.Address, error) {
+	p, err := c.Param(ParamConfigAddress)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAddress(p)
+}
+
+// ElectorAddress returns the address of the elector contract (param 1)
+func (c *Config) ElectorAddress() (*address.Address, error) {
+	p, err := c.Param(ParamElectorAddress)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAddress(p)
+}
+
+// Workchains returns the workchain descriptors (param 12)
+func (c *Config) Workchains() (map[int32]*WorkchainDescr, error) {
+	p, err := c.Param(ParamWorkchains)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWorkchains(p)
+}
+
+// ElectionTimings returns the election timings (param 15)
+func (c *Config) ElectionTimings() (*ElectionTimings, error) {
+	p, err := c.Param(ParamElectionTimings)
+	if err != nil {
+		return nil, err
+	}
+	return ParseElectionTimings(p)
+}
+
+// StakeLimits returns the validator stake limits (param 17)
+func (c *Config) StakeLimits() (*StakeLimits, error) {
+	p, err := c.Param(ParamStakeLimits)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStakeLimits(p)
+}
+
+// StoragePrices returns the storage prices (param 18)
+func (c *Config) StoragePrices() ([]StoragePrices, error) {
+	p, err := c.Param(ParamStoragePrices)
+	if err != nil {
+		return nil, err
+	}
+	return ParseStoragePrices(p)
+}
+
+// GasPrices returns the gas prices of the masterchain (param 20) or the basechain (param 21)
+func (c *Config) GasPrices(masterchain bool) (*GasPrices, error) {
+	n := int32(ParamGasPrices)
+	if masterchain {
+		n = ParamMasterchainGasPrices
+	}
+	p, err := c.Param(n)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGasPrices(p)
+}
+
+// MsgForwardPrices returns the forwarding prices of the masterchain (param 24) or the
+// basechain (param 25)
+func (c *Config) MsgForwardPrices(masterchain bool) (*MsgForwardPrices, error) {
+	n := int32(ParamMsgForwardPrices)
+	if masterchain {
+		n = ParamMasterchainMsgForwardPrices
+	}
+	p, err := c.Param(n)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMsgForwardPrices(p)
+}
+
+// Validators returns the current validator set (param 34)
+func (c *Config) Validators() (*ValidatorSet, error) {
+	p, err := c.Param(ParamValidators)
+	if err != nil {
+		return nil, err
+	}
+	return ParseValidatorSet(p)
+}
+
+// invalidParam wraps ErrInvalidParam with a description
+func invalidParam(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidParam, fmt.Sprintf(format, args...))
+}
+
+// parse begins parsing a parameter cell, failing for missing and pruned cells
+func parse(c *cell.Cell) (*cell.Slice, error) {
+	if c == nil {
+		return nil, invalidParam("missing cell")
+	}
+	if c.Type() == cell.PrunedBranch {
+		return nil, invalidParam("pruned cell")
+	}
+	return c.BeginParse(), nil
+}
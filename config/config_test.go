@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+func must(c *cell.Cell, err error) *cell.Cell {
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// testConfig returns a config with params 0, 18, 21 and 25 as set on mainnet
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+	storage := cell.NewDict(32)
+	err := storage.Set(cell.UintKey(0, 32), must(cell.BeginCell().
+		StoreUInt(0xcc, 8).
+		StoreUInt(0, 32).
+		StoreUInt(1, 64).
+		StoreUInt(500, 64).
+		StoreUInt(1000, 64).
+		StoreUInt(500000, 64).
+		EndCell()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	params := map[uint64]*cell.Cell{
+		ParamConfigAddress: must(cell.BeginCell().StoreBytes(make([]byte, 32)).EndCell()),
+		ParamStoragePrices: must(storage.ToCell()),
+		ParamGasPrices: must(cell.BeginCell().
+			StoreUInt(0xd1, 8).StoreUInt(100, 64).StoreUInt(40000, 64).
+			StoreUInt(0xde, 8).
+			StoreUInt(26214400, 64).
+			StoreUInt(1000000, 64).
+			StoreUInt(1000000, 64).
+			StoreUInt(10000, 64).
+			StoreUInt(10000000, 64).
+			StoreUInt(100000000, 64).
+			StoreUInt(1000000000, 64).
+			EndCell()),
+		ParamMsgForwardPrices: must(cell.BeginCell().
+			StoreUInt(0xea, 8).
+			StoreUInt(400000, 64).
+			StoreUInt(26214400, 64).
+			StoreUInt(2621440000, 64).
+			StoreUInt(98304, 32).
+			StoreUInt(21845, 16).
+			StoreUInt(21845, 16).
+			EndCell()),
+	}
+	root := cell.NewDict(32)
+	for n, p := range params {
+		if err := root.Set(cell.UintKey(n, 32), must(cell.BeginCell().StoreRef(p).EndCell())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := root.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(c)
+}
+
+func TestGasPrices(t *testing.T) {
+	g, err := testConfig(t).GasPrices(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := GasPrices{
+		FlatGasLimit:    100,
+		FlatGasPrice:    40000,
+		GasPrice:        26214400,
+		GasLimit:        1000000,
+		SpecialGasLimit: 1000000,
+		GasCredit:       10000,
+		BlockGasLimit:   10000000,
+		FreezeDueLimit:  100000000,
+		DeleteDueLimit:  1000000000,
+	}
+	if *g != want {
+		t.Errorf("got %+v, want %+v", *g, want)
+	}
+}
+
+func TestMsgForwardPrices(t *testing.T) {
+	f, err := testConfig(t).MsgForwardPrices(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MsgForwardPrices{LumpPrice: 400000, BitPrice: 26214400, CellPrice: 2621440000, IHRPriceFactor: 98304, FirstFrac: 21845, NextFrac: 21845}
+	if *f != want {
+		t.Errorf("got %+v, want %+v", *f, want)
+	}
+}
+
+func TestStoragePrices(t *testing.T) {
+	s, err := testConfig(t).StoragePrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := StoragePrices{BitPricePS: 1, CellPricePS: 500, MCBitPricePS: 1000, MCCellPricePS: 500000}
+	if len(s) != 1 || s[0] != want {
+		t.Errorf("got %+v, want [%+v]", s, want)
+	}
+}
+
+func TestConfigAddress(t *testing.T) {
+	a, err := testConfig(t).ConfigAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Workchain != -1 {
+		t.Errorf("workchain %d, want -1", a.Workchain)
+	}
+}
+
+func TestMissingParam(t *testing.T) {
+	if _, err := testConfig(t).GasPrices(true); !errors.Is(err, ErrParamNotFound) {
+		t.Errorf("error %v, want ErrParamNotFound", err)
+	}
+}
+
+func TestInvalidParam(t *testing.T) {
+	if _, err := ParseGasPrices(must(cell.BeginCell().StoreUInt(0xd1, 8).StoreUInt(0, 128).StoreUInt(0xd1, 8).EndCell())); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("nested flat prices: error %v, want ErrInvalidParam", err)
+	}
+	if _, err := ParseMsgForwardPrices(must(cell.BeginCell().StoreUInt(0xeb, 8).EndCell())); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("wrong tag: error %v, want ErrInvalidParam", err)
+	}
+}
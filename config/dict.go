@@ -0,0 +1,37 @@
+package config
+
+import (
+	"errors"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// dictForEach calls fn for every value of the Hashmap rooted at root in key order.
+// Keys are passed as unsigned integers, so keyLen is at most 64.
+func dictForEach(root *cell.Cell, keyLen int, fn func(key uint64, value *cell.Slice) error) error {
+	if root == nil {
+		return invalidParam("missing cell")
+	}
+	err := cell.LoadDict(root, keyLen).ForEach(func(key []byte, value *cell.Slice) error {
+		return fn(cell.KeyUint(key, keyLen), value)
+	})
+	return dictError(err)
+}
+
+// dictLookup finds key in the Hashmap rooted at root and returns its value, nil if
+// the key is absent
+func dictLookup(root *cell.Cell, key uint64, keyLen int) (*cell.Slice, error) {
+	if root == nil {
+		return nil, invalidParam("missing cell")
+	}
+	v, err := cell.LoadDict(root, keyLen).Get(cell.UintKey(key, keyLen))
+	return v, dictError(err)
+}
+
+// dictError reports pruned dictionary cells as invalid params
+func dictError(err error) error {
+	if errors.Is(err, cell.ErrPruned) {
+		return invalidParam("pruned cell")
+	}
+	return err
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// Param numbers
+const (
+	ParamConfigAddress               = 0
+	ParamElectorAddress              = 1
+	ParamWorkchains                  = 12
+	ParamElectionTimings             = 15
+	ParamStakeLimits                 = 17
+	ParamStoragePrices               = 18
+	ParamMasterchainGasPrices        = 20
+	ParamGasPrices                   = 21
+	ParamMasterchainMsgForwardPrices = 24
+	ParamMsgForwardPrices            = 25
+)
+
+// ParseAddress decodes a masterchain contract address param (0-5)
+func ParseAddress(c *cell.Cell) (*address.Address, error) {
+	s, err := parse(c)
+	if err != nil {
+		return nil, err
+	}
+	a := &address.Address{Workchain: -1, Bounceable: true}
+	copy(a.Hash[:], s.LoadBytes(32))
+	return a, s.Err()
+}
+
+// WorkchainDescr is a workchain descriptor of param 12
+type WorkchainDescr struct {
+	EnabledSince   uint32
+	ActualMinSplit uint8
+	MinSplit       uint8
+	MaxSplit       uint8
+	Basic          bool
+	Active         bool
+	AcceptMsgs     bool
+	ZeroStateRoot  []byte
+	ZeroStateFile  []byte
+	Version        uint32
+	
+	// VMVersion and VMMode are set for basic workchains
+	VMVersion int32
+	VMMode    uint64
+}
+
+// ParseWorkchains decodes param 12, the workchain descriptors keyed by workchain ID
+func ParseWorkchains(c *cell.Cell) (map[int32]*WorkchainDescr, error) {
+	s, err := parse(c)
+	if err != nil {
+		return nil, err
+	}
+	
+	out := make(map[int32]*WorkchainDescr)
+	root := s.LoadMaybeRef()
+	if err := s.Err(); err != nil || root == nil {
+		return out, err
+	}
+	err = dictForEach(root, 32, func(key uint64, v *cell.Slice) error {
+		// workchain#a6 or workchain_v2#a7 enabled_since:uint32 actual_min_split:(## 8)
+		// min_split:(## 8) max_split:(## 8) basic:(## 1) active:Bool accept_msgs:Bool
+		// flags:(## 13) zerostate_root_hash:bits256 zerostate_file_hash:bits256
+		// version:uint32 format:(WorkchainFormat basic)
+		if tag := v.LoadUInt(8); tag != 0xa6 && tag != 0xa7 {
+			return invalidParam("workchain descriptor tag %#x", tag)
+		}
+		wc := &WorkchainDescr{
+			EnabledSince:   uint32(v.LoadUInt(32)),
+			ActualMinSplit: uint8(v.LoadUInt(8)),
+			MinSplit:       uint8(v.LoadUInt(8)),
+			MaxSplit:       uint8(v.LoadUInt(8)),
+			Basic:          v.LoadBit(),
+			Active:         v.LoadBit(),
+			AcceptMsgs:     v.LoadBit(),
+		}
+		v.Skip(13)
+		wc.ZeroStateRoot = v.LoadBytes(32)
+		wc.ZeroStateFile = v.LoadBytes(32)
+		wc.Version = uint32(v.LoadUInt(32))
+		if wc.Basic {
+			// wfmt_basic#1 vm_version:int32 vm_mode:uint64
+			v.Skip(4)
+			wc.VMVersion = int32(v.LoadInt(32))
+			wc.VMMode = v.LoadUInt(64)
+		}
+		if err := v.Err(); err != nil {
+			return err
+		}
+		out[int32(uint32(key))] = wc
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ElectionTimings is param 15
+type ElectionTimings struct {
+	ValidatorsElectedFor uint32
+	ElectionsStartBefore uint32
+	ElectionsEndBefore   uint32
+	StakeHeldFor         uint32
+}
+
+// ParseElectionTimings decodes param 15
+func ParseElectionTimings(c *cell.Cell) (*ElectionTimings, error) {
+	s, err := parse(c)
+	if err != nil {
+		return nil, err
+	}
+	t := &ElectionTimings{
+		ValidatorsElectedFor: uint32(s.LoadUInt(32)),
+		ElectionsStartBefore: uint32(s.LoadUInt(32)),
+		ElectionsEndBefore:   uint32(s.LoadUInt(32)),
+		StakeHeldFor:         uint32(s.LoadUInt(32)),
+	}
+	return t, s.Err()
+}
+
+// StakeLimits is param 17; stakes are in nanotons
+type StakeLimits struct {
+	MinStake      *big.Int
+	MaxStake      *big.Int
+	MinTotalStake *big.Int
+	
+	// MaxStakeFactor is a fixed point number with 16 fractional bits
+	MaxStakeFactor uint32
+}
+
+// ParseStakeLimits decodes param 17
+func ParseStakeLimits(c *cell.Cell) (*StakeLimits, error) {
+	s, err := parse(c)
+	if err != nil {
+		return nil, err
+	}
+	l := &StakeLimits{
+		MinStake:       s.LoadCoins(),
+		MaxStake:       s.LoadCoins(),
+		MinTotalStake:  s.LoadCoins(),
+		MaxStakeFactor: uint32(s.LoadUInt(32)),
+	}
+	return l, s.Err()
+}
+
+// StoragePrices is an entry of param 18. Prices are in nanotons per bit or cell per
+// second, as fixed point numbers with 16 fractional bits.
+type StoragePrices struct {
+	UtimeSince    uint32
+	BitPricePS    uint64
+	CellPricePS   uint64
+	MCBitPricePS  uint64
+	MCCellPricePS uint64
+}
+
+// ParseStoragePrices decodes param 18, ordered by UtimeSince
+func ParseStoragePrices(c *cell.Cell) ([]StoragePrices, error) {
+	var out []StoragePrices
+	err := dictForEach(c, 32, func(_ uint64, v *cell.Slice) error {
+		// ex#cc utime_since:uint32 bit_price_ps:uint64 cell_price_ps:uint64
+		// mc_bit_price_ps:uint64 mc_cell_price_ps:uint64
+		if tag := v.LoadUInt(8); tag != 0xcc {
+			return invalidParam("storage prices tag %#x", tag)
+		}
+		out = append(out, StoragePrices{
+			UtimeSince:    uint32(v.LoadUInt(32)),
+			BitPricePS:    v.LoadUInt(64),
+			CellPricePS:   v.LoadUInt(64),
+			MCBitPricePS:  v.LoadUInt(64),
+			MCCellPricePS: v.LoadUInt(64),
+		})
+		return v.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GasPrices is param 20 or 21. GasPrice is in nanotons per 65536 gas units.
+type GasPrices struct {
+	// FlatGasLimit gas units cost FlatGasPrice nanotons; both are zero without a flat part
+	FlatGasLimit uint64
+	FlatGasPrice uint64
+	
+	GasPrice        uint64
+	GasLimit        uint64
+	SpecialGasLimit uint64
+	GasCredit       uint64
+	BlockGasLimit   uint64
+	FreezeDueLimit  uint64
+	DeleteDueLimit  uint64
+}
+
+// ParseGasPrices decodes param 20 or 21
+func ParseGasPrices(c *cell.Cell) (*GasPrices, error) {
+	s, err := parse(c)
+	if err != nil {
+		return nil, err
+	}
+	g := &GasPrices{}
+	return g, g.load(s)
+}
+
+// load reads a GasLimitsPrices
+func (g *GasPrices) load(s *cell.Slice) error {
+	switch tag := s.LoadUInt(8); tag {
+	case 0xd1:
+		// gas_flat_pfx#d1 flat_gas_limit:uint64 flat_gas_price:uint64 other:GasLimitsPrices
+		g.FlatGasLimit = s.LoadUInt(64)
+		g.FlatGasPrice = s.LoadUInt(64)
+		if s.PreloadUInt(8) == 0xd1 {
+			return invalidParam("nested flat gas prices")
+		}
+		return g.load(s)
+	case 0xdd:
+		// gas_prices#dd gas_price gas_limit gas_credit block_gas_limit freeze_due_limit delete_due_limit
+		g.GasPrice = s.LoadUInt(64)
+		g.GasLimit = s.LoadUInt(64)
+		g.SpecialGasLimit = g.GasLimit
+	case 0xde:
+		// gas_prices_ext#de gas_price gas_limit special_gas_limit gas_credit block_gas_limit
+		// freeze_due_limit delete_due_limit
+		g.GasPrice = s.LoadUInt(64)
+		g.GasLimit = s.LoadUInt(64)
+		g.SpecialGasLimit = s.LoadUInt(64)
+	default:
+		if s.Err() != nil {
+			return s.Err()
+		}
+		return invalidParam("gas prices tag %#x", tag)
+	}
+	g.GasCredit = s.LoadUInt(64)
+	g.BlockGasLimit = s.LoadUInt(64)
+	g.FreezeDueLimit = s.LoadUInt(64)
+	g.DeleteDueLimit = s.LoadUInt(64)
+	return s.Err()
+}
+
+// MsgForwardPrices is param 24 or 25. BitPrice and CellPrice are in nanotons per
+// 65536 bits or cells; the fractions are out of 65536.
+type MsgForwardPrices struct {
+	LumpPrice      uint64
+	BitPrice       uint64
+	CellPrice      uint64
+	IHRPriceFactor uint32
+	FirstFrac      uint16
+	NextFrac       uint16
+}
+
+// ParseMsgForwardPrices decodes param 24 or 25
+func ParseMsgForwardPrices(c *cell.Cell) (*MsgForwardPrices, error) {
+	s, err := parse(c)
+	if err != nil {
+		return nil, err
+	}
+	// msg_forward_prices#ea lump_price:uint64 bit_price:uint64 cell_price:uint64
+	// ihr_price_factor:uint32 first_frac:uint16 next_frac:uint16
+	if tag := s.LoadUInt(8); tag != 0xea {
+		if s.Err() != nil {
+			return nil, s.Err()
+		}
+		return nil, invalidParam("forward prices tag %#x", tag)
+	}
+	p := &MsgForwardPrices{
+		LumpPrice:      s.LoadUInt(64),
+		BitPrice:       s.LoadUInt(64),
+		CellPrice:      s.LoadUInt(64),
+		IHRPriceFactor: uint32(s.LoadUInt(32)),
+		FirstFrac:      uint16(s.LoadUInt(16)),
+		NextFrac:       uint16(s.LoadUInt(16)),
+	}
+	return p, s.Err()
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// BOCs of mainnet params in the form getConfigParam returns them (config.bytes)
+const (
+	param18BOC = "te6cckEBAQEAKQAATdBmAAAAAAAAAAAAAAAAgAAAAAAAAPoAAAAAAAAB9AAAAAAAA9CQQJVLVZs="
+	param20BOC = "te6cckEBAQEATAAAlNEAAAAAAAAAZAAAAAAAD0JA3gAAAAAnEAAAAAAAAAAPQkAAAAAABCwdgAAAAAAAACcQAAAAAAAmJaAAAAAABfXhAAAAAAA7msoAKm2gQw=="
+	param21BOC = "te6cckEBAQEATAAAlNEAAAAAAAAAZAAAAAAAAJxA3gAAAAABkAAAAAAAAAAPQkAAAAAAAA9CQAAAAAAAACcQAAAAAACYloAAAAAABfXhAAAAAAA7msoAGR7wcQ=="
+	param24BOC = "te6cckEBAQEAIwAAQuoAAAAAAJiWgAAAAAAnEAAAAAAAD0JAAAAAAYAAVVVVVX2jQy8="
+	param25BOC = "te6cckEBAQEAIwAAQuoAAAAAAAYagAAAAAABkAAAAAAAAJxAAAAAAYAAVVVVVXYlR3Q="
+)
+
+// configBOC is a config dictionary holding the params above
+const configBOC = "te6cckECDgEAATMAAgmcAAAAGAEJAgEgAgQBAWoDAE3QZgAAAAAAAAAAAAAAAIAAAAAAAAD6AAAAAAAAAfQAAAAAAAPQkEACAUgFBwEBIAYAlNEAAAAAAAAAZAAAAAAAD0JA3gAAAAAnEAAAAAAAAAAPQkAAAAAABCwdgAAAAAAAACcQAAAAAAAmJaAAAAAABfXhAAAAAAA7msoAAQEgCACU0QAAAAAAAABkAAAAAAAAnEDeAAAAAAGQAAAAAAAAAA9CQAAAAAAAD0JAAAAAAAAAJxAAAAAAAJiWgAAAAAAF9eEAAAAAADuaygACAdQKDAEBIAsAQuoAAAAAAJiWgAAAAAAnEAAAAAAAD0JAAAAAAYAAVVVVVQEBIA0AQuoAAAAAAAYagAAAAAABkAAAAAAAAJxAAAAAAYAAVVVVVQ0BGmg="
+
+var (
+	mainnetStoragePrices = []StoragePrices{{UtimeSince: 0, BitPricePS: 1, CellPricePS: 500, MCBitPricePS: 1000, MCCellPricePS: 500000}}
+	
+	mainnetMasterchainGasPrices = GasPrices{
+		FlatGasLimit:    100,
+		FlatGasPrice:    1000000,
+		GasPrice:        655360000,
+		GasLimit:        1000000,
+		SpecialGasLimit: 70000000,
+		GasCredit:       10000,
+		BlockGasLimit:   2500000,
+		FreezeDueLimit:  100000000,
+		DeleteDueLimit:  1000000000,
+	}
+	mainnetGasPrices = GasPrices{
+		FlatGasLimit:    100,
+		FlatGasPrice:    40000,
+		GasPrice:        26214400,
+		GasLimit:        1000000,
+		SpecialGasLimit: 1000000,
+		GasCredit:       10000,
+		BlockGasLimit:   10000000,
+		FreezeDueLimit:  100000000,
+		DeleteDueLimit:  1000000000,
+	}
+	
+	mainnetMasterchainMsgForwardPrices = MsgForwardPrices{
+		LumpPrice:      10000000,
+		BitPrice:       655360000,
+		CellPrice:      65536000000,
+		IHRPriceFactor: 98304,
+		FirstFrac:      21845,
+		NextFrac:       21845,
+	}
+	mainnetMsgForwardPrices = MsgForwardPrices{
+		LumpPrice:      400000,
+		BitPrice:       26214400,
+		CellPrice:      2621440000,
+		IHRPriceFactor: 98304,
+		FirstFrac:      21845,
+		NextFrac:       21845,
+	}
+)
+
+func fromBOC(t *testing.T, boc string) *cell.Cell {
+	t.Helper()
+	c, err := cell.FromBOCBase64(boc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestParseStoragePricesBOC(t *testing.T) {
+	p, err := ParseStoragePrices(fromBOC(t, param18BOC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p) != 1 || p[0] != mainnetStoragePrices[0] {
+		t.Errorf("got %+v, want %+v", p, mainnetStoragePrices)
+	}
+}
+
+func TestParseGasPricesBOC(t *testing.T) {
+	for _, tc := range []struct {
+		boc  string
+		want GasPrices
+	}{
+		{param20BOC, mainnetMasterchainGasPrices},
+		{param21BOC, mainnetGasPrices},
+	} {
+		g, err := ParseGasPrices(fromBOC(t, tc.boc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *g != tc.want {
+			t.Errorf("got %+v, want %+v", *g, tc.want)
+		}
+	}
+}
+
+func TestParseMsgForwardPricesBOC(t *testing.T) {
+	for _, tc := range []struct {
+		boc  string
+		want MsgForwardPrices
+	}{
+		{param24BOC, mainnetMasterchainMsgForwardPrices},
+		{param25BOC, mainnetMsgForwardPrices},
+	} {
+		p, err := ParseMsgForwardPrices(fromBOC(t, tc.boc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *p != tc.want {
+			t.Errorf("got %+v, want %+v", *p, tc.want)
+		}
+	}
+}
+
+func TestParseWrongParam(t *testing.T) {
+	if _, err := ParseMsgForwardPrices(fromBOC(t, param21BOC)); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("param 21 as forward prices: error %v", err)
+	}
+	if _, err := ParseGasPrices(fromBOC(t, param25BOC)); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("param 25 as gas prices: error %v", err)
+	}
+}
+
+func TestConfigBOC(t *testing.T) {
+	cfg := New(fromBOC(t, configBOC))
+	
+	storage, err := cfg.StoragePrices()
+	if err != nil || len(storage) != 1 || storage[0] != mainnetStoragePrices[0] {
+		t.Errorf("storage prices %+v, %v", storage, err)
+	}
+	for _, masterchain := range []bool{true, false} {
+		wantGas, wantFwd := mainnetGasPrices, mainnetMsgForwardPrices
+		if masterchain {
+			wantGas, wantFwd = mainnetMasterchainGasPrices, mainnetMasterchainMsgForwardPrices
+		}
+		if g, err := cfg.GasPrices(masterchain); err != nil || *g != wantGas {
+			t.Errorf("masterchain %v: gas prices %+v, %v", masterchain, g, err)
+		}
+		if p, err := cfg.MsgForwardPrices(masterchain); err != nil || *p != wantFwd {
+			t.Errorf("masterchain %v: forward prices %+v, %v", masterchain, p, err)
+		}
+	}
+	
+	if _, err := cfg.Param(34); !errors.Is(err, ErrParamNotFound) {
+		t.Errorf("param 34: error %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"crypto/ed25519"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// Validator set parameters
+const (
+	ParamPrevValidators     = 32
+	ParamPrevTempValidators = 33
+	ParamValidators         = 34
+	ParamTempValidators     = 35
+	ParamNextValidators     = 36
+	ParamNextTempValidators = 37
+)
+
+// Validator is a ValidatorDescr
+type Validator struct {
+	PublicKey ed25519.PublicKey
+	Weight    uint64
+	
+	// ADNLAddr is nil for validators without an ADNL address
+	ADNLAddr []byte
+}
+
+// ValidatorSet is the validator set of config params 32-37
+type ValidatorSet struct {
+	UtimeSince uint32
+	UtimeUntil uint32
+	Total      int
+	Main       int
+	
+	// TotalWeight is the weight of all validators of the list
+	TotalWeight uint64
+	
+	// Validators are ordered by index; the first Main of them validate the masterchain
+	Validators []Validator
+}
+
+// ParseValidatorSet decodes a ValidatorSet
+func ParseValidatorSet(c *cell.Cell) (*ValidatorSet, error) {
+	s, err := parse(c)
+	if err != nil {
+		return nil, err
+	}
+	
+	vs := &ValidatorSet{}
+	tag := s.LoadUInt(8)
+	vs.UtimeSince = uint32(s.LoadUInt(32))
+	vs.UtimeUntil = uint32(s.LoadUInt(32))
+	vs.Total = int(s.LoadUInt(16))
+	vs.Main = int(s.LoadUInt(16))
+	
+	var list *cell.Cell
+	switch tag {
+	case 0x11:
+		// validators#11 ... list:(Hashmap 16 ValidatorDescr)
+		if list, err = s.ToCell(); err != nil {
+			return nil, err
+		}
+	case 0x12:
+		// validators_ext#12 ... total_weight:uint64 list:(HashmapE 16 ValidatorDescr)
+		vs.TotalWeight = s.LoadUInt(64)
+		list = s.LoadMaybeRef()
+	default:
+		return nil, invalidParam("validator set tag %#x", tag)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if vs.Main < 1 || vs.Main > vs.Total || list == nil {
+		return nil, invalidParam("empty validator set")
+	}
+	
+	var weight uint64
+	err = dictForEach(list, 16, func(_ uint64, v *cell.Slice) error {
+		val, err := loadValidator(v)
+		if err != nil {
+			return err
+		}
+		weight += val.Weight
+		vs.Validators = append(vs.Validators, *val)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	if len(vs.Validators) != vs.Total {
+		return nil, invalidParam("validator set lists %d of %d validators", len(vs.Validators), vs.Total)
+	}
+	if tag == 0x12 && weight != vs.TotalWeight {
+		return nil, invalidParam("validator weights do not add up to the total weight")
+	}
+	vs.TotalWeight = weight
+	return vs, nil
+}
+
+// loadValidator reads a ValidatorDescr
+func loadValidator(s *cell.Slice) (*Validator, error) {
+	// validator#53 public_key:SigPubKey weight:uint64
+	// validator_addr#73 public_key:SigPubKey weight:uint64 adnl_addr:bits256
+	tag := s.LoadUInt(8)
+	if tag != 0x53 && tag != 0x73 {
+		return nil, invalidParam("validator tag %#x", tag)
+	}
+	
+	// ed25519_pubkey#8e81278a pubkey:bits256
+	if s.LoadUInt(32) != 0x8e81278a {
+		return nil, invalidParam("validator public key type")
+	}
+	v := &Validator{
+		PublicKey: ed25519.PublicKey(s.LoadBytes(32)),
+		Weight:    s.LoadUInt(64),
+	}
+	if tag == 0x73 {
+		v.ADNLAddr = s.LoadBytes(32)
+	}
+	return v, s.Err()
+}
+
+// MainValidators returns the validators that sign masterchain blocks
+func (vs *ValidatorSet) MainValidators() []Validator {
+	if vs.Main < len(vs.Validators) {
+		return vs.Validators[:vs.Main]
+	}
+	return vs.Validators
+}
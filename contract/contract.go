@@ -0,0 +1,276 @@
+// Package contract identifies contracts by the hash of their code and decodes their
+// persistent data.
+//
+// GetAddressInformation returns the code and data of an account as base64 BOCs. A
+// Registry maps code hashes to contract types and types to data decoders.
+// DefaultRegistry knows the code of the standard wallets (v1 to v5 and highload) and
+// of the reference jetton minter, jetton wallet, NFT collection and NFT item, and
+// decodes the data of jetton wallets and minters, NFT items and collections, DNS
+// items, multisig v2 and vesting wallets. The code of the other deployments of these
+// contracts differs, so their hashes are added with RegisterCode.
+package contract
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// ErrInvalidData is returned for data cells that do not match the layout of their contract type
+var ErrInvalidData = errors.New("contract: invalid data")
+
+// invalidData returns an ErrInvalidData error with details
+func invalidData(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidData, fmt.Sprintf(format, args...))
+}
+
+// Type is a kind of contract. Wallet types use the names of the wallet_type field of
+// GetWalletInformation.
+type Type string
+
+// Contract types
+const (
+	Unknown       Type = ""
+	WalletV1R1    Type = "wallet v1 r1"
+	WalletV1R2    Type = "wallet v1 r2"
+	WalletV1R3    Type = "wallet v1 r3"
+	WalletV2R1    Type = "wallet v2 r1"
+	WalletV2R2    Type = "wallet v2 r2"
+	WalletV3R1    Type = "wallet v3 r1"
+	WalletV3R2    Type = "wallet v3 r2"
+	WalletV4R1    Type = "wallet v4 r1"
+	WalletV4R2    Type = "wallet v4 r2"
+	WalletV5R1    Type = "wallet v5 r1"
+	HighloadV1R1  Type = "highload wallet v1 r1"
+	HighloadV1R2  Type = "highload wallet v1 r2"
+	HighloadV2    Type = "highload wallet v2"
+	HighloadV3    Type = "highload wallet v3"
+	JettonWallet  Type = "jetton wallet"
+	JettonMinter  Type = "jetton minter"
+	NFTItem       Type = "nft item"
+	NFTCollection Type = "nft collection"
+	DNSItem       Type = "dns item"
+	MultisigV2    Type = "multisig v2"
+	Vesting       Type = "vesting wallet"
+)
+
+// knownCodes maps the code hashes of standard contracts to their types
+var knownCodes = map[string]Type{
+	"a0cfc2c48aee16a271f2cfc0b7382d81756cecb1017d077faaab3bb602f6868c": WalletV1R1,
+	"d4902fcc9fad74698fa8e353220a68da0dcf72e32bcb2eb9ee04217c17d3062c": WalletV1R2,
+	"587cc789eff1c84f46ec3797e45fc809a14ff5ae24f1e0c7a6a99cc9dc9061ff": WalletV1R3,
+	"5c9a5e68c108e18721a07c42f9956bfb39ad77ec6d624b60c576ec88eee65329": WalletV2R1,
+	"fe9530d3243853083ef2ef0b4c2908c0abf6fa1c31ea243aacaa5bf8c7d753f1": WalletV2R2,
+	"b61041a58a7980b946e8fb9e198e3c904d24799ffa36574ea4251c41a566f581": WalletV3R1,
+	"84dafa449f98a6987789ba232358072bc0f76dc4524002a5d0918b9a75d2d599": WalletV3R2,
+	"64dd54805522c5be8a9db59cea0105ccf0d08786ca79bebc8cb79e880a8d7322": WalletV4R1,
+	"feb5ff6820e2ff0d9483e7e0d62c817d846789fb4ae580c878866d959dabd5c0": WalletV4R2,
+	"20834b7b72b112147e1b2fb457b84e74d1a30f04f737d4f62a668e9552d2b72f": WalletV5R1,
+	"d8cdbbb79f2c5caa677ac450770be0351be21e1250486de85cc52aa33dd16484": HighloadV1R1,
+	"0dceed21269d66013e95b19fbb5c55a6f01adad40837baa8e521cde3a02aa46c": HighloadV1R2,
+	"9494d1cc8edf12f05671a1a9ba09921096eb50811e1924ec65c3c629fbb80812": HighloadV2,
+	"11acad7955844090f283bf238bc1449871f783e7cc0979408d3f4859483e8525": HighloadV3,
+	
+	// Reference implementations of token-contract, also shipped by tonweb
+	"f95ba0330b38cdf3459b1e811e5fc6fa6cfee566d7b764455c0468140365a737": JettonMinter,
+	"4adf48135cb575adbaed476799c87ff2904269b1f949ada4d0479e9104b6f217": JettonWallet,
+	"64bb2d4661b5f2dc1a83bf5cbbe09e92ac0b460a1b879a5519386fca4c348bca": NFTCollection,
+	"4c9123828682fa6f43797ab41732bca890cae01766e0674100250516e0bf8d42": NFTItem,
+}
+
+// standard holds the decoders installed by NewRegistry
+var standard = map[Type]DecodeFunc{
+	WalletV1R1:    decodeSimpleWallet,
+	WalletV1R2:    decodeSimpleWallet,
+	WalletV1R3:    decodeSimpleWallet,
+	WalletV2R1:    decodeSimpleWallet,
+	WalletV2R2:    decodeSimpleWallet,
+	WalletV3R1:    decodeWalletV3,
+	WalletV3R2:    decodeWalletV3,
+	WalletV4R1:    decodeWalletV4,
+	WalletV4R2:    decodeWalletV4,
+	WalletV5R1:    decodeWalletV5,
+	HighloadV1R1:  decodeWalletV3,
+	HighloadV1R2:  decodeWalletV3,
+	HighloadV2:    decodeHighloadV2,
+	HighloadV3:    decodeHighloadV3,
+	JettonWallet:  decodeJettonWallet,
+	JettonMinter:  decodeJettonMinter,
+	NFTItem:       decodeNFTItem,
+	NFTCollection: decodeNFTCollection,
+	DNSItem:       decodeDNSItem,
+	MultisigV2:    decodeMultisigV2,
+	Vesting:       decodeVesting,
+}
+
+// DecodeFunc decodes the data cell of a contract
+type DecodeFunc func(s *cell.Slice) (interface{}, error)
+
+// State is an identified contract
+type State struct {
+	Type     Type   // Unknown when the code hash is not registered
+	CodeHash []byte // nil for accounts without code
+	
+	// Data is the decoded data, e.g. a *WalletV4Data or a *JettonWalletData; nil
+	// when the account has no data or its type has no decoder
+	Data interface{}
+}
+
+// Registry maps code hashes to contract types and types to data decoders. The zero
+// value knows nothing and is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	codes    map[string]Type
+	decoders map[Type]DecodeFunc
+}
+
+// NewRegistry returns a registry with the standard code hashes and decoders
+func NewRegistry() *Registry {
+	r := &Registry{}
+	for h, t := range knownCodes {
+		hash, _ := hex.DecodeString(h)
+		r.RegisterCode(hash, t)
+	}
+	for t, fn := range standard {
+		r.RegisterDecoder(t, fn)
+	}
+	return r
+}
+
+// DefaultRegistry is the registry used by Identify, Decode and RegisterCode
+var DefaultRegistry = NewRegistry()
+
+// RegisterCode sets the type of the contracts whose code has the given hash, e.g. the
+// jetton wallet code returned by get_jetton_data
+func (r *Registry) RegisterCode(hash []byte, t Type) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	if r.codes == nil {
+		r.codes = make(map[string]Type)
+	}
+	r.codes[string(hash)] = t
+}
+
+// RegisterDecoder sets the data decoder of a type, replacing any previous one
+func (r *Registry) RegisterDecoder(t Type, fn DecodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	if r.decoders == nil {
+		r.decoders = make(map[Type]DecodeFunc)
+	}
+	r.decoders[t] = fn
+}
+
+// Identify returns the type of a contract code, Unknown if its hash is not registered
+func (r *Registry) Identify(code *cell.Cell) Type {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.codes[string(code.Hash())]
+}
+
+// Decode identifies a contract by its code and decodes its data. code and data may be
+// nil for uninitialized accounts.
+func (r *Registry) Decode(code, data *cell.Cell) (*State, error) {
+	st := &State{}
+	if code == nil {
+		return st, nil
+	}
+	st.CodeHash = code.Hash()
+	st.Type = r.Identify(code)
+	
+	r.mu.RLock()
+	fn, ok := r.decoders[st.Type]
+	r.mu.RUnlock()
+	if !ok || data == nil {
+		return st, nil
+	}
+	if data.Type() == cell.PrunedBranch {
+		return nil, invalidData("pruned cell")
+	}
+	
+	s := data.BeginParse()
+	v, err := fn(s)
+	if err == nil {
+		err = s.Err()
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidData) {
+			return nil, err
+		}
+		return nil, invalidData("%s: %v", st.Type, err)
+	}
+	st.Data = v
+	return st, nil
+}
+
+// DecodeBase64 is Decode for code and data given as base64 BOCs, e.g. the Code and
+// Data of GetAddressInformation; empty strings stand for missing cells
+func (r *Registry) DecodeBase64(code, data string) (*State, error) {
+	var cc, dc *cell.Cell
+	var err error
+	if code != "" {
+		if cc, err = cell.FromBOCBase64(code); err != nil {
+			return nil, fmt.Errorf("contract: invalid code: %w", err)
+		}
+	}
+	if data != "" {
+		if dc, err = cell.FromBOCBase64(data); err != nil {
+			return nil, invalidData("%v", err)
+		}
+	}
+	return r.Decode(cc, dc)
+}
+
+// RegisterCode sets the type of a code hash in DefaultRegistry
+func RegisterCode(hash []byte, t Type) {
+	DefaultRegistry.RegisterCode(hash, t)
+}
+
+// Identify returns the type of a contract code with DefaultRegistry
+func Identify(code *cell.Cell) Type {
+	return DefaultRegistry.Identify(code)
+}
+
+// Decode identifies a contract and decodes its data with DefaultRegistry
+func Decode(code, data *cell.Cell) (*State, error) {
+	return DefaultRegistry.Decode(code, data)
+}
+
+// loadAddresses reads the keys of a dictionary keyed by MsgAddressInt
+func loadAddresses(d *cell.Dict) ([]*address.Address, error) {
+	var out []*address.Address
+	err := d.ForEach(func(key []byte, _ *cell.Slice) error {
+		c, err := cell.BeginCell().StoreBits(key, d.KeyLen()).EndCell()
+		if err != nil {
+			return err
+		}
+		s := c.BeginParse()
+		a := s.LoadAddress()
+		if err := s.Err(); err != nil {
+			return err
+		}
+		out = append(out, a)
+		return nil
+	})
+	return out, err
+}
+
+// loadAddressValues reads the values of a dictionary of MsgAddressInt in key order
+func loadAddressValues(d *cell.Dict) ([]*address.Address, error) {
+	var out []*address.Address
+	err := d.ForEach(func(_ []byte, v *cell.Slice) error {
+		a := v.LoadAddress()
+		if err := v.Err(); err != nil {
+			return err
+		}
+		out = append(out, a)
+		return nil
+	})
+	return out, err
+}
@@ -0,0 +1,284 @@
+package contract
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+func must(c *cell.Cell, err error) *cell.Cell {
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// testCode returns a code cell of no standard contract
+func testCode() *cell.Cell {
+	return must(cell.BeginCell().StoreUInt(0xdead, 16).EndCell())
+}
+
+// registry returns a registry with the standard decoders where testCode has type t
+func registry(t Type) *Registry {
+	r := NewRegistry()
+	r.RegisterCode(testCode().Hash(), t)
+	return r
+}
+
+var (
+	testKey  = append([]byte{9}, make([]byte, 31)...)
+	testAddr = address.New(0, append(make([]byte, 31), 1))
+)
+
+func TestIdentify(t *testing.T) {
+	// The highload tests identify a standard code through Decode
+	if typ := Identify(testCode()); typ != Unknown {
+		t.Errorf("unknown code identified as %q", typ)
+	}
+	if typ := registry(JettonWallet).Identify(testCode()); typ != JettonWallet {
+		t.Errorf("registered code identified as %q", typ)
+	}
+	var empty Registry
+	empty.RegisterDecoder(JettonWallet, decodeJettonWallet)
+	if typ := empty.Identify(testCode()); typ != Unknown {
+		t.Errorf("zero registry identified %q", typ)
+	}
+	empty.RegisterCode(testCode().Hash(), JettonWallet)
+	if typ := empty.Identify(testCode()); typ != JettonWallet {
+		t.Errorf("code registered in a zero registry identified as %q", typ)
+	}
+}
+
+func TestDecodeWithoutCodeOrDecoder(t *testing.T) {
+	st, err := Decode(nil, nil)
+	if err != nil || st.Type != Unknown || st.CodeHash != nil || st.Data != nil {
+		t.Errorf("uninitialized account: %+v, %v", st, err)
+	}
+	// Unknown code has no decoder, so the data is left alone
+	st, err = Decode(testCode(), must(cell.BeginCell().StoreUInt(1, 3).EndCell()))
+	if err != nil || st.Type != Unknown || st.Data != nil {
+		t.Errorf("unknown code: %+v, %v", st, err)
+	}
+}
+
+func TestDecodeWalletV4(t *testing.T) {
+	plugins := cell.NewDict(264)
+	key := append([]byte{0xff, 7}, make([]byte, 31)...)
+	plugins.Set(key, must(cell.BeginCell().EndCell()))
+	data := must(cell.BeginCell().StoreUInt(5, 32).StoreUInt(698983191, 32).StoreBytes(testKey).StoreDict(plugins).EndCell())
+	
+	st, err := registry(WalletV4R2).DecodeBase64(testCode().ToBOCBase64(), data.ToBOCBase64())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := st.Data.(*WalletV4Data)
+	if w.Seqno != 5 || w.SubwalletID != 698983191 || w.PublicKey[0] != 9 {
+		t.Errorf("wallet %+v", w)
+	}
+	if len(w.Plugins) != 1 || w.Plugins[0].Workchain != -1 || w.Plugins[0].Hash[0] != 7 {
+		t.Errorf("plugins %v", w.Plugins)
+	}
+}
+
+func TestDecodeJettonWallet(t *testing.T) {
+	r := registry(JettonWallet)
+	data := must(cell.BeginCell().StoreCoins(big.NewInt(1234)).StoreAddress(testAddr).StoreAddress(testAddr).StoreRef(testCode()).EndCell())
+	st, err := r.Decode(testCode(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := st.Data.(*JettonWalletData); w.Balance.Int64() != 1234 || w.Owner.String() != testAddr.String() || w.WalletCode == nil {
+		t.Errorf("jetton wallet %+v", w)
+	}
+	
+	if _, err := r.Decode(testCode(), must(cell.BeginCell().StoreUInt(1, 3).EndCell())); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("short data: error %v, want ErrInvalidData", err)
+	}
+}
+
+func TestHighloadV3Processed(t *testing.T) {
+	// Query 3:1 was processed before the last cleanup and 3:2 after it
+	old := cell.NewDict(13)
+	old.Set(cell.UintKey(3, 13), must(cell.BeginCell().StoreUInt(0b01, 2).EndCell()))
+	queries := cell.NewDict(13)
+	queries.Set(cell.UintKey(3, 13), must(cell.BeginCell().StoreUInt(0b001, 3).EndCell()))
+	data := must(cell.BeginCell().
+		StoreBytes(testKey).
+		StoreUInt(1, 32).
+		StoreDict(old).
+		StoreDict(queries).
+		StoreUInt(100, 64).
+		StoreUInt(3600, 22).
+		EndCell())
+	
+	st, err := registry(HighloadV3).Decode(testCode(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := st.Data.(*HighloadV3Data)
+	if h.SubwalletID != 1 || h.LastCleanTime != 100 || h.Timeout != 3600 {
+		t.Errorf("highload wallet %+v", h)
+	}
+	for q, want := range map[uint32]bool{3<<10 | 1: true, 3<<10 | 2: true, 3<<10 | 0: false, 3<<10 | 500: false, 4 << 10: false} {
+		if got, err := h.Processed(q); err != nil || got != want {
+			t.Errorf("Processed(%d:%d) = %v, %v; want %v", q>>10, q&1023, got, err, want)
+		}
+	}
+}
+
+func TestDecodeMultisig(t *testing.T) {
+	signers := cell.NewDict(8)
+	for i := uint64(0); i < 2; i++ {
+		signers.Set(cell.UintKey(i, 8), must(cell.BeginCell().StoreAddress(address.New(0, append(make([]byte, 31), byte(i)))).EndCell()))
+	}
+	proposers := cell.NewDict(8)
+	proposers.Set(cell.UintKey(0, 8), must(cell.BeginCell().StoreAddress(testAddr).EndCell()))
+	data := func(signersNum uint64) *cell.Cell {
+		return must(cell.BeginCell().
+			StoreUInt(7, 256).
+			StoreUInt(2, 8).
+			StoreRef(must(signers.ToCell())).
+			StoreUInt(signersNum, 8).
+			StoreDict(proposers).
+			StoreBit(true).
+			EndCell())
+	}
+	
+	r := registry(MultisigV2)
+	st, err := r.Decode(testCode(), data(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := st.Data.(*MultisigData)
+	if m.NextOrderSeqno.Int64() != 7 || m.Threshold != 2 || !m.AllowArbitrarySeqno {
+		t.Errorf("multisig %+v", m)
+	}
+	if len(m.Signers) != 2 || m.Signers[1].Hash[31] != 1 || len(m.Proposers) != 1 {
+		t.Errorf("signers %v, proposers %v", m.Signers, m.Proposers)
+	}
+	
+	if _, err := r.Decode(testCode(), data(3)); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("wrong signers_num: error %v, want ErrInvalidData", err)
+	}
+}
+
+func TestDecodeVesting(t *testing.T) {
+	whitelist := cell.NewDict(267)
+	whitelist.Set(must(cell.BeginCell().StoreAddress(testAddr).EndCell()).Data(), must(cell.BeginCell().EndCell()))
+	params := must(cell.BeginCell().
+		StoreUInt(1, 64).
+		StoreUInt(2, 32).
+		StoreUInt(3, 32).
+		StoreUInt(4, 32).
+		StoreCoins(big.NewInt(5)).
+		StoreAddress(testAddr).
+		StoreAddress(testAddr).
+		EndCell())
+	data := must(cell.BeginCell().StoreUInt(1, 32).StoreUInt(2, 32).StoreBytes(testKey).StoreDict(whitelist).StoreRef(params).EndCell())
+	
+	st, err := registry(Vesting).Decode(testCode(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := st.Data.(*VestingData)
+	if len(v.Whitelist) != 1 || v.Whitelist[0].String() != testAddr.String() {
+		t.Errorf("whitelist %v", v.Whitelist)
+	}
+	if v.StartTime != 1 || v.CliffDuration != 4 || v.TotalAmount.Int64() != 5 || v.Owner.String() != testAddr.String() {
+		t.Errorf("vesting %+v", v)
+	}
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	r := registry("counter")
+	r.RegisterDecoder("counter", func(s *cell.Slice) (interface{}, error) {
+		return s.LoadUInt(32), nil
+	})
+	st, err := r.Decode(testCode(), must(cell.BeginCell().StoreUInt(42, 32).EndCell()))
+	if err != nil || st.Type != "counter" || st.Data != uint64(42) {
+		t.Errorf("custom type: %+v, %v", st, err)
+	}
+	if _, err := r.Decode(testCode(), must(cell.BeginCell().StoreUInt(42, 16).EndCell())); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("short data: error %v, want ErrInvalidData", err)
+	}
+}
+
+// referenceCode holds the code BOCs of the reference token contracts as published
+// in tonweb; FromBOC checks their CRC32C
+var referenceCode = map[Type]string{
+	JettonMinter: "" +
+		"b5ee9c7241020b010001ed000114ff00f4a413f4bcf2c80b0102016202030202cc040502037a60090a03efd9910e3804" +
+		"8adf068698180b8d848adf07d201800e98fe99ff6a2687d007d206a6a18400aa9385d47181a9aa8aae382f9702480fd2" +
+		"07d006a18106840306b90fd001812881a28217804502a906428027d012c678b666664f6aa7041083deecbef29385d718" +
+		"11a92e001f1811802600271812f82c207f97840607080093dfc142201b82a1009aa0a01e428027d012c678b00e78b666" +
+		"491646580897a007a00658064907c80383a6465816503e5ffe4e83bc00c646582ac678b28027d0109e5b589666664b8f" +
+		"d80400fe3603fa00fa40f82854120870542013541403c85004fa0258cf1601cf16ccc922c8cb0112f400f400cb00c9f9" +
+		"007074c8cb02ca07cbffc9d05008c705f2e04a12a1035024c85004fa0258cf16ccccc9ed5401fa403020d70b01c3008e" +
+		"1f8210d53276db708010c8cb055003cf1622fa0212cb6acb1fcb3fc98042fb00915be200303515c705f2e049fa403059" +
+		"c85004fa0258cf16ccccc9ed54002e5143c705f2e049d43001c85004fa0258cf16ccccc9ed54007dadbcf6a2687d007d" +
+		"206a6a183618fc1400b82a1009aa0a01e428027d012c678b00e78b666491646580897a007a00658064fc80383a646581" +
+		"6503e5ffe4e840001faf16f6a2687d007d206a6a183faa904051007f09",
+	JettonWallet: "" +
+		"b5ee9c7241021201000328000114ff00f4a413f4bcf2c80b0102016202030202cc0405001ba0f605da89a1f401f481f4" +
+		"81a8610201d40607020148080900bb0831c02497c138007434c0c05c6c2544d7c0fc02f83e903e900c7e800c5c75c87e" +
+		"800c7e800c00b4c7e08403e29fa954882ea54c4d167c0238208405e3514654882ea58c511100fc02780d60841657c1ef" +
+		"2ea4d67c02b817c12103fcbc2000113e910c1c2ebcb853600201200a0b020120101101f500f4cffe803e90087c007b51" +
+		"343e803e903e90350c144da8548ab1c17cb8b04a30bffcb8b0950d109c150804d50500f214013e809633c58073c5b332" +
+		"48b232c044bd003d0032c032483e401c1d3232c0b281f2fff274013e903d010c7e801de0063232c1540233c59c3e8085" +
+		"f2dac4f3208405e351467232c7c6600c03f73b51343e803e903e90350c0234cffe80145468017e903e9014d6f1c1551c" +
+		"db5c150804d50500f214013e809633c58073c5b33248b232c044bd003d0032c0327e401c1d3232c0b281f2fff2741403" +
+		"71c1472c7cb8b0c2be80146a2860822625a020822625a004ad822860822625a028062849f8c3c975c2c070c008e00d0e" +
+		"0f009acb3f5007fa0222cf165006cf1625fa025003cf16c95005cc2391729171e25008a813a08208989680aa00820898" +
+		"9680a0a014bcf2e2c504c98040fb001023c85004fa0258cf1601cf16ccc9ed5400705279a018a182107362d09cc8cb1f" +
+		"5230cb3f58fa025007cf165007cf16c9718018c8cb0524cf165006fa0215cb6a14ccc971fb0010241023000e10491038" +
+		"375f040076c200b08e218210d53276db708010c8cb055008cf165004fa0216cb6a12cb1f12cb3fc972fb0093356c21e2" +
+		"03c85004fa0258cf1601cf16ccc9ed5400db3b51343e803e903e90350c01f4cffe803e900c145468549271c17cb8b049" +
+		"f0bffcb8b0a0822625a02a8005a805af3cb8b0e0841ef765f7b232c7c572cfd400fe8088b3c58073c5b25c60063232c1" +
+		"4933c59c3e80b2dab33260103ec01004f214013e809633c58073c5b3327b55200083200835c87b51343e803e903e9035" +
+		"0c0134c7e08405e3514654882ea0841ef765f784ee84ac7cb8b174cfcc7e800c04e81408f214013e809633c58073c5b3" +
+		"327b55205eccf23d",
+	NFTCollection: "" +
+		"b5ee9c724102140100021f000114ff00f4a413f4bcf2c80b0102016202030202cd04050201200e0f04e7d10638048adf" +
+		"000e8698180b8d848adf07d201800e98fe99ff6a2687d20699fea6a6a184108349e9ca829405d47141baf8280e841085" +
+		"4658056b84008646582a802e78b127d010a65b509e58fe59f80e78b64c0207d80701b28b9e382f970c892e000f18112e" +
+		"001718112e001f181181981e0024060708090201200a0b00603502d33f5313bbf2e1925313ba01fa00d43028103459f0" +
+		"068e1201a44343c85005cf1613cb3fccccccc9ed54925f05e200a6357003d4308e378040f4966fa5208e2906a4208100" +
+		"fabe93f2c18fde81019321a05325bbf2f402fa00d43022544b30f00623ba9302a402de04926c21e2b3e6303250444313" +
+		"c85005cf1613cb3fccccccc9ed54002c323401fa40304144c85005cf1613cb3fccccccc9ed54003c8e15d4d430103441" +
+		"30c85005cf1613cb3fccccccc9ed54e05f04840ff2f00201200c0d003d45af0047021f005778018c8cb0558cf165004f" +
+		"a0213cb6b12ccccc971fb008002d007232cffe0a33c5b25c083232c044fd003d0032c03260001b3e401d3232c084b281" +
+		"f2fff2742002012010110025bc82df6a2687d20699fea6a6a182de86a182c40043b8b5d31ed44d0fa40d33fd4d4d4301" +
+		"0245f04d0d431d430d071c8cb0701cf16ccc980201201213002fb5dafda89a1f481a67fa9a9a860d883a1a61fa61ff48" +
+		"0610002db4f47da89a1f481a67fa9a9a86028be09e008e003e00b01a500c6e",
+	NFTItem: "" +
+		"b5ee9c7241020d010001d0000114ff00f4a413f4bcf2c80b0102016202030202ce04050009a11f9fe005020120060702" +
+		"01200b0c02d70c8871c02497c0f83434c0c05c6c2497c0f83e903e900c7e800c5c75c87e800c7e800c3c00812ce3850c" +
+		"1b088d148cb1c17cb865407e90350c0408fc00f801b4c7f4cfe08417f30f45148c2ea3a1cc840dd78c9004f80c0d0d0d" +
+		"4d60840bf2c9a884aeb8c097c12103fcbc20080900113e910c1c2ebcb8536001f65135c705f2e191fa4021f001fa40d2" +
+		"0031fa00820afaf0801ba121945315a0a1de22d70b01c300209206a19136e220c2fff2e192218e3e821005138d91c850" +
+		"09cf16500bcf16712449145446a0708010c8cb055007cf165005fa0215cb6a12cb1fcb3f226eb39458cf17019132e201" +
+		"c901fb00104794102a375be20a00727082108b77173505c8cbff5004cf1610248040708010c8cb055007cf165005fa02" +
+		"15cb6a12cb1fcb3f226eb39458cf17019132e201c901fb000082028e3526f0018210d53276db103744006d71708010c8" +
+		"cb055007cf165005fa0215cb6a12cb1fcb3f226eb39458cf17019132e201c901fb0093303234e25502f003003b3b5134" +
+		"34cffe900835d27080269fc07e90350c04090408f80c1c165b5b60001d00f232cfd633c58073c5b3327b5520bf75041b",
+}
+
+func TestIdentifyReferenceCode(t *testing.T) {
+	for typ, h := range referenceCode {
+		raw, err := hex.DecodeString(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		code, err := cell.FromBOC(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", typ, err)
+		}
+		if got := Identify(code); got != typ {
+			t.Errorf("%s code identified as %q", typ, got)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package contract
+
+import (
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// MultisigData is the data of a multisig v2 wallet
+type MultisigData struct {
+	NextOrderSeqno      *big.Int
+	Threshold           uint8
+	Signers             []*address.Address // in signer index order
+	Proposers           []*address.Address // in proposer index order
+	AllowArbitrarySeqno bool
+}
+
+// decodeMultisigV2 decodes next_order_seqno:uint256 threshold:uint8
+// signers:^(Hashmap 8 MsgAddressInt) signers_num:uint8
+// proposers:(HashmapE 8 MsgAddressInt) allow_arbitrary_order_seqno:Bool
+func decodeMultisigV2(s *cell.Slice) (interface{}, error) {
+	m := &MultisigData{
+		NextOrderSeqno: s.LoadBigUInt(256),
+		Threshold:      uint8(s.LoadUInt(8)),
+	}
+	signers := cell.LoadDict(s.LoadRef(), 8)
+	signersNum := int(s.LoadUInt(8))
+	proposers := s.LoadDict(8)
+	m.AllowArbitrarySeqno = s.LoadBool()
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	
+	var err error
+	if m.Signers, err = loadAddressValues(signers); err != nil {
+		return nil, err
+	}
+	if len(m.Signers) != signersNum {
+		return nil, invalidData("multisig has %d signers, %d expected", len(m.Signers), signersNum)
+	}
+	if m.Proposers, err = loadAddressValues(proposers); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
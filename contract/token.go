@@ -0,0 +1,139 @@
+package contract
+
+import (
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// JettonWalletData is the data of a standard jetton wallet (TEP-74)
+type JettonWalletData struct {
+	Balance    *big.Int
+	Owner      *address.Address
+	Master     *address.Address
+	WalletCode *cell.Cell
+}
+
+// decodeJettonWallet decodes balance:Coins owner_address:MsgAddress
+// jetton_master_address:MsgAddress jetton_wallet_code:^Cell
+func decodeJettonWallet(s *cell.Slice) (interface{}, error) {
+	return &JettonWalletData{
+		Balance:    s.LoadCoins(),
+		Owner:      s.LoadAddress(),
+		Master:     s.LoadAddress(),
+		WalletCode: s.LoadRef(),
+	}, nil
+}
+
+// JettonMinterData is the data of a standard jetton minter (TEP-74)
+type JettonMinterData struct {
+	TotalSupply *big.Int
+	Admin       *address.Address
+	Content     *cell.Cell
+	WalletCode  *cell.Cell
+}
+
+// decodeJettonMinter decodes total_supply:Coins admin_address:MsgAddress content:^Cell
+// jetton_wallet_code:^Cell
+func decodeJettonMinter(s *cell.Slice) (interface{}, error) {
+	return &JettonMinterData{
+		TotalSupply: s.LoadCoins(),
+		Admin:       s.LoadAddress(),
+		Content:     s.LoadRef(),
+		WalletCode:  s.LoadRef(),
+	}, nil
+}
+
+// NFTItemData is the data of a standard NFT item (TEP-62). Owner and Content are nil
+// until the collection initializes the item.
+type NFTItemData struct {
+	Index      uint64
+	Collection *address.Address
+	Owner      *address.Address
+	Content    *cell.Cell
+}
+
+// decodeNFTItem decodes index:uint64 collection_address:MsgAddress and, once
+// initialized, owner_address:MsgAddress content:^Cell
+func decodeNFTItem(s *cell.Slice) (interface{}, error) {
+	item := &NFTItemData{
+		Index:      s.LoadUInt(64),
+		Collection: s.LoadAddress(),
+	}
+	if s.Err() == nil && s.BitsLeft() > 0 {
+		item.Owner = s.LoadAddress()
+		item.Content = s.LoadRef()
+	}
+	return item, nil
+}
+
+// Royalty are the royalty parameters of an NFT collection: Numerator/Denominator of
+// every sale goes to Destination
+type Royalty struct {
+	Numerator   uint16
+	Denominator uint16
+	Destination *address.Address
+}
+
+// NFTCollectionData is the data of a standard NFT collection (TEP-62)
+type NFTCollectionData struct {
+	Owner         *address.Address
+	NextItemIndex uint64
+	Content       *cell.Cell
+	CommonContent *cell.Cell
+	ItemCode      *cell.Cell
+	Royalty       Royalty
+}
+
+// decodeNFTCollection decodes owner_address:MsgAddress next_item_index:uint64
+// content:^[collection_content:^Cell common_content:^Cell] nft_item_code:^Cell
+// royalty_params:^[numerator:uint16 denominator:uint16 destination:MsgAddress]
+func decodeNFTCollection(s *cell.Slice) (interface{}, error) {
+	c := &NFTCollectionData{
+		Owner:         s.LoadAddress(),
+		NextItemIndex: s.LoadUInt(64),
+	}
+	content := s.LoadRefSlice()
+	c.Content = content.LoadRef()
+	c.CommonContent = content.LoadRef()
+	c.ItemCode = s.LoadRef()
+	royalty := s.LoadRefSlice()
+	c.Royalty = Royalty{
+		Numerator:   uint16(royalty.LoadUInt(16)),
+		Denominator: uint16(royalty.LoadUInt(16)),
+		Destination: royalty.LoadAddress(),
+	}
+	if err := content.Err(); err != nil {
+		return nil, err
+	}
+	return c, royalty.Err()
+}
+
+// DNSItemData is the data of a .ton domain, an NFT item of the DNS collection
+type DNSItemData struct {
+	Index          []byte // sha256 of the domain
+	Collection     *address.Address
+	Owner          *address.Address
+	Content        *cell.Cell // DNS records
+	Domain         string
+	Auction        *cell.Cell // nil when no auction is running
+	LastFillUpTime uint64
+}
+
+// decodeDNSItem decodes index:uint256 collection_address:MsgAddress
+// owner_address:MsgAddress content:^Cell domain:^Cell auction:(Maybe ^Cell)
+// last_fill_up_time:uint64
+func decodeDNSItem(s *cell.Slice) (interface{}, error) {
+	item := &DNSItemData{
+		Index:      s.LoadBytes(32),
+		Collection: s.LoadAddress(),
+		Owner:      s.LoadAddress(),
+		Content:    s.LoadRef(),
+	}
+	domain := s.LoadRefSlice()
+	item.Domain = domain.LoadStringSnake()
+	item.Auction = s.LoadMaybeRef()
+	item.LastFillUpTime = s.LoadUInt(64)
+	return item, domain.Err()
+}
@@ -0,0 +1,213 @@
+package contract
+
+import (
+	"crypto/ed25519"
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// SimpleWalletData is the data of wallets v1 and v2
+type SimpleWalletData struct {
+	Seqno     uint32
+	PublicKey ed25519.PublicKey
+}
+
+// decodeSimpleWallet decodes seqno:uint32 public_key:bits256
+func decodeSimpleWallet(s *cell.Slice) (interface{}, error) {
+	return &SimpleWalletData{
+		Seqno:     uint32(s.LoadUInt(32)),
+		PublicKey: s.LoadBytes(32),
+	}, nil
+}
+
+// WalletV3Data is the data of wallets v3 and highload wallets v1
+type WalletV3Data struct {
+	Seqno       uint32
+	SubwalletID uint32
+	PublicKey   ed25519.PublicKey
+}
+
+// decodeWalletV3 decodes seqno:uint32 subwallet_id:uint32 public_key:bits256
+func decodeWalletV3(s *cell.Slice) (interface{}, error) {
+	return &WalletV3Data{
+		Seqno:       uint32(s.LoadUInt(32)),
+		SubwalletID: uint32(s.LoadUInt(32)),
+		PublicKey:   s.LoadBytes(32),
+	}, nil
+}
+
+// WalletV4Data is the data of wallets v4
+type WalletV4Data struct {
+	Seqno       uint32
+	SubwalletID uint32
+	PublicKey   ed25519.PublicKey
+	Plugins     []*address.Address
+}
+
+// decodeWalletV4 decodes seqno:uint32 subwallet_id:uint32 public_key:bits256
+// plugins:(HashmapE 264 Cell), keyed by workchain:int8 and account id
+func decodeWalletV4(s *cell.Slice) (interface{}, error) {
+	w := &WalletV4Data{
+		Seqno:       uint32(s.LoadUInt(32)),
+		SubwalletID: uint32(s.LoadUInt(32)),
+		PublicKey:   s.LoadBytes(32),
+	}
+	plugins := s.LoadDict(8 + 256)
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	err := plugins.ForEach(func(key []byte, _ *cell.Slice) error {
+		w.Plugins = append(w.Plugins, address.New(int32(int8(key[0])), key[1:]))
+		return nil
+	})
+	return w, err
+}
+
+// WalletV5Data is the data of wallets v5
+type WalletV5Data struct {
+	SignatureAllowed bool
+	Seqno            uint32
+	WalletID         uint32
+	PublicKey        ed25519.PublicKey
+	Extensions       [][]byte // account ids in the workchain of the wallet
+}
+
+// decodeWalletV5 decodes is_signature_allowed:Bool seqno:uint32 wallet_id:uint32
+// public_key:bits256 extensions_dict:(HashmapE 256 int1)
+func decodeWalletV5(s *cell.Slice) (interface{}, error) {
+	w := &WalletV5Data{
+		SignatureAllowed: s.LoadBool(),
+		Seqno:            uint32(s.LoadUInt(32)),
+		WalletID:         uint32(s.LoadUInt(32)),
+		PublicKey:        s.LoadBytes(32),
+	}
+	extensions := s.LoadDict(256)
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	err := extensions.ForEach(func(key []byte, _ *cell.Slice) error {
+		w.Extensions = append(w.Extensions, key)
+		return nil
+	})
+	return w, err
+}
+
+// HighloadV2Data is the data of highload wallets v2
+type HighloadV2Data struct {
+	SubwalletID uint32
+	LastCleaned uint64
+	PublicKey   ed25519.PublicKey
+	OldQueries  []uint64 // query ids processed since LastCleaned
+}
+
+// decodeHighloadV2 decodes subwallet_id:uint32 last_cleaned:uint64 public_key:bits256
+// old_queries:(HashmapE 64 Cell)
+func decodeHighloadV2(s *cell.Slice) (interface{}, error) {
+	w := &HighloadV2Data{
+		SubwalletID: uint32(s.LoadUInt(32)),
+		LastCleaned: s.LoadUInt(64),
+		PublicKey:   s.LoadBytes(32),
+	}
+	queries := s.LoadDict(64)
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	err := queries.ForEach(func(key []byte, _ *cell.Slice) error {
+		w.OldQueries = append(w.OldQueries, cell.KeyUint(key, 64))
+		return nil
+	})
+	return w, err
+}
+
+// HighloadV3Data is the data of highload wallets v3. Processed query ids are
+// kept as bit strings per shift: Queries since LastCleanTime and OldQueries from
+// the timeout before.
+type HighloadV3Data struct {
+	PublicKey     ed25519.PublicKey
+	SubwalletID   uint32
+	OldQueries    *cell.Dict
+	Queries       *cell.Dict
+	LastCleanTime uint64
+	Timeout       uint32
+}
+
+// decodeHighloadV3 decodes public_key:bits256 subwallet_id:uint32
+// old_queries:(HashmapE 13 Bits) queries:(HashmapE 13 Bits) last_clean_time:uint64
+// timeout:uint22
+func decodeHighloadV3(s *cell.Slice) (interface{}, error) {
+	return &HighloadV3Data{
+		PublicKey:     s.LoadBytes(32),
+		SubwalletID:   uint32(s.LoadUInt(32)),
+		OldQueries:    s.LoadDict(13),
+		Queries:       s.LoadDict(13),
+		LastCleanTime: s.LoadUInt(64),
+		Timeout:       uint32(s.LoadUInt(22)),
+	}, nil
+}
+
+// Processed reports whether the wallet has processed a query id, made of a 13-bit
+// shift and a 10-bit bit number, and has not cleaned it up yet
+func (w *HighloadV3Data) Processed(queryID uint32) (bool, error) {
+	shift, bitNumber := int(queryID>>10&(1<<13-1)), int(queryID&(1<<10-1))
+	for _, d := range []*cell.Dict{w.Queries, w.OldQueries} {
+		v, err := d.Get(cell.UintKey(uint64(shift), 13))
+		if err != nil {
+			return false, err
+		}
+		if v != nil && v.BitsLeft() > bitNumber {
+			v.Skip(bitNumber)
+			if v.LoadBit() {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// VestingData is the data of vesting wallets
+type VestingData struct {
+	Seqno         uint32
+	SubwalletID   uint32
+	PublicKey     ed25519.PublicKey
+	Whitelist     []*address.Address
+	StartTime     uint64
+	TotalDuration uint32
+	UnlockPeriod  uint32
+	CliffDuration uint32
+	TotalAmount   *big.Int
+	Sender        *address.Address
+	Owner         *address.Address
+}
+
+// decodeVesting decodes seqno:uint32 subwallet_id:uint32 public_key:bits256
+// whitelist:(HashmapE 267 True) vesting_parameters:^[vesting_start_time:uint64
+// vesting_total_duration:uint32 unlock_period:uint32 cliff_duration:uint32
+// vesting_total_amount:Coins vesting_sender_address:MsgAddress owner_address:MsgAddress]
+func decodeVesting(s *cell.Slice) (interface{}, error) {
+	w := &VestingData{
+		Seqno:       uint32(s.LoadUInt(32)),
+		SubwalletID: uint32(s.LoadUInt(32)),
+		PublicKey:   s.LoadBytes(32),
+	}
+	whitelist := s.LoadDict(267)
+	p := s.LoadRefSlice()
+	w.StartTime = p.LoadUInt(64)
+	w.TotalDuration = uint32(p.LoadUInt(32))
+	w.UnlockPeriod = uint32(p.LoadUInt(32))
+	w.CliffDuration = uint32(p.LoadUInt(32))
+	w.TotalAmount = p.LoadCoins()
+	w.Sender = p.LoadAddress()
+	w.Owner = p.LoadAddress()
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	
+	var err error
+	w.Whitelist, err = loadAddresses(whitelist)
+	return w, err
+}
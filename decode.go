@@ -0,0 +1,87 @@
+package toncenterzp
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/contract"
+	"github.com/zhaopeng331/toncenterzp/payload"
+)
+
+// MsgDataEncryptedText is the MessageData type of messages whose Text holds an encrypted comment
+const MsgDataEncryptedText = "msg.dataEncryptedText"
+
+// DecodeBody decodes the body of a message with r, or with payload.DefaultRegistry
+// when r is nil. Messages that only carry a text comment decode to a payload.Comment
+// or a payload.EncryptedComment.
+func (m *Message) DecodeBody(r *payload.Registry) (payload.Body, error) {
+	if r == nil {
+		r = payload.DefaultRegistry
+	}
+	
+	if m.MsgData.Body == "" {
+		if m.MsgData.Text == "" {
+			return nil, nil
+		}
+		text, err := base64.StdEncoding.DecodeString(m.MsgData.Text)
+		if err != nil {
+			return nil, NewError(ErrInvalidResponse, "invalid message text", err)
+		}
+		if m.MsgData.Type == MsgDataEncryptedText {
+			return &payload.EncryptedComment{Data: text}, nil
+		}
+		return &payload.Comment{Text: string(text)}, nil
+	}
+	return r.DecodeBase64(m.MsgData.Body)
+}
+
+// DecryptComment returns the text of an encrypted comment using the private key of the
+// sender or of the recipient of the message. testnet tells whether the message was
+// sent on testnet.
+func (m *Message) DecryptComment(priv ed25519.PrivateKey, testnet bool) (string, error) {
+	body := m.Decoded
+	if body == nil {
+		var err error
+		if body, err = m.DecodeBody(nil); err != nil {
+			return "", err
+		}
+	}
+	comment, ok := body.(*payload.EncryptedComment)
+	if !ok {
+		return "", NewError(ErrInvalidParams, "message has no encrypted comment", nil)
+	}
+	
+	sender, err := address.Parse(m.Source)
+	if err != nil {
+		return "", NewError(ErrInvalidAddress, "invalid message source", err)
+	}
+	return comment.Decrypt(sender, testnet, priv)
+}
+
+// decodeBodies sets Message.Decoded on the messages of transactions, leaving it nil
+// for bodies that fail to decode
+func (c *Client) decodeBodies(txs []TransactionDetails) {
+	decode := func(m *Message) {
+		m.Decoded, _ = m.DecodeBody(c.Decoder)
+	}
+	for i := range txs {
+		decode(&txs[i].InMsg)
+		for j := range txs[i].OutMsgs {
+			decode(&txs[i].OutMsgs[j])
+		}
+	}
+}
+
+// Contract identifies the account by the hash of its code and decodes its data with r,
+// or with contract.DefaultRegistry when r is nil
+func (resp *GetAddressInformationResponse) Contract(r *contract.Registry) (*contract.State, error) {
+	if r == nil {
+		r = contract.DefaultRegistry
+	}
+	st, err := r.DecodeBase64(resp.Result.Code, resp.Result.Data)
+	if err != nil {
+		return nil, NewError(ErrInvalidResponse, "invalid account state", err)
+	}
+	return st, nil
+}
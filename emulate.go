@@ -0,0 +1,67 @@
+package toncenterzp
+
+import (
+	"errors"
+)
+
+// EmulateRequest represents a message to execute without broadcasting it
+type EmulateRequest struct {
+	Boc string `json:"boc"`
+	
+	// IgnoreSignature skips the signature check of the wallet, so unsigned
+	// messages can be dry-run before asking for a signature
+	IgnoreSignature bool `json:"ignore_chksig,omitempty"`
+}
+
+// EmulateResult represents the predicted outcome of a message
+type EmulateResult struct {
+	// Transaction is the transaction the message causes on its destination. Its
+	// compute, action and bounce phases and its fee fields are predictions; block
+	// and logical time fields may be empty.
+	Transaction TransactionDetails
+	
+	// Children are the transactions caused by the outgoing messages of Transaction,
+	// when the emulator follows them
+	Children []EmulateResult
+}
+
+// Success reports whether the compute and action phases of the transaction succeeded
+func (r *EmulateResult) Success() bool {
+	return r.Transaction.ComputePhase.Success && r.Transaction.ActionPhase.Success
+}
+
+// Emulator executes messages against the current state of the network without
+// broadcasting them. The v3 package implements it with the provider's /emulate
+// endpoint; a local TVM emulator can implement it to dry-run offline.
+type Emulator interface {
+	Emulate(req EmulateRequest) (*EmulateResult, error)
+}
+
+// Emulators tries each emulator in order and returns the first result, so a local
+// emulator can back up a provider without an emulation endpoint
+type Emulators []Emulator
+
+// Emulate returns the result of the first emulator that succeeds
+func (e Emulators) Emulate(req EmulateRequest) (*EmulateResult, error) {
+	var errs []error
+	for _, emulator := range e {
+		result, err := emulator.Emulate(req)
+		if err == nil {
+			return result, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, NewError(ErrInvalidMethod, "no emulator configured", nil)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// DryRun executes a message with the client's Emulator instead of broadcasting it,
+// e.g. to check that a transfer succeeds before calling SendBoc
+func (c *Client) DryRun(req EmulateRequest) (*EmulateResult, error) {
+	if c.Emulator == nil {
+		return nil, NewError(ErrInvalidMethod, "no emulator configured", nil)
+	}
+	return c.Emulator.Emulate(req)
+}
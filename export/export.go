@@ -0,0 +1,366 @@
+// Package export writes the transaction history of an address as normalized
+// rows in CSV or JSON Lines, for account statements and accounting.
+//
+// The history is walked page by page through GetTransactions and every page is
+// written before the next one is requested, so memory use does not grow with
+// the number of transactions.
+package export
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf8"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	v3 "github.com/zhaopeng331/toncenterzp/v3"
+)
+
+// Format is the output format of an export
+type Format int
+
+const (
+	// FormatCSV writes a header line followed by one comma-separated line per row
+	FormatCSV Format = iota
+	
+	// FormatJSONL writes one JSON object per line
+	FormatJSONL
+)
+
+// ParseFormat parses "csv" or "jsonl"
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	default:
+		return 0, fmt.Errorf("unknown export format %q (want csv or jsonl)", s)
+	}
+}
+
+// Directions of a row
+const (
+	DirectionIn   = "in"
+	DirectionOut  = "out"
+	DirectionNone = "none"
+)
+
+// Statuses of a row
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// DefaultPageSize is the number of transactions requested per GetTransactions call
+const DefaultPageSize = 100
+
+// Row is one value transfer of a transaction. A transaction produces one row for its
+// internal inbound message and one per outbound message; a transaction with neither
+// produces a single row with direction "none". Fees are reported on the first row of
+// each transaction only so that summing the fee columns never double counts.
+type Row struct {
+	Time         time.Time `json:"time"`
+	Lt           string    `json:"lt"`
+	Hash         string    `json:"hash"`
+	Direction    string    `json:"direction"`
+	Counterparty string    `json:"counterparty"`
+	Amount       string    `json:"amount"`
+	Fee          string    `json:"fee"`
+	StorageFee   string    `json:"storage_fee"`
+	GasFee       string    `json:"gas_fee"`
+	FwdFee       string    `json:"fwd_fee"`
+	OtherFee     string    `json:"other_fee"`
+	Comment      string    `json:"comment"`
+	Status       string    `json:"status"`
+}
+
+// csvHeader is the header line of CSV exports
+var csvHeader = []string{
+	"time", "lt", "hash", "direction", "counterparty", "amount",
+	"fee", "storage_fee", "gas_fee", "fwd_fee", "other_fee", "comment", "status",
+}
+
+// record returns the row as CSV fields in csvHeader order
+func (r Row) record() []string {
+	return []string{
+		r.Time.UTC().Format(time.RFC3339), r.Lt, r.Hash, r.Direction, r.Counterparty, r.Amount,
+		r.Fee, r.StorageFee, r.GasFee, r.FwdFee, r.OtherFee, r.Comment, r.Status,
+	}
+}
+
+// Options selects the part of the history to export. Zero values leave a bound open.
+// Time and logical time bounds are inclusive and may be combined.
+type Options struct {
+	Address string
+	
+	FromTime time.Time
+	ToTime   time.Time
+	FromLt   uint64
+	ToLt     uint64
+	
+	Format Format
+	
+	// PageSize is the number of transactions fetched per request, DefaultPageSize if zero
+	PageSize int
+	
+	// ArchiveOnly requests old history from archive nodes
+	ArchiveOnly bool
+}
+
+// Exporter walks the history of an address with a client
+type Exporter struct {
+	Client *toncenterzp.Client
+	
+	// Index finds the newest transaction within ToLt and ToTime so that a walk with
+	// an upper bound starts there instead of at the head of the history. Without
+	// it, or when the provider has no v3 API, such a walk pages down from the head
+	// and skips the transactions above the bounds.
+	Index *v3.Client
+}
+
+// New creates an exporter using the given client and the v3 API next to it
+func New(client *toncenterzp.Client) *Exporter {
+	return &Exporter{Client: client, Index: v3.New(client)}
+}
+
+// Export writes the rows of all transactions matching opts to w, newest first,
+// and returns the number of transactions exported
+func (e *Exporter) Export(w io.Writer, opts Options) (int, error) {
+	if opts.Address == "" {
+		return 0, toncenterzp.NewError(toncenterzp.ErrInvalidParams, "address is required", nil)
+	}
+	
+	write, flush, err := newRowWriter(w, opts.Format)
+	if err != nil {
+		return 0, err
+	}
+	
+	count := 0
+	err = e.Walk(opts, func(tx *toncenterzp.TransactionDetails) error {
+		for _, row := range Rows(tx) {
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		count++
+		return nil
+	}, flush)
+	if err != nil {
+		return count, err
+	}
+	
+	return count, flush()
+}
+
+// Walk calls fn for every transaction matching opts, newest first. pageDone, if not
+// nil, is called after every page, e.g. to flush buffered output.
+func (e *Exporter) Walk(opts Options, fn func(tx *toncenterzp.TransactionDetails) error, pageDone func() error) error {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	
+	req := toncenterzp.GetTransactionsRequest{
+		Address:     opts.Address,
+		Limit:       pageSize,
+		ArchiveOnly: opts.ArchiveOnly,
+	}
+	// to_lt is exclusive
+	if opts.FromLt > 0 {
+		req.ToLt = strconv.FormatUint(opts.FromLt-1, 10)
+	}
+	if opts.ToLt > 0 || !opts.ToTime.IsZero() {
+		lt, hash, ok := e.seek(opts)
+		if !ok {
+			return nil
+		}
+		req.Lt = lt
+		req.Hash = hash
+	}
+	
+	var lastHash string
+	for {
+		resp, err := e.Client.GetTransactions(req)
+		if err != nil {
+			return err
+		}
+		
+		txs := resp.Result.Transactions
+		// Every page after the first starts with the last transaction of the previous one
+		if len(txs) > 0 && lastHash != "" && txs[0].Hash == lastHash {
+			txs = txs[1:]
+		}
+		if len(txs) == 0 {
+			return nil
+		}
+		
+		for i := range txs {
+			tx := &txs[i]
+			lt, err := strconv.ParseUint(tx.Lt, 10, 64)
+			if err != nil {
+				return toncenterzp.NewError(toncenterzp.ErrInvalidResponse, fmt.Sprintf("invalid transaction lt %q", tx.Lt), err)
+			}
+			
+			if (opts.FromLt > 0 && lt < opts.FromLt) || (!opts.FromTime.IsZero() && int64(tx.Now) < opts.FromTime.Unix()) {
+				return nil
+			}
+			if (opts.ToLt > 0 && lt > opts.ToLt) || (!opts.ToTime.IsZero() && int64(tx.Now) > opts.ToTime.Unix()) {
+				continue
+			}
+			
+			if err := fn(tx); err != nil {
+				return err
+			}
+		}
+		
+		if pageDone != nil {
+			if err := pageDone(); err != nil {
+				return err
+			}
+		}
+		
+		// A short page means the beginning of the history was reached
+		last := txs[len(txs)-1]
+		if len(resp.Result.Transactions) < pageSize || (last.Lt == req.Lt && last.Hash == req.Hash) {
+			return nil
+		}
+		req.Lt = last.Lt
+		req.Hash = last.Hash
+		lastHash = last.Hash
+	}
+}
+
+// seek returns the logical time and hash of the newest transaction within the upper
+// bounds of opts, or ok false if there is none. Without a working index it returns
+// empty values, the head of the history.
+func (e *Exporter) seek(opts Options) (lt, hash string, ok bool) {
+	if e.Index == nil {
+		return "", "", true
+	}
+	
+	req := v3.GetTransactionsRequest{
+		Account: []string{opts.Address},
+		Page:    v3.Page{Limit: 1, Sort: "desc"},
+	}
+	if opts.ToLt > 0 {
+		req.EndLt = strconv.FormatUint(opts.ToLt, 10)
+	}
+	if !opts.ToTime.IsZero() {
+		req.EndUtime = opts.ToTime.Unix()
+	}
+	resp, err := e.Index.GetTransactions(req)
+	if err != nil {
+		// v2-only providers answer with an error or a response of another shape
+		return "", "", true
+	}
+	if len(resp.Transactions) == 0 {
+		return "", "", false
+	}
+	tx := resp.Transactions[0]
+	return tx.Lt, tx.Hash, true
+}
+
+// Rows normalizes a transaction into rows
+func Rows(tx *toncenterzp.TransactionDetails) []Row {
+	base := Row{
+		Time:   time.Unix(int64(tx.Now), 0).UTC(),
+		Lt:     tx.Lt,
+		Hash:   tx.Hash,
+		Status: status(tx),
+	}
+	
+	var rows []Row
+	if tx.InMsg.Source != "" {
+		row := base
+		row.Direction = DirectionIn
+		row.Counterparty = tx.InMsg.Source
+		row.Amount = tx.InMsg.Value
+		row.Comment = comment(tx.InMsg.MsgData.Text)
+		rows = append(rows, row)
+	}
+	for _, msg := range tx.OutMsgs {
+		row := base
+		row.Direction = DirectionOut
+		row.Counterparty = msg.Destination
+		row.Amount = msg.Value
+		row.Comment = comment(msg.MsgData.Text)
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		row := base
+		row.Direction = DirectionNone
+		row.Amount = "0"
+		rows = append(rows, row)
+	}
+	
+	rows[0].Fee = tx.Fee
+	rows[0].StorageFee = tx.StorageFee
+	rows[0].GasFee = tx.GasFee
+	rows[0].FwdFee = tx.FwdFee
+	rows[0].OtherFee = tx.OtherFee
+	
+	return rows
+}
+
+// status summarizes the compute and action phases of a transaction. Transactions
+// whose phases were not reported by the API are considered successful.
+func status(tx *toncenterzp.TransactionDetails) string {
+	if tx.ComputePhase.SkippedReason != "" {
+		return StatusSkipped
+	}
+	
+	computed := tx.ComputePhase.Success || tx.ComputePhase.GasUsed != "" || tx.ComputePhase.VmSteps > 0
+	if computed && (!tx.ComputePhase.Success || (tx.ActionPhase.TotalActions > 0 && !tx.ActionPhase.Success)) {
+		return StatusFailed
+	}
+	
+	return StatusOK
+}
+
+// comment returns the text of a message. toncenter returns text comments base64
+// encoded; values that do not decode to valid UTF-8 are returned unchanged.
+func comment(text string) string {
+	if text == "" {
+		return ""
+	}
+	if raw, err := base64.StdEncoding.DecodeString(text); err == nil && utf8.Valid(raw) {
+		return string(raw)
+	}
+	return text
+}
+
+// newRowWriter returns functions that write a row and flush buffered output
+func newRowWriter(w io.Writer, format Format) (func(Row) error, func() error, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, nil, err
+		}
+		write := func(row Row) error {
+			return cw.Write(row.record())
+		}
+		flush := func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+		return write, flush, nil
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		write := func(row Row) error {
+			return enc.Encode(row)
+		}
+		flush := func() error {
+			return nil
+		}
+		return write, flush, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown export format %d", format)
+	}
+}
@@ -0,0 +1,170 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp"
+)
+
+// history is a fake toncenter with one account whose transactions have logical
+// times 100, 90, ... 10 and the logical time as their time. With v2Only set it
+// answers the v3 API with 404 like a provider that only serves v2.
+type history struct {
+	lts      []uint64
+	requests []toncenterzp.GetTransactionsRequest
+	v2Only   bool
+}
+
+func newHistory(t *testing.T) (*history, *Exporter) {
+	t.Helper()
+	h := &history{}
+	for lt := uint64(100); lt >= 10; lt -= 10 {
+		h.lts = append(h.lts, lt)
+	}
+	
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/getTransactions", h.getTransactions)
+	mux.HandleFunc("/api/v3/transactions", h.transactions)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	
+	client := toncenterzp.NewClientWithOptions("", srv.URL+"/api/v2", time.Second)
+	return h, New(client)
+}
+
+func txHash(lt uint64) string {
+	return fmt.Sprintf("hash%d", lt)
+}
+
+func (h *history) tx(lt uint64) toncenterzp.TransactionDetails {
+	return toncenterzp.TransactionDetails{Lt: strconv.FormatUint(lt, 10), Hash: txHash(lt), Now: int(lt)}
+}
+
+// getTransactions serves pages from lt/hash inclusive, or from the head, down to
+// to_lt exclusive
+func (h *history) getTransactions(w http.ResponseWriter, r *http.Request) {
+	var req toncenterzp.GetTransactionsRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	h.requests = append(h.requests, req)
+	
+	toLt, _ := strconv.ParseUint(req.ToLt, 10, 64)
+	started := req.Lt == ""
+	var resp toncenterzp.GetTransactionsResponse
+	resp.OK = true
+	for _, lt := range h.lts {
+		if !started && strconv.FormatUint(lt, 10) == req.Lt && txHash(lt) == req.Hash {
+			started = true
+		}
+		if !started || lt <= toLt || len(resp.Result.Transactions) == req.Limit {
+			continue
+		}
+		resp.Result.Transactions = append(resp.Result.Transactions, h.tx(lt))
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// transactions serves the newest transaction within end_lt and end_utime inclusive
+func (h *history) transactions(w http.ResponseWriter, r *http.Request) {
+	if h.v2Only {
+		http.NotFound(w, r)
+		return
+	}
+	q := r.URL.Query()
+	endLt, _ := strconv.ParseUint(q.Get("end_lt"), 10, 64)
+	endUtime, _ := strconv.ParseUint(q.Get("end_utime"), 10, 64)
+	
+	var txs []map[string]interface{}
+	for _, lt := range h.lts {
+		if (endLt == 0 || lt <= endLt) && (endUtime == 0 || lt <= endUtime) {
+			txs = append(txs, map[string]interface{}{"lt": strconv.FormatUint(lt, 10), "hash": txHash(lt), "now": lt})
+			break
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"transactions": txs})
+}
+
+func walk(t *testing.T, e *Exporter, opts Options) []string {
+	t.Helper()
+	opts.Address = "EQ..."
+	opts.PageSize = 3
+	var lts []string
+	err := e.Walk(opts, func(tx *toncenterzp.TransactionDetails) error {
+		lts = append(lts, tx.Lt)
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lts
+}
+
+func TestWalkFromLtInclusive(t *testing.T) {
+	_, e := newHistory(t)
+	got := fmt.Sprint(walk(t, e, Options{FromLt: 50}))
+	if want := "[100 90 80 70 60 50]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestWalkStartsAtToLt(t *testing.T) {
+	h, e := newHistory(t)
+	got := fmt.Sprint(walk(t, e, Options{FromLt: 30, ToLt: 75}))
+	if want := "[70 60 50 40 30]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if first := h.requests[0]; first.Lt != "70" || first.Hash != txHash(70) {
+		t.Errorf("first page requested from lt %q hash %q, want the transaction at 70", first.Lt, first.Hash)
+	}
+}
+
+func TestWalkStartsAtToTime(t *testing.T) {
+	h, e := newHistory(t)
+	got := fmt.Sprint(walk(t, e, Options{ToTime: time.Unix(50, 0), FromTime: time.Unix(40, 0)}))
+	if want := "[50 40]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if first := h.requests[0]; first.Lt != "50" {
+		t.Errorf("first page requested from lt %q, want 50", first.Lt)
+	}
+}
+
+func TestWalkEmptyRange(t *testing.T) {
+	h, e := newHistory(t)
+	if got := walk(t, e, Options{ToLt: 5}); len(got) != 0 {
+		t.Errorf("got %v, want nothing", got)
+	}
+	if len(h.requests) != 0 {
+		t.Errorf("%d getTransactions requests for an empty range", len(h.requests))
+	}
+}
+
+func TestWalkWithoutIndex(t *testing.T) {
+	for _, v2Only := range []bool{false, true} {
+		h, e := newHistory(t)
+		if v2Only {
+			h.v2Only = true
+		} else {
+			e.Index = nil
+		}
+		
+		// Without the index the walk pages from the head and skips the transactions above ToLt
+		got := fmt.Sprint(walk(t, e, Options{FromLt: 30, ToLt: 75}))
+		if want := "[70 60 50 40 30]"; got != want {
+			t.Errorf("v2 only %v: got %s, want %s", v2Only, got, want)
+		}
+		if first := h.requests[0]; first.Lt != "" {
+			t.Errorf("v2 only %v: first page requested from lt %q, want the head", v2Only, first.Lt)
+		}
+		
+		got = fmt.Sprint(walk(t, e, Options{ToTime: time.Unix(50, 0), FromTime: time.Unix(40, 0)}))
+		if want := "[50 40]"; got != want {
+			t.Errorf("v2 only %v: got %s, want %s", v2Only, got, want)
+		}
+	}
+}
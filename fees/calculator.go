@@ -0,0 +1,83 @@
+package fees
+
+import (
+	"math/big"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp/config"
+)
+
+// Calculator computes the fees of transactions of one workchain
+type Calculator struct {
+	Masterchain bool
+	Gas         *config.GasPrices
+	Forward     *config.MsgForwardPrices
+	Storage     []config.StoragePrices
+}
+
+// NewCalculator reads the prices of the masterchain or the basechain from a config
+func NewCalculator(cfg *config.Config, masterchain bool) (*Calculator, error) {
+	c := &Calculator{Masterchain: masterchain}
+	var err error
+	if c.Gas, err = cfg.GasPrices(masterchain); err != nil {
+		return nil, err
+	}
+	if c.Forward, err = cfg.MsgForwardPrices(masterchain); err != nil {
+		return nil, err
+	}
+	if c.Storage, err = cfg.StoragePrices(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Usage describes the resources a transaction uses
+type Usage struct {
+	GasUsed uint64
+	
+	// ImportMsg is the size of an external inbound message; internal inbound messages
+	// have their forward fee paid by the sender
+	ImportMsg *Size
+	
+	// OutMsgs are the sizes of the messages sent, see MessageSize
+	OutMsgs []Size
+	
+	// Account is the size of the account state, charged from LastPaid, the storage
+	// last_paid time of the account; nothing is charged if LastPaid is zero
+	Account  Size
+	LastPaid time.Time
+}
+
+// Fees is a fee breakdown in nanotons
+type Fees struct {
+	Gas     *big.Int
+	Import  *big.Int
+	Forward *big.Int
+	Storage *big.Int
+	Total   *big.Int
+}
+
+// Estimate computes the fees of a transaction at time now, the time of its block.
+// The time only affects the storage fee.
+func (c *Calculator) Estimate(u Usage, now time.Time) *Fees {
+	f := &Fees{
+		Gas:     Gas(c.Gas, u.GasUsed),
+		Import:  new(big.Int),
+		Forward: new(big.Int),
+		Storage: new(big.Int),
+	}
+	if u.ImportMsg != nil {
+		f.Import = Forward(c.Forward, *u.ImportMsg)
+	}
+	for _, m := range u.OutMsgs {
+		f.Forward.Add(f.Forward, Forward(c.Forward, m))
+	}
+	if !u.LastPaid.IsZero() {
+		f.Storage = Storage(c.Storage, c.Masterchain, u.Account, uint32(u.LastPaid.Unix()), uint32(now.Unix()))
+	}
+	
+	f.Total = new(big.Int).Add(f.Gas, f.Import)
+	f.Total.Add(f.Total, f.Forward)
+	f.Total.Add(f.Total, f.Storage)
+	return f
+}
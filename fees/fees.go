@@ -0,0 +1,123 @@
+// Package fees computes transaction fees offline from blockchain config params.
+//
+// The formulas and rounding follow the node: prices are fixed point numbers with 16
+// fractional bits and every fee is rounded up to a whole nanoton. Config params are
+// read with package config, e.g. from Client.GetConfigAll.
+package fees
+
+import (
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/config"
+)
+
+// Size is the storage size of a cell tree
+type Size struct {
+	Cells uint64
+	Bits  uint64
+}
+
+// CellSize returns the size of the tree rooted at root, counting every distinct cell once
+func CellSize(root *cell.Cell) Size {
+	var s Size
+	addCells(root, &s, make(map[string]bool))
+	return s
+}
+
+// MessageSize returns the size of a message the way forward fees count it: the cells
+// of the state init and body, without the root cell of the message
+func MessageSize(msg *cell.Cell) Size {
+	var s Size
+	seen := make(map[string]bool)
+	for i := 0; i < msg.RefsNum(); i++ {
+		addCells(msg.Ref(i), &s, seen)
+	}
+	return s
+}
+
+// addCells adds the cells of a tree that were not seen yet to s
+func addCells(c *cell.Cell, s *Size, seen map[string]bool) {
+	h := string(c.Hash())
+	if seen[h] {
+		return
+	}
+	seen[h] = true
+	s.Cells++
+	s.Bits += uint64(c.BitsSize())
+	for i := 0; i < c.RefsNum(); i++ {
+		addCells(c.Ref(i), s, seen)
+	}
+}
+
+// Gas returns the fee for gasUsed units of gas
+func Gas(p *config.GasPrices, gasUsed uint64) *big.Int {
+	fee := new(big.Int).SetUint64(p.FlatGasPrice)
+	if gasUsed <= p.FlatGasLimit {
+		return fee
+	}
+	v := new(big.Int).Mul(bigUint(p.GasPrice), bigUint(gasUsed-p.FlatGasLimit))
+	return fee.Add(fee, shiftCeil(v))
+}
+
+// Forward returns the forward fee of a message of the given size (see MessageSize).
+// The same formula gives the import fee of external inbound messages.
+func Forward(p *config.MsgForwardPrices, size Size) *big.Int {
+	v := new(big.Int).Mul(bigUint(p.BitPrice), bigUint(size.Bits))
+	v.Add(v, new(big.Int).Mul(bigUint(p.CellPrice), bigUint(size.Cells)))
+	v = shiftCeil(v)
+	return v.Add(v, bigUint(p.LumpPrice))
+}
+
+// ActionFee returns the part of a forward fee the validators collect when the message
+// is sent; the rest is carried by the message and collected on the next hop
+func ActionFee(p *config.MsgForwardPrices, fwdFee *big.Int) *big.Int {
+	v := new(big.Int).Mul(fwdFee, bigUint(uint64(p.FirstFrac)))
+	return v.Rsh(v, 16)
+}
+
+// Storage returns the storage fee of an account of the given size (see CellSize) for
+// the time from lastPaid to now (unix times), using the price periods of param 18 in
+// effect. Like the node, it charges nothing when lastPaid is zero.
+func Storage(prices []config.StoragePrices, masterchain bool, size Size, lastPaid, now uint32) *big.Int {
+	total := new(big.Int)
+	if len(prices) == 0 || lastPaid == 0 || now <= lastPaid {
+		return total
+	}
+	
+	upto := lastPaid
+	if upto < prices[0].UtimeSince {
+		upto = prices[0].UtimeSince
+	}
+	for i := 0; i < len(prices) && upto < now; i++ {
+		until := now
+		if i < len(prices)-1 && prices[i+1].UtimeSince < now {
+			until = prices[i+1].UtimeSince
+		}
+		if upto >= until {
+			continue
+		}
+		
+		bitPrice, cellPrice := prices[i].BitPricePS, prices[i].CellPricePS
+		if masterchain {
+			bitPrice, cellPrice = prices[i].MCBitPricePS, prices[i].MCCellPricePS
+		}
+		v := new(big.Int).Mul(bigUint(bitPrice), bigUint(size.Bits))
+		v.Add(v, new(big.Int).Mul(bigUint(cellPrice), bigUint(size.Cells)))
+		v.Mul(v, bigUint(uint64(until-upto)))
+		total.Add(total, v)
+		upto = until
+	}
+	return shiftCeil(total)
+}
+
+// shiftCeil divides v by 2^16, rounding up
+func shiftCeil(v *big.Int) *big.Int {
+	v.Add(v, big.NewInt(0xffff))
+	return v.Rsh(v, 16)
+}
+
+// bigUint converts a uint64 to a big.Int
+func bigUint(v uint64) *big.Int {
+	return new(big.Int).SetUint64(v)
+}
@@ -0,0 +1,178 @@
+package fees
+
+import (
+	"math/big"
+	"testing"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/config"
+)
+
+// Prices of params 18, 20, 21, 24 and 25 on mainnet
+var (
+	basechainGas = &config.GasPrices{
+		FlatGasLimit: 100, FlatGasPrice: 40000,
+		GasPrice: 26214400, GasLimit: 1000000, SpecialGasLimit: 1000000, GasCredit: 10000,
+		BlockGasLimit: 10000000, FreezeDueLimit: 100000000, DeleteDueLimit: 1000000000,
+	}
+	masterchainGas = &config.GasPrices{
+		FlatGasLimit: 100, FlatGasPrice: 1000000,
+		GasPrice: 655360000, GasLimit: 1000000, SpecialGasLimit: 70000000, GasCredit: 10000,
+		BlockGasLimit: 2500000, FreezeDueLimit: 100000000, DeleteDueLimit: 1000000000,
+	}
+	basechainForward = &config.MsgForwardPrices{
+		LumpPrice: 400000, BitPrice: 26214400, CellPrice: 2621440000,
+		IHRPriceFactor: 98304, FirstFrac: 21845, NextFrac: 21845,
+	}
+	storagePrices = []config.StoragePrices{
+		{UtimeSince: 0, BitPricePS: 1, CellPricePS: 500, MCBitPricePS: 1000, MCCellPricePS: 500000},
+	}
+)
+
+func TestGas(t *testing.T) {
+	tests := []struct {
+		prices  *config.GasPrices
+		gasUsed uint64
+		want    int64
+	}{
+		{basechainGas, 0, 40000},
+		{basechainGas, 100, 40000},
+		{basechainGas, 101, 40400},
+		// Wallet v4 transfer
+		{basechainGas, 3308, 1323200},
+		{masterchainGas, 100, 1000000},
+		{masterchainGas, 2994, 29940000},
+	}
+	for _, tt := range tests {
+		if got := Gas(tt.prices, tt.gasUsed); got.Int64() != tt.want {
+			t.Errorf("gas %d: fee %s, want %d", tt.gasUsed, got, tt.want)
+		}
+	}
+}
+
+// message builds an internal message to a fixed address with the given body
+func message(t *testing.T, body *cell.Cell) *cell.Cell {
+	t.Helper()
+	dest := address.MustParse("0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8")
+	b := cell.BeginCell().
+		StoreUInt(0, 1). // int_msg_info
+		StoreBit(true).  // ihr_disabled
+		StoreBit(true).  // bounce
+		StoreBit(false). // bounced
+		StoreAddress(nil).
+		StoreAddress(dest).
+		StoreCoins(big.NewInt(1000000000)).
+		StoreBit(false). // other currencies
+		StoreCoins(big.NewInt(0)).
+		StoreCoins(big.NewInt(0)).
+		StoreUInt(0, 64).
+		StoreUInt(0, 32).
+		StoreBit(false) // init
+	if body == nil {
+		b.StoreBit(false)
+	} else {
+		b.StoreBit(true).StoreRef(body)
+	}
+	c, err := b.EndCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestForward(t *testing.T) {
+	// A transfer without a body pays the lump price only, of which the validators
+	// collect 133331 and the message carries fwd_fee 266669
+	msg := message(t, nil)
+	fwd := Forward(basechainForward, MessageSize(msg))
+	if fwd.Int64() != 400000 {
+		t.Fatalf("forward fee %s, want 400000", fwd)
+	}
+	action := ActionFee(basechainForward, fwd)
+	if action.Int64() != 133331 {
+		t.Errorf("action fee %s, want 133331", action)
+	}
+	if rest := new(big.Int).Sub(fwd, action); rest.Int64() != 266669 {
+		t.Errorf("message fwd_fee %s, want 266669", rest)
+	}
+	
+	// A text comment "Hello" in a body cell of 72 bits
+	body, err := cell.BeginCell().StoreUInt(0, 32).StoreBytes([]byte("Hello")).EndCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg = message(t, body)
+	if size := MessageSize(msg); size != (Size{Cells: 1, Bits: 72}) {
+		t.Fatalf("message size %+v", size)
+	}
+	fwd = Forward(basechainForward, MessageSize(msg))
+	if fwd.Int64() != 468800 {
+		t.Errorf("forward fee %s, want 468800", fwd)
+	}
+	if action := ActionFee(basechainForward, fwd); action.Int64() != 156264 {
+		t.Errorf("action fee %s, want 156264", action)
+	}
+}
+
+func TestMessageSizeCountsSharedCellsOnce(t *testing.T) {
+	leaf, _ := cell.BeginCell().StoreUInt(1, 8).EndCell()
+	body, _ := cell.BeginCell().StoreRef(leaf).StoreRef(leaf).EndCell()
+	if size := MessageSize(message(t, body)); size != (Size{Cells: 2, Bits: 8}) {
+		t.Errorf("size %+v, want 2 cells and 8 bits", size)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	size := Size{Cells: 3, Bits: 1000}
+	day := uint32(86400)
+	
+	// (1000*1 + 3*500) * 86400 / 65536, rounded up
+	if got := Storage(storagePrices, false, size, 1000, 1000+day); got.Int64() != 3296 {
+		t.Errorf("basechain fee %s, want 3296", got)
+	}
+	// (1000*1000 + 3*500000) * 86400 / 65536
+	if got := Storage(storagePrices, true, size, 1000, 1000+day); got.Int64() != 3295899 {
+		t.Errorf("masterchain fee %s, want 3295899", got)
+	}
+	if got := Storage(storagePrices, false, size, 0, 1000+day); got.Sign() != 0 {
+		t.Errorf("fee %s without last_paid, want 0", got)
+	}
+	
+	// The price doubles halfway; the sum over both periods is rounded once
+	prices := append(storagePrices, config.StoragePrices{UtimeSince: 1000 + day/2, BitPricePS: 2, CellPricePS: 1000})
+	if got := Storage(prices, false, size, 1000, 1000+day); got.Int64() != 4944 {
+		t.Errorf("fee over two price periods %s, want 4944", got)
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	c := &Calculator{Gas: basechainGas, Forward: basechainForward, Storage: storagePrices}
+	now := time.Unix(1700000000, 0)
+	u := Usage{
+		GasUsed:   3308,
+		ImportMsg: &Size{},
+		OutMsgs:   []Size{{}, {Cells: 1, Bits: 72}},
+		Account:   Size{Cells: 3, Bits: 1000},
+		LastPaid:  now.Add(-24 * time.Hour),
+	}
+	
+	f := c.Estimate(u, now)
+	want := map[string]int64{"gas": 1323200, "import": 400000, "forward": 868800, "storage": 3296, "total": 2595296}
+	got := map[string]int64{"gas": f.Gas.Int64(), "import": f.Import.Int64(), "forward": f.Forward.Int64(), "storage": f.Storage.Int64(), "total": f.Total.Int64()}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s fee %d, want %d", k, got[k], v)
+		}
+	}
+	
+	// The estimate depends on the given time only
+	if again := c.Estimate(u, now); again.Total.Cmp(f.Total) != 0 {
+		t.Errorf("second estimate %s, want %s", again.Total, f.Total)
+	}
+	u.LastPaid = time.Time{}
+	if f := c.Estimate(u, now); f.Storage.Sign() != 0 {
+		t.Errorf("storage fee %s without last_paid, want 0", f.Storage)
+	}
+}
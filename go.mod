@@ -0,0 +1,3 @@
+module github.com/zhaopeng331/toncenterzp
+
+go 1.22
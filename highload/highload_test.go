@@ -0,0 +1,275 @@
+package highload
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/contract"
+	"github.com/zhaopeng331/toncenterzp/message"
+)
+
+func testWallet(t *testing.T) *Wallet {
+	t.Helper()
+	w, err := New(ed25519.NewKeyFromSeed(make([]byte, 32)), DefaultSubwalletID, 3600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// transfers returns n internal messages to a fixed address
+func transfers(t *testing.T, n int) []message.Send {
+	t.Helper()
+	dest := address.New(0, make([]byte, 32))
+	var msgs []message.Send
+	for i := 0; i < n; i++ {
+		body, err := message.Comment("payout")
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := (&message.Internal{Destination: dest, Amount: big.NewInt(int64(i + 1)), Body: body}).ToCell()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, message.Send{Mode: message.ModePayFeesSeparately | message.ModeIgnoreErrors, Message: m})
+	}
+	return msgs
+}
+
+// signedInner checks the external message of tr is addressed to the wallet and
+// signed by it, and returns the signed cell and whether it carries the state init
+func signedInner(t *testing.T, w *Wallet, tr *Transfer) (*cell.Slice, bool) {
+	t.Helper()
+	self, err := w.Address()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := tr.Message.BeginParse()
+	if s.LoadUInt(2) != 2 { // ext_in_msg_info
+		t.Fatal("not an inbound external message")
+	}
+	s.LoadAddress()
+	if dest := s.LoadAddress(); dest == nil || dest.String() != self.String() {
+		t.Fatalf("message to %v, want %s", dest, self)
+	}
+	s.LoadCoins()
+	hasInit := s.LoadBool()
+	if hasInit {
+		if !s.LoadBool() || s.LoadRef() == nil {
+			t.Fatal("state init not in a reference")
+		}
+	}
+	if !s.LoadBool() {
+		t.Fatal("body not in a reference")
+	}
+	body := s.LoadRefSlice()
+	sig := body.LoadBytes(64)
+	inner := body.LoadRef()
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(w.PublicKey(), inner.Hash(), sig) {
+		t.Fatal("invalid signature")
+	}
+	return inner.BeginParse(), hasInit
+}
+
+func TestCode(t *testing.T) {
+	if got := hex.EncodeToString(Code.Hash()); got != "11acad7955844090f283bf238bc1449871f783e7cc0979408d3f4859483e8525" {
+		t.Errorf("code hash %s", got)
+	}
+}
+
+func TestStateInit(t *testing.T) {
+	init, err := testWallet(t).StateInit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := init.BeginParse()
+	s.LoadUInt(2) // split_depth, special
+	code := s.LoadMaybeRef()
+	data := s.LoadMaybeRef()
+	st, err := contract.Decode(code, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Type != contract.HighloadV3 {
+		t.Fatalf("decoded as %v", st.Type)
+	}
+	d := st.Data.(*contract.HighloadV3Data)
+	if d.SubwalletID != DefaultSubwalletID || d.Timeout != 3600 {
+		t.Errorf("subwallet %#x, timeout %d", d.SubwalletID, d.Timeout)
+	}
+}
+
+func TestSignSingle(t *testing.T) {
+	w := testWallet(t)
+	msgs := transfers(t, 1)
+	q, _ := NewQueryID(5, 7)
+	now := time.Unix(1700000000, 0)
+	tr, err := w.Sign(Request{QueryID: q, CreatedAt: now, Messages: msgs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tr.ValidUntil.Equal(now.Add(time.Hour)) {
+		t.Errorf("valid until %v, want %v", tr.ValidUntil, now.Add(time.Hour))
+	}
+	
+	s, hasInit := signedInner(t, w, tr)
+	if hasInit {
+		t.Error("state init sent without Deploy")
+	}
+	if s.LoadUInt(32) != uint64(DefaultSubwalletID) {
+		t.Error("wrong subwallet id")
+	}
+	msg := s.LoadRef()
+	if msg == nil || !bytes.Equal(msg.Hash(), msgs[0].Message.Hash()) {
+		t.Error("message not sent as is")
+	}
+	if s.LoadUInt(8) != uint64(msgs[0].Mode) || s.LoadUInt(13) != 5 || s.LoadUInt(10) != 7 ||
+		s.LoadUInt(64) != 1700000000 || s.LoadUInt(22) != 3600 {
+		t.Error("wrong mode, query id, created_at or timeout")
+	}
+	if err := s.Err(); err != nil || s.BitsLeft() != 0 || s.RefsLeft() != 0 {
+		t.Errorf("data left in the signed cell: %v", err)
+	}
+}
+
+func TestSignBatch(t *testing.T) {
+	w := testWallet(t)
+	self, _ := w.Address()
+	q, _ := NewQueryID(1, 2)
+	tr, err := w.Sign(Request{QueryID: q, CreatedAt: time.Unix(1700000000, 0), Messages: transfers(t, 3), Deploy: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	s, hasInit := signedInner(t, w, tr)
+	if !hasInit {
+		t.Error("state init not sent with Deploy")
+	}
+	s.LoadUInt(32)
+	batch := s.LoadRefSlice()
+	if mode := s.LoadUInt(8); mode != uint64(message.ModePayFeesSeparately|message.ModeIgnoreErrors) {
+		t.Errorf("batch sent with mode %d", mode)
+	}
+	
+	// The batch is an internal message to the wallet itself
+	batch.LoadUInt(4) // int_msg_info, ihr_disabled, bounce, bounced
+	batch.LoadAddress()
+	if dest := batch.LoadAddress(); dest == nil || dest.String() != self.String() {
+		t.Errorf("batch sent to %v, want the wallet", dest)
+	}
+	if err := batch.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignInvalid(t *testing.T) {
+	w := testWallet(t)
+	now := time.Unix(1700000000, 0)
+	for name, r := range map[string]Request{
+		"no messages":       {CreatedAt: now},
+		"too many messages": {CreatedAt: now, Messages: transfers(t, MaxMessages+1)},
+		"no created_at":     {Messages: transfers(t, 1)},
+	} {
+		if _, err := w.Sign(r); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("%s: error %v, want ErrInvalidRequest", name, err)
+		}
+	}
+}
+
+func TestQueryID(t *testing.T) {
+	q, err := NewQueryID(5, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint32(q) != 5<<10|7 || q.Shift() != 5 || q.BitNumber() != 7 || q.String() != "5:7" {
+		t.Errorf("query id %d (%s)", uint32(q), q)
+	}
+	if _, err := NewQueryID(MaxShift+1, 0); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("shift out of range: error %v", err)
+	}
+	if _, err := NewQueryID(0, MaxBitNumber+1); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("bit number out of range: error %v", err)
+	}
+	
+	q, _ = NewQueryID(0, MaxBitNumber)
+	if next, ok := q.Next(); !ok || next.String() != "1:0" {
+		t.Errorf("after %s: %s, %v", q, next, ok)
+	}
+	last, _ := NewQueryID(MaxShift, MaxBitNumber)
+	if _, ok := last.Next(); ok {
+		t.Errorf("query id after the last one %s", last)
+	}
+}
+
+func TestQueryIDsPersist(t *testing.T) {
+	store := &FileQueryIDStore{Path: filepath.Join(t.TempDir(), "query_ids.json")}
+	a, err := NewQueryIDs(store, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0:0 to 0:1022, then 1:0 and 1:1
+	want := QueryID(0)
+	for i := 0; i < MaxBitNumber+3; i++ {
+		if got, err := a.Next(); err != nil || got != want {
+			t.Fatalf("got %s, %v; want %s", got, err, want)
+		}
+		want, _ = want.Next()
+	}
+	
+	// A restart continues after the last query id handed out
+	b, err := NewQueryIDs(store, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := b.Next(); err != nil || got.String() != "1:2" {
+		t.Errorf("after restart got %s, %v; want 1:2", got, err)
+	}
+}
+
+func TestQueryIDsExhausted(t *testing.T) {
+	store := &FileQueryIDStore{Path: filepath.Join(t.TempDir(), "query_ids.json")}
+	last, _ := NewQueryID(MaxShift, MaxBitNumber)
+	if err := store.Save(QueryIDState{Next: last}); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewQueryIDs(store, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	a.now = func() time.Time { return now }
+	if got, err := a.Next(); err != nil || got != last {
+		t.Fatalf("got %s, %v; want the last query id", got, err)
+	}
+	if _, err := a.Next(); !errors.Is(err, ErrQueryIDsExhausted) {
+		t.Fatalf("error %v, want ErrQueryIDsExhausted", err)
+	}
+	
+	// The state survives a restart, and query ids are reused only once the wallet
+	// forgot them
+	b, err := NewQueryIDs(store, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.now = func() time.Time { return now.Add(3*time.Minute - time.Second) }
+	if _, err := b.Next(); !errors.Is(err, ErrQueryIDsExhausted) {
+		t.Fatalf("before 3 timeouts: error %v, want ErrQueryIDsExhausted", err)
+	}
+	b.now = func() time.Time { return now.Add(3 * time.Minute) }
+	for want := QueryID(0); want < 2; want++ {
+		if got, err := b.Next(); err != nil || got != want {
+			t.Fatalf("got %s, %v; want %s", got, err, want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package highload
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxShift is the largest shift of a query id
+	MaxShift = 1<<13 - 1
+	
+	// MaxBitNumber is the largest bit number of a query id
+	MaxBitNumber = 1022
+)
+
+// ErrQueryIDsExhausted is returned by QueryIDs.Next when every query id was handed
+// out and the wallet may still remember some of them
+var ErrQueryIDsExhausted = errors.New("highload: query ids exhausted")
+
+// QueryID identifies a request to a highload wallet: shift:uint13 bit_number:uint10.
+// The wallet remembers the query ids it processed, which makes a request
+// impossible to replay.
+type QueryID uint32
+
+// NewQueryID returns the query id with a shift and bit number
+func NewQueryID(shift, bitNumber uint32) (QueryID, error) {
+	if shift > MaxShift || bitNumber > MaxBitNumber {
+		return 0, fmt.Errorf("%w: query id %d:%d out of range", ErrInvalidRequest, shift, bitNumber)
+	}
+	return QueryID(shift<<10 | bitNumber), nil
+}
+
+// Shift returns the shift of the query id
+func (q QueryID) Shift() uint32 {
+	return uint32(q) >> 10
+}
+
+// BitNumber returns the bit number of the query id
+func (q QueryID) BitNumber() uint32 {
+	return uint32(q) & 1023
+}
+
+// Next returns the query id following q; ok is false if q is the last one
+func (q QueryID) Next() (next QueryID, ok bool) {
+	switch {
+	case q.BitNumber() < MaxBitNumber:
+		return q + 1, true
+	case q.Shift() < MaxShift:
+		return QueryID((q.Shift() + 1) << 10), true
+	}
+	return 0, false
+}
+
+// String formats the query id as shift:bit_number
+func (q QueryID) String() string {
+	return fmt.Sprintf("%d:%d", q.Shift(), q.BitNumber())
+}
+
+// QueryIDState is the persisted state of QueryIDs
+type QueryIDState struct {
+	Next QueryID `json:"next"`
+	
+	// ExhaustedAt is when the last query id was handed out; zero while some are left
+	ExhaustedAt time.Time `json:"exhausted_at"`
+}
+
+// QueryIDStore persists the state of QueryIDs
+type QueryIDStore interface {
+	// Load returns the saved state, the zero state if none was saved
+	Load() (QueryIDState, error)
+	
+	// Save replaces the saved state
+	Save(st QueryIDState) error
+}
+
+// QueryIDs hands out the query ids of a wallet in order and saves its state
+// before returning each of them, so that a restart never reuses a query id.
+// Once all of them are used it starts over, but only after the wallet forgot
+// them: a request stays valid for the wallet timeout and the wallet remembers
+// its query id for up to twice the timeout after processing it.
+type QueryIDs struct {
+	mu      sync.Mutex
+	store   QueryIDStore
+	timeout time.Duration
+	state   QueryIDState
+	
+	now func() time.Time
+}
+
+// NewQueryIDs loads the state of the query ids of a wallet with the given timeout
+func NewQueryIDs(store QueryIDStore, timeout time.Duration) (*QueryIDs, error) {
+	st, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &QueryIDs{store: store, timeout: timeout, state: st, now: time.Now}, nil
+}
+
+// Next returns an unused query id
+func (a *QueryIDs) Next() (QueryID, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	
+	st := a.state
+	if !st.ExhaustedAt.IsZero() {
+		if a.now().Before(st.ExhaustedAt.Add(3 * a.timeout)) {
+			return 0, ErrQueryIDsExhausted
+		}
+		st = QueryIDState{}
+	}
+	
+	q := st.Next
+	next, ok := q.Next()
+	if ok {
+		st.Next = next
+	} else {
+		st = QueryIDState{ExhaustedAt: a.now()}
+	}
+	if err := a.store.Save(st); err != nil {
+		return 0, err
+	}
+	a.state = st
+	return q, nil
+}
+
+// MemoryQueryIDStore is a QueryIDStore that keeps the state in memory
+type MemoryQueryIDStore struct {
+	mu    sync.Mutex
+	state QueryIDState
+}
+
+// Load returns the saved state
+func (s *MemoryQueryIDStore) Load() (QueryIDState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, nil
+}
+
+// Save replaces the saved state
+func (s *MemoryQueryIDStore) Save(st QueryIDState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = st
+	return nil
+}
+
+// FileQueryIDStore is a QueryIDStore that keeps the state in a JSON file
+type FileQueryIDStore struct {
+	Path string
+}
+
+// Load reads the state from the file
+func (s *FileQueryIDStore) Load() (QueryIDState, error) {
+	var st QueryIDState
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("error reading query ids: %w", err)
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("error parsing query ids: %w", err)
+	}
+	return st, nil
+}
+
+// Save atomically rewrites the file
+func (s *FileQueryIDStore) Save(st QueryIDState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("error writing query ids: %w", err)
+	}
+	return os.Rename(tmp, s.Path)
+}
@@ -0,0 +1,282 @@
+// Package highload implements highload wallet v3 for mass payouts.
+//
+// Unlike regular wallets, a highload wallet does not order its requests by seqno:
+// every request carries a query id that the wallet remembers for its timeout, so
+// any number of requests can be in flight at once. A request sends one message or,
+// through an internal message to the wallet itself, a batch of up to MaxMessages.
+// QueryIDs hands out query ids and persists them so that they are never reused
+// while the wallet still remembers them.
+package highload
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/message"
+)
+
+// codeHex is the highload wallet v3 code
+const codeHex = "b5ee9c7241021001000228000114ff00f4a413f4bcf2c80b01020120020d02014803040078d020d74bc00101c060b0915be101d0d3030171b0915be0fa4030f828c705b39130e0d31f018210ae42e5a4ba9d8040d721d74cf82a01ed55fb04e030020120050a02027306070011adce76a2686b85ffc00201200809001aabb6ed44d0810122d721d70b3f0018aa3bed44d08307d721d70b1f0201200b0c001bb9a6eed44d0810162d721d70b15800e5b8bf2eda2edfb21ab09028409b0ed44d0810120d721f404f404d33fd315d1058e1bf82325a15210b99f326df82305aa0015a112b992306dde923033e2923033e25230800df40f6fa19ed021d721d70a00955f037fdb31e09130e259800df40f6fa19cd001d721d70a00937fdb31e0915be270801f6f2d48308d718d121f900ed44d0d3ffd31ff404f404d33fd315d1f82321a15220b98e12336df82324aa00a112b9926d32de58f82301de541675f910f2a106d0d31fd4d307d30cd309d33fd315d15168baf2a2515abaf2a6f8232aa15250bcf2a304f823bbf2a35304800df40f6fa199d024d721d70a00f2649130e20e01fe5309800df40f6fa18e13d05004d718d20001f264c858cf16cf8301cf168e1030c824cf40cf8384095005a1a514cf40e2f800c94039800df41704c8cbff13cb1ff40012f40012cb3f12cb15c9ed54f80f21d0d30001f265d3020171b0925f03e0fa4001d70b01c000f2a5fa4031fa0031f401fa0031fa00318060d721d300010f0020f265d2000193d431d19130e272b1fb00b585bf03"
+
+// Code is the highload wallet v3 code
+var Code = mustCode(codeHex)
+
+// mustCode parses a code BOC given as hex
+func mustCode(s string) *cell.Cell {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	c, err := cell.FromBOC(data)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+const (
+	// DefaultSubwalletID is the subwallet id commonly used for highload wallets v3
+	DefaultSubwalletID uint32 = 0x10ad
+	
+	// MaxTimeout is the longest timeout, in seconds, a wallet can have
+	MaxTimeout = 1<<22 - 1
+	
+	// MaxMessages is the maximum number of messages of a batch
+	MaxMessages = 254
+	
+	// OpInternalTransfer is the opcode of the message a wallet sends itself to
+	// perform a batch
+	OpInternalTransfer uint32 = 0xae42e5a4
+)
+
+// DefaultBatchValue is the amount attached to the internal message that performs a
+// batch when Request.BatchValue is nil; it pays for processing the batch
+var DefaultBatchValue = big.NewInt(100_000_000)
+
+// ErrInvalidRequest is returned for transfers the wallet would reject
+var ErrInvalidRequest = errors.New("highload: invalid request")
+
+// Wallet is a highload wallet v3. Its address depends on the public key, the
+// subwallet id and the timeout.
+type Wallet struct {
+	key         ed25519.PrivateKey
+	SubwalletID uint32
+	Timeout     uint32 // seconds a query id is remembered and a request stays valid
+	Workchain   int32
+}
+
+// New returns the wallet of a private key in the basechain
+func New(key ed25519.PrivateKey, subwalletID, timeout uint32) (*Wallet, error) {
+	if timeout == 0 || timeout > MaxTimeout {
+		return nil, fmt.Errorf("%w: timeout %d out of range", ErrInvalidRequest, timeout)
+	}
+	return &Wallet{key: key, SubwalletID: subwalletID, Timeout: timeout}, nil
+}
+
+// PublicKey returns the public key of the wallet
+func (w *Wallet) PublicKey() ed25519.PublicKey {
+	return w.key.Public().(ed25519.PublicKey)
+}
+
+// StateInit returns the state init that deploys the wallet
+func (w *Wallet) StateInit() (*cell.Cell, error) {
+	// public_key:bits256 subwallet_id:uint32 old_queries:(HashmapE 13 Bits)
+	// queries:(HashmapE 13 Bits) last_clean_time:uint64 timeout:uint22
+	data, err := cell.BeginCell().
+		StoreBytes(w.PublicKey()).
+		StoreUInt(uint64(w.SubwalletID), 32).
+		StoreBit(false).
+		StoreBit(false).
+		StoreUInt(0, 64).
+		StoreUInt(uint64(w.Timeout), 22).
+		EndCell()
+	if err != nil {
+		return nil, err
+	}
+	return message.StateInit(Code, data)
+}
+
+// Address returns the address of the wallet
+func (w *Wallet) Address() (*address.Address, error) {
+	init, err := w.StateInit()
+	if err != nil {
+		return nil, err
+	}
+	return message.Address(w.Workchain, init), nil
+}
+
+// Request is a request to the wallet
+type Request struct {
+	QueryID QueryID
+	
+	// CreatedAt must not be ahead of the clock of the validators; a minute in the
+	// past is usually safe. The request is valid for the timeout of the wallet.
+	CreatedAt time.Time
+	
+	// Messages are sent in order. A single message is sent directly with its mode;
+	// more are sent as a batch.
+	Messages []message.Send
+	
+	// BatchValue is attached to the internal message of a batch, DefaultBatchValue
+	// if nil
+	BatchValue *big.Int
+	
+	// Deploy includes the state init of the wallet, for its first request
+	Deploy bool
+}
+
+// Transfer is a signed request ready for SendBoc
+type Transfer struct {
+	QueryID    QueryID
+	CreatedAt  time.Time
+	ValidUntil time.Time
+	Message    *cell.Cell // the external message
+	Hash       []byte     // hash of Message, as returned by SendBocReturnHash
+	
+	// Body is the body of Message and BatchBody the body of the internal message
+	// of a batch, nil for a single message. Their hashes identify the transactions
+	// of the transfer in GetTransactions results.
+	Body      *cell.Cell
+	BatchBody *cell.Cell
+}
+
+// BOC returns the external message as a base64 BOC
+func (t *Transfer) BOC() string {
+	return t.Message.ToBOCBase64()
+}
+
+// SendBocRequest returns the request that broadcasts the transfer
+func (t *Transfer) SendBocRequest() toncenterzp.SendBocReturnHashRequest {
+	return toncenterzp.SendBocReturnHashRequest{Boc: t.BOC()}
+}
+
+// Sign builds and signs the external message of a request
+func (w *Wallet) Sign(req Request) (*Transfer, error) {
+	if len(req.Messages) == 0 || len(req.Messages) > MaxMessages {
+		return nil, fmt.Errorf("%w: %d messages, 1 to %d allowed", ErrInvalidRequest, len(req.Messages), MaxMessages)
+	}
+	if req.CreatedAt.IsZero() {
+		return nil, fmt.Errorf("%w: missing creation time", ErrInvalidRequest)
+	}
+	self, err := w.Address()
+	if err != nil {
+		return nil, err
+	}
+	
+	send := req.Messages[0]
+	var batchBody *cell.Cell
+	if len(req.Messages) > 1 {
+		if send, batchBody, err = w.batch(self, req); err != nil {
+			return nil, err
+		}
+	}
+	
+	// msg_inner: subwallet_id:uint32 message_to_send:^Cell send_mode:uint8
+	// query_id:(shift:uint13 bit_number:uint10) created_at:uint64 timeout:uint22
+	inner, err := cell.BeginCell().
+		StoreUInt(uint64(w.SubwalletID), 32).
+		StoreRef(send.Message).
+		StoreUInt(uint64(send.Mode), 8).
+		StoreUInt(uint64(req.QueryID), 23).
+		StoreUInt(uint64(req.CreatedAt.Unix()), 64).
+		StoreUInt(uint64(w.Timeout), 22).
+		EndCell()
+	if err != nil {
+		return nil, err
+	}
+	body, err := cell.BeginCell().
+		StoreBytes(ed25519.Sign(w.key, inner.Hash())).
+		StoreRef(inner).
+		EndCell()
+	if err != nil {
+		return nil, err
+	}
+	
+	ext := &message.External{Destination: self, Body: body}
+	if req.Deploy {
+		if ext.StateInit, err = w.StateInit(); err != nil {
+			return nil, err
+		}
+	}
+	msg, err := ext.ToCell()
+	if err != nil {
+		return nil, err
+	}
+	return &Transfer{
+		QueryID:    req.QueryID,
+		CreatedAt:  req.CreatedAt,
+		ValidUntil: req.CreatedAt.Add(time.Duration(w.Timeout) * time.Second),
+		Message:    msg,
+		Hash:       msg.Hash(),
+		Body:       body,
+		BatchBody:  batchBody,
+	}, nil
+}
+
+// batch builds the internal message the wallet sends itself to send a batch and
+// returns it with its body
+func (w *Wallet) batch(self *address.Address, req Request) (message.Send, *cell.Cell, error) {
+	actions, err := message.OutList(req.Messages)
+	if err != nil {
+		return message.Send{}, nil, err
+	}
+	// internal_transfer#ae42e5a4 query_id:uint64 actions:^OutList
+	body, err := cell.BeginCell().
+		StoreUInt(uint64(OpInternalTransfer), 32).
+		StoreUInt(uint64(req.QueryID), 64).
+		StoreRef(actions).
+		EndCell()
+	if err != nil {
+		return message.Send{}, nil, err
+	}
+	
+	value := req.BatchValue
+	if value == nil {
+		value = DefaultBatchValue
+	}
+	msg, err := (&message.Internal{Destination: self, Amount: value, Body: body}).ToCell()
+	if err != nil {
+		return message.Send{}, nil, err
+	}
+	return message.Send{Mode: message.ModePayFeesSeparately | message.ModeIgnoreErrors, Message: msg}, body, nil
+}
+
+// GetMethodRunner runs get methods, e.g. a *toncenterzp.Client
+type GetMethodRunner interface {
+	RunGetMethod(req toncenterzp.RunGetMethodRequest) (*toncenterzp.RunGetMethodResponse, error)
+}
+
+// Processed reports whether the wallet has processed a query id, using its
+// processed? get method. A query id that is not processed once its request
+// expired never will be. needClean makes the answer account for query ids the
+// wallet would forget on its next request.
+func (w *Wallet) Processed(c GetMethodRunner, q QueryID, needClean bool) (bool, error) {
+	addr, err := w.Address()
+	if err != nil {
+		return false, err
+	}
+	clean := big.NewInt(0)
+	if needClean {
+		clean.SetInt64(-1)
+	}
+	resp, err := c.RunGetMethod(toncenterzp.RunGetMethodRequest{
+		Address: addr.String(),
+		Method:  "processed?",
+		Stack:   []interface{}{toncenterzp.StackNum(big.NewInt(int64(q))), toncenterzp.StackNum(clean)},
+	})
+	if err != nil {
+		return false, err
+	}
+	v, err := resp.StackNum(0)
+	if err != nil {
+		return false, err
+	}
+	return v.Sign() != 0, nil
+}
@@ -0,0 +1,315 @@
+// Package websocket is a minimal RFC 6455 WebSocket client used by the
+// streaming subscriptions. It supports text and binary messages, fragmentation,
+// ping/pong and the closing handshake; extensions are not negotiated.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Opcodes of WebSocket frames
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// acceptGUID is appended to the handshake key to compute Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// MaxMessageSize is the largest message ReadMessage accepts
+const MaxMessageSize = 16 << 20
+
+// ErrClosed is returned by ReadMessage after the peer closed the connection
+var ErrClosed = errors.New("websocket: connection closed")
+
+// Conn is a client WebSocket connection. ReadMessage must not be called
+// concurrently; writes may be called from any goroutine.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	
+	// idle is the read timeout renewed before every frame, zero for none
+	idle time.Duration
+	
+	writeMu sync.Mutex
+}
+
+// Dial opens a WebSocket connection to a ws:// or wss:// URL
+func Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("websocket: invalid url: %w", err)
+	}
+	
+	host := u.Host
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	case "wss":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("websocket: dial: %w", err)
+	}
+	
+	if u.Scheme == "wss" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("websocket: tls handshake: %w", err)
+		}
+		conn = tlsConn
+	}
+	
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	
+	c, err := handshake(conn, u, header)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	
+	conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// handshake performs the HTTP upgrade on an established connection
+func handshake(conn net.Conn, u *url.URL, header http.Header) (*Conn, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Host:       u.Host,
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("websocket: write handshake: %w", err)
+	}
+	
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("websocket: read handshake: %w", err)
+	}
+	resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("websocket: unexpected handshake status %d", resp.StatusCode)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return nil, errors.New("websocket: missing upgrade header")
+	}
+	
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, errors.New("websocket: invalid Sec-WebSocket-Accept")
+	}
+	
+	return &Conn{conn: conn, br: br}, nil
+}
+
+// ReadMessage returns the next text or binary message. Control frames are handled
+// internally: pings are answered and a close frame ends the connection with ErrClosed.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var message []byte
+	started := false
+	
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.writeFrame(opClose, payload)
+			c.conn.Close()
+			return nil, ErrClosed
+		case opText, opBinary:
+			if started {
+				return nil, errors.New("websocket: new message inside fragmented message")
+			}
+			started = true
+			message = payload
+		case opContinuation:
+			if !started {
+				return nil, errors.New("websocket: continuation without message")
+			}
+			if len(message)+len(payload) > MaxMessageSize {
+				return nil, errors.New("websocket: message too large")
+			}
+			message = append(message, payload...)
+		default:
+			return nil, fmt.Errorf("websocket: unknown opcode %d", opcode)
+		}
+		
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// readFrame reads a single frame
+func (c *Conn) readFrame() (bool, byte, []byte, error) {
+	if c.idle > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.idle))
+	}
+	
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	
+	fin := head[0]&0x80 != 0
+	opcode := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+	
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > MaxMessageSize {
+		return false, 0, nil, errors.New("websocket: frame too large")
+	}
+	
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	
+	return fin, opcode, payload, nil
+}
+
+// WriteText sends a text message
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// Ping sends a ping control frame
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// writeFrame sends a single masked frame
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|opcode)
+	
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Close sends a close frame and closes the underlying connection
+func (c *Conn) Close() error {
+	c.writeFrame(opClose, []byte{0x03, 0xE8})
+	return c.conn.Close()
+}
+
+// SetReadDeadline sets the deadline for ReadMessage
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetIdleTimeout makes ReadMessage fail when no frame, including control frames
+// such as pongs, arrives for d. Zero disables the timeout.
+func (c *Conn) SetIdleTimeout(d time.Duration) {
+	c.idle = d
+}
@@ -0,0 +1,64 @@
+// Package x25519 derives x25519 shared secrets from ed25519 keys, as used by ADNL
+// handshakes and encrypted comments.
+package x25519
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"math/big"
+)
+
+// SharedKey computes the x25519 shared secret of an ed25519 key pair and a peer ed25519 public key
+func SharedKey(priv ed25519.PrivateKey, peer ed25519.PublicKey) ([]byte, error) {
+	h := sha512.Sum512(priv.Seed())
+	scalar, err := ecdh.X25519().NewPrivateKey(clamp(h[:32]))
+	if err != nil {
+		return nil, err
+	}
+	
+	pub, err := ecdh.X25519().NewPublicKey(edwardsToMontgomery(peer))
+	if err != nil {
+		return nil, err
+	}
+	return scalar.ECDH(pub)
+}
+
+// clamp applies the x25519 scalar clamping
+func clamp(k []byte) []byte {
+	out := append([]byte(nil), k...)
+	out[0] &= 248
+	out[31] &= 127
+	out[31] |= 64
+	return out
+}
+
+// curveP is the field prime 2^255 - 19
+var curveP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// edwardsToMontgomery converts an ed25519 public key to an x25519 public key: u = (1+y)/(1-y)
+func edwardsToMontgomery(pub ed25519.PublicKey) []byte {
+	le := append([]byte(nil), pub...)
+	le[31] &= 0x7f
+	y := new(big.Int).SetBytes(reverse(le))
+	
+	num := new(big.Int).Add(big.NewInt(1), y)
+	den := new(big.Int).Sub(big.NewInt(1), y)
+	den.Mod(den, curveP)
+	den.ModInverse(den, curveP)
+	u := num.Mul(num, den)
+	u.Mod(u, curveP)
+	
+	out := make([]byte, 32)
+	u.FillBytes(out)
+	return reverse(out)
+}
+
+// reverse returns b in reverse byte order
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package x25519
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+)
+
+func TestEdwardsToMontgomery(t *testing.T) {
+	// The converted ed25519 public key must be the x25519 public key of the scalar
+	// derived from the same seed
+	for i := 0; i < 20; i++ {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := sha512.Sum512(priv.Seed())
+		scalar, err := ecdh.X25519().NewPrivateKey(clamp(h[:32]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := edwardsToMontgomery(pub), scalar.PublicKey().Bytes(); !bytes.Equal(got, want) {
+			t.Fatalf("converted key %x, want %x", got, want)
+		}
+	}
+}
+
+func TestSharedKeySymmetric(t *testing.T) {
+	// RFC 8032 test keys 1 and 2; both sides must derive the same secret
+	seed1, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	seed2, _ := hex.DecodeString("4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb")
+	priv1, priv2 := ed25519.NewKeyFromSeed(seed1), ed25519.NewKeyFromSeed(seed2)
+	if got := hex.EncodeToString(priv1.Public().(ed25519.PublicKey)); got != "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a" {
+		t.Fatalf("public key 1 %s", got)
+	}
+	
+	a, err := SharedKey(priv1, priv2.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SharedKey(priv2, priv1.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Fatalf("shared keys differ: %x and %x", a, b)
+	}
+	if len(a) != 32 || bytes.Equal(a, make([]byte, 32)) {
+		t.Errorf("shared key %x", a)
+	}
+}
+
+func TestSharedKeyDiffersPerPeer(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(nil)
+	peer1, _, _ := ed25519.GenerateKey(nil)
+	peer2, _, _ := ed25519.GenerateKey(nil)
+	a, _ := SharedKey(priv, peer1)
+	b, _ := SharedKey(priv, peer2)
+	if bytes.Equal(a, b) {
+		t.Error("same shared key for two peers")
+	}
+}
@@ -0,0 +1,181 @@
+package liteclient
+
+import (
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// Account statuses
+const (
+	StatusNonexist      = "nonexist"
+	StatusUninitialized = "uninitialized"
+	StatusActive        = "active"
+	StatusFrozen        = "frozen"
+)
+
+// shardStateTag is the tag of shard_state (ShardStateUnsplit)
+const shardStateTag = 0x9023afe2
+
+// Account is a decoded account state
+type Account struct {
+	Address *address.Address
+	Status  string
+	Balance *big.Int
+	
+	LastTransLt   uint64
+	LastTransHash []byte
+	
+	// Code and Data are set for active accounts, FrozenHash for frozen ones
+	Code       *cell.Cell
+	Data       *cell.Cell
+	FrozenHash []byte
+	
+	// SyncUtime is the generation time of the shard state the account was read from
+	SyncUtime uint32
+}
+
+// Account decodes the account state. The last transaction hash and sync time come
+// from the state proof; the proof is not verified against the block.
+func (st *AccountState) Account(addr *address.Address) (*Account, error) {
+	acc := &Account{
+		Address: addr,
+		Status:  StatusNonexist,
+		Balance: new(big.Int),
+	}
+	
+	if len(st.State) > 0 {
+		root, err := cell.FromBOC(st.State)
+		if err != nil {
+			return nil, err
+		}
+		if err := acc.load(root.BeginParse()); err != nil {
+			return nil, err
+		}
+	}
+	
+	if len(st.Proof) > 0 {
+		if err := acc.loadShardAccount(st.Proof); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+// load reads an Account
+func (acc *Account) load(s *cell.Slice) error {
+	// account_none$0 | account$1
+	if !s.LoadBit() {
+		return s.Err()
+	}
+	
+	s.LoadAddress()
+	
+	// storage_stat:StorageInfo
+	s.LoadVarUInt(3) // used cells
+	s.LoadVarUInt(3) // used bits
+	switch s.LoadUInt(3) {
+	case 0b000:
+		// storage_extra_none, or a zero public_cells in the older layout
+	case 0b001:
+		s.Skip(256) // storage_extra_info dict_hash
+	default:
+		// public_cells:(VarUInteger 7) of the older layout
+		return errUnsupported("storage info layout")
+	}
+	s.LoadUInt(32) // last_paid
+	if s.LoadBit() {
+		s.LoadCoins() // due_payment
+	}
+	
+	// storage:AccountStorage
+	acc.LastTransLt = s.LoadUInt(64)
+	acc.Balance = s.LoadCoins()
+	s.LoadMaybeRef() // extra currencies
+	
+	switch {
+	case s.LoadBit():
+		// account_active$1 _:StateInit
+		acc.Status = StatusActive
+		if s.LoadBit() {
+			s.Skip(5) // split_depth
+		}
+		if s.LoadBit() {
+			s.Skip(2) // special
+		}
+		acc.Code = s.LoadMaybeRef()
+		acc.Data = s.LoadMaybeRef()
+	case s.LoadBit():
+		// account_frozen$01
+		acc.Status = StatusFrozen
+		acc.FrozenHash = s.LoadBytes(32)
+	default:
+		// account_uninit$00
+		acc.Status = StatusUninitialized
+	}
+	return s.Err()
+}
+
+// loadShardAccount reads the ShardAccount of the account from the shard state proof
+func (acc *Account) loadShardAccount(proof []byte) error {
+	roots, err := cell.FromBOCMultiRoot(proof)
+	if err != nil {
+		return err
+	}
+	if len(roots) < 2 || roots[1].Type() != cell.MerkleProof {
+		return errUnsupported("account state proof")
+	}
+	
+	utime, v, err := shardAccount(roots[1].Ref(0), acc.Address.Hash[:])
+	if err != nil {
+		return err
+	}
+	acc.SyncUtime = utime
+	if v == nil {
+		return nil
+	}
+	
+	// account:^Account last_trans_hash:bits256 last_trans_lt:uint64
+	v.LoadRef()
+	acc.LastTransHash = v.LoadBytes(32)
+	acc.LastTransLt = v.LoadUInt(64)
+	return v.Err()
+}
+
+// shardAccount looks up the ShardAccount of an account in a shard state and returns
+// the generation time of the state and the ShardAccount, nil if the account is absent
+func shardAccount(root *cell.Cell, accountID []byte) (uint32, *cell.Slice, error) {
+	state, err := parseProven(root, "shard state")
+	if err != nil {
+		return 0, nil, err
+	}
+	if state.LoadUInt(32) != shardStateTag {
+		return 0, nil, errUnsupported("split shard state")
+	}
+	state.Skip(32 + 104 + 32 + 32) // global_id, shard_id, seq_no, vert_seq_no
+	utime := uint32(state.LoadUInt(32))
+	state.LoadRef() // out_msg_queue_info
+	
+	accounts, err := parseProven(state.LoadRef(), "shard accounts")
+	if err != nil {
+		return 0, nil, err
+	}
+	
+	// HashmapAugE 256 ShardAccount DepthBalanceInfo
+	if !accounts.LoadBit() {
+		return utime, nil, accounts.Err()
+	}
+	root = accounts.LoadRef()
+	if root == nil {
+		return 0, nil, accounts.Err()
+	}
+	
+	skipDepthBalance := func(s *cell.Slice) {
+		s.Skip(5)
+		s.LoadCoins()
+		s.LoadMaybeRef()
+	}
+	v, err := dictLookup(root, accountID, 256, skipDepthBalance)
+	return utime, v, err
+}
@@ -0,0 +1,269 @@
+package liteclient
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp/internal/x25519"
+)
+
+// maxPacketSize limits the size of a single ADNL packet
+const maxPacketSize = 16 << 20
+
+// ErrClosed is returned for queries on a closed connection
+var ErrClosed = errors.New("liteclient: connection closed")
+
+// conn is an encrypted ADNL over TCP connection
+type conn struct {
+	nc net.Conn
+	rd cipher.Stream
+	wr cipher.Stream
+	
+	wmu sync.Mutex
+	
+	mu      sync.Mutex
+	pending map[string]chan []byte
+	err     error
+	done    chan struct{}
+}
+
+// keyID returns the ADNL short ID of an ed25519 public key
+func keyID(pub ed25519.PublicKey) []byte {
+	var w tlWriter
+	w.uint32(tlPubEd25519).int256(pub)
+	sum := sha256.Sum256(w.buf)
+	return sum[:]
+}
+
+// newCTR creates an AES-256-CTR stream
+func newCTR(key, iv []byte) cipher.Stream {
+	block, _ := aes.NewCipher(key)
+	return cipher.NewCTR(block, iv)
+}
+
+// handshakeCipher returns the stream that encrypts the session parameters
+func handshakeCipher(shared, paramsHash []byte) cipher.Stream {
+	key := append(append([]byte(nil), shared[:16]...), paramsHash[16:32]...)
+	iv := append(append([]byte(nil), paramsHash[:4]...), shared[20:32]...)
+	return newCTR(key, iv)
+}
+
+// dial connects to a liteserver and performs the ADNL handshake
+func dial(ctx context.Context, addr string, serverKey ed25519.PublicKey) (*conn, error) {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	
+	c, err := clientHandshake(ctx, nc, serverKey)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// clientHandshake sends the session parameters and waits for the empty confirmation packet
+func clientHandshake(ctx context.Context, nc net.Conn, serverKey ed25519.PublicKey) (*conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		nc.SetDeadline(deadline)
+		defer nc.SetDeadline(time.Time{})
+	}
+	
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := x25519.SharedKey(priv, serverKey)
+	if err != nil {
+		return nil, fmt.Errorf("liteclient: key exchange: %w", err)
+	}
+	
+	params := make([]byte, 160)
+	if _, err := rand.Read(params); err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(params)
+	
+	encrypted := make([]byte, len(params))
+	handshakeCipher(shared, hash[:]).XORKeyStream(encrypted, params)
+	
+	packet := make([]byte, 0, 256)
+	packet = append(packet, keyID(serverKey)...)
+	packet = append(packet, pub...)
+	packet = append(packet, hash[:]...)
+	packet = append(packet, encrypted...)
+	if _, err := nc.Write(packet); err != nil {
+		return nil, err
+	}
+	
+	c := newConn(nc, params[0:32], params[64:80], params[32:64], params[80:96])
+	if _, err := c.readPacket(); err != nil {
+		return nil, fmt.Errorf("liteclient: handshake: %w", err)
+	}
+	return c, nil
+}
+
+// newConn creates a connection with the given read and write ciphers
+func newConn(nc net.Conn, rdKey, rdIV, wrKey, wrIV []byte) *conn {
+	return &conn{
+		nc:      nc,
+		rd:      newCTR(rdKey, rdIV),
+		wr:      newCTR(wrKey, wrIV),
+		pending: map[string]chan []byte{},
+		done:    make(chan struct{}),
+	}
+}
+
+// writePacket encrypts and sends a packet: size, nonce, payload and checksum
+func (c *conn) writePacket(payload []byte) error {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	
+	buf := make([]byte, 4, 4+32+len(payload)+32)
+	binary.LittleEndian.PutUint32(buf, uint32(32+len(payload)+32))
+	buf = append(buf, nonce...)
+	buf = append(buf, payload...)
+	sum := sha256.Sum256(buf[4:])
+	buf = append(buf, sum[:]...)
+	
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	c.wr.XORKeyStream(buf, buf)
+	_, err := c.nc.Write(buf)
+	return err
+}
+
+// readPacket reads and decrypts one packet and returns its payload
+func (c *conn) readPacket() ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(c.nc, size[:]); err != nil {
+		return nil, err
+	}
+	c.rd.XORKeyStream(size[:], size[:])
+	
+	n := binary.LittleEndian.Uint32(size[:])
+	if n < 64 || n > maxPacketSize {
+		return nil, fmt.Errorf("liteclient: invalid packet size %d", n)
+	}
+	
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(c.nc, buf); err != nil {
+		return nil, err
+	}
+	c.rd.XORKeyStream(buf, buf)
+	
+	sum := sha256.Sum256(buf[:n-32])
+	if string(sum[:]) != string(buf[n-32:]) {
+		return nil, errors.New("liteclient: packet checksum mismatch")
+	}
+	return buf[32 : n-32], nil
+}
+
+// readLoop dispatches answers to waiting queries until the connection fails
+func (c *conn) readLoop() {
+	for {
+		payload, err := c.readPacket()
+		if err != nil {
+			c.close(err)
+			return
+		}
+		if len(payload) == 0 {
+			continue
+		}
+		
+		r := &tlReader{data: payload}
+		switch r.uint32() {
+		case tlADNLAnswer:
+			id := string(r.int256())
+			answer := r.bytes()
+			if r.err != nil {
+				continue
+			}
+			c.mu.Lock()
+			ch := c.pending[id]
+			delete(c.pending, id)
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- answer
+			}
+		case tlTCPPong:
+		}
+	}
+}
+
+// query sends a liteServer request and waits for its answer
+func (c *conn) query(ctx context.Context, request []byte) ([]byte, error) {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	
+	var inner tlWriter
+	inner.uint32(tlLiteQuery).bytes(request)
+	var w tlWriter
+	w.uint32(tlADNLQuery).int256(id).bytes(inner.buf)
+	
+	ch := make(chan []byte, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.pending[string(id)] = ch
+	c.mu.Unlock()
+	
+	if err := c.writePacket(w.buf); err != nil {
+		c.close(err)
+		return nil, err
+	}
+	
+	select {
+	case answer := <-ch:
+		return answer, nil
+	case <-c.done:
+		return nil, c.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, string(id))
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// ping sends a tcp.ping keepalive
+func (c *conn) ping() error {
+	var w tlWriter
+	w.uint32(tlTCPPing).int64(time.Now().UnixNano())
+	return c.writePacket(w.buf)
+}
+
+// close closes the connection with the given cause
+func (c *conn) close(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	if err == nil || errors.Is(err, net.ErrClosed) {
+		err = ErrClosed
+	}
+	c.err = err
+	close(c.done)
+	c.nc.Close()
+}
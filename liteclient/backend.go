@@ -0,0 +1,496 @@
+package liteclient
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// Default page sizes, matching the HTTP API
+const (
+	DefaultTransactionsLimit      = 10
+	DefaultBlockTransactionsCount = 40
+	
+	// maxTransactionsPerQuery is the liteserver limit of getTransactions
+	maxTransactionsPerQuery = 16
+)
+
+// Backend serves toncenterzp.Client methods from liteservers:
+//
+//	client := toncenterzp.NewClient(apiKey)
+//	client.Backend = liteclient.NewBackend(lc, toncenterzp.NewHTTPBackend(client))
+//
+// Operations liteservers cannot answer are passed to the embedded fallback Backend.
+// Responses have the same shape as those of the HTTP API. Get-method stacks use
+// ["num", "0x.."], ["cell", {"bytes": boc}], ["slice", {"bytes": boc}],
+// ["tuple", {"elements": [...]}] and ["null", null] entries.
+type Backend struct {
+	// Backend serves the operations not implemented here
+	toncenterzp.Backend
+	
+	Client *Client
+	
+	// LightClient, if set, makes account states be read at the last block it proved
+	// and checked against their proofs
+	LightClient *LightClient
+}
+
+var _ toncenterzp.Backend = (*Backend)(nil)
+
+// NewBackend creates a backend using the given liteserver client. A nil fallback
+// makes the remaining operations fail with an unsupported method error.
+func NewBackend(c *Client, fallback toncenterzp.Backend) *Backend {
+	if fallback == nil {
+		fallback = toncenterzp.UnimplementedBackend{}
+	}
+	return &Backend{Backend: fallback, Client: c}
+}
+
+// GetMasterchainInfo gets information about the masterchain
+func (b *Backend) GetMasterchainInfo() (*toncenterzp.GetMasterchainInfoResponse, error) {
+	info, err := b.Client.GetMasterchainInfo(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	
+	resp := &toncenterzp.GetMasterchainInfoResponse{OK: true}
+	last := blockIDToAPI(info.Last)
+	resp.Result.LastBlockID.Workchain = last.Workchain
+	resp.Result.LastBlockID.Shard = last.Shard
+	resp.Result.LastBlockID.SeqNo = last.SeqNo
+	resp.Result.LastBlockID.RootHash = last.RootHash
+	resp.Result.LastBlockID.FileHash = last.FileHash
+	resp.Result.StateRootHash = base64.StdEncoding.EncodeToString(info.StateRootHash)
+	return resp, nil
+}
+
+// account reads and decodes an account at the last masterchain block
+func (b *Backend) account(ctx context.Context, addr *address.Address) (*Account, error) {
+	if b.LightClient != nil {
+		block, err := b.LightClient.Sync(ctx)
+		if err != nil {
+			return nil, err
+		}
+		st, err := b.Client.GetAccountState(ctx, block, addr)
+		if err != nil {
+			return nil, err
+		}
+		return st.Verify(block, addr)
+	}
+	
+	info, err := b.Client.GetMasterchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st, err := b.Client.GetAccountState(ctx, info.Last, addr)
+	if err != nil {
+		return nil, err
+	}
+	return st.Account(addr)
+}
+
+// GetAddressInformation gets detailed information about a TON address
+func (b *Backend) GetAddressInformation(addr string) (*toncenterzp.GetAddressInformationResponse, error) {
+	a, err := parseAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := b.account(context.Background(), a)
+	if err != nil {
+		return nil, err
+	}
+	
+	resp := &toncenterzp.GetAddressInformationResponse{OK: true}
+	r := &resp.Result
+	r.Address = addr
+	r.Balance = acc.Balance.String()
+	r.LastTransLT = strconv.FormatUint(acc.LastTransLt, 10)
+	r.LastTransHash = base64.StdEncoding.EncodeToString(acc.LastTransHash)
+	r.SyncUtime = int(acc.SyncUtime)
+	r.State = acc.Status
+	if acc.Status == StatusNonexist {
+		r.State = StatusUninitialized
+	}
+	if acc.Code != nil {
+		r.Code = acc.Code.ToBOCBase64()
+	}
+	if acc.Data != nil {
+		r.Data = acc.Data.ToBOCBase64()
+	}
+	if acc.FrozenHash != nil {
+		r.FrozenHash = base64.StdEncoding.EncodeToString(acc.FrozenHash)
+	}
+	return resp, nil
+}
+
+// GetAddressBalance gets the balance of a TON address
+func (b *Backend) GetAddressBalance(addr string) (*toncenterzp.GetAddressBalanceResponse, error) {
+	info, err := b.GetAddressInformation(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &toncenterzp.GetAddressBalanceResponse{OK: true, Result: info.Result.Balance}, nil
+}
+
+// GetAddressState gets the state of a TON address
+func (b *Backend) GetAddressState(addr string) (*toncenterzp.GetAddressStateResponse, error) {
+	info, err := b.GetAddressInformation(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &toncenterzp.GetAddressStateResponse{OK: true, Result: info.Result.State}, nil
+}
+
+// GetTransactions gets transactions for a TON address
+func (b *Backend) GetTransactions(req toncenterzp.GetTransactionsRequest) (*toncenterzp.GetTransactionsResponse, error) {
+	ctx := context.Background()
+	addr, err := parseAddress(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultTransactionsLimit
+	}
+	
+	var lt uint64
+	var hash []byte
+	if req.Lt != "" {
+		if lt, err = strconv.ParseUint(req.Lt, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid lt %q: %w", req.Lt, err)
+		}
+		if hash, err = decodeHash(req.Hash); err != nil {
+			return nil, err
+		}
+	} else {
+		acc, err := b.account(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		lt, hash = acc.LastTransLt, acc.LastTransHash
+	}
+	
+	var toLt uint64
+	if req.ToLt != "" {
+		if toLt, err = strconv.ParseUint(req.ToLt, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid to_lt %q: %w", req.ToLt, err)
+		}
+	}
+	
+	resp := &toncenterzp.GetTransactionsResponse{OK: true}
+	resp.Result.Transactions = []toncenterzp.TransactionDetails{}
+	for lt != 0 && lt > toLt && len(resp.Result.Transactions) < limit {
+		n := limit - len(resp.Result.Transactions)
+		if n > maxTransactionsPerQuery {
+			n = maxTransactionsPerQuery
+		}
+		
+		list, err := b.Client.GetTransactions(ctx, addr, lt, hash, n)
+		if err != nil {
+			return nil, err
+		}
+		if len(list.Transactions) == 0 {
+			break
+		}
+		
+		for i, root := range list.Transactions {
+			tx, err := ParseTransaction(root, list.Blocks[i])
+			if err != nil {
+				return nil, err
+			}
+			
+			txLt, _ := strconv.ParseUint(tx.Lt, 10, 64)
+			if txLt <= toLt {
+				lt = 0
+				break
+			}
+			resp.Result.Transactions = append(resp.Result.Transactions, *tx)
+			
+			lt, _ = strconv.ParseUint(tx.PrevTransLt, 10, 64)
+			hash, _ = base64.StdEncoding.DecodeString(tx.PrevTransHash)
+		}
+	}
+	return resp, nil
+}
+
+// GetBlockTransactions gets the transactions in a block
+func (b *Backend) GetBlockTransactions(req toncenterzp.GetBlockTransactionsRequest) (*toncenterzp.GetBlockTransactionsResponse, error) {
+	ctx := context.Background()
+	shard, err := parseShard(req.Shard)
+	if err != nil {
+		return nil, err
+	}
+	
+	block := BlockID{Workchain: int32(req.Workchain), Shard: shard, SeqNo: uint32(req.SeqNo)}
+	if req.RootHash != "" && req.FileHash != "" {
+		if block.RootHash, err = decodeHash(req.RootHash); err != nil {
+			return nil, err
+		}
+		if block.FileHash, err = decodeHash(req.FileHash); err != nil {
+			return nil, err
+		}
+	} else {
+		lookup := BlockLookup{Workchain: block.Workchain, Shard: shard, SeqNo: block.SeqNo}
+		if block, err = b.Client.LookupBlock(ctx, lookup); err != nil {
+			return nil, err
+		}
+	}
+	
+	count := req.Count
+	if count <= 0 {
+		count = DefaultBlockTransactionsCount
+	}
+	
+	var after *TransactionID
+	if req.AfterLt != "" {
+		lt, err := strconv.ParseUint(req.AfterLt, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid after_lt %q: %w", req.AfterLt, err)
+		}
+		account, err := decodeAccountID(req.AfterHash)
+		if err != nil {
+			return nil, err
+		}
+		after = &TransactionID{Account: account, Lt: lt}
+	}
+	
+	res, err := b.Client.ListBlockTransactions(ctx, block, count, after)
+	if err != nil {
+		return nil, err
+	}
+	
+	resp := &toncenterzp.GetBlockTransactionsResponse{OK: true}
+	resp.Result.Incomplete = res.Incomplete
+	resp.Result.Transactions = []toncenterzp.Transaction{}
+	for _, id := range res.Transactions {
+		resp.Result.Transactions = append(resp.Result.Transactions, toncenterzp.Transaction{
+			Account: address.New(block.Workchain, id.Account).Raw(),
+			Hash:    base64.StdEncoding.EncodeToString(id.Hash),
+			Lt:      strconv.FormatUint(id.Lt, 10),
+		})
+	}
+	return resp, nil
+}
+
+// LookupBlock looks up a block by various criteria
+func (b *Backend) LookupBlock(req toncenterzp.LookupBlockRequest) (*toncenterzp.LookupBlockResponse, error) {
+	shard, err := parseShard(req.Shard)
+	if err != nil {
+		return nil, err
+	}
+	
+	q := BlockLookup{
+		Workchain: int32(req.Workchain),
+		Shard:     shard,
+		SeqNo:     uint32(req.SeqNo),
+		UnixTime:  uint32(req.UnixTime),
+	}
+	if req.Lt != "" {
+		if q.Lt, err = strconv.ParseUint(req.Lt, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid lt %q: %w", req.Lt, err)
+		}
+	}
+	
+	id, err := b.Client.LookupBlock(context.Background(), q)
+	if err != nil {
+		return nil, err
+	}
+	return &toncenterzp.LookupBlockResponse{OK: true, Result: blockIDToAPI(id)}, nil
+}
+
+// RunGetMethod runs a get method on a TON contract
+func (b *Backend) RunGetMethod(req toncenterzp.RunGetMethodRequest) (*toncenterzp.RunGetMethodResponse, error) {
+	addr, err := parseAddress(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	args, err := stackFromAPI(req.Stack)
+	if err != nil {
+		return nil, err
+	}
+	
+	stack, exitCode, err := b.Client.RunGetMethod(context.Background(), addr, req.Method, args...)
+	if err != nil {
+		return nil, err
+	}
+	
+	resp := &toncenterzp.RunGetMethodResponse{OK: true}
+	resp.Result.ExitCode = int(exitCode)
+	resp.Result.Stack = [][]interface{}{}
+	for _, v := range stack {
+		resp.Result.Stack = append(resp.Result.Stack, stackEntryToAPI(v))
+	}
+	return resp, nil
+}
+
+// SendBoc sends a bag of cells to the TON network
+func (b *Backend) SendBoc(req toncenterzp.SendBocRequest) (*toncenterzp.SendBocResponse, error) {
+	status, hash, err := b.send(req.Boc)
+	if err != nil {
+		return nil, err
+	}
+	
+	resp := &toncenterzp.SendBocResponse{OK: true}
+	resp.Result.Status = int(status)
+	resp.Result.Hash = hash
+	return resp, nil
+}
+
+// SendBocReturnHash sends a bag of cells to the TON network and returns the hash
+func (b *Backend) SendBocReturnHash(req toncenterzp.SendBocReturnHashRequest) (*toncenterzp.SendBocReturnHashResponse, error) {
+	_, hash, err := b.send(req.Boc)
+	if err != nil {
+		return nil, err
+	}
+	return &toncenterzp.SendBocReturnHashResponse{OK: true, Result: hash}, nil
+}
+
+// send broadcasts a base64 encoded external message and returns the status and message hash
+func (b *Backend) send(boc string) (int32, string, error) {
+	data, err := base64.StdEncoding.DecodeString(boc)
+	if err != nil {
+		return 0, "", toncenterzp.NewError(toncenterzp.ErrInvalidBoc, "invalid base64 BOC", err)
+	}
+	root, err := cell.FromBOC(data)
+	if err != nil {
+		return 0, "", toncenterzp.NewError(toncenterzp.ErrInvalidBoc, "invalid BOC", err)
+	}
+	
+	status, err := b.Client.SendMessage(context.Background(), data)
+	if err != nil {
+		return 0, "", err
+	}
+	return status, base64.StdEncoding.EncodeToString(root.Hash()), nil
+}
+
+// parseAddress parses an address and wraps errors like the HTTP client does
+func parseAddress(s string) (*address.Address, error) {
+	a, err := address.Parse(s)
+	if err != nil {
+		return nil, toncenterzp.NewError(toncenterzp.ErrInvalidAddress, "invalid address", err)
+	}
+	return a, nil
+}
+
+// parseShard parses a shard given as signed decimal or unsigned hex; empty means the whole workchain
+func parseShard(s string) (int64, error) {
+	if s == "" {
+		return -1 << 63, nil
+	}
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(s), "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid shard %q", s)
+	}
+	return int64(v), nil
+}
+
+// decodeAccountID decodes an account ID given as a hash or as an address
+func decodeAccountID(s string) ([]byte, error) {
+	if h, err := decodeHash(s); err == nil {
+		return h, nil
+	}
+	a, err := parseAddress(s)
+	if err != nil {
+		return nil, err
+	}
+	return a.Hash[:], nil
+}
+
+// stackFromAPI converts get-method arguments from the HTTP API format
+func stackFromAPI(entries []interface{}) ([]interface{}, error) {
+	var out []interface{}
+	for _, e := range entries {
+		pair, ok := e.([]interface{})
+		if !ok || len(pair) == 0 {
+			return nil, fmt.Errorf("invalid stack entry %v", e)
+		}
+		kind, _ := pair[0].(string)
+		var value interface{}
+		if len(pair) > 1 {
+			value = pair[1]
+		}
+		
+		switch kind {
+		case "num", "int":
+			n, err := parseStackNumber(value)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, n)
+		case "cell", "tvm.Cell", "slice", "tvm.Slice":
+			s, _ := value.(string)
+			c, err := cell.FromBOCBase64(s)
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasSuffix(strings.ToLower(kind), "slice") {
+				out = append(out, c.BeginParse())
+			} else {
+				out = append(out, c)
+			}
+		case "null":
+			out = append(out, nil)
+		default:
+			return nil, fmt.Errorf("unsupported stack entry type %q", kind)
+		}
+	}
+	return out, nil
+}
+
+// parseStackNumber parses a number given as JSON number, decimal or 0x-prefixed hex string
+func parseStackNumber(v interface{}) (*big.Int, error) {
+	switch v := v.(type) {
+	case float64:
+		return big.NewInt(int64(v)), nil
+	case string:
+		n, ok := new(big.Int).SetString(v, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid stack number %q", v)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("invalid stack number %v", v)
+}
+
+// stackEntryToAPI converts a stack value to the HTTP API format
+func stackEntryToAPI(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case *big.Int:
+		if v.Sign() < 0 {
+			return []interface{}{"num", "-0x" + new(big.Int).Neg(v).Text(16)}
+		}
+		return []interface{}{"num", "0x" + v.Text(16)}
+	case *cell.Cell:
+		return []interface{}{"cell", map[string]interface{}{"bytes": v.ToBOCBase64()}}
+	case *cell.Slice:
+		c, err := v.ToCell()
+		if err != nil {
+			return []interface{}{"slice", nil}
+		}
+		return []interface{}{"slice", map[string]interface{}{"bytes": c.ToBOCBase64()}}
+	case *cell.Builder:
+		c, err := v.EndCell()
+		if err != nil {
+			return []interface{}{"builder", nil}
+		}
+		return []interface{}{"builder", map[string]interface{}{"bytes": c.ToBOCBase64()}}
+	case []interface{}:
+		elements := []interface{}{}
+		for _, e := range v {
+			elements = append(elements, stackEntryToAPI(e))
+		}
+		return []interface{}{"tuple", map[string]interface{}{"elements": elements}}
+	}
+	return []interface{}{"null", nil}
+}
@@ -0,0 +1,688 @@
+// Package liteclient talks to TON liteservers directly over ADNL/TCP.
+//
+// Client sends liteServer queries to the servers of a global config, failing over to
+// the next server when a connection breaks. Backend adapts a Client to the
+// toncenterzp.Backend interface, so the methods of toncenterzp.Client can be served by
+// liteservers instead of an HTTP gateway. AccountState.Verify, VerifyShardBlockProof and
+// VerifyAddressInformation check the Merkle proofs of answers against a trusted
+// masterchain block, and LightClient proves masterchain blocks from a trusted key block
+// with validator signatures.
+package liteclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// Default client settings
+const (
+	DefaultTimeout      = 10 * time.Second
+	DefaultPingInterval = 10 * time.Second
+)
+
+// ErrUnsupported is returned for data layouts and operations the package does not handle
+var ErrUnsupported = errors.New("liteclient: unsupported")
+
+// errUnsupported wraps ErrUnsupported with a description
+func errUnsupported(what string) error {
+	return fmt.Errorf("%w %s", ErrUnsupported, what)
+}
+
+// BlockID identifies a block (tonNode.blockIdExt)
+type BlockID struct {
+	Workchain int32
+	Shard     int64
+	SeqNo     uint32
+	RootHash  []byte
+	FileHash  []byte
+}
+
+// Error is an error returned by a liteserver
+type Error struct {
+	Code    int32
+	Message string
+}
+
+// Error returns the error message
+func (e *Error) Error() string {
+	return fmt.Sprintf("liteserver error %d: %s", e.Code, e.Message)
+}
+
+// endpoint is a liteserver address and key
+type endpoint struct {
+	addr string
+	key  ed25519.PublicKey
+}
+
+// Client sends queries to liteservers
+type Client struct {
+	// Timeout bounds queries whose context has no deadline
+	Timeout time.Duration
+	
+	// PingInterval is the interval of keepalive pings; zero uses DefaultPingInterval
+	PingInterval time.Duration
+	
+	endpoints []endpoint
+	
+	mu     sync.Mutex
+	conn   *conn
+	next   int
+	closed bool
+}
+
+// NewClient creates a client for a single liteserver
+func NewClient(addr string, key ed25519.PublicKey) *Client {
+	return &Client{
+		Timeout:   DefaultTimeout,
+		endpoints: []endpoint{{addr: addr, key: key}},
+	}
+}
+
+// NewClientFromConfig creates a client for the liteservers of a global config.
+// Connections are established on the first query.
+func NewClientFromConfig(cfg *GlobalConfig) (*Client, error) {
+	c := &Client{Timeout: DefaultTimeout}
+	for _, s := range cfg.LiteServers {
+		key, err := s.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		c.endpoints = append(c.endpoints, endpoint{addr: s.Addr(), key: key})
+	}
+	if len(c.endpoints) == 0 {
+		return nil, errors.New("liteclient: no liteservers")
+	}
+	return c, nil
+}
+
+// Close closes the current connection; further queries fail
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	c.closed = true
+	if c.conn != nil {
+		c.conn.close(nil)
+		c.conn = nil
+	}
+	return nil
+}
+
+// connection returns the current connection, connecting to the next liteserver if needed
+func (c *Client) connection(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	
+	if c.closed {
+		return nil, ErrClosed
+	}
+	if c.conn != nil {
+		select {
+		case <-c.conn.done:
+			c.conn = nil
+		default:
+			return c.conn, nil
+		}
+	}
+	
+	var lastErr error
+	for i := 0; i < len(c.endpoints); i++ {
+		ep := c.endpoints[c.next]
+		c.next = (c.next + 1) % len(c.endpoints)
+		
+		conn, err := dial(ctx, ep.addr, ep.key)
+		if err != nil {
+			lastErr = fmt.Errorf("liteclient: connecting to %s: %w", ep.addr, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		c.conn = conn
+		go c.keepalive(conn)
+		return conn, nil
+	}
+	return nil, lastErr
+}
+
+// keepalive pings a connection until it is closed
+func (c *Client) keepalive(conn *conn) {
+	interval := c.PingInterval
+	if interval <= 0 {
+		interval = DefaultPingInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-conn.done:
+			return
+		case <-ticker.C:
+			if err := conn.ping(); err != nil {
+				conn.close(err)
+				return
+			}
+		}
+	}
+}
+
+// Query sends a TL serialized liteServer function and returns the TL serialized result.
+// A liteServer.error answer is returned as *Error. Broken connections are retried on
+// the next liteserver.
+func (c *Client) Query(ctx context.Context, request []byte) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok && c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	
+	var lastErr error
+	for attempt := 0; attempt < len(c.endpoints); attempt++ {
+		conn, err := c.connection(ctx)
+		if err != nil {
+			return nil, err
+		}
+		
+		answer, err := conn.query(ctx, request)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			conn.close(err)
+			lastErr = err
+			continue
+		}
+		
+		r := &tlReader{data: answer}
+		if r.uint32() == tlLiteError {
+			code := r.int32()
+			msg := r.bytes()
+			return nil, &Error{Code: code, Message: string(msg)}
+		}
+		return answer, nil
+	}
+	return nil, lastErr
+}
+
+// MasterchainInfo is the result of GetMasterchainInfo
+type MasterchainInfo struct {
+	Last          BlockID
+	StateRootHash []byte
+	Init          BlockID
+}
+
+// GetMasterchainInfo returns the last masterchain block known to the liteserver
+func (c *Client) GetMasterchainInfo(ctx context.Context) (*MasterchainInfo, error) {
+	var w tlWriter
+	w.uint32(tlGetMasterchainInfo)
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return nil, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlMasterchainInfo)
+	info := &MasterchainInfo{
+		Last:          r.blockID(),
+		StateRootHash: r.int256(),
+		Init: BlockID{
+			Workchain: r.int32(),
+			RootHash:  r.int256(),
+			FileHash:  r.int256(),
+		},
+	}
+	return info, r.err
+}
+
+// GetTime returns the current time of the liteserver
+func (c *Client) GetTime(ctx context.Context) (uint32, error) {
+	var w tlWriter
+	w.uint32(tlGetTime)
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return 0, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlCurrentTime)
+	now := r.uint32()
+	return now, r.err
+}
+
+// AccountState is the raw result of GetAccountState
+type AccountState struct {
+	Block      BlockID
+	ShardBlock BlockID
+	ShardProof []byte
+	Proof      []byte
+	
+	// State is the BOC of the Account, empty if the account does not exist
+	State []byte
+}
+
+// GetAccountState returns the state of an account at a masterchain block
+func (c *Client) GetAccountState(ctx context.Context, block BlockID, addr *address.Address) (*AccountState, error) {
+	var w tlWriter
+	w.uint32(tlGetAccountState).blockID(&block).int32(addr.Workchain).int256(addr.Hash[:])
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return nil, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlAccountState)
+	st := &AccountState{
+		Block:      r.blockID(),
+		ShardBlock: r.blockID(),
+		ShardProof: r.bytes(),
+		Proof:      r.bytes(),
+		State:      r.bytes(),
+	}
+	return st, r.err
+}
+
+// RunMethodResult is the result of RunSmcMethod
+type RunMethodResult struct {
+	Block      BlockID
+	ShardBlock BlockID
+	ExitCode   int32
+	
+	// Result is the BOC of the resulting VM stack
+	Result []byte
+}
+
+// RunSmcMethod runs a get-method with a serialized VM stack as parameters (see EncodeStack)
+func (c *Client) RunSmcMethod(ctx context.Context, block BlockID, addr *address.Address, methodID uint64, params []byte) (*RunMethodResult, error) {
+	const modeResult = 1 << 2
+	
+	var w tlWriter
+	w.uint32(tlRunSmcMethod).uint32(modeResult).blockID(&block).int32(addr.Workchain).int256(addr.Hash[:])
+	w.int64(int64(methodID)).bytes(params)
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return nil, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlRunMethodResult)
+	mode := r.uint32()
+	res := &RunMethodResult{
+		Block:      r.blockID(),
+		ShardBlock: r.blockID(),
+	}
+	if mode&1 != 0 {
+		r.bytes() // shard_proof
+		r.bytes() // proof
+	}
+	if mode&2 != 0 {
+		r.bytes() // state_proof
+	}
+	if mode&8 != 0 {
+		r.bytes() // init_c7
+	}
+	if mode&16 != 0 {
+		r.bytes() // lib_extras
+	}
+	res.ExitCode = r.int32()
+	if mode&4 != 0 {
+		res.Result = r.bytes()
+	}
+	return res, r.err
+}
+
+// RunGetMethod runs a get-method by name at the last masterchain block and decodes the
+// resulting stack, bottom first
+func (c *Client) RunGetMethod(ctx context.Context, addr *address.Address, method string, args ...interface{}) ([]interface{}, int32, error) {
+	info, err := c.GetMasterchainInfo(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	
+	params, err := EncodeStack(args)
+	if err != nil {
+		return nil, 0, err
+	}
+	
+	res, err := c.RunSmcMethod(ctx, info.Last, addr, MethodID(method), params.ToBOC())
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(res.Result) == 0 {
+		return nil, res.ExitCode, nil
+	}
+	
+	root, err := cell.FromBOC(res.Result)
+	if err != nil {
+		return nil, res.ExitCode, err
+	}
+	stack, err := DecodeStack(root)
+	return stack, res.ExitCode, err
+}
+
+// SendMessage broadcasts an external message BOC and returns the liteserver status
+func (c *Client) SendMessage(ctx context.Context, boc []byte) (int32, error) {
+	var w tlWriter
+	w.uint32(tlSendMessage).bytes(boc)
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return 0, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlSendMsgStatus)
+	status := r.int32()
+	return status, r.err
+}
+
+// TransactionList is the result of GetTransactions
+type TransactionList struct {
+	// Blocks holds the block of every transaction
+	Blocks       []BlockID
+	Transactions []*cell.Cell
+}
+
+// GetTransactions returns up to count transactions of an account, starting with the
+// transaction (lt, hash) and going back in time
+func (c *Client) GetTransactions(ctx context.Context, addr *address.Address, lt uint64, hash []byte, count int) (*TransactionList, error) {
+	var w tlWriter
+	w.uint32(tlGetTransactions).uint32(uint32(count)).int32(addr.Workchain).int256(addr.Hash[:])
+	w.int64(int64(lt)).int256(hash)
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return nil, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlTransactionList)
+	list := &TransactionList{}
+	n := int(r.uint32())
+	for i := 0; i < n && r.err == nil; i++ {
+		list.Blocks = append(list.Blocks, r.blockID())
+	}
+	data := r.bytes()
+	if r.err != nil {
+		return nil, r.err
+	}
+	
+	if len(data) > 0 {
+		list.Transactions, err = cell.FromBOCMultiRoot(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(list.Transactions) != len(list.Blocks) {
+		return nil, fmt.Errorf("liteclient: %d transactions for %d blocks", len(list.Transactions), len(list.Blocks))
+	}
+	return list, nil
+}
+
+// BlockLookup selects a block by seqno, logical time or unix time. Exactly one of
+// SeqNo, Lt and UnixTime should be set.
+type BlockLookup struct {
+	Workchain int32
+	Shard     int64
+	SeqNo     uint32
+	Lt        uint64
+	UnixTime  uint32
+}
+
+// LookupBlock finds the full ID of a block
+func (c *Client) LookupBlock(ctx context.Context, q BlockLookup) (BlockID, error) {
+	var mode uint32
+	switch {
+	case q.Lt != 0:
+		mode = 2
+	case q.UnixTime != 0:
+		mode = 4
+	default:
+		mode = 1
+	}
+	
+	var w tlWriter
+	w.uint32(tlLookupBlock).uint32(mode).int32(q.Workchain).int64(q.Shard).uint32(q.SeqNo)
+	if mode == 2 {
+		w.int64(int64(q.Lt))
+	}
+	if mode == 4 {
+		w.uint32(q.UnixTime)
+	}
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return BlockID{}, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlBlockHeader)
+	id := r.blockID()
+	return id, r.err
+}
+
+// TransactionID is a transaction in a block
+type TransactionID struct {
+	Account []byte
+	Lt      uint64
+	Hash    []byte
+}
+
+// BlockTransactions is the result of ListBlockTransactions
+type BlockTransactions struct {
+	Block        BlockID
+	Incomplete   bool
+	Transactions []TransactionID
+}
+
+// ListBlockTransactions lists up to count transactions of a block, ordered by account
+// and lt, starting after the given transaction if after is not nil
+func (c *Client) ListBlockTransactions(ctx context.Context, block BlockID, count int, after *TransactionID) (*BlockTransactions, error) {
+	mode := uint32(1 | 2 | 4)
+	if after != nil {
+		mode |= 1 << 7
+	}
+	
+	var w tlWriter
+	w.uint32(tlListBlockTransactions).blockID(&block).uint32(mode).uint32(uint32(count))
+	if after != nil {
+		w.int256(after.Account).int64(int64(after.Lt))
+	}
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return nil, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlBlockTransactions)
+	res := &BlockTransactions{Block: r.blockID()}
+	r.uint32() // req_count
+	res.Incomplete = r.bool()
+	n := int(r.uint32())
+	for i := 0; i < n && r.err == nil; i++ {
+		var id TransactionID
+		m := r.uint32()
+		if m&1 != 0 {
+			id.Account = r.int256()
+		}
+		if m&2 != 0 {
+			id.Lt = uint64(r.int64())
+		}
+		if m&4 != 0 {
+			id.Hash = r.int256()
+		}
+		res.Transactions = append(res.Transactions, id)
+	}
+	r.bytes() // proof
+	return res, r.err
+}
+
+// BlockSignature is a validator signature of a block
+type BlockSignature struct {
+	// NodeID is the short ID (key hash) of the validator
+	NodeID    []byte
+	Signature []byte
+}
+
+// BlockLink is a step of a block proof. A forward link proves To with the signatures
+// of the validators of From, a key block; a backward link proves To through the
+// previous blocks recorded in the state of From.
+type BlockLink struct {
+	Forward    bool
+	ToKeyBlock bool
+	From       BlockID
+	To         BlockID
+	
+	// DestProof is a Merkle proof of the header of To
+	DestProof []byte
+	
+	// Proof and StateProof prove the state of From in backward links
+	Proof      []byte
+	StateProof []byte
+	
+	// ConfigProof proves the config of From in forward links
+	ConfigProof    []byte
+	CatchainSeqNo  uint32
+	ValidatorsHash uint32
+	Signatures     []BlockSignature
+}
+
+// BlockProof is the result of GetBlockProof. It may stop before the target block;
+// the proof is then continued from its To block.
+type BlockProof struct {
+	Complete bool
+	From     BlockID
+	To       BlockID
+	Steps    []BlockLink
+}
+
+// GetBlockProof returns a chain of links from the known masterchain block to the target
+// block, or to the last masterchain block if target is nil
+func (c *Client) GetBlockProof(ctx context.Context, known BlockID, target *BlockID) (*BlockProof, error) {
+	var mode uint32
+	if target != nil {
+		mode = 1
+	}
+	
+	var w tlWriter
+	w.uint32(tlGetBlockProof).uint32(mode).blockID(&known)
+	if target != nil {
+		w.blockID(target)
+	}
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return nil, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlPartialBlockProof)
+	proof := &BlockProof{
+		Complete: r.bool(),
+		From:     r.blockID(),
+		To:       r.blockID(),
+	}
+	n := int(r.uint32())
+	for i := 0; i < n && r.err == nil; i++ {
+		var link BlockLink
+		switch r.uint32() {
+		case tlBlockLinkBack:
+		case tlBlockLinkForward:
+			link.Forward = true
+		default:
+			if r.err == nil {
+				r.err = fmt.Errorf("%w: unknown block link", errTL)
+			}
+			continue
+		}
+		link.ToKeyBlock = r.bool()
+		link.From = r.blockID()
+		link.To = r.blockID()
+		link.DestProof = r.bytes()
+		if !link.Forward {
+			link.Proof = r.bytes()
+			link.StateProof = r.bytes()
+			proof.Steps = append(proof.Steps, link)
+			continue
+		}
+		
+		link.ConfigProof = r.bytes()
+		r.expect(tlSignatureSet)
+		link.ValidatorsHash = r.uint32()
+		link.CatchainSeqNo = r.uint32()
+		m := int(r.uint32())
+		for j := 0; j < m && r.err == nil; j++ {
+			r.expect(tlSignature)
+			link.Signatures = append(link.Signatures, BlockSignature{NodeID: r.int256(), Signature: r.bytes()})
+		}
+		proof.Steps = append(proof.Steps, link)
+	}
+	return proof, r.err
+}
+
+// ConfigInfo is the result of GetConfigParams
+type ConfigInfo struct {
+	Block       BlockID
+	StateProof  []byte
+	ConfigProof []byte
+}
+
+// GetConfigParams returns Merkle proofs of config params at a masterchain block. With
+// fromKeyBlock the params are read from the block itself, which must be a key block,
+// and ConfigProof proves the block; otherwise StateProof proves the block and
+// ConfigProof its state.
+func (c *Client) GetConfigParams(ctx context.Context, block BlockID, fromKeyBlock bool, params ...int32) (*ConfigInfo, error) {
+	var mode uint32
+	if fromKeyBlock {
+		mode = 0x8000
+	}
+	
+	var w tlWriter
+	w.uint32(tlGetConfigParams).uint32(mode).blockID(&block).uint32(uint32(len(params)))
+	for _, p := range params {
+		w.int32(p)
+	}
+	
+	answer, err := c.Query(ctx, w.buf)
+	if err != nil {
+		return nil, err
+	}
+	
+	r := &tlReader{data: answer}
+	r.expect(tlConfigInfo)
+	r.uint32() // mode
+	info := &ConfigInfo{
+		Block:       r.blockID(),
+		StateProof:  r.bytes(),
+		ConfigProof: r.bytes(),
+	}
+	return info, r.err
+}
+
+// MethodID returns the get-method ID of a method name
+func MethodID(name string) uint64 {
+	var crc uint16
+	for _, b := range []byte(name) {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return uint64(crc) | 0x10000
+}
@@ -0,0 +1,207 @@
+package liteclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startServer runs a fake liteserver on a local port and returns a client for it
+func startServer(t *testing.T, handler Handler) (*Server, *Client) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(key, handler)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+	
+	c := NewClient(l.Addr().String(), key.Public().(ed25519.PublicKey))
+	c.Timeout = 5 * time.Second
+	t.Cleanup(func() { c.Close() })
+	return srv, c
+}
+
+// echo answers every request with the request itself
+func echo(request []byte) ([]byte, error) {
+	return request, nil
+}
+
+func hash32(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestGetMasterchainInfo(t *testing.T) {
+	last := BlockID{Workchain: -1, Shard: -1 << 63, SeqNo: 42, RootHash: hash32(1), FileHash: hash32(2)}
+	_, c := startServer(t, func(request []byte) ([]byte, error) {
+		r := &tlReader{data: request}
+		if id := r.uint32(); id != tlGetMasterchainInfo {
+			return nil, fmt.Errorf("unexpected function %08x", id)
+		}
+		var w tlWriter
+		w.uint32(tlMasterchainInfo).blockID(&last).int256(hash32(3)).int32(-1).int256(hash32(4)).int256(hash32(5))
+		return w.buf, nil
+	})
+	
+	info, err := c.GetMasterchainInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameBlock(info.Last, last) || info.Last.SeqNo != 42 || info.Last.Shard != -1<<63 {
+		t.Errorf("last block %+v, want %+v", info.Last, last)
+	}
+	if !bytes.Equal(info.StateRootHash, hash32(3)) || info.Init.Workchain != -1 || !bytes.Equal(info.Init.FileHash, hash32(5)) {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func TestQueryPayloadSizes(t *testing.T) {
+	_, c := startServer(t, echo)
+	
+	// TL bytes switch to the long length form at 254 bytes; the session ciphers
+	// must stay in step across packets of every size
+	for _, n := range []int{0, 1, 3, 4, 253, 254, 255, 1000, 70000} {
+		request := make([]byte, n)
+		for i := range request {
+			request[i] = byte(i * 7)
+		}
+		answer, err := c.Query(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(answer, request) {
+			t.Fatalf("%d bytes: answer differs from request", n)
+		}
+	}
+}
+
+func TestQueryConcurrent(t *testing.T) {
+	_, c := startServer(t, func(request []byte) ([]byte, error) {
+		// Answer out of order so that answers are matched by query id
+		time.Sleep(time.Duration(request[0]%5) * time.Millisecond)
+		return request, nil
+	})
+	
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			request := []byte{byte(i), byte(i >> 8), 1, 2, 3}
+			answer, err := c.Query(context.Background(), request)
+			if err == nil && !bytes.Equal(answer, request) {
+				err = fmt.Errorf("query %d got the answer of another query", i)
+			}
+			if err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestQueryLiteServerError(t *testing.T) {
+	_, c := startServer(t, func(request []byte) ([]byte, error) {
+		return nil, errors.New("block not found")
+	})
+	
+	_, err := c.GetTime(context.Background())
+	var liteErr *Error
+	if !errors.As(err, &liteErr) {
+		t.Fatalf("error %v, want *Error", err)
+	}
+	if liteErr.Code != 500 || liteErr.Message != "block not found" {
+		t.Errorf("error %+v", liteErr)
+	}
+}
+
+func TestHandshakeWrongServerKey(t *testing.T) {
+	_, c := startServer(t, echo)
+	other, _, _ := ed25519.GenerateKey(nil)
+	c.endpoints[0].key = other
+	c.Timeout = time.Second
+	
+	if _, err := c.Query(context.Background(), []byte{1}); err == nil {
+		t.Fatal("query succeeded with the wrong server key")
+	}
+}
+
+func TestPingKeepsConnection(t *testing.T) {
+	_, c := startServer(t, echo)
+	c.PingInterval = 10 * time.Millisecond
+	
+	if _, err := c.Query(context.Background(), []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	first := c.conn
+	time.Sleep(100 * time.Millisecond)
+	if _, err := c.Query(context.Background(), []byte{2}); err != nil {
+		t.Fatal(err)
+	}
+	if c.conn != first {
+		t.Error("connection was replaced although the server answered pings")
+	}
+}
+
+func TestFailoverToNextServer(t *testing.T) {
+	_, c := startServer(t, echo)
+	
+	// A server that is gone comes first
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := l.Addr().String()
+	l.Close()
+	c.endpoints = append([]endpoint{{addr: dead, key: c.endpoints[0].key}}, c.endpoints...)
+	
+	answer, err := c.Query(context.Background(), []byte{7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(answer, []byte{7}) {
+		t.Errorf("answer %x", answer)
+	}
+}
+
+func TestReconnectAfterServerDropsConnection(t *testing.T) {
+	srv, c := startServer(t, echo)
+	
+	if _, err := c.Query(context.Background(), []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	srv.mu.Lock()
+	for conn := range srv.conns {
+		conn.close(nil)
+	}
+	srv.mu.Unlock()
+	<-c.conn.done
+	
+	if _, err := c.Query(context.Background(), []byte{2}); err != nil {
+		t.Fatalf("query after reconnect: %v", err)
+	}
+}
+
+func TestClosedClient(t *testing.T) {
+	_, c := startServer(t, echo)
+	c.Close()
+	if _, err := c.Query(context.Background(), []byte{1}); !errors.Is(err, ErrClosed) {
+		t.Errorf("error %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,123 @@
+package liteclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// Public global config URLs
+const (
+	MainnetConfigURL = "https://ton.org/global-config.json"
+	TestnetConfigURL = "https://ton.org/testnet-global.config.json"
+)
+
+// GlobalConfig is the part of a TON global config JSON used by the client
+type GlobalConfig struct {
+	LiteServers []LiteServer    `json:"liteservers"`
+	Validator   ValidatorConfig `json:"validator"`
+}
+
+// LiteServer is a liteserver entry of the global config
+type LiteServer struct {
+	IP   int64 `json:"ip"`
+	Port int   `json:"port"`
+	ID   struct {
+		Type string `json:"@type"`
+		Key  string `json:"key"`
+	} `json:"id"`
+}
+
+// Addr returns the host:port of the liteserver
+func (s LiteServer) Addr() string {
+	var ip [4]byte
+	binary.BigEndian.PutUint32(ip[:], uint32(s.IP))
+	return net.JoinHostPort(net.IP(ip[:]).String(), strconv.Itoa(s.Port))
+}
+
+// PublicKey decodes the ed25519 key of the liteserver
+func (s LiteServer) PublicKey() (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(s.ID.Key)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("liteclient: invalid liteserver key %q", s.ID.Key)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// ValidatorConfig holds the trusted blocks of the global config
+type ValidatorConfig struct {
+	ZeroState ConfigBlock   `json:"zero_state"`
+	InitBlock ConfigBlock   `json:"init_block"`
+	Hardforks []ConfigBlock `json:"hardforks"`
+}
+
+// ConfigBlock is a block ID as written in the global config
+type ConfigBlock struct {
+	Workchain int32  `json:"workchain"`
+	Shard     int64  `json:"shard"`
+	SeqNo     uint32 `json:"seqno"`
+	RootHash  []byte `json:"root_hash"`
+	FileHash  []byte `json:"file_hash"`
+}
+
+// BlockID converts the config block to a BlockID
+func (b ConfigBlock) BlockID() BlockID {
+	return BlockID{
+		Workchain: b.Workchain,
+		Shard:     b.Shard,
+		SeqNo:     b.SeqNo,
+		RootHash:  b.RootHash,
+		FileHash:  b.FileHash,
+	}
+}
+
+// ParseConfig parses a global config JSON
+func ParseConfig(data []byte) (*GlobalConfig, error) {
+	var cfg GlobalConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("liteclient: parsing global config: %w", err)
+	}
+	if len(cfg.LiteServers) == 0 {
+		return nil, fmt.Errorf("liteclient: global config has no liteservers")
+	}
+	return &cfg, nil
+}
+
+// LoadConfig reads a global config from a file
+func LoadConfig(path string) (*GlobalConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data)
+}
+
+// FetchConfig downloads a global config, e.g. MainnetConfigURL
+func FetchConfig(ctx context.Context, url string) (*GlobalConfig, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("liteclient: fetching global config: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data)
+}
@@ -0,0 +1,32 @@
+package liteclient
+
+import (
+	"errors"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// dictLookup finds key in the Hashmap rooted at root. skipExtra, if not nil, skips
+// the augmentation stored in every fork and leaf of an augmented dictionary. It
+// returns nil if the key is absent.
+func dictLookup(root *cell.Cell, key []byte, keyLen int, skipExtra func(*cell.Slice)) (*cell.Slice, error) {
+	if skipExtra == nil {
+		v, err := cell.LoadDict(root, keyLen).Get(key)
+		return v, dictError(err)
+	}
+	v, _, err := cell.LoadAugDict(root, keyLen, &cell.Augmentation{Skip: skipExtra}).Get(key)
+	return v, dictError(err)
+}
+
+// dictForEach calls fn for every value of the Hashmap rooted at root in key order
+func dictForEach(root *cell.Cell, keyLen int, fn func(key []byte, value *cell.Slice) error) error {
+	return dictError(cell.LoadDict(root, keyLen).ForEach(fn))
+}
+
+// dictError reports pruned dictionary paths as invalid proofs
+func dictError(err error) error {
+	if errors.Is(err, cell.ErrPruned) {
+		return invalidProof("dictionary path is pruned")
+	}
+	return err
+}
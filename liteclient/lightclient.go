@@ -0,0 +1,309 @@
+package liteclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"math/bits"
+	"sync"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/config"
+)
+
+// Tags of the block extra structures holding the config of a key block
+const (
+	blockExtraTag   = 0x4a33f6fd
+	mcBlockExtraTag = 0xcca5
+)
+
+// LightClient follows the masterchain from a trusted key block. A block is accepted
+// only if it is signed by validators holding over 2/3 of the weight of the validator
+// set of a key block already accepted, or recorded in the state of an accepted block,
+// so liteserver answers can be verified against Last without trusting the server.
+type LightClient struct {
+	Client *Client
+	
+	mu         sync.Mutex
+	keyBlock   BlockID
+	validators *config.ValidatorSet
+	last       BlockID
+}
+
+// NewLightClient creates a light client trusting the key block init, e.g. the init
+// block of the global config
+func NewLightClient(c *Client, init BlockID) *LightClient {
+	return &LightClient{Client: c, keyBlock: init, last: init}
+}
+
+// Last returns the newest proven masterchain block
+func (lc *LightClient) Last() BlockID {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.last
+}
+
+// KeyBlock returns the newest proven key block
+func (lc *LightClient) KeyBlock() BlockID {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.keyBlock
+}
+
+// Validators returns the validator set of the newest proven key block, which signs
+// the masterchain blocks after it
+func (lc *LightClient) Validators(ctx context.Context) (*config.ValidatorSet, error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.validators != nil {
+		return lc.validators, nil
+	}
+	
+	info, err := lc.Client.GetConfigParams(ctx, lc.keyBlock, true, config.ParamValidators)
+	if err != nil {
+		return nil, err
+	}
+	root, err := cell.FromBOC(info.ConfigProof)
+	if err != nil {
+		return nil, err
+	}
+	vs, err := keyBlockValidators(root, lc.keyBlock)
+	if err != nil {
+		return nil, err
+	}
+	lc.validators = vs
+	return vs, nil
+}
+
+// Sync proves the last masterchain block of the liteserver and returns it
+func (lc *LightClient) Sync(ctx context.Context) (BlockID, error) {
+	info, err := lc.Client.GetMasterchainInfo(ctx)
+	if err != nil {
+		return BlockID{}, err
+	}
+	if err := lc.Prove(ctx, info.Last); err != nil {
+		return BlockID{}, err
+	}
+	return info.Last, nil
+}
+
+// Prove proves the masterchain block target from the newest proven block, following
+// key blocks forward or previous block records backward. A newer target becomes the
+// newest proven block.
+func (lc *LightClient) Prove(ctx context.Context, target BlockID) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	
+	st := &lightState{cur: lc.last, keyBlock: lc.keyBlock, validators: lc.validators}
+	for !sameBlock(st.cur, target) {
+		proof, err := lc.Client.GetBlockProof(ctx, st.cur, &target)
+		if err != nil {
+			return err
+		}
+		if !sameBlock(proof.From, st.cur) {
+			return invalidProof("block proof starts at another block")
+		}
+		if len(proof.Steps) == 0 {
+			return invalidProof("block proof has no links")
+		}
+		for _, link := range proof.Steps {
+			if err := st.check(link); err != nil {
+				return err
+			}
+		}
+		if !sameBlock(st.cur, proof.To) || proof.Complete && !sameBlock(st.cur, target) {
+			return invalidProof("block proof does not end at its target")
+		}
+	}
+	
+	if target.SeqNo > lc.last.SeqNo {
+		lc.last, lc.keyBlock, lc.validators = st.cur, st.keyBlock, st.validators
+	}
+	return nil
+}
+
+// lightState is the progress of a light client through a block proof
+type lightState struct {
+	cur        BlockID
+	keyBlock   BlockID
+	validators *config.ValidatorSet
+}
+
+// check checks a link starting at the current block and moves to its end
+func (st *lightState) check(link BlockLink) error {
+	if !sameBlock(link.From, st.cur) {
+		return invalidProof("link does not start at the last proven block")
+	}
+	if link.To.Workchain != -1 {
+		return invalidProof("link to a non-masterchain block")
+	}
+	
+	if link.Forward {
+		if link.To.SeqNo <= link.From.SeqNo {
+			return invalidProof("forward link to an older block")
+		}
+		root, err := cell.FromBOC(link.ConfigProof)
+		if err != nil {
+			return err
+		}
+		vs, err := keyBlockValidators(root, link.From)
+		if err != nil {
+			return err
+		}
+		if err := CheckBlockSignatures(vs, link.To, link.Signatures); err != nil {
+			return err
+		}
+		st.keyBlock, st.validators = link.From, vs
+	} else {
+		proof, err := cell.FromBOC(link.Proof)
+		if err != nil {
+			return err
+		}
+		stateProof, err := cell.FromBOC(link.StateProof)
+		if err != nil {
+			return err
+		}
+		state, _, err := checkBlockState(proof, stateProof, link.From)
+		if err != nil {
+			return err
+		}
+		if err := checkPrevMcBlock(state, link.To); err != nil {
+			return err
+		}
+	}
+	
+	if link.ToKeyBlock || len(link.DestProof) > 0 {
+		dest, err := cell.FromBOC(link.DestProof)
+		if err != nil {
+			return err
+		}
+		if err := checkKeyBlockFlag(dest, link.To, link.ToKeyBlock); err != nil {
+			return err
+		}
+	}
+	
+	st.cur = link.To
+	if link.Forward && link.ToKeyBlock {
+		st.keyBlock, st.validators = link.To, nil
+	}
+	return nil
+}
+
+// keyBlockValidators reads the current validator set (config param 34) from a Merkle
+// proof of the key block id
+func keyBlockValidators(proof *cell.Cell, id BlockID) (*config.ValidatorSet, error) {
+	block, info, err := checkBlock(proof, id)
+	if err != nil {
+		return nil, err
+	}
+	if !info.keyBlock {
+		return nil, invalidProof("block %d is not a key block", id.SeqNo)
+	}
+	
+	// block_extra#4a33f6fd in_msg_descr:^InMsgDescr out_msg_descr:^OutMsgDescr
+	// account_blocks:^ShardAccountBlocks rand_seed:bits256 created_by:bits256
+	// custom:(Maybe ^McBlockExtra)
+	extra, err := parseProven(block.Ref(3), "block extra")
+	if err != nil {
+		return nil, err
+	}
+	if extra.LoadUInt(32) != blockExtraTag {
+		return nil, invalidProof("not a block extra")
+	}
+	extra.LoadRef()
+	extra.LoadRef()
+	extra.LoadRef()
+	extra.Skip(256 + 256)
+	mc, err := parseProven(extra.LoadMaybeRef(), "masterchain block extra")
+	if err != nil {
+		return nil, err
+	}
+	
+	// masterchain_block_extra#cca5 key_block:(## 1) shard_hashes:ShardHashes
+	// shard_fees:ShardFees ^[ ... ] config:key_block?ConfigParams
+	if mc.LoadUInt(16) != mcBlockExtraTag || !mc.LoadBit() {
+		return nil, invalidProof("key block has no config")
+	}
+	mc.LoadMaybeRef() // shard_hashes
+	mc.LoadMaybeRef() // shard_fees
+	for i := 0; i < 2; i++ {
+		// ShardFeeCreated fees:CurrencyCollection create:CurrencyCollection
+		mc.LoadCoins()
+		mc.LoadMaybeRef()
+	}
+	mc.LoadRef()
+	mc.Skip(256) // config_addr
+	params := mc.LoadRef()
+	if err := mc.Err(); err != nil {
+		return nil, err
+	}
+	
+	key := []byte{0, 0, 0, config.ParamValidators}
+	v, err := dictLookup(params, key, 32, nil)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, invalidProof("key block config has no validator set")
+	}
+	return config.ParseValidatorSet(v.LoadRef())
+}
+
+// CheckBlockSignatures checks that masterchain validators of vs holding more than 2/3
+// of their total weight signed the block id. Signatures of unknown nodes are ignored.
+func CheckBlockSignatures(vs *config.ValidatorSet, id BlockID, sigs []BlockSignature) error {
+	var msg tlWriter
+	msg.uint32(tlTonBlockID).int256(id.RootHash).int256(id.FileHash)
+	
+	validators := vs.MainValidators()
+	index := make(map[string]int, len(validators))
+	var total uint64
+	for i, v := range validators {
+		index[string(keyID(v.PublicKey))] = i
+		total += v.Weight
+	}
+	
+	signed := make([]bool, len(validators))
+	var weight uint64
+	for _, sig := range sigs {
+		i, ok := index[string(sig.NodeID)]
+		if !ok || signed[i] {
+			continue
+		}
+		if !ed25519.Verify(validators[i].PublicKey, msg.buf, sig.Signature) {
+			return invalidProof("bad signature of validator %x", sig.NodeID)
+		}
+		signed[i] = true
+		weight += validators[i].Weight
+	}
+	
+	// weight*3 > total*2 without overflowing
+	hi, lo := bits.Mul64(weight, 3)
+	thi, tlo := bits.Mul64(total, 2)
+	if hi < thi || hi == thi && lo <= tlo {
+		return invalidProof("signers hold %d of %d validator weight", weight, total)
+	}
+	return nil
+}
+
+// VerifyBlockSignatures checks the answer of getMasterchainBlockSignatures for the
+// block id against a validator set, see CheckBlockSignatures
+func VerifyBlockSignatures(resp *toncenterzp.GetMasterchainBlockSignaturesResponse, id BlockID, vs *config.ValidatorSet) error {
+	sigs := make([]BlockSignature, 0, len(resp.Result.Signatures))
+	for _, s := range resp.Result.Signatures {
+		nodeID, err := decodeHash(s.NodeID)
+		if err != nil {
+			return err
+		}
+		r, err := decodeHash(s.R)
+		if err != nil {
+			return err
+		}
+		sv, err := decodeHash(s.S)
+		if err != nil {
+			return err
+		}
+		sigs = append(sigs, BlockSignature{NodeID: nodeID, Signature: append(r, sv...)})
+	}
+	return CheckBlockSignatures(vs, id, sigs)
+}
@@ -0,0 +1,227 @@
+package liteclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/config"
+)
+
+// validatorSet returns a validator set of n validators of weight 1 and their keys
+func validatorSet(t *testing.T, n int) (*cell.Cell, []ed25519.PrivateKey) {
+	t.Helper()
+	list := cell.NewDict(16)
+	var keys []ed25519.PrivateKey
+	for i := 0; i < n; i++ {
+		pub, key, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+		v := must(cell.BeginCell().
+			StoreUInt(0x53, 8).        // validator
+			StoreUInt(0x8e81278a, 32). // ed25519_pubkey
+			StoreBytes(pub).
+			StoreUInt(1, 64). // weight
+			EndCell())
+		if err := list.Set(cell.UintKey(uint64(i), 16), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	vs := must(cell.BeginCell().
+		StoreUInt(0x12, 8). // validators_ext
+		StoreUInt(1, 32).   // utime_since
+		StoreUInt(2, 32).   // utime_until
+		StoreUInt(uint64(n), 16).
+		StoreUInt(uint64(n), 16).
+		StoreUInt(uint64(n), 64).
+		StoreDict(list).
+		EndCell())
+	return vs, keys
+}
+
+// keyBlock returns masterchain key block seqNo whose config holds the validator set vs
+func keyBlock(t *testing.T, seqNo uint32, vs *cell.Cell) *cell.Cell {
+	t.Helper()
+	params := cell.NewDict(32)
+	if err := params.Set(cell.UintKey(config.ParamValidators, 32), must(cell.BeginCell().StoreRef(vs).EndCell())); err != nil {
+		t.Fatal(err)
+	}
+	mcExtra := must(cell.BeginCell().
+		StoreUInt(mcBlockExtraTag, 16).
+		StoreBit(true).  // key_block
+		StoreBit(false). // shard_hashes
+		StoreBit(false). // shard_fees
+		StoreCoins(big.NewInt(0)).StoreBit(false).
+		StoreCoins(big.NewInt(0)).StoreBit(false).
+		StoreRef(prune(filler(9))).
+		StoreBytes(hash(0)). // config_addr
+		StoreDict(params).
+		EndCell())
+	extra := must(cell.BeginCell().
+		StoreUInt(blockExtraTag, 32).
+		StoreRef(prune(filler(10))).
+		StoreRef(prune(filler(11))).
+		StoreRef(prune(filler(12))).
+		StoreBytes(hash(0)). // rand_seed
+		StoreBytes(hash(0)). // created_by
+		StoreMaybeRef(mcExtra).
+		EndCell())
+	return must(cell.BeginCell().
+		StoreUInt(blockTag, 32).
+		StoreUInt(0, 32).
+		StoreRef(testBlockInfo(-1, seqNo, true, BlockID{RootHash: hash(0), FileHash: hash(0)})).
+		StoreRef(prune(filler(1))).
+		StoreRef(merkleUpdate(prune(filler(2)), prune(filler(3)))).
+		StoreRef(extra).
+		EndCell())
+}
+
+func masterchainID(seqNo uint32, block *cell.Cell) BlockID {
+	return BlockID{Workchain: -1, Shard: -1 << 63, SeqNo: seqNo, RootHash: block.HashAt(0), FileHash: hash(byte(seqNo))}
+}
+
+func signBlock(id BlockID, keys []ed25519.PrivateKey) []BlockSignature {
+	var msg tlWriter
+	msg.uint32(tlTonBlockID).int256(id.RootHash).int256(id.FileHash)
+	var sigs []BlockSignature
+	for _, key := range keys {
+		sigs = append(sigs, BlockSignature{NodeID: keyID(key.Public().(ed25519.PublicKey)), Signature: ed25519.Sign(key, msg.buf)})
+	}
+	return sigs
+}
+
+func TestCheckBlockSignatures(t *testing.T) {
+	root, keys := validatorSet(t, 3)
+	vs, err := config.ParseValidatorSet(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs.Validators) != 3 {
+		t.Fatalf("%d validators, want 3", len(vs.Validators))
+	}
+	id := masterchainID(5, filler(5))
+	
+	if err := CheckBlockSignatures(vs, id, signBlock(id, keys)); err != nil {
+		t.Errorf("all validators: %v", err)
+	}
+	// Exactly 2/3 of the weight is not enough
+	if err := CheckBlockSignatures(vs, id, signBlock(id, keys[:2])); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("2 of 3 validators: error %v, want ErrInvalidProof", err)
+	}
+	// A repeated signature counts once
+	sigs := signBlock(id, keys[:2])
+	if err := CheckBlockSignatures(vs, id, append(sigs, sigs[0])); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("repeated signature: error %v, want ErrInvalidProof", err)
+	}
+	
+	sigs = signBlock(id, keys)
+	sigs[1].Signature[0] ^= 1
+	if err := CheckBlockSignatures(vs, id, sigs); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("bad signature: error %v, want ErrInvalidProof", err)
+	}
+	
+	other := id
+	other.FileHash = hash(99)
+	if err := CheckBlockSignatures(vs, other, signBlock(id, keys)); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("signatures of another block: error %v, want ErrInvalidProof", err)
+	}
+}
+
+// lightServer serves the config of the key block and a forward proof from it to
+// target signed by signers
+func lightServer(t *testing.T, key *cell.Cell, keyID, target BlockID, targetBlock *cell.Cell, signers []ed25519.PrivateKey) *Client {
+	t.Helper()
+	_, c := startServer(t, func(request []byte) ([]byte, error) {
+		r := &tlReader{data: request}
+		var w tlWriter
+		switch r.uint32() {
+		case tlGetBlockProof:
+			w.uint32(tlPartialBlockProof).uint32(tlBoolTrue).blockID(&keyID).blockID(&target).uint32(1)
+			w.uint32(tlBlockLinkForward).uint32(tlBoolFalse).blockID(&keyID).blockID(&target)
+			w.bytes(merkleProof(targetBlock).ToBOC()).bytes(merkleProof(key).ToBOC())
+			sigs := signBlock(target, signers)
+			w.uint32(tlSignatureSet).uint32(0).uint32(0).uint32(uint32(len(sigs)))
+			for _, s := range sigs {
+				w.uint32(tlSignature).int256(s.NodeID).bytes(s.Signature)
+			}
+		case tlGetConfigParams:
+			w.uint32(tlConfigInfo).uint32(0x8000).blockID(&keyID).bytes(nil).bytes(merkleProof(key).ToBOC())
+		default:
+			return nil, errors.New("unexpected query")
+		}
+		return w.buf, nil
+	})
+	return c
+}
+
+func TestLightClientProve(t *testing.T) {
+	root, keys := validatorSet(t, 3)
+	key := keyBlock(t, 100, root)
+	keyID := masterchainID(100, key)
+	block := testBlock(testBlockInfo(-1, 105, false, keyID), filler(4))
+	target := masterchainID(105, block)
+	
+	lc := NewLightClient(lightServer(t, key, keyID, target, block, keys), keyID)
+	vs, err := lc.Validators(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs.Validators) != 3 {
+		t.Errorf("%d validators, want 3", len(vs.Validators))
+	}
+	if err := lc.Prove(context.Background(), target); err != nil {
+		t.Fatal(err)
+	}
+	if !sameBlock(lc.Last(), target) {
+		t.Errorf("last block %+v, want %+v", lc.Last(), target)
+	}
+}
+
+func TestLightClientRejectsWeakSignatures(t *testing.T) {
+	root, keys := validatorSet(t, 3)
+	key := keyBlock(t, 100, root)
+	keyID := masterchainID(100, key)
+	block := testBlock(testBlockInfo(-1, 105, false, keyID), filler(4))
+	target := masterchainID(105, block)
+	
+	lc := NewLightClient(lightServer(t, key, keyID, target, block, keys[:2]), keyID)
+	if err := lc.Prove(context.Background(), target); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("error %v, want ErrInvalidProof", err)
+	}
+	if !sameBlock(lc.Last(), keyID) {
+		t.Error("light client moved to an unproven block")
+	}
+}
+
+func TestLightClientRejectsForeignValidators(t *testing.T) {
+	root, _ := validatorSet(t, 3)
+	_, others := validatorSet(t, 3)
+	key := keyBlock(t, 100, root)
+	keyID := masterchainID(100, key)
+	block := testBlock(testBlockInfo(-1, 105, false, keyID), filler(4))
+	target := masterchainID(105, block)
+	
+	lc := NewLightClient(lightServer(t, key, keyID, target, block, others), keyID)
+	if err := lc.Prove(context.Background(), target); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("error %v, want ErrInvalidProof", err)
+	}
+}
+
+func TestLightClientRejectsOrdinaryBlockAsKeyBlock(t *testing.T) {
+	root, keys := validatorSet(t, 3)
+	key := keyBlock(t, 100, root)
+	keyID := masterchainID(100, key)
+	block := testBlock(testBlockInfo(-1, 105, false, keyID), filler(4))
+	target := masterchainID(105, block)
+	
+	// Trusting block 105, whose proof the server passes off as the key block proof
+	lc := NewLightClient(lightServer(t, block, target, target, block, keys), target)
+	if _, err := lc.Validators(context.Background()); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("error %v, want ErrInvalidProof", err)
+	}
+}
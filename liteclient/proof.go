@@ -0,0 +1,603 @@
+package liteclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// ErrInvalidProof is returned when a proof does not prove the data it comes with,
+// i.e. the answer of the server was tampered with or is incomplete
+var ErrInvalidProof = errors.New("liteclient: invalid proof")
+
+// invalidProof wraps ErrInvalidProof with a description
+func invalidProof(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidProof, fmt.Sprintf(format, args...))
+}
+
+// Tags of the structures read from proofs
+const (
+	blockTag        = 0x11ef55aa
+	blockInfoTag    = 0x9bc7a987
+	mcStateExtraTag = 0xcc26
+)
+
+// CheckMerkleProof checks that root is a Merkle proof of a cell with the given
+// representation hash and returns that cell. Subtrees left out of the proof are
+// pruned branches and cannot be read.
+func CheckMerkleProof(root *cell.Cell, hash []byte) (*cell.Cell, error) {
+	if root == nil || root.Type() != cell.MerkleProof {
+		return nil, invalidProof("not a merkle proof")
+	}
+	proven := root.Ref(0)
+	if !bytes.Equal(proven.HashAt(0), hash) {
+		return nil, invalidProof("merkle proof hash mismatch")
+	}
+	return proven, nil
+}
+
+// parseProven begins parsing a cell of a proof, failing if it was pruned
+func parseProven(c *cell.Cell, what string) (*cell.Slice, error) {
+	if c == nil {
+		return nil, invalidProof("%s is missing", what)
+	}
+	if c.Type() == cell.PrunedBranch {
+		return nil, invalidProof("%s is pruned", what)
+	}
+	return c.BeginParse(), nil
+}
+
+// sameBlock reports whether two block IDs refer to the same block
+func sameBlock(a, b BlockID) bool {
+	return a.Workchain == b.Workchain && a.Shard == b.Shard && a.SeqNo == b.SeqNo &&
+		bytes.Equal(a.RootHash, b.RootHash) && bytes.Equal(a.FileHash, b.FileHash)
+}
+
+// blockInfo is the part of a BlockInfo needed to check proofs
+type blockInfo struct {
+	workchain int32
+	shard     int64
+	seqNo     uint32
+	keyBlock  bool
+	
+	// prev holds the ExtBlkRef of the previous block, two after a merge
+	prev []*cell.Slice
+}
+
+// checkBlock checks a Merkle proof of the block id and returns the proven block
+// cell and its header
+func checkBlock(proof *cell.Cell, id BlockID) (*cell.Cell, *blockInfo, error) {
+	block, err := CheckMerkleProof(proof, id.RootHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	s, err := parseProven(block, "block")
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.LoadUInt(32) != blockTag {
+		return nil, nil, invalidProof("not a block")
+	}
+	infoCell := s.LoadRef()
+	info, err := parseBlockInfo(infoCell)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.workchain != id.Workchain || info.shard != id.Shard || info.seqNo != id.SeqNo {
+		return nil, nil, invalidProof("block header does not match block %d:%x:%d", id.Workchain, uint64(id.Shard), id.SeqNo)
+	}
+	return block, info, nil
+}
+
+// parseBlockInfo reads a BlockInfo
+func parseBlockInfo(c *cell.Cell) (*blockInfo, error) {
+	s, err := parseProven(c, "block info")
+	if err != nil {
+		return nil, err
+	}
+	if s.LoadUInt(32) != blockInfoTag {
+		return nil, invalidProof("not a block info")
+	}
+	
+	info := &blockInfo{}
+	s.Skip(32) // version
+	notMaster := s.LoadBit()
+	afterMerge := s.LoadBit()
+	s.Skip(4) // before_split, after_split, want_split, want_merge
+	info.keyBlock = s.LoadBit()
+	s.Skip(1 + 8) // vert_seqno_incr, flags
+	info.seqNo = uint32(s.LoadUInt(32))
+	s.Skip(32) // vert_seq_no
+	
+	// shard_ident$00 shard_pfx_bits:(#<= 60) workchain_id:int32 shard_prefix:uint64
+	s.Skip(2)
+	pfxBits := s.LoadUInt(6)
+	info.workchain = int32(s.LoadInt(32))
+	info.shard = int64(s.LoadUInt(64) | 1<<(63-pfxBits))
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	
+	// master_ref:not_master?^BlkMasterInfo prev_ref:^(BlkPrevInfo after_merge)
+	prevRef := c.Ref(0)
+	if notMaster {
+		prevRef = c.Ref(1)
+	}
+	prev, err := parseProven(prevRef, "previous block reference")
+	if err != nil {
+		return nil, err
+	}
+	if !afterMerge {
+		info.prev = []*cell.Slice{prev}
+		return info, nil
+	}
+	for i := 0; i < 2; i++ {
+		p, err := parseProven(prev.LoadRef(), "previous block reference")
+		if err != nil {
+			return nil, err
+		}
+		info.prev = append(info.prev, p)
+	}
+	return info, nil
+}
+
+// blockStateHash returns the hash of the shard state after the proven block
+func blockStateHash(block *cell.Cell) ([]byte, error) {
+	// block#11ef55aa global_id:int32 info:^BlockInfo value_flow:^ValueFlow
+	// state_update:^(MERKLE_UPDATE ShardState) extra:^BlockExtra
+	update := block.Ref(2)
+	if update == nil || update.Type() != cell.MerkleUpdate {
+		return nil, invalidProof("block state update is missing")
+	}
+	return update.Data()[1+32 : 1+64], nil
+}
+
+// checkBlockState checks the proofs of a block and of its state and returns the proven state
+func checkBlockState(blockProof, stateProof *cell.Cell, id BlockID) (*cell.Cell, *blockInfo, error) {
+	block, info, err := checkBlock(blockProof, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash, err := blockStateHash(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	state, err := CheckMerkleProof(stateProof, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, info, nil
+}
+
+// matchExtBlkRef checks that an ExtBlkRef refers to the block id
+func matchExtBlkRef(s *cell.Slice, id BlockID) bool {
+	// ext_blk_ref$_ end_lt:uint64 seq_no:uint32 root_hash:bits256 file_hash:bits256
+	s.Skip(64)
+	seqNo := uint32(s.LoadUInt(32))
+	rootHash := s.LoadBytes(32)
+	fileHash := s.LoadBytes(32)
+	return s.Err() == nil && seqNo == id.SeqNo && bytes.Equal(rootHash, id.RootHash) && bytes.Equal(fileHash, id.FileHash)
+}
+
+// mcStateExtra returns the McStateExtra of a proven masterchain state
+func mcStateExtra(state *cell.Cell) (*cell.Slice, error) {
+	s, err := parseProven(state, "masterchain state")
+	if err != nil {
+		return nil, err
+	}
+	if s.LoadUInt(32) != shardStateTag {
+		return nil, invalidProof("not a masterchain state")
+	}
+	s.Skip(32 + 104 + 32 + 32 + 32 + 64 + 32) // global_id ... min_ref_mc_seqno
+	s.LoadRef()                               // out_msg_queue_info
+	s.LoadRef()                               // accounts
+	s.LoadRef()                               // ^[ overload_history ... master_ref ]
+	s.Skip(1)                                 // before_split
+	
+	extra, err := parseProven(s.LoadMaybeRef(), "masterchain state extra")
+	if err != nil {
+		return nil, err
+	}
+	if extra.LoadUInt(16) != mcStateExtraTag {
+		return nil, invalidProof("not a masterchain state extra")
+	}
+	return extra, nil
+}
+
+// checkShardBlock checks that the proven masterchain state lists id as the latest
+// block of its shard
+func checkShardBlock(state *cell.Cell, id BlockID) error {
+	extra, err := mcStateExtra(state)
+	if err != nil {
+		return err
+	}
+	
+	// shard_hashes:(HashmapE 32 ^(BinTree ShardDescr))
+	root := extra.LoadMaybeRef()
+	if err := extra.Err(); err != nil {
+		return err
+	}
+	if root == nil {
+		return invalidProof("masterchain state has no shards")
+	}
+	key := []byte{byte(id.Workchain >> 24), byte(id.Workchain >> 16), byte(id.Workchain >> 8), byte(id.Workchain)}
+	v, err := dictLookup(root, key, 32, nil)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return invalidProof("workchain %d is not in the masterchain state", id.Workchain)
+	}
+	
+	// bt_leaf$0 leaf:X | bt_fork$1 left:^(BinTree X) right:^(BinTree X)
+	s, err := parseProven(v.LoadRef(), "shard tree")
+	if err != nil {
+		return err
+	}
+	for depth := 0; s.LoadBit(); depth++ {
+		if depth >= 60 {
+			return invalidProof("shard tree is too deep")
+		}
+		left, right := s.LoadRef(), s.LoadRef()
+		next := left
+		if uint64(id.Shard)>>(63-depth)&1 == 1 {
+			next = right
+		}
+		if s, err = parseProven(next, "shard tree"); err != nil {
+			return err
+		}
+	}
+	
+	// shard_descr#b or shard_descr_new#a seq_no:uint32 reg_mc_seqno:uint32
+	// start_lt:uint64 end_lt:uint64 root_hash:bits256 file_hash:bits256 ...
+	s.Skip(4)
+	seqNo := uint32(s.LoadUInt(32))
+	s.Skip(32 + 64 + 64)
+	rootHash := s.LoadBytes(32)
+	fileHash := s.LoadBytes(32)
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if seqNo != id.SeqNo || !bytes.Equal(rootHash, id.RootHash) || !bytes.Equal(fileHash, id.FileHash) {
+		return invalidProof("shard block %d:%x:%d is not in the masterchain state", id.Workchain, uint64(id.Shard), id.SeqNo)
+	}
+	return nil
+}
+
+// checkPrevMcBlock checks that the proven masterchain state lists id among the
+// previous masterchain blocks
+func checkPrevMcBlock(state *cell.Cell, id BlockID) error {
+	extra, err := mcStateExtra(state)
+	if err != nil {
+		return err
+	}
+	extra.LoadMaybeRef() // shard_hashes
+	extra.Skip(256)      // config_addr
+	extra.LoadRef()      // config
+	
+	// ^[ flags:(## 16) validator_info:ValidatorInfo prev_blocks:OldMcBlocksInfo ... ]
+	s, err := parseProven(extra.LoadRef(), "masterchain state extra")
+	if err != nil {
+		return err
+	}
+	s.Skip(16 + 65)
+	root := s.LoadMaybeRef()
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if root == nil {
+		return invalidProof("masterchain state has no previous blocks")
+	}
+	
+	// HashmapAugE 32 KeyExtBlkRef KeyMaxLt
+	key := []byte{byte(id.SeqNo >> 24), byte(id.SeqNo >> 16), byte(id.SeqNo >> 8), byte(id.SeqNo)}
+	skipKeyMaxLt := func(s *cell.Slice) {
+		s.Skip(1 + 64)
+	}
+	v, err := dictLookup(root, key, 32, skipKeyMaxLt)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return invalidProof("block %d is not among the previous masterchain blocks", id.SeqNo)
+	}
+	v.Skip(1) // key
+	if !matchExtBlkRef(v, id) {
+		return invalidProof("masterchain block %d does not match the state", id.SeqNo)
+	}
+	return nil
+}
+
+// CheckShardProof checks the shard proof of a liteserver answer: shardBlock must be
+// the latest block of its shard in the trusted masterchain block mc
+func CheckShardProof(mc, shardBlock BlockID, proof []byte) error {
+	if shardBlock.Workchain == -1 {
+		if !sameBlock(mc, shardBlock) {
+			return invalidProof("masterchain shard block differs from the masterchain block")
+		}
+		return nil
+	}
+	
+	roots, err := cell.FromBOCMultiRoot(proof)
+	if err != nil {
+		return err
+	}
+	if len(roots) != 2 {
+		return invalidProof("shard proof has %d roots", len(roots))
+	}
+	state, _, err := checkBlockState(roots[0], roots[1], mc)
+	if err != nil {
+		return err
+	}
+	return checkShardBlock(state, shardBlock)
+}
+
+// provenAccount is a ShardAccount read from a checked state proof
+type provenAccount struct {
+	syncUtime uint32
+	exists    bool
+	
+	// account is the Account cell; it is a pruned branch unless the proof includes it
+	account       *cell.Cell
+	lastTransHash []byte
+	lastTransLt   uint64
+}
+
+// checkAccountProof checks an account state proof, a BOC with the proofs of the shard
+// block and of its state, and reads the ShardAccount of the account from it
+func checkAccountProof(shardBlock BlockID, proof []byte, addr *address.Address) (*provenAccount, error) {
+	roots, err := cell.FromBOCMultiRoot(proof)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) != 2 {
+		return nil, invalidProof("account state proof has %d roots", len(roots))
+	}
+	state, _, err := checkBlockState(roots[0], roots[1], shardBlock)
+	if err != nil {
+		return nil, err
+	}
+	
+	if addr.Workchain != shardBlock.Workchain || !shardContains(shardBlock.Shard, addr.Hash[:]) {
+		return nil, invalidProof("account is not in shard %d:%x", shardBlock.Workchain, uint64(shardBlock.Shard))
+	}
+	
+	utime, v, err := shardAccount(state, addr.Hash[:])
+	if err != nil {
+		return nil, err
+	}
+	acc := &provenAccount{syncUtime: utime}
+	if v == nil {
+		return acc, nil
+	}
+	
+	// account:^Account last_trans_hash:bits256 last_trans_lt:uint64
+	acc.exists = true
+	acc.account = v.LoadRef()
+	acc.lastTransHash = v.LoadBytes(32)
+	acc.lastTransLt = v.LoadUInt(64)
+	return acc, v.Err()
+}
+
+// shardContains reports whether the account ID falls into the shard
+func shardContains(shard int64, accountID []byte) bool {
+	s := uint64(shard)
+	id := uint64(0)
+	for _, b := range accountID[:8] {
+		id = id<<8 | uint64(b)
+	}
+	mask := ^(s&-s<<1 - 1)
+	return id&mask == s&mask
+}
+
+// Verify checks the proofs of the answer against the trusted masterchain block and
+// returns the decoded account. It fails with ErrInvalidProof unless the state is
+// exactly the one recorded in the block.
+func (st *AccountState) Verify(trusted BlockID, addr *address.Address) (*Account, error) {
+	if !sameBlock(st.Block, trusted) {
+		return nil, invalidProof("answer is for another masterchain block")
+	}
+	if err := CheckShardProof(trusted, st.ShardBlock, st.ShardProof); err != nil {
+		return nil, err
+	}
+	
+	proven, err := checkAccountProof(st.ShardBlock, st.Proof, addr)
+	if err != nil {
+		return nil, err
+	}
+	if !proven.exists {
+		if len(st.State) > 0 {
+			return nil, invalidProof("state of a nonexistent account")
+		}
+		return st.Account(addr)
+	}
+	if len(st.State) == 0 {
+		return nil, invalidProof("account state is missing")
+	}
+	root, err := cell.FromBOC(st.State)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(root.Hash(), proven.account.HashAt(0)) {
+		return nil, invalidProof("account state hash mismatch")
+	}
+	return st.Account(addr)
+}
+
+// VerifyShardBlockProof walks the links of a getShardBlockProof answer starting at the
+// trusted masterchain block and returns the last block of the chain. Every link proves
+// its To block from the From block before it: through the previous block references
+// of a shard block, or through the shard hashes or the previous blocks list of the
+// state of a masterchain block.
+func VerifyShardBlockProof(resp *toncenterzp.GetShardBlockProofResponse, trusted BlockID) (BlockID, error) {
+	cur := trusted
+	for i, link := range resp.Result.Links {
+		from, err := blockIDFromAPI(link.From.Workchain, link.From.Shard, link.From.SeqNo, link.From.RootHash, link.From.FileHash)
+		if err != nil {
+			return BlockID{}, err
+		}
+		to, err := blockIDFromAPI(link.To.Workchain, link.To.Shard, link.To.SeqNo, link.To.RootHash, link.To.FileHash)
+		if err != nil {
+			return BlockID{}, err
+		}
+		if !sameBlock(from, cur) {
+			return BlockID{}, invalidProof("link %d does not start at the last proven block", i)
+		}
+		
+		proof, err := cell.FromBOCBase64(link.Proof)
+		if err != nil {
+			return BlockID{}, err
+		}
+		if from.Workchain != -1 {
+			if err := checkPrevLink(proof, from, to); err != nil {
+				return BlockID{}, err
+			}
+		} else {
+			stateProof, err := cell.FromBOCBase64(link.StateProof)
+			if err != nil {
+				return BlockID{}, err
+			}
+			state, _, err := checkBlockState(proof, stateProof, from)
+			if err != nil {
+				return BlockID{}, err
+			}
+			if to.Workchain == -1 {
+				err = checkPrevMcBlock(state, to)
+			} else {
+				err = checkShardBlock(state, to)
+			}
+			if err != nil {
+				return BlockID{}, err
+			}
+		}
+		
+		if link.ToKeyBlock {
+			destProof, err := cell.FromBOCBase64(link.Dest_Proof)
+			if err != nil {
+				return BlockID{}, err
+			}
+			if err := checkKeyBlockFlag(destProof, to, true); err != nil {
+				return BlockID{}, err
+			}
+		}
+		cur = to
+	}
+	return cur, nil
+}
+
+// checkKeyBlockFlag checks a Merkle proof of the header of the block id and that the
+// block is a key block exactly if keyBlock is set
+func checkKeyBlockFlag(proof *cell.Cell, id BlockID, keyBlock bool) error {
+	_, info, err := checkBlock(proof, id)
+	if err != nil {
+		return err
+	}
+	if info.keyBlock != keyBlock {
+		return invalidProof("key block flag of block %d does not match", id.SeqNo)
+	}
+	return nil
+}
+
+// checkPrevLink checks that to is a previous block of the proven block from
+func checkPrevLink(proof *cell.Cell, from, to BlockID) error {
+	_, info, err := checkBlock(proof, from)
+	if err != nil {
+		return err
+	}
+	for _, prev := range info.prev {
+		if matchExtBlkRef(prev.Copy(), to) {
+			return nil
+		}
+	}
+	return invalidProof("block %d is not a previous block of %d", to.SeqNo, from.SeqNo)
+}
+
+// VerifyAddressInformation checks the proof_of_state_val of a getAddressInformation
+// answer, a BOC with the proofs of shardBlock and of its state, and returns the account
+// read from the proof. shardBlock must be trusted already, e.g. returned by
+// VerifyShardBlockProof. The last transaction, sync time, balance, code and data of the
+// answer are all checked, so the proof of an existing account must include its account
+// cell; a proof pruning it fails with ErrInvalidProof.
+func VerifyAddressInformation(resp *toncenterzp.GetAddressInformationResponse, shardBlock BlockID, addr *address.Address) (*Account, error) {
+	data, err := base64.StdEncoding.DecodeString(resp.Result.ProofOfStateVal)
+	if err != nil {
+		return nil, invalidProof("invalid base64 proof")
+	}
+	proven, err := checkAccountProof(shardBlock, data, addr)
+	if err != nil {
+		return nil, err
+	}
+	
+	acc := &Account{
+		Address:   addr,
+		Status:    StatusNonexist,
+		Balance:   new(big.Int),
+		SyncUtime: proven.syncUtime,
+	}
+	if resp.Result.SyncUtime != 0 && uint32(resp.Result.SyncUtime) != acc.SyncUtime {
+		return nil, invalidProof("sync time mismatch")
+	}
+	if !proven.exists {
+		if resp.Result.LastTransLT != "" && resp.Result.LastTransLT != "0" {
+			return nil, invalidProof("transactions of a nonexistent account")
+		}
+		return acc, nil
+	}
+	
+	acc.LastTransLt = proven.lastTransLt
+	acc.LastTransHash = proven.lastTransHash
+	if resp.Result.LastTransLT != strconv.FormatUint(acc.LastTransLt, 10) {
+		return nil, invalidProof("last transaction lt mismatch")
+	}
+	if h, err := decodeHash(resp.Result.LastTransHash); err != nil || !bytes.Equal(h, acc.LastTransHash) {
+		return nil, invalidProof("last transaction hash mismatch")
+	}
+	
+	if proven.account.Type() == cell.PrunedBranch {
+		return nil, invalidProof("account cell is pruned, balance, code and data are not proven")
+	}
+	if err := acc.load(proven.account.BeginParse()); err != nil {
+		return nil, err
+	}
+	acc.LastTransLt = proven.lastTransLt
+	if resp.Result.Balance != acc.Balance.String() {
+		return nil, invalidProof("balance mismatch")
+	}
+	if !sameBOC(resp.Result.Code, acc.Code) || !sameBOC(resp.Result.Data, acc.Data) {
+		return nil, invalidProof("code or data mismatch")
+	}
+	return acc, nil
+}
+
+// sameBOC reports whether a base64 encoded BOC holds the cell c; empty matches nil
+func sameBOC(boc string, c *cell.Cell) bool {
+	if boc == "" || c == nil {
+		return boc == "" && c == nil
+	}
+	root, err := cell.FromBOCBase64(boc)
+	return err == nil && bytes.Equal(root.Hash(), c.Hash())
+}
+
+// blockIDFromAPI converts a block ID of the HTTP API
+func blockIDFromAPI(workchain int, shard string, seqNo int, rootHash, fileHash string) (BlockID, error) {
+	s, err := parseShard(shard)
+	if err != nil {
+		return BlockID{}, err
+	}
+	id := BlockID{Workchain: int32(workchain), Shard: s, SeqNo: uint32(seqNo)}
+	if id.RootHash, err = decodeHash(rootHash); err != nil {
+		return BlockID{}, err
+	}
+	if id.FileHash, err = decodeHash(fileHash); err != nil {
+		return BlockID{}, err
+	}
+	return id, nil
+}
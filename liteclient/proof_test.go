@@ -0,0 +1,372 @@
+package liteclient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// must panics on builder errors, which only happen on a broken test
+func must(c *cell.Cell, err error) *cell.Cell {
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// filler returns a distinct cell standing in for a part of a block the proofs
+// do not look at
+func filler(tag uint64) *cell.Cell {
+	return must(cell.BeginCell().StoreUInt(tag, 8).EndCell())
+}
+
+func prune(c *cell.Cell) *cell.Cell {
+	return must(cell.BeginCell().
+		StoreUInt(uint64(cell.PrunedBranch), 8).
+		StoreUInt(1, 8).
+		StoreBytes(c.HashAt(0)).
+		StoreUInt(uint64(c.DepthAt(0)), 16).
+		EndExoticCell())
+}
+
+func merkleProof(c *cell.Cell) *cell.Cell {
+	return must(cell.BeginCell().
+		StoreUInt(uint64(cell.MerkleProof), 8).
+		StoreBytes(c.HashAt(0)).
+		StoreUInt(uint64(c.DepthAt(0)), 16).
+		StoreRef(c).
+		EndExoticCell())
+}
+
+func merkleUpdate(from, to *cell.Cell) *cell.Cell {
+	return must(cell.BeginCell().
+		StoreUInt(uint64(cell.MerkleUpdate), 8).
+		StoreBytes(from.HashAt(0)).
+		StoreBytes(to.HashAt(0)).
+		StoreUInt(uint64(from.DepthAt(0)), 16).
+		StoreUInt(uint64(to.DepthAt(0)), 16).
+		StoreRef(from).
+		StoreRef(to).
+		EndExoticCell())
+}
+
+// storeShardIdent stores the ShardIdent of the whole workchain
+func storeShardIdent(b *cell.Builder, workchain int32) {
+	b.StoreUInt(0, 2).StoreUInt(0, 6).StoreInt(int64(workchain), 32).StoreUInt(0, 64)
+}
+
+func extBlkRef(id BlockID) *cell.Builder {
+	return cell.BeginCell().StoreUInt(0, 64).StoreUInt(uint64(id.SeqNo), 32).StoreBytes(id.RootHash).StoreBytes(id.FileHash)
+}
+
+func testBlockInfo(workchain int32, seqNo uint32, keyBlock bool, prev BlockID) *cell.Cell {
+	b := cell.BeginCell().
+		StoreUInt(blockInfoTag, 32).
+		StoreUInt(0, 32).
+		StoreBit(workchain != -1). // not_master
+		StoreBit(false).           // after_merge
+		StoreUInt(0, 4).           // before_split, after_split, want_split, want_merge
+		StoreBit(keyBlock).        // key_block
+		StoreBit(false).           // vert_seqno_incr
+		StoreUInt(0, 8).           // flags
+		StoreUInt(uint64(seqNo), 32).
+		StoreUInt(0, 32) // vert_seq_no
+	storeShardIdent(b, workchain)
+	if workchain != -1 {
+		b.StoreRef(must(cell.BeginCell().EndCell())) // master_ref
+	}
+	b.StoreRef(must(extBlkRef(prev).EndCell()))
+	return must(b.EndCell())
+}
+
+func testState(workchain int32, accounts, custom *cell.Cell) *cell.Cell {
+	b := cell.BeginCell().StoreUInt(shardStateTag, 32).StoreUInt(0, 32)
+	storeShardIdent(b, workchain)
+	b.StoreUInt(1, 32)          // seq_no
+	b.StoreUInt(0, 32)          // vert_seq_no
+	b.StoreUInt(1700000000, 32) // gen_utime
+	b.StoreUInt(0, 64)          // gen_lt
+	b.StoreUInt(0, 32)          // min_ref_mc_seqno
+	b.StoreRef(prune(filler(6))).StoreRef(accounts).StoreRef(prune(filler(7)))
+	b.StoreBit(false).StoreMaybeRef(custom)
+	return must(b.EndCell())
+}
+
+func testBlock(info, state *cell.Cell) *cell.Cell {
+	return must(cell.BeginCell().
+		StoreUInt(blockTag, 32).
+		StoreUInt(0, 32).
+		StoreRef(info).
+		StoreRef(prune(filler(1))).
+		StoreRef(merkleUpdate(prune(filler(2)), prune(state))).
+		StoreRef(prune(filler(3))).
+		EndCell())
+}
+
+func hash(b byte) []byte {
+	h := make([]byte, 32)
+	h[0] = b
+	return h
+}
+
+// chain is a masterchain block 20 whose state lists shard block 10 of workchain 0
+// as the latest block of its shard and masterchain block 19 as a previous block.
+// The shard state holds one account.
+type chain struct {
+	addr    *address.Address
+	account *cell.Cell
+	
+	mcID, prevMcID       BlockID
+	mcBlock, mcState     *cell.Cell
+	shardID, prevShardID BlockID
+	shardBlock           *cell.Cell
+	shardState           *cell.Cell
+}
+
+func testAccount(addr *address.Address, balance int64) *cell.Cell {
+	return must(cell.BeginCell().
+		StoreBit(true). // account
+		StoreAddress(addr).
+		StoreVarUInt(big.NewInt(0), 3). // storage_stat: cells
+		StoreVarUInt(big.NewInt(0), 3). // bits
+		StoreUInt(0, 3).                // public_cells
+		StoreUInt(0, 32).               // last_paid
+		StoreBit(false).                // due_payment
+		StoreUInt(99, 64).              // last_trans_lt
+		StoreCoins(big.NewInt(balance)).
+		StoreBit(false). // other currencies
+		StoreUInt(0, 2). // account_uninit
+		EndCell())
+}
+
+// newChain builds the chain; with fullAccount the account cell is part of the shard
+// state, otherwise it is pruned as in liteserver answers
+func newChain(fullAccount bool) *chain {
+	c := &chain{addr: address.MustParse("0:1100000000000000000000000000000000000000000000000000000000000022")}
+	c.account = testAccount(c.addr, 12345)
+	
+	// ShardAccounts with a single leaf for the account
+	account := prune(c.account)
+	if fullAccount {
+		account = c.account
+	}
+	leaf := must(cell.BeginCell().
+		StoreUInt(0b10, 2). // hml_long label
+		StoreUInt(256, 9).
+		StoreBytes(c.addr.Hash[:]).
+		StoreUInt(0, 5). // DepthBalanceInfo: split_depth
+		StoreCoins(big.NewInt(12345)).
+		StoreBit(false).
+		StoreRef(account).
+		StoreBytes(hash(5)). // last_trans_hash
+		StoreUInt(100, 64).  // last_trans_lt
+		EndCell())
+	accounts := must(cell.BeginCell().StoreBit(true).StoreRef(leaf).EndCell())
+	
+	c.prevShardID = BlockID{Workchain: 0, Shard: -1 << 63, SeqNo: 9, RootHash: hash(9), FileHash: hash(10)}
+	c.shardState = testState(0, accounts, nil)
+	c.shardBlock = testBlock(testBlockInfo(0, 10, false, c.prevShardID), c.shardState)
+	c.shardID = BlockID{Workchain: 0, Shard: -1 << 63, SeqNo: 10, RootHash: c.shardBlock.HashAt(0), FileHash: hash(2)}
+	
+	// McStateExtra with the shard description and the previous block
+	descr := must(cell.BeginCell().
+		StoreBit(false).
+		StoreUInt(0xb, 4).
+		StoreUInt(10, 32).
+		StoreUInt(0, 32).
+		StoreUInt(0, 64).
+		StoreUInt(0, 64).
+		StoreBytes(c.shardID.RootHash).
+		StoreBytes(c.shardID.FileHash).
+		EndCell())
+	shards := must(cell.BeginCell().StoreUInt(0b10, 2).StoreUInt(32, 6).StoreUInt(0, 32).StoreRef(descr).EndCell())
+	c.prevMcID = BlockID{Workchain: -1, Shard: -1 << 63, SeqNo: 19, RootHash: hash(19), FileHash: hash(20)}
+	prevBlocks := must(cell.BeginCell().
+		StoreUInt(0b10, 2).
+		StoreUInt(32, 6).
+		StoreUInt(19, 32).
+		StoreBit(false).
+		StoreUInt(0, 64).
+		StoreBit(false).
+		StoreBuilder(extBlkRef(c.prevMcID)).
+		EndCell())
+	info := must(cell.BeginCell().StoreUInt(0, 16).StoreUInt(0, 65).StoreMaybeRef(prevBlocks).EndCell())
+	extra := must(cell.BeginCell().
+		StoreUInt(mcStateExtraTag, 16).
+		StoreMaybeRef(shards).
+		StoreBytes(hash(0)).
+		StoreRef(prune(filler(4))).
+		StoreRef(info).
+		EndCell())
+	
+	c.mcState = testState(-1, prune(filler(8)), extra)
+	c.mcBlock = testBlock(testBlockInfo(-1, 20, false, c.prevMcID), c.mcState)
+	c.mcID = BlockID{Workchain: -1, Shard: -1 << 63, SeqNo: 20, RootHash: c.mcBlock.HashAt(0), FileHash: hash(1)}
+	return c
+}
+
+func (c *chain) accountState() *AccountState {
+	return &AccountState{
+		Block:      c.mcID,
+		ShardBlock: c.shardID,
+		ShardProof: cell.ToBOCMultiRoot(merkleProof(c.mcBlock), merkleProof(c.mcState)),
+		Proof:      cell.ToBOCMultiRoot(merkleProof(c.shardBlock), merkleProof(c.shardState)),
+		State:      c.account.ToBOC(),
+	}
+}
+
+func TestCheckMerkleProof(t *testing.T) {
+	c := newChain(false)
+	proof := merkleProof(c.mcBlock)
+	if _, err := CheckMerkleProof(proof, c.mcID.RootHash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CheckMerkleProof(proof, hash(77)); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("error %v, want ErrInvalidProof", err)
+	}
+	if _, err := CheckMerkleProof(c.mcBlock, c.mcID.RootHash); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("ordinary cell: error %v, want ErrInvalidProof", err)
+	}
+}
+
+func TestAccountStateVerify(t *testing.T) {
+	c := newChain(false)
+	acc, err := c.accountState().Verify(c.mcID, c.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Status != "uninitialized" || acc.Balance.Int64() != 12345 || acc.LastTransLt != 100 || acc.LastTransHash[0] != 5 || acc.SyncUtime != 1700000000 {
+		t.Errorf("account %+v", acc)
+	}
+}
+
+func TestAccountStateVerifyRejectsTampering(t *testing.T) {
+	c := newChain(false)
+	
+	st := c.accountState()
+	st.State = testAccount(c.addr, 99999).ToBOC()
+	if _, err := st.Verify(c.mcID, c.addr); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("tampered account: error %v, want ErrInvalidProof", err)
+	}
+	
+	other := c.mcID
+	other.RootHash = hash(77)
+	if _, err := c.accountState().Verify(other, c.addr); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("other trusted block: error %v, want ErrInvalidProof", err)
+	}
+	
+	st = c.accountState()
+	st.Block = other
+	if _, err := st.Verify(other, c.addr); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("proof of another block: error %v, want ErrInvalidProof", err)
+	}
+	
+	st = c.accountState()
+	st.ShardBlock.FileHash = hash(3)
+	if _, err := st.Verify(c.mcID, c.addr); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("shard block not in the masterchain state: error %v, want ErrInvalidProof", err)
+	}
+}
+
+// shardBlockProof builds a getShardBlockProof answer with links from, to, proof and
+// state proof
+func shardBlockProof(t *testing.T, links ...[4]interface{}) *toncenterzp.GetShardBlockProofResponse {
+	t.Helper()
+	apiID := func(id BlockID) map[string]interface{} {
+		return map[string]interface{}{
+			"workchain": id.Workchain,
+			"shard":     strconv.FormatInt(id.Shard, 10),
+			"seqno":     id.SeqNo,
+			"root_hash": base64.StdEncoding.EncodeToString(id.RootHash),
+			"file_hash": base64.StdEncoding.EncodeToString(id.FileHash),
+		}
+	}
+	boc := func(c interface{}) string {
+		if c == nil {
+			return ""
+		}
+		return merkleProof(c.(*cell.Cell)).ToBOCBase64()
+	}
+	var list []map[string]interface{}
+	for _, l := range links {
+		list = append(list, map[string]interface{}{
+			"from":        apiID(l[0].(BlockID)),
+			"to":          apiID(l[1].(BlockID)),
+			"proof":       boc(l[2]),
+			"state_proof": boc(l[3]),
+		})
+	}
+	data, _ := json.Marshal(map[string]interface{}{"ok": true, "result": map[string]interface{}{"links": list}})
+	var resp toncenterzp.GetShardBlockProofResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return &resp
+}
+
+func TestVerifyShardBlockProof(t *testing.T) {
+	c := newChain(false)
+	
+	// Masterchain block 20 lists shard block 10, whose header refers to shard block 9
+	resp := shardBlockProof(t,
+		[4]interface{}{c.mcID, c.shardID, c.mcBlock, c.mcState},
+		[4]interface{}{c.shardID, c.prevShardID, c.shardBlock, nil})
+	last, err := VerifyShardBlockProof(resp, c.mcID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameBlock(last, c.prevShardID) {
+		t.Errorf("last block %+v, want %+v", last, c.prevShardID)
+	}
+	
+	resp = shardBlockProof(t, [4]interface{}{c.mcID, c.prevMcID, c.mcBlock, c.mcState})
+	if _, err := VerifyShardBlockProof(resp, c.mcID); err != nil {
+		t.Errorf("previous masterchain block: %v", err)
+	}
+	
+	wrong := c.prevMcID
+	wrong.SeqNo = 18
+	resp = shardBlockProof(t, [4]interface{}{c.mcID, wrong, c.mcBlock, c.mcState})
+	if _, err := VerifyShardBlockProof(resp, c.mcID); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("unlisted masterchain block: error %v, want ErrInvalidProof", err)
+	}
+}
+
+func TestVerifyAddressInformation(t *testing.T) {
+	c := newChain(true)
+	
+	var resp toncenterzp.GetAddressInformationResponse
+	resp.Result.ProofOfStateVal = base64.StdEncoding.EncodeToString(cell.ToBOCMultiRoot(merkleProof(c.shardBlock), merkleProof(c.shardState)))
+	resp.Result.Balance = "12345"
+	resp.Result.LastTransLT = "100"
+	resp.Result.LastTransHash = base64.StdEncoding.EncodeToString(hash(5))
+	resp.Result.SyncUtime = 1700000000
+	
+	acc, err := VerifyAddressInformation(&resp, c.shardID, c.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Balance.Int64() != 12345 {
+		t.Errorf("balance %s", acc.Balance)
+	}
+	
+	resp.Result.Balance = "1"
+	if _, err := VerifyAddressInformation(&resp, c.shardID, c.addr); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("tampered balance: error %v, want ErrInvalidProof", err)
+	}
+	
+	// A proof pruning the account cell proves neither balance nor code and data
+	c = newChain(false)
+	resp.Result.ProofOfStateVal = base64.StdEncoding.EncodeToString(cell.ToBOCMultiRoot(merkleProof(c.shardBlock), merkleProof(c.shardState)))
+	resp.Result.Balance = "12345"
+	if acc, err := VerifyAddressInformation(&resp, c.shardID, c.addr); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("pruned account: %v, error %v, want ErrInvalidProof", acc, err)
+	}
+}
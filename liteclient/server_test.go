@@ -0,0 +1,157 @@
+package liteclient
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	
+	"github.com/zhaopeng331/toncenterzp/internal/x25519"
+)
+
+// Handler answers a liteServer request. The request and the returned answer are TL
+// serialized liteServer functions and results, without the liteServer.query and ADNL
+// wrappers. Returning an error sends a liteServer.error with code 500.
+type Handler func(request []byte) ([]byte, error)
+
+// Server is a fake liteserver for the tests: it accepts ADNL connections and
+// answers liteServer queries with a Handler.
+type Server struct {
+	Key     ed25519.PrivateKey
+	Handler Handler
+	
+	mu    sync.Mutex
+	conns map[*conn]bool
+	ln    net.Listener
+}
+
+// NewServer creates a server with the given key and handler
+func NewServer(key ed25519.PrivateKey, handler Handler) *Server {
+	return &Server{Key: key, Handler: handler}
+}
+
+// Serve accepts connections on l until it is closed
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.ln = l
+	s.mu.Unlock()
+	
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(nc)
+	}
+}
+
+// Close stops the listener and closes all connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	for c := range s.conns {
+		c.close(nil)
+	}
+	if s.ln != nil {
+		return s.ln.Close()
+	}
+	return nil
+}
+
+// serveConn performs the server side of the handshake and answers queries
+func (s *Server) serveConn(nc net.Conn) {
+	c, err := s.handshake(nc)
+	if err != nil {
+		nc.Close()
+		return
+	}
+	
+	s.mu.Lock()
+	if s.conns == nil {
+		s.conns = map[*conn]bool{}
+	}
+	s.conns[c] = true
+	s.mu.Unlock()
+	
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.close(nil)
+	}()
+	
+	for {
+		payload, err := c.readPacket()
+		if err != nil {
+			return
+		}
+		
+		r := &tlReader{data: payload}
+		switch r.uint32() {
+		case tlTCPPing:
+			var w tlWriter
+			w.uint32(tlTCPPong).int64(r.int64())
+			if c.writePacket(w.buf) != nil {
+				return
+			}
+		case tlADNLQuery:
+			id := r.int256()
+			q := &tlReader{data: r.bytes()}
+			q.expect(tlLiteQuery)
+			request := q.bytes()
+			if r.err != nil || q.err != nil {
+				return
+			}
+			
+			go func() {
+				answer, err := s.Handler(request)
+				if err != nil {
+					var w tlWriter
+					w.uint32(tlLiteError).int32(500).bytes([]byte(err.Error()))
+					answer = w.buf
+				}
+				var w tlWriter
+				w.uint32(tlADNLAnswer).int256(id).bytes(answer)
+				c.writePacket(w.buf)
+			}()
+		}
+	}
+}
+
+// handshake reads the client session parameters and confirms them with an empty packet
+func (s *Server) handshake(nc net.Conn) (*conn, error) {
+	packet := make([]byte, 256)
+	if _, err := io.ReadFull(nc, packet); err != nil {
+		return nil, err
+	}
+	
+	pub := s.Key.Public().(ed25519.PublicKey)
+	if !bytes.Equal(packet[:32], keyID(pub)) {
+		return nil, errors.New("liteclient: handshake for unknown key")
+	}
+	
+	shared, err := x25519.SharedKey(s.Key, ed25519.PublicKey(packet[32:64]))
+	if err != nil {
+		return nil, err
+	}
+	
+	hash := packet[64:96]
+	params := make([]byte, 160)
+	handshakeCipher(shared, hash).XORKeyStream(params, packet[96:])
+	if sum := sha256.Sum256(params); !bytes.Equal(sum[:], hash) {
+		return nil, errors.New("liteclient: handshake checksum mismatch")
+	}
+	
+	c := newConn(nc, params[32:64], params[80:96], params[0:32], params[64:80])
+	if err := c.writePacket(nil); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,245 @@
+package liteclient
+
+import (
+	"fmt"
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// VM stack values are represented as nil (null), *big.Int, *cell.Cell, *cell.Slice,
+// *cell.Builder and []interface{} (tuple).
+
+// EncodeStack serializes values, bottom first, as a VmStack cell
+func EncodeStack(values []interface{}) (*cell.Cell, error) {
+	rest, err := cell.BeginCell().EndCell()
+	if err != nil {
+		return nil, err
+	}
+	
+	for i, v := range values {
+		b := cell.BeginCell()
+		if i == len(values)-1 {
+			b.StoreUInt(uint64(len(values)), 24)
+		}
+		b.StoreRef(rest)
+		if err := storeStackValue(b, v); err != nil {
+			return nil, err
+		}
+		if i == len(values)-1 {
+			return b.EndCell()
+		}
+		if rest, err = b.EndCell(); err != nil {
+			return nil, err
+		}
+	}
+	return cell.BeginCell().StoreUInt(0, 24).EndCell()
+}
+
+// storeStackValue stores a VmStackValue
+func storeStackValue(b *cell.Builder, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		b.StoreUInt(0x00, 8)
+	case int:
+		storeStackInt(b, big.NewInt(int64(v)))
+	case int64:
+		storeStackInt(b, big.NewInt(v))
+	case uint64:
+		storeStackInt(b, new(big.Int).SetUint64(v))
+	case *big.Int:
+		storeStackInt(b, v)
+	case *cell.Cell:
+		b.StoreUInt(0x03, 8).StoreRef(v)
+	case *cell.Slice:
+		c, err := v.ToCell()
+		if err != nil {
+			return err
+		}
+		b.StoreUInt(0x04, 8).StoreRef(c)
+		b.StoreUInt(0, 10).StoreUInt(uint64(c.BitsSize()), 10)
+		b.StoreUInt(0, 3).StoreUInt(uint64(c.RefsNum()), 3)
+	case *cell.Builder:
+		c, err := v.EndCell()
+		if err != nil {
+			return err
+		}
+		b.StoreUInt(0x05, 8).StoreRef(c)
+	case []interface{}:
+		if len(v) > 255 {
+			return fmt.Errorf("liteclient: tuple of %d elements is too long", len(v))
+		}
+		b.StoreUInt(0x07, 8).StoreUInt(uint64(len(v)), 16)
+		if err := storeTuple(b, v); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("liteclient: unsupported stack value %T", v)
+	}
+	return b.Err()
+}
+
+// storeStackInt stores an integer as vm_stk_tinyint or vm_stk_int
+func storeStackInt(b *cell.Builder, v *big.Int) {
+	if v.IsInt64() {
+		b.StoreUInt(0x01, 8).StoreInt(v.Int64(), 64)
+		return
+	}
+	b.StoreUInt(0x0100, 15).StoreBigInt(v, 257)
+}
+
+// storeTuple stores the VmTuple of values
+func storeTuple(b *cell.Builder, values []interface{}) error {
+	n := len(values)
+	if n == 0 {
+		return nil
+	}
+	
+	// head:(VmTupleRef n-1) tail:^VmStackValue
+	switch {
+	case n-1 == 1:
+		c, err := stackValueCell(values[0])
+		if err != nil {
+			return err
+		}
+		b.StoreRef(c)
+	case n-1 >= 2:
+		hb := cell.BeginCell()
+		if err := storeTuple(hb, values[:n-1]); err != nil {
+			return err
+		}
+		head, err := hb.EndCell()
+		if err != nil {
+			return err
+		}
+		b.StoreRef(head)
+	}
+	
+	tail, err := stackValueCell(values[n-1])
+	if err != nil {
+		return err
+	}
+	b.StoreRef(tail)
+	return b.Err()
+}
+
+// stackValueCell stores a single value in its own cell
+func stackValueCell(v interface{}) (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := storeStackValue(b, v); err != nil {
+		return nil, err
+	}
+	return b.EndCell()
+}
+
+// DecodeStack parses a VmStack cell and returns its values, bottom first
+func DecodeStack(root *cell.Cell) ([]interface{}, error) {
+	s := root.BeginParse()
+	depth := int(s.LoadUInt(24))
+	
+	values := make([]interface{}, depth)
+	for i := depth - 1; i >= 0; i-- {
+		rest := s.LoadRef()
+		v, err := loadStackValue(s)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+		if i > 0 {
+			if rest == nil {
+				return nil, s.Err()
+			}
+			s = rest.BeginParse()
+		}
+	}
+	return values, s.Err()
+}
+
+// loadStackValue reads a VmStackValue
+func loadStackValue(s *cell.Slice) (interface{}, error) {
+	switch tag := s.LoadUInt(8); tag {
+	case 0x00:
+		return nil, s.Err()
+	case 0x01:
+		return big.NewInt(s.LoadInt(64)), s.Err()
+	case 0x02:
+		if s.LoadUInt(7) == 0 {
+			return s.LoadBigInt(257), s.Err()
+		}
+		s.LoadBit()
+		return nil, fmt.Errorf("liteclient: NaN on stack")
+	case 0x03:
+		return s.LoadRef(), s.Err()
+	case 0x04:
+		c := s.LoadRef()
+		stBits, endBits := int(s.LoadUInt(10)), int(s.LoadUInt(10))
+		stRef, endRef := int(s.LoadUInt(3)), int(s.LoadUInt(3))
+		if s.Err() != nil {
+			return nil, s.Err()
+		}
+		return subSlice(c, stBits, endBits, stRef, endRef)
+	case 0x05:
+		c := s.LoadRef()
+		if c == nil {
+			return nil, s.Err()
+		}
+		return cell.BeginCell().StoreSlice(c.BeginParse()), s.Err()
+	case 0x06:
+		return nil, fmt.Errorf("liteclient: continuations on stack are not supported")
+	case 0x07:
+		n := int(s.LoadUInt(16))
+		return loadTuple(s, n)
+	default:
+		if s.Err() != nil {
+			return nil, s.Err()
+		}
+		return nil, fmt.Errorf("liteclient: unknown stack value tag %#x", tag)
+	}
+}
+
+// subSlice returns the part of a cell selected by a VmCellSlice
+func subSlice(c *cell.Cell, stBits, endBits, stRef, endRef int) (*cell.Slice, error) {
+	if c == nil || stBits > endBits || endBits > c.BitsSize() || stRef > endRef || endRef > c.RefsNum() {
+		return nil, fmt.Errorf("liteclient: invalid slice bounds")
+	}
+	
+	s := c.BeginParse()
+	s.Skip(stBits)
+	b := cell.BeginCell().StoreBits(s.LoadBits(endBits-stBits), endBits-stBits)
+	for i := stRef; i < endRef; i++ {
+		b.StoreRef(c.Ref(i))
+	}
+	sub, err := b.EndCell()
+	if err != nil {
+		return nil, err
+	}
+	return sub.BeginParse(), nil
+}
+
+// loadTuple reads a VmTuple of n elements
+func loadTuple(s *cell.Slice, n int) ([]interface{}, error) {
+	if n == 0 {
+		return []interface{}{}, nil
+	}
+	
+	var head []interface{}
+	switch {
+	case n-1 == 1:
+		v, err := loadStackValue(s.LoadRefSlice())
+		if err != nil {
+			return nil, err
+		}
+		head = []interface{}{v}
+	case n-1 >= 2:
+		var err error
+		if head, err = loadTuple(s.LoadRefSlice(), n-1); err != nil {
+			return nil, err
+		}
+	}
+	
+	tail, err := loadStackValue(s.LoadRefSlice())
+	if err != nil {
+		return nil, err
+	}
+	return append(head, tail), s.Err()
+}
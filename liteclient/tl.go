@@ -0,0 +1,191 @@
+package liteclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// TL constructor IDs
+const (
+	tlTCPPing    = 0x4d082b9a
+	tlTCPPong    = 0xdc69fb03
+	tlADNLQuery  = 0xb48bf97a
+	tlADNLAnswer = 0x0fac8416
+	tlPubEd25519 = 0x4813b4c6
+	tlLiteQuery  = 0x798c06df
+	tlLiteError  = 0xbba9e148
+	tlBoolTrue   = 0x997275b5
+	tlBoolFalse  = 0xbc799737
+	
+	tlGetMasterchainInfo    = 0x89b5e62e
+	tlMasterchainInfo       = 0x85832881
+	tlGetTime               = 0x16ad5a34
+	tlCurrentTime           = 0xe953000d
+	tlGetAccountState       = 0x6b890e25
+	tlAccountState          = 0x7079c751
+	tlRunSmcMethod          = 0x5cc65dd2
+	tlRunMethodResult       = 0xa39a616b
+	tlSendMessage           = 0x690ad482
+	tlSendMsgStatus         = 0x3950e597
+	tlGetTransactions       = 0x1c40e7a1
+	tlTransactionList       = 0x6f26c60b
+	tlLookupBlock           = 0xfac8f71e
+	tlBlockHeader           = 0x752d8219
+	tlListBlockTransactions = 0xadfcc7da
+	tlBlockTransactions     = 0xbd8cad2b
+	tlGetBlockProof         = 0x8aea9c44
+	tlPartialBlockProof     = 0x8ed0d2c1
+	tlBlockLinkBack         = 0xef7e1bef
+	tlBlockLinkForward      = 0x520fce1c
+	tlSignatureSet          = 0xf644a6e6
+	tlSignature             = 0xa3def855
+	tlTonBlockID            = 0xc50b6e70
+	tlGetConfigParams       = 0x2a111c19
+	tlConfigInfo            = 0xae7b272f
+)
+
+// errTL is returned for malformed TL data
+var errTL = errors.New("malformed TL data")
+
+// tlWriter serializes TL values
+type tlWriter struct {
+	buf []byte
+}
+
+// uint32 writes a 32-bit little-endian integer
+func (w *tlWriter) uint32(v uint32) *tlWriter {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+	return w
+}
+
+// int32 writes a signed 32-bit integer
+func (w *tlWriter) int32(v int32) *tlWriter {
+	return w.uint32(uint32(v))
+}
+
+// int64 writes a signed 64-bit integer
+func (w *tlWriter) int64(v int64) *tlWriter {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, uint64(v))
+	return w
+}
+
+// int256 writes 32 raw bytes
+func (w *tlWriter) int256(v []byte) *tlWriter {
+	var b [32]byte
+	copy(b[:], v)
+	w.buf = append(w.buf, b[:]...)
+	return w
+}
+
+// bytes writes a length-prefixed byte string padded to 4 bytes
+func (w *tlWriter) bytes(v []byte) *tlWriter {
+	n := len(v)
+	if n < 254 {
+		w.buf = append(w.buf, byte(n))
+		n++
+	} else {
+		w.buf = append(w.buf, 0xfe, byte(n), byte(n>>8), byte(n>>16))
+		n += 4
+	}
+	w.buf = append(w.buf, v...)
+	for n%4 != 0 {
+		w.buf = append(w.buf, 0)
+		n++
+	}
+	return w
+}
+
+// blockID writes a tonNode.blockIdExt
+func (w *tlWriter) blockID(id *BlockID) *tlWriter {
+	return w.int32(id.Workchain).int64(id.Shard).uint32(id.SeqNo).int256(id.RootHash).int256(id.FileHash)
+}
+
+// tlReader parses TL values, keeping the first error
+type tlReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// take returns the next n bytes
+func (r *tlReader) take(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = fmt.Errorf("%w: unexpected end of data", errTL)
+		return make([]byte, n)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// uint32 reads a 32-bit little-endian integer
+func (r *tlReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.take(4))
+}
+
+// int32 reads a signed 32-bit integer
+func (r *tlReader) int32() int32 {
+	return int32(r.uint32())
+}
+
+// int64 reads a signed 64-bit integer
+func (r *tlReader) int64() int64 {
+	return int64(binary.LittleEndian.Uint64(r.take(8)))
+}
+
+// int256 reads 32 raw bytes
+func (r *tlReader) int256() []byte {
+	return append([]byte(nil), r.take(32)...)
+}
+
+// bool reads a Bool
+func (r *tlReader) bool() bool {
+	switch r.uint32() {
+	case tlBoolTrue:
+		return true
+	case tlBoolFalse:
+		return false
+	}
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: invalid Bool", errTL)
+	}
+	return false
+}
+
+// bytes reads a length-prefixed byte string
+func (r *tlReader) bytes() []byte {
+	n := int(r.take(1)[0])
+	hdr := 1
+	if n == 0xfe {
+		l := r.take(3)
+		n = int(l[0]) | int(l[1])<<8 | int(l[2])<<16
+		hdr = 4
+	}
+	b := append([]byte(nil), r.take(n)...)
+	if pad := (hdr + n) % 4; pad != 0 {
+		r.take(4 - pad)
+	}
+	return b
+}
+
+// blockID reads a tonNode.blockIdExt
+func (r *tlReader) blockID() BlockID {
+	return BlockID{
+		Workchain: r.int32(),
+		Shard:     r.int64(),
+		SeqNo:     r.uint32(),
+		RootHash:  r.int256(),
+		FileHash:  r.int256(),
+	}
+}
+
+// expect reads a constructor ID and fails if it differs from id
+func (r *tlReader) expect(id uint32) {
+	if got := r.uint32(); r.err == nil && got != id {
+		r.err = fmt.Errorf("%w: unexpected constructor %08x, want %08x", errTL, got, id)
+	}
+}
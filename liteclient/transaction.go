@@ -0,0 +1,371 @@
+package liteclient
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// accountStatusNames maps AccountStatus tags to toncenter names
+var accountStatusNames = [4]string{"uninit", "frozen", "active", "nonexist"}
+
+// statusChangeNames maps AccStatusChange values to names
+var statusChangeNames = map[uint64]string{0: "unchanged", 2: "frozen", 3: "deleted"}
+
+// ParseTransaction decodes a Transaction cell into the toncenter representation
+func ParseTransaction(root *cell.Cell, block BlockID) (*toncenterzp.TransactionDetails, error) {
+	s := root.BeginParse()
+	if s.LoadUInt(4) != 0b0111 {
+		if s.Err() != nil {
+			return nil, s.Err()
+		}
+		return nil, errUnsupported("transaction tag")
+	}
+	
+	tx := &toncenterzp.TransactionDetails{
+		Data:       root.ToBOCBase64(),
+		Hash:       base64.StdEncoding.EncodeToString(root.Hash()),
+		BlockID:    blockIDToAPI(block),
+		OutMsgs:    []toncenterzp.Message{},
+		InMsg:      toncenterzp.Message{},
+		OtherFee:   "0",
+		GasFee:     "0",
+		FwdFee:     "0",
+		StorageFee: "0",
+	}
+	
+	account := address.New(block.Workchain, s.LoadBytes(32))
+	tx.AccountAddr = account.String()
+	tx.Lt = strconv.FormatUint(s.LoadUInt(64), 10)
+	tx.PrevTransHash = base64.StdEncoding.EncodeToString(s.LoadBytes(32))
+	tx.PrevTransLt = strconv.FormatUint(s.LoadUInt(64), 10)
+	tx.Now = int(s.LoadUInt(32))
+	tx.OutMsgsCount = int(s.LoadUInt(15))
+	tx.OrigStatus = accountStatusNames[s.LoadUInt(2)]
+	tx.EndStatus = accountStatusNames[s.LoadUInt(2)]
+	
+	msgs := s.LoadRefSlice()
+	if in := msgs.LoadMaybeRef(); in != nil {
+		msg, err := parseMessage(in)
+		if err != nil {
+			return nil, err
+		}
+		tx.InMsg = *msg
+	}
+	if msgs.LoadBit() {
+		err := dictForEach(msgs.LoadRef(), 15, func(_ []byte, v *cell.Slice) error {
+			msg, err := parseMessage(v.LoadRef())
+			if err != nil {
+				return err
+			}
+			tx.OutMsgs = append(tx.OutMsgs, *msg)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if msgs.Err() != nil {
+		return nil, msgs.Err()
+	}
+	
+	totalFees := s.LoadCoins()
+	s.LoadMaybeRef() // extra currencies
+	tx.Fee = totalFees.String()
+	tx.TotalFees = tx.Fee
+	s.LoadRef() // state_update
+	
+	descr := s.LoadRefSlice()
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if err := parseDescription(descr, tx); err != nil {
+		return nil, err
+	}
+	
+	storage, _ := new(big.Int).SetString(tx.StorageFee, 10)
+	tx.OtherFee = new(big.Int).Sub(totalFees, storage).String()
+	return tx, nil
+}
+
+// parseDescription reads an ordinary or tick-tock TransactionDescr into tx
+func parseDescription(s *cell.Slice, tx *toncenterzp.TransactionDetails) error {
+	switch {
+	case s.PreloadUInt(4) == 0b0000:
+		// trans_ord$0000 credit_first:Bool
+		tx.Description = "ord"
+		s.Skip(4 + 1)
+		if s.LoadBit() {
+			parseStoragePhase(s, tx)
+		}
+		if s.LoadBit() {
+			if s.LoadBit() {
+				tx.CreditPhase.DueFeesCollected = s.LoadCoins().String()
+			}
+			tx.CreditPhase.Credit = s.LoadCoins().String()
+			s.LoadMaybeRef()
+		}
+	case s.PreloadUInt(3) == 0b001:
+		// trans_tick_tock$001 is_tock:Bool
+		tx.Description = "tick_tock"
+		s.Skip(3 + 1)
+		parseStoragePhase(s, tx)
+	default:
+		tx.Description = "other"
+		return s.Err()
+	}
+	
+	parseComputePhase(s, tx)
+	if action := s.LoadMaybeRef(); action != nil {
+		parseActionPhase(action.BeginParse(), tx)
+	}
+	s.LoadBit() // aborted
+	if tx.Description == "ord" && s.LoadBit() {
+		parseBouncePhase(s, tx)
+	}
+	return s.Err()
+}
+
+// parseStoragePhase reads a TrStoragePhase
+func parseStoragePhase(s *cell.Slice, tx *toncenterzp.TransactionDetails) {
+	fees := s.LoadCoins()
+	if s.LoadBit() {
+		s.LoadCoins() // storage_fees_due
+	}
+	tx.StoragePhase.StorageFeesCollected = fees.String()
+	tx.StoragePhase.StatusChange = loadStatusChange(s)
+	tx.StorageFee = fees.String()
+}
+
+// parseComputePhase reads a TrComputePhase
+func parseComputePhase(s *cell.Slice, tx *toncenterzp.TransactionDetails) {
+	if !s.LoadBit() {
+		// tr_phase_compute_skipped$0 reason:ComputeSkipReason
+		switch s.LoadUInt(2) {
+		case 0b00:
+			tx.ComputePhase.SkippedReason = "no_state"
+		case 0b01:
+			tx.ComputePhase.SkippedReason = "bad_state"
+		case 0b10:
+			tx.ComputePhase.SkippedReason = "no_gas"
+		default:
+			s.Skip(1)
+			tx.ComputePhase.SkippedReason = "suspended"
+		}
+		return
+	}
+	
+	tx.ComputePhase.Success = s.LoadBit()
+	s.Skip(2) // msg_state_used, account_activated
+	tx.GasFee = s.LoadCoins().String()
+	
+	vm := s.LoadRefSlice()
+	tx.ComputePhase.GasUsed = vm.LoadVarUInt(3).String()
+	vm.LoadVarUInt(3) // gas_limit
+	if vm.LoadBit() {
+		vm.LoadVarUInt(2) // gas_credit
+	}
+	vm.Skip(8) // mode
+	tx.ComputePhase.ExitCode = int(vm.LoadInt(32))
+	if vm.LoadBit() {
+		vm.Skip(32) // exit_arg
+	}
+	tx.ComputePhase.VmSteps = int(vm.LoadUInt(32))
+}
+
+// parseActionPhase reads a TrActionPhase
+func parseActionPhase(s *cell.Slice, tx *toncenterzp.TransactionDetails) {
+	a := &tx.ActionPhase
+	a.Success = s.LoadBit()
+	a.Valid = s.LoadBit()
+	a.NoFunds = s.LoadBit()
+	a.StatusChange = loadStatusChange(s)
+	a.TotalFwdFees = "0"
+	if s.LoadBit() {
+		a.TotalFwdFees = s.LoadCoins().String()
+	}
+	a.TotalActionFees = "0"
+	if s.LoadBit() {
+		a.TotalActionFees = s.LoadCoins().String()
+	}
+	a.ResultCode = int(s.LoadInt(32))
+	if s.LoadBit() {
+		s.Skip(32) // result_arg
+	}
+	a.TotalActions = int(s.LoadUInt(16))
+	tx.FwdFee = a.TotalFwdFees
+}
+
+// parseBouncePhase reads a TrBouncePhase
+func parseBouncePhase(s *cell.Slice, tx *toncenterzp.TransactionDetails) {
+	b := &tx.BouncePhase
+	switch {
+	case s.LoadBit():
+		// tr_phase_bounce_ok$1 msg_size:StorageUsed msg_fees:Grams fwd_fees:Grams
+		b.BounceType = "ok"
+		s.LoadVarUInt(3)
+		s.LoadVarUInt(3)
+		b.MsgFees = s.LoadCoins().String()
+		b.FwdFees = s.LoadCoins().String()
+	case s.LoadBit():
+		// tr_phase_bounce_nofunds$01 msg_size:StorageUsed req_fwd_fees:Grams
+		b.BounceType = "no_funds"
+		s.LoadVarUInt(3)
+		s.LoadVarUInt(3)
+		b.ReqFwdFees = s.LoadCoins().String()
+	default:
+		b.BounceType = "negative_funds"
+	}
+}
+
+// loadStatusChange reads an AccStatusChange
+func loadStatusChange(s *cell.Slice) string {
+	if !s.LoadBit() {
+		return statusChangeNames[0]
+	}
+	return statusChangeNames[2|s.LoadUInt(1)]
+}
+
+// parseMessage decodes a Message Any cell into the toncenter representation
+func parseMessage(c *cell.Cell) (*toncenterzp.Message, error) {
+	if c == nil {
+		return nil, errUnsupported("empty message reference")
+	}
+	
+	s := c.BeginParse()
+	msg := &toncenterzp.Message{Value: "0", FwdFee: "0", IhrFee: "0", CreatedLt: "0"}
+	
+	switch {
+	case !s.LoadBit():
+		// int_msg_info$0 ihr_disabled bounce bounced src dest value ihr_fee fwd_fee created_lt created_at
+		msg.MsgType = "int"
+		s.Skip(3)
+		msg.Source = formatAddress(s.LoadAddress())
+		msg.Destination = formatAddress(s.LoadAddress())
+		msg.Value = s.LoadCoins().String()
+		s.LoadMaybeRef()
+		msg.IhrFee = s.LoadCoins().String()
+		msg.FwdFee = s.LoadCoins().String()
+		msg.CreatedLt = strconv.FormatUint(s.LoadUInt(64), 10)
+		s.Skip(32)
+	case !s.LoadBit():
+		// ext_in_msg_info$10 src:MsgAddressExt dest:MsgAddressInt import_fee:Grams
+		msg.MsgType = "ext_in"
+		s.LoadAddress()
+		msg.Destination = formatAddress(s.LoadAddress())
+		s.LoadCoins()
+	default:
+		// ext_out_msg_info$11 src:MsgAddressInt dest:MsgAddressExt created_lt created_at
+		msg.MsgType = "ext_out"
+		msg.Source = formatAddress(s.LoadAddress())
+		s.LoadAddress()
+		msg.CreatedLt = strconv.FormatUint(s.LoadUInt(64), 10)
+		s.Skip(32)
+	}
+	
+	// init:(Maybe (Either StateInit ^StateInit))
+	if s.LoadBit() {
+		var init *cell.Cell
+		if s.LoadBit() {
+			init = s.LoadRef()
+		} else {
+			init = loadInlineStateInit(s)
+		}
+		if init != nil {
+			msg.MsgData.InitState = init.ToBOCBase64()
+		}
+	}
+	
+	// body:(Either X ^X)
+	var body *cell.Cell
+	if s.LoadBit() {
+		body = s.LoadRef()
+	} else if s.Err() == nil {
+		var err error
+		if body, err = s.ToCell(); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	
+	msg.BodyHash = base64.StdEncoding.EncodeToString(body.Hash())
+	msg.MsgData.Body = body.ToBOCBase64()
+	if text, ok := textComment(body); ok {
+		msg.MsgData.Text = base64.StdEncoding.EncodeToString([]byte(text))
+	}
+	return msg, nil
+}
+
+// loadInlineStateInit reads a StateInit stored inline and returns it as a cell
+func loadInlineStateInit(s *cell.Slice) *cell.Cell {
+	b := cell.BeginCell()
+	if s.LoadBit() {
+		b.StoreBit(true).StoreUInt(s.LoadUInt(5), 5)
+	} else {
+		b.StoreBit(false)
+	}
+	if s.LoadBit() {
+		b.StoreBit(true).StoreUInt(s.LoadUInt(2), 2)
+	} else {
+		b.StoreBit(false)
+	}
+	b.StoreMaybeRef(s.LoadMaybeRef())
+	b.StoreMaybeRef(s.LoadMaybeRef())
+	b.StoreMaybeRef(s.LoadMaybeRef())
+	
+	c, err := b.EndCell()
+	if err != nil || s.Err() != nil {
+		return nil
+	}
+	return c
+}
+
+// textComment returns the text of a plain comment body (opcode 0)
+func textComment(body *cell.Cell) (string, bool) {
+	s := body.BeginParse()
+	if s.BitsLeft() < 32 || s.LoadUInt(32) != 0 {
+		return "", false
+	}
+	text := s.LoadStringSnake()
+	return text, s.Err() == nil
+}
+
+// formatAddress returns the user-friendly form of an address, or "" for none
+func formatAddress(a *address.Address) string {
+	if a == nil {
+		return ""
+	}
+	return a.String()
+}
+
+// blockIDToAPI converts a BlockID to the toncenter representation
+func blockIDToAPI(id BlockID) toncenterzp.BlockID {
+	return toncenterzp.BlockID{
+		Workchain: int(id.Workchain),
+		Shard:     strconv.FormatInt(id.Shard, 10),
+		SeqNo:     int(id.SeqNo),
+		RootHash:  base64.StdEncoding.EncodeToString(id.RootHash),
+		FileHash:  base64.StdEncoding.EncodeToString(id.FileHash),
+	}
+}
+
+// decodeHash decodes a base64 or hex encoded 32-byte hash
+func decodeHash(s string) ([]byte, error) {
+	if b, err := hex.DecodeString(s); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	if b, err := base64.URLEncoding.DecodeString(s); err == nil && len(b) == 32 {
+		return b, nil
+	}
+	return nil, errUnsupported("hash encoding of " + strconv.Quote(s))
+}
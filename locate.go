@@ -22,10 +22,10 @@ type TryLocateResultTxResponse struct {
 }
 
 // TryLocateResultTx tries to locate a result transaction
-func (c *Client) TryLocateResultTx(req TryLocateResultTxRequest) (*TryLocateResultTxResponse, error) {
+func (h *HTTPBackend) TryLocateResultTx(req TryLocateResultTxRequest) (*TryLocateResultTxResponse, error) {
 	endpoint := "/tryLocateResultTx"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -58,10 +58,10 @@ type TryLocateSourceTxResponse struct {
 }
 
 // TryLocateSourceTx tries to locate a source transaction
-func (c *Client) TryLocateSourceTx(req TryLocateSourceTxRequest) (*TryLocateSourceTxResponse, error) {
+func (h *HTTPBackend) TryLocateSourceTx(req TryLocateSourceTxRequest) (*TryLocateSourceTxResponse, error) {
 	endpoint := "/tryLocateSourceTx"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -92,10 +92,10 @@ type TryLocateTxResponse struct {
 }
 
 // TryLocateTx tries to locate a transaction by hash
-func (c *Client) TryLocateTx(req TryLocateTxRequest) (*TryLocateTxResponse, error) {
+func (h *HTTPBackend) TryLocateTx(req TryLocateTxRequest) (*TryLocateTxResponse, error) {
 	endpoint := "/tryLocateTx"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
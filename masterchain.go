@@ -28,10 +28,10 @@ type GetMasterchainBlockSignaturesResponse struct {
 }
 
 // GetMasterchainBlockSignatures gets the signatures of a masterchain block
-func (c *Client) GetMasterchainBlockSignatures(req GetMasterchainBlockSignaturesRequest) (*GetMasterchainBlockSignaturesResponse, error) {
+func (h *HTTPBackend) GetMasterchainBlockSignatures(req GetMasterchainBlockSignaturesRequest) (*GetMasterchainBlockSignaturesResponse, error) {
 	endpoint := "/getMasterchainBlockSignatures"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -65,10 +65,10 @@ type GetMasterchainInfoResponse struct {
 }
 
 // GetMasterchainInfo gets information about the masterchain
-func (c *Client) GetMasterchainInfo() (*GetMasterchainInfoResponse, error) {
+func (h *HTTPBackend) GetMasterchainInfo() (*GetMasterchainInfoResponse, error) {
 	endpoint := "/getMasterchainInfo"
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -128,10 +128,10 @@ type GetShardBlockProofResponse struct {
 }
 
 // GetShardBlockProof gets the proof of a shard block
-func (c *Client) GetShardBlockProof(req GetShardBlockProofRequest) (*GetShardBlockProofResponse, error) {
+func (h *HTTPBackend) GetShardBlockProof(req GetShardBlockProofRequest) (*GetShardBlockProofResponse, error) {
 	endpoint := "/getShardBlockProof"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -160,10 +160,10 @@ type GetTokenDataResponse struct {
 }
 
 // GetTokenData gets data about a token
-func (c *Client) GetTokenData(address string) (*GetTokenDataResponse, error) {
+func (h *HTTPBackend) GetTokenData(address string) (*GetTokenDataResponse, error) {
 	endpoint := fmt.Sprintf("/getTokenData?address=%s", address)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,165 @@
+// Package message builds the messages wallets send: internal messages, inbound
+// external messages with their state init, comment bodies and the action lists
+// of transfers, and derives contract addresses from state inits.
+package message
+
+import (
+	"fmt"
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// Send modes of action_send_msg
+const (
+	ModePayFeesSeparately uint8 = 1
+	ModeIgnoreErrors      uint8 = 2
+	ModeBounceOnFail      uint8 = 16
+	ModeDestroyIfZero     uint8 = 32
+	ModeCarryInbound      uint8 = 64
+	ModeCarryBalance      uint8 = 128
+)
+
+// tagSendMsg is the tag of action_send_msg
+const tagSendMsg = 0x0ec3c86d
+
+// MaxActions is the maximum number of actions of a transaction
+const MaxActions = 255
+
+// Internal is an internal message sent by a contract. The source, fees and
+// timestamps are filled in by the network.
+type Internal struct {
+	Bounce      bool
+	Destination *address.Address
+	Amount      *big.Int
+	StateInit   *cell.Cell // optional
+	Body        *cell.Cell // optional
+}
+
+// ToCell builds the message as a MessageRelaxed
+func (m *Internal) ToCell() (*cell.Cell, error) {
+	if m.Destination == nil {
+		return nil, fmt.Errorf("message: missing destination")
+	}
+	amount := m.Amount
+	if amount == nil {
+		amount = new(big.Int)
+	}
+	
+	// int_msg_info$0 ihr_disabled:Bool bounce:Bool bounced:Bool src:MsgAddress
+	// dest:MsgAddressInt value:CurrencyCollection ihr_fee:Grams fwd_fee:Grams
+	// created_lt:uint64 created_at:uint32
+	b := cell.BeginCell().
+		StoreBit(false).
+		StoreBit(true).
+		StoreBit(m.Bounce).
+		StoreBit(false).
+		StoreAddress(nil).
+		StoreAddress(m.Destination).
+		StoreCoins(amount).
+		StoreBit(false). // no extra currencies
+		StoreCoins(nil).
+		StoreCoins(nil).
+		StoreUInt(0, 64).
+		StoreUInt(0, 32)
+	storeInitAndBody(b, m.StateInit, m.Body)
+	return b.EndCell()
+}
+
+// External is an inbound external message, e.g. a signed wallet request
+type External struct {
+	Destination *address.Address
+	StateInit   *cell.Cell // set to deploy the destination
+	Body        *cell.Cell
+}
+
+// ToCell builds the message
+func (m *External) ToCell() (*cell.Cell, error) {
+	if m.Destination == nil {
+		return nil, fmt.Errorf("message: missing destination")
+	}
+	
+	// ext_in_msg_info$10 src:MsgAddressExt dest:MsgAddressInt import_fee:Grams
+	b := cell.BeginCell().
+		StoreUInt(0b10, 2).
+		StoreAddress(nil).
+		StoreAddress(m.Destination).
+		StoreCoins(nil)
+	storeInitAndBody(b, m.StateInit, m.Body)
+	return b.EndCell()
+}
+
+// storeInitAndBody stores init:(Maybe (Either StateInit ^StateInit)) and
+// body:(Either X ^X), both by reference
+func storeInitAndBody(b *cell.Builder, init, body *cell.Cell) {
+	if init != nil {
+		b.StoreUInt(0b11, 2).StoreRef(init)
+	} else {
+		b.StoreBit(false)
+	}
+	if body != nil {
+		b.StoreBit(true).StoreRef(body)
+	} else {
+		b.StoreBit(false)
+	}
+}
+
+// StateInit builds the state init of a contract with the given code and data
+func StateInit(code, data *cell.Cell) (*cell.Cell, error) {
+	// split_depth:(Maybe (## 5)) special:(Maybe TickTock) code:(Maybe ^Cell)
+	// data:(Maybe ^Cell) library:(HashmapE 256 SimpleLib)
+	return cell.BeginCell().
+		StoreBit(false).
+		StoreBit(false).
+		StoreMaybeRef(code).
+		StoreMaybeRef(data).
+		StoreBit(false).
+		EndCell()
+}
+
+// Address returns the address of the contract deployed with a state init
+func Address(workchain int32, stateInit *cell.Cell) *address.Address {
+	return address.New(workchain, stateInit.Hash())
+}
+
+// Comment builds the body of a text comment
+func Comment(text string) (*cell.Cell, error) {
+	return cell.BeginCell().StoreUInt(0, 32).StoreStringSnake(text).EndCell()
+}
+
+// Send is an outgoing message with its send mode
+type Send struct {
+	Mode    uint8
+	Message *cell.Cell // a MessageRelaxed, e.g. from Internal.ToCell
+}
+
+// OutList builds the action list sending msgs in order, as set by wallets in c5
+func OutList(msgs []Send) (*cell.Cell, error) {
+	if len(msgs) > MaxActions {
+		return nil, fmt.Errorf("message: %d actions, at most %d allowed", len(msgs), MaxActions)
+	}
+	
+	// out_list_empty$_ = OutList 0
+	list, err := cell.BeginCell().EndCell()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		if m.Message == nil {
+			return nil, fmt.Errorf("message: missing message")
+		}
+		// out_list$_ prev:^(OutList n) action:OutAction
+		// action_send_msg#0ec3c86d mode:(## 8) out_msg:^(MessageRelaxed Any)
+		list, err = cell.BeginCell().
+			StoreRef(list).
+			StoreUInt(tagSendMsg, 32).
+			StoreUInt(uint64(m.Mode), 8).
+			StoreRef(m.Message).
+			EndCell()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,278 @@
+// Package multisig works with multisig wallet v2 contracts.
+//
+// A multisig holds funds and executes orders approved by a threshold of its
+// signers. Every order lives in its own order contract, deployed by the new_order
+// message of a signer or proposer and addressed by the multisig address and the
+// order seqno. Signers approve by sending approve messages to the order, which
+// asks the multisig to execute it once the threshold is reached. Multisig reads
+// the parameters and orders with get methods and follows the execution of an
+// order through its transactions.
+package multisig
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/contract"
+	"github.com/zhaopeng331/toncenterzp/message"
+)
+
+// Opcodes of multisig v2 messages and order actions
+const (
+	OpNewOrder        uint32 = 0xf718510f
+	OpExecute         uint32 = 0x75097f5d
+	OpExecuteInternal uint32 = 0xa32c59bf
+	OpApprove         uint32 = 0xa762230f
+	OpApproveAccepted uint32 = 0x82609bf6
+	OpApproveRejected uint32 = 0xafaf283e
+	
+	OpSendMessage  uint32 = 0xf1381e5b
+	OpUpdateParams uint32 = 0x1d0cfbd3
+)
+
+// DefaultPageSize is the default Multisig.PageSize
+const DefaultPageSize = 50
+
+// ErrOrderNotFound is returned for orders that were not deployed
+var ErrOrderNotFound = errors.New("multisig: order not found")
+
+// Multisig is a deployed multisig wallet v2
+type Multisig struct {
+	Client  *toncenterzp.Client
+	Address *address.Address
+	
+	// PageSize is the GetTransactions page size used to follow orders
+	PageSize int
+}
+
+// New returns the multisig at an address
+func New(client *toncenterzp.Client, addr *address.Address) *Multisig {
+	return &Multisig{Client: client, Address: addr, PageSize: DefaultPageSize}
+}
+
+// run runs a get method and checks its exit code
+func run(c *toncenterzp.Client, addr *address.Address, method string, stack ...interface{}) (*toncenterzp.RunGetMethodResponse, error) {
+	resp, err := c.RunGetMethod(toncenterzp.RunGetMethodRequest{Address: addr.String(), Method: method, Stack: stack})
+	if err != nil {
+		return nil, err
+	}
+	if code := resp.Result.ExitCode; code != 0 && code != 1 {
+		return resp, fmt.Errorf("multisig: %s failed with exit code %d", method, code)
+	}
+	return resp, nil
+}
+
+// Data returns the parameters of the multisig with the get_multisig_data get
+// method. NextOrderSeqno is nil when the multisig allows arbitrary order seqnos.
+func (m *Multisig) Data() (*contract.MultisigData, error) {
+	resp, err := run(m.Client, m.Address, "get_multisig_data")
+	if err != nil {
+		return nil, err
+	}
+	
+	d := &contract.MultisigData{}
+	if d.NextOrderSeqno, err = resp.StackNum(0); err != nil {
+		return nil, err
+	}
+	if d.NextOrderSeqno.Sign() < 0 {
+		d.NextOrderSeqno = nil
+		d.AllowArbitrarySeqno = true
+	}
+	threshold, err := resp.StackNum(1)
+	if err != nil {
+		return nil, err
+	}
+	d.Threshold = uint8(threshold.Uint64())
+	
+	signers, err := resp.StackCell(2)
+	if err != nil {
+		return nil, err
+	}
+	if d.Signers, err = loadAddresses(cell.LoadDict(signers, 8)); err != nil {
+		return nil, err
+	}
+	proposers, err := resp.StackMaybeCell(3)
+	if err != nil {
+		return nil, err
+	}
+	if d.Proposers, err = loadAddresses(cell.LoadDict(proposers, 8)); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// OrderAddress returns the address of an order with the get_order_address get method
+func (m *Multisig) OrderAddress(seqno *big.Int) (*address.Address, error) {
+	resp, err := run(m.Client, m.Address, "get_order_address", toncenterzp.StackNum(seqno))
+	if err != nil {
+		return nil, err
+	}
+	return resp.StackAddress(0)
+}
+
+// OrderAddress computes the address of an order of a multisig without a request.
+// orderCode must be the order code the multisig deploys orders with.
+func OrderAddress(multisig *address.Address, seqno *big.Int, orderCode *cell.Cell) (*address.Address, error) {
+	data, err := cell.BeginCell().StoreAddress(multisig).StoreBigUInt(seqno, 256).EndCell()
+	if err != nil {
+		return nil, err
+	}
+	init, err := message.StateInit(orderCode, data)
+	if err != nil {
+		return nil, err
+	}
+	return message.Address(multisig.Workchain, init), nil
+}
+
+// Order is the state of an order contract
+type Order struct {
+	Address   *address.Address
+	Multisig  *address.Address
+	Seqno     *big.Int
+	Threshold uint8
+	Executed  bool // sent to the multisig for execution
+	Signers   []*address.Address
+	
+	// ApprovalsMask has bit i set when signer i approved
+	ApprovalsMask *big.Int
+	Approvals     uint8
+	ExpiresAt     time.Time
+	Actions       *cell.Cell // the order, see LoadActions
+}
+
+// Approved reports whether signer index approved the order
+func (o *Order) Approved(index uint8) bool {
+	return o.ApprovalsMask.Bit(int(index)) == 1
+}
+
+// Pending reports whether the order can still be approved and executed
+func (o *Order) Pending(now time.Time) bool {
+	return !o.Executed && now.Before(o.ExpiresAt)
+}
+
+// Order returns the state of an order with the get_order_data get method
+func (m *Multisig) Order(seqno *big.Int) (*Order, error) {
+	addr, err := m.OrderAddress(seqno)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.Client.RunGetMethod(toncenterzp.RunGetMethodRequest{Address: addr.String(), Method: "get_order_data"})
+	if err != nil {
+		return nil, err
+	}
+	if code := resp.Result.ExitCode; code != 0 && code != 1 {
+		return nil, ErrOrderNotFound
+	}
+	null, err := resp.StackIsNull(2)
+	if err != nil {
+		return nil, err
+	}
+	if null {
+		return nil, ErrOrderNotFound
+	}
+	return loadOrder(addr, resp)
+}
+
+// loadOrder reads the result of get_order_data: multisig_address order_seqno
+// threshold sent_for_execution signers approvals_mask approvals_num
+// expiration_date order
+func loadOrder(addr *address.Address, resp *toncenterzp.RunGetMethodResponse) (*Order, error) {
+	o := &Order{Address: addr}
+	nums := make([]*big.Int, 9)
+	for _, i := range []int{1, 2, 3, 5, 6, 7} {
+		n, err := resp.StackNum(i)
+		if err != nil {
+			return nil, err
+		}
+		nums[i] = n
+	}
+	
+	var err error
+	if o.Multisig, err = resp.StackAddress(0); err != nil {
+		return nil, err
+	}
+	signers, err := resp.StackCell(4)
+	if err != nil {
+		return nil, err
+	}
+	if o.Signers, err = loadAddresses(cell.LoadDict(signers, 8)); err != nil {
+		return nil, err
+	}
+	if o.Actions, err = resp.StackCell(8); err != nil {
+		return nil, err
+	}
+	
+	o.Seqno = nums[1]
+	o.Threshold = uint8(nums[2].Uint64())
+	o.Executed = nums[3].Sign() != 0
+	o.ApprovalsMask = nums[5]
+	o.Approvals = uint8(nums[6].Uint64())
+	o.ExpiresAt = time.Unix(nums[7].Int64(), 0)
+	return o, nil
+}
+
+// PendingOrders returns the orders from seqno from on that can still be approved,
+// in seqno order. It is not supported by multisigs with arbitrary order seqnos,
+// whose orders cannot be enumerated.
+func (m *Multisig) PendingOrders(from *big.Int) ([]*Order, error) {
+	d, err := m.Data()
+	if err != nil {
+		return nil, err
+	}
+	if d.AllowArbitrarySeqno {
+		return nil, toncenterzp.NewError(toncenterzp.ErrInvalidParams, "multisig allows arbitrary order seqnos", nil)
+	}
+	
+	now := time.Now()
+	var orders []*Order
+	for seqno := new(big.Int).Set(from); seqno.Cmp(d.NextOrderSeqno) < 0; seqno.Add(seqno, big.NewInt(1)) {
+		o, err := m.Order(new(big.Int).Set(seqno))
+		if errors.Is(err, ErrOrderNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if o.Pending(now) {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+// loadAddresses returns the addresses of a Hashmap 8 MsgAddressInt in key order
+func loadAddresses(d *cell.Dict) ([]*address.Address, error) {
+	var out []*address.Address
+	err := d.ForEach(func(_ []byte, v *cell.Slice) error {
+		a := v.LoadAddress()
+		if err := v.Err(); err != nil {
+			return err
+		}
+		out = append(out, a)
+		return nil
+	})
+	return out, err
+}
+
+// storeAddresses builds a Hashmap 8 MsgAddressInt keyed by index, nil if empty
+func storeAddresses(addrs []*address.Address) (*cell.Cell, error) {
+	if len(addrs) > 255 {
+		return nil, fmt.Errorf("multisig: %d addresses, at most 255 allowed", len(addrs))
+	}
+	d := cell.NewDict(8)
+	for i, a := range addrs {
+		v, err := cell.BeginCell().StoreAddress(a).EndCell()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.Set(cell.UintKey(uint64(i), 8), v); err != nil {
+			return nil, err
+		}
+	}
+	return d.ToCell()
+}
@@ -0,0 +1,296 @@
+package multisig
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/message"
+)
+
+func addr(b byte) *address.Address {
+	return address.New(0, append(make([]byte, 31), b))
+}
+
+// testOrder is an order served by fakeBackend
+type testOrder struct {
+	approvals int64 // approvals mask
+	executed  bool
+	expiresAt time.Time
+}
+
+// fakeBackend serves a multisig with signers 1, 2 and 3 and threshold 2 whose
+// order n is at addr(100+n)
+type fakeBackend struct {
+	toncenterzp.UnimplementedBackend
+	multisig  *address.Address
+	nextSeqno int64
+	orders    map[int64]*testOrder
+	txs       map[string][]toncenterzp.TransactionDetails
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{multisig: addr(50), orders: map[int64]*testOrder{}, txs: map[string][]toncenterzp.TransactionDetails{}}
+}
+
+func stack(entries ...[]interface{}) *toncenterzp.RunGetMethodResponse {
+	r := &toncenterzp.RunGetMethodResponse{OK: true}
+	r.Result.Stack = entries
+	return r
+}
+
+func stackAddress(a *address.Address) []interface{} {
+	c, _ := cell.BeginCell().StoreAddress(a).EndCell()
+	return []interface{}{"slice", map[string]interface{}{"bytes": c.ToBOCBase64()}}
+}
+
+func num(n int64) []interface{} {
+	return toncenterzp.StackNum(big.NewInt(n))
+}
+
+func (f *fakeBackend) RunGetMethod(req toncenterzp.RunGetMethodRequest) (*toncenterzp.RunGetMethodResponse, error) {
+	signers, err := storeAddresses([]*address.Address{addr(1), addr(2), addr(3)})
+	if err != nil {
+		return nil, err
+	}
+	switch req.Method {
+	case "get_multisig_data":
+		return stack(num(f.nextSeqno), num(2), toncenterzp.StackCell(signers), []interface{}{"null", nil}), nil
+	case "get_order_address":
+		n, _ := new(big.Int).SetString(req.Stack[0].([]interface{})[1].(string), 0)
+		return stack(stackAddress(addr(byte(100 + n.Int64())))), nil
+	case "get_order_data":
+		a, _ := address.Parse(req.Address)
+		seqno := int64(a.Hash[31]) - 100
+		o := f.orders[seqno]
+		if o == nil {
+			r := stack()
+			r.Result.ExitCode = -13
+			return r, nil
+		}
+		order, err := PackOrder([]Action{SendMessage(3, signers)})
+		if err != nil {
+			return nil, err
+		}
+		executed := int64(0)
+		if o.executed {
+			executed = -1
+		}
+		approvals := int64(0)
+		for m := o.approvals; m != 0; m &= m - 1 {
+			approvals++
+		}
+		return stack(
+			stackAddress(f.multisig),
+			num(seqno),
+			num(2),
+			num(executed),
+			toncenterzp.StackCell(signers),
+			num(o.approvals),
+			num(approvals),
+			num(o.expiresAt.Unix()),
+			toncenterzp.StackCell(order),
+		), nil
+	}
+	return nil, errors.New("unexpected get method " + req.Method)
+}
+
+func (f *fakeBackend) GetTransactions(req toncenterzp.GetTransactionsRequest) (*toncenterzp.GetTransactionsResponse, error) {
+	r := &toncenterzp.GetTransactionsResponse{OK: true}
+	if req.Hash == "" {
+		a, _ := address.Parse(req.Address)
+		r.Result.Transactions = f.txs[a.Raw()]
+	}
+	return r, nil
+}
+
+func testMultisig(f *fakeBackend) *Multisig {
+	c := toncenterzp.NewClient("")
+	c.Backend = f
+	return New(c, f.multisig)
+}
+
+func TestData(t *testing.T) {
+	f := newFakeBackend()
+	f.nextSeqno = 3
+	d, err := testMultisig(f).Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.NextOrderSeqno.Int64() != 3 || d.Threshold != 2 || d.AllowArbitrarySeqno {
+		t.Errorf("data %+v", d)
+	}
+	if len(d.Signers) != 3 || !d.Signers[1].Equal(addr(2)) || len(d.Proposers) != 0 {
+		t.Errorf("signers %v, proposers %v", d.Signers, d.Proposers)
+	}
+	
+	// A negative next seqno means arbitrary seqnos are allowed
+	f.nextSeqno = -1
+	m := testMultisig(f)
+	if d, err := m.Data(); err != nil || !d.AllowArbitrarySeqno || d.NextOrderSeqno != nil {
+		t.Errorf("arbitrary seqnos: %+v, %v", d, err)
+	}
+	if _, err := m.PendingOrders(big.NewInt(0)); err == nil {
+		t.Error("orders of a multisig with arbitrary seqnos enumerated")
+	}
+}
+
+func TestPendingOrders(t *testing.T) {
+	f := newFakeBackend()
+	f.nextSeqno = 4
+	later := time.Now().Add(time.Hour)
+	// Order 0 was not deployed, 2 was executed and 3 expired
+	f.orders[1] = &testOrder{approvals: 0b101, expiresAt: later}
+	f.orders[2] = &testOrder{approvals: 0b011, executed: true, expiresAt: later}
+	f.orders[3] = &testOrder{approvals: 0b001, expiresAt: time.Now().Add(-time.Hour)}
+	m := testMultisig(f)
+	
+	pending, err := m.PendingOrders(big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Seqno.Int64() != 1 {
+		t.Fatalf("pending orders %v", pending)
+	}
+	o := pending[0]
+	if !o.Approved(0) || o.Approved(1) || !o.Approved(2) || o.Approvals != 2 || o.Threshold != 2 {
+		t.Errorf("approvals %b (%d)", o.ApprovalsMask, o.Approvals)
+	}
+	if !o.Multisig.Equal(f.multisig) || !o.Address.Equal(addr(101)) || len(o.Signers) != 3 {
+		t.Errorf("order %+v", o)
+	}
+	actions, err := LoadActions(o.Actions)
+	if err != nil || len(actions) != 1 || actions[0].Mode != 3 {
+		t.Errorf("actions %+v, %v", actions, err)
+	}
+	
+	if _, err := m.Order(big.NewInt(0)); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("order 0: error %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestNewOrderBody(t *testing.T) {
+	inner, err := (&message.Internal{Destination: addr(9), Amount: big.NewInt(1)}).ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := Params{Threshold: 1, Signers: []*address.Address{addr(1)}, Proposers: []*address.Address{addr(4), addr(5)}}
+	o := &NewOrder{
+		QueryID:   7,
+		Seqno:     big.NewInt(3),
+		Signer:    true,
+		Index:     1,
+		ExpiresAt: time.Unix(2000000000, 0),
+		Actions:   []Action{SendMessage(1, inner), UpdateParams(params)},
+	}
+	body, err := o.Body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := body.BeginParse()
+	if uint32(s.LoadUInt(32)) != OpNewOrder || s.LoadUInt(64) != 7 || s.LoadBigUInt(256).Int64() != 3 ||
+		!s.LoadBool() || s.LoadUInt(8) != 1 || s.LoadUInt(48) != 2000000000 {
+		t.Fatal("wrong new_order fields")
+	}
+	
+	actions, err := LoadActions(s.LoadRef())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 2 || actions[0].Mode != 1 || !bytes.Equal(actions[0].Message.Hash(), inner.Hash()) {
+		t.Fatalf("actions %+v", actions)
+	}
+	u := actions[1].Update
+	if u == nil || u.Threshold != 1 || len(u.Signers) != 1 || len(u.Proposers) != 2 || !u.Proposers[1].Equal(addr(5)) {
+		t.Errorf("update %+v", u)
+	}
+	
+	if _, err := (&NewOrder{Actions: o.Actions}).Body(); err == nil {
+		t.Error("order without a seqno accepted")
+	}
+	if _, err := PackOrder(nil); err == nil {
+		t.Error("order without actions accepted")
+	}
+}
+
+func TestApproveBody(t *testing.T) {
+	body, err := ApproveBody(9, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := body.BeginParse()
+	if uint32(s.LoadUInt(32)) != OpApprove || s.LoadUInt(64) != 9 || s.LoadUInt(8) != 2 || s.BitsLeft() != 0 {
+		t.Error("wrong approve fields")
+	}
+}
+
+func TestOrderAddress(t *testing.T) {
+	code, _ := cell.BeginCell().StoreUInt(1, 8).EndCell()
+	a, err := OrderAddress(addr(50), big.NewInt(1), code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := OrderAddress(addr(50), big.NewInt(2), code)
+	other, _ := OrderAddress(addr(51), big.NewInt(1), code)
+	if a.Workchain != 0 || a.Equal(b) || a.Equal(other) {
+		t.Errorf("order addresses %s, %s, %s", a, b, other)
+	}
+	
+	// The address is that of the order's state init
+	data, _ := cell.BeginCell().StoreAddress(addr(50)).StoreBigUInt(big.NewInt(1), 256).EndCell()
+	init, _ := message.StateInit(code, data)
+	if want := message.Address(0, init); !a.Equal(want) {
+		t.Errorf("order address %s, want %s", a, want)
+	}
+}
+
+func TestExecution(t *testing.T) {
+	f := newFakeBackend()
+	m := testMultisig(f)
+	if _, err := m.Execution(big.NewInt(2)); !errors.Is(err, ErrNotExecuted) {
+		t.Fatalf("no transactions: error %v, want ErrNotExecuted", err)
+	}
+	
+	// The order sent the execute message, which the multisig did not process yet
+	execute, _ := cell.BeginCell().StoreUInt(uint64(OpExecute), 32).StoreUInt(0, 64).EndCell()
+	order := addr(102)
+	f.txs[order.Raw()] = []toncenterzp.TransactionDetails{{
+		Hash: "order tx",
+		Lt:   "10",
+		OutMsgs: []toncenterzp.Message{{
+			Destination: f.multisig.String(),
+			CreatedLt:   "11",
+			MsgData:     toncenterzp.MessageData{Body: execute.ToBOCBase64()},
+		}},
+	}}
+	if _, err := m.Execution(big.NewInt(2)); !errors.Is(err, ErrNotExecuted) {
+		t.Fatalf("execute message in flight: error %v, want ErrNotExecuted", err)
+	}
+	
+	done := toncenterzp.TransactionDetails{
+		Hash:    "multisig tx",
+		Lt:      "12",
+		InMsg:   toncenterzp.Message{Source: order.String(), CreatedLt: "11"},
+		OutMsgs: []toncenterzp.Message{{Destination: addr(9).String()}},
+	}
+	done.ComputePhase.Success = true
+	done.ActionPhase.Success = true
+	other := toncenterzp.TransactionDetails{Hash: "other", InMsg: toncenterzp.Message{Source: addr(7).String(), CreatedLt: "11"}}
+	f.txs[f.multisig.Raw()] = []toncenterzp.TransactionDetails{other, done}
+	
+	x, err := m.Execution(big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.OrderTx.Hash != "order tx" || x.MultisigTx.Hash != "multisig tx" {
+		t.Errorf("transactions %s and %s", x.OrderTx.Hash, x.MultisigTx.Hash)
+	}
+	if !x.Success() || len(x.Messages()) != 1 {
+		t.Errorf("success %v, %d messages", x.Success(), len(x.Messages()))
+	}
+}
@@ -0,0 +1,188 @@
+package multisig
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// MaxActions is the maximum number of actions of an order
+const MaxActions = 255
+
+// Params are the parameters set by an update_multisig_params action
+type Params struct {
+	Threshold uint8
+	Signers   []*address.Address // in signer index order
+	Proposers []*address.Address // in proposer index order
+}
+
+// Action is an action of an order: a message the multisig sends or, when Update is
+// set, new multisig parameters
+type Action struct {
+	Mode    uint8
+	Message *cell.Cell // a MessageRelaxed, e.g. from message.Internal.ToCell
+	Update  *Params
+}
+
+// SendMessage returns an action sending a message with a send mode
+func SendMessage(mode uint8, msg *cell.Cell) Action {
+	return Action{Mode: mode, Message: msg}
+}
+
+// UpdateParams returns an action replacing the threshold, signers and proposers
+func UpdateParams(p Params) Action {
+	return Action{Update: &p}
+}
+
+// ToCell builds the action
+func (a Action) ToCell() (*cell.Cell, error) {
+	if a.Update == nil {
+		if a.Message == nil {
+			return nil, fmt.Errorf("multisig: action without message")
+		}
+		// send_message#f1381e5b mode:uint8 message:^MessageRelaxed
+		return cell.BeginCell().
+			StoreUInt(uint64(OpSendMessage), 32).
+			StoreUInt(uint64(a.Mode), 8).
+			StoreRef(a.Message).
+			EndCell()
+	}
+	
+	p := a.Update
+	if p.Threshold == 0 || int(p.Threshold) > len(p.Signers) {
+		return nil, fmt.Errorf("multisig: threshold %d with %d signers", p.Threshold, len(p.Signers))
+	}
+	signers, err := storeAddresses(p.Signers)
+	if err != nil {
+		return nil, err
+	}
+	proposers, err := storeAddresses(p.Proposers)
+	if err != nil {
+		return nil, err
+	}
+	// update_multisig_params#1d0cfbd3 threshold:uint8 signers:^(Hashmap 8 MsgAddressInt)
+	// proposers:(HashmapE 8 MsgAddressInt)
+	return cell.BeginCell().
+		StoreUInt(uint64(OpUpdateParams), 32).
+		StoreUInt(uint64(p.Threshold), 8).
+		StoreRef(signers).
+		StoreMaybeRef(proposers).
+		EndCell()
+}
+
+// PackOrder builds an order, a Hashmap 8 ^Action of the actions in execution order
+func PackOrder(actions []Action) (*cell.Cell, error) {
+	if len(actions) == 0 || len(actions) > MaxActions {
+		return nil, fmt.Errorf("multisig: %d actions, 1 to %d allowed", len(actions), MaxActions)
+	}
+	d := cell.NewDict(8)
+	for i, a := range actions {
+		action, err := a.ToCell()
+		if err != nil {
+			return nil, err
+		}
+		v, err := cell.BeginCell().StoreRef(action).EndCell()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.Set(cell.UintKey(uint64(i), 8), v); err != nil {
+			return nil, err
+		}
+	}
+	return d.ToCell()
+}
+
+// LoadActions reads the actions of an order in execution order
+func LoadActions(order *cell.Cell) ([]Action, error) {
+	var actions []Action
+	err := cell.LoadDict(order, 8).ForEach(func(_ []byte, v *cell.Slice) error {
+		s := v.LoadRefSlice()
+		var a Action
+		switch op := uint32(s.LoadUInt(32)); op {
+		case OpSendMessage:
+			a.Mode = uint8(s.LoadUInt(8))
+			a.Message = s.LoadRef()
+		case OpUpdateParams:
+			a.Update = &Params{Threshold: uint8(s.LoadUInt(8))}
+			signers := cell.LoadDict(s.LoadRef(), 8)
+			proposers := s.LoadDict(8)
+			if err := s.Err(); err != nil {
+				return err
+			}
+			var err error
+			if a.Update.Signers, err = loadAddresses(signers); err != nil {
+				return err
+			}
+			if a.Update.Proposers, err = loadAddresses(proposers); err != nil {
+				return err
+			}
+		default:
+			if err := s.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("multisig: unknown action 0x%08x", op)
+		}
+		if err := v.Err(); err != nil {
+			return err
+		}
+		if err := s.Err(); err != nil {
+			return err
+		}
+		actions = append(actions, a)
+		return nil
+	})
+	return actions, err
+}
+
+// NewOrder is a new_order message, sent by a signer or proposer to the multisig
+// with enough value to deploy the order contract
+type NewOrder struct {
+	QueryID uint64
+	
+	// Seqno must be the next order seqno of the multisig unless it allows
+	// arbitrary seqnos
+	Seqno *big.Int
+	
+	// Signer tells whether Index is a signer index or a proposer index. A signer
+	// creating an order approves it at once.
+	Signer bool
+	Index  uint8
+	
+	ExpiresAt time.Time
+	Actions   []Action
+}
+
+// Body builds the message body
+func (o *NewOrder) Body() (*cell.Cell, error) {
+	if o.Seqno == nil || o.Seqno.Sign() < 0 {
+		return nil, fmt.Errorf("multisig: invalid order seqno")
+	}
+	order, err := PackOrder(o.Actions)
+	if err != nil {
+		return nil, err
+	}
+	// new_order#f718510f query_id:uint64 order_seqno:uint256 signer:(## 1)
+	// index:uint8 expiration_date:uint48 order:^Order
+	return cell.BeginCell().
+		StoreUInt(uint64(OpNewOrder), 32).
+		StoreUInt(o.QueryID, 64).
+		StoreBigUInt(o.Seqno, 256).
+		StoreBit(o.Signer).
+		StoreUInt(uint64(o.Index), 8).
+		StoreUInt(uint64(o.ExpiresAt.Unix()), 48).
+		StoreRef(order).
+		EndCell()
+}
+
+// ApproveBody builds the body of the approve message a signer sends to an order
+func ApproveBody(queryID uint64, signerIndex uint8) (*cell.Cell, error) {
+	// approve#a762230f query_id:uint64 signer_index:uint8
+	return cell.BeginCell().
+		StoreUInt(uint64(OpApprove), 32).
+		StoreUInt(queryID, 64).
+		StoreUInt(uint64(signerIndex), 8).
+		EndCell()
+}
@@ -0,0 +1,132 @@
+package multisig
+
+import (
+	"errors"
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// ErrNotExecuted is returned by Execution for orders the multisig has not executed yet
+var ErrNotExecuted = errors.New("multisig: order not executed")
+
+// Execution is the execution of an order: the order transaction that reached the
+// threshold and sent the execute message, and the multisig transaction that
+// performed the actions
+type Execution struct {
+	OrderTx    *toncenterzp.TransactionDetails
+	MultisigTx *toncenterzp.TransactionDetails
+}
+
+// Success reports whether the multisig performed all actions
+func (x *Execution) Success() bool {
+	tx := x.MultisigTx
+	return tx.ComputePhase.Success && tx.ActionPhase.Success
+}
+
+// Messages returns the messages the multisig sent for the order
+func (x *Execution) Messages() []toncenterzp.Message {
+	return x.MultisigTx.OutMsgs
+}
+
+// Execution follows an order to its execution: it finds the order transaction
+// that sent the execute message to the multisig, then the multisig transaction
+// that received it. ErrNotExecuted is returned until both exist.
+func (m *Multisig) Execution(seqno *big.Int) (*Execution, error) {
+	orderAddr, err := m.OrderAddress(seqno)
+	if err != nil {
+		return nil, err
+	}
+	
+	x := &Execution{}
+	var execute toncenterzp.Message
+	err = m.scan(orderAddr, "", func(tx *toncenterzp.TransactionDetails) bool {
+		for _, msg := range tx.OutMsgs {
+			if sameAddress(msg.Destination, m.Address) && bodyOp(msg) == OpExecute {
+				x.OrderTx = tx
+				execute = msg
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if x.OrderTx == nil {
+		return nil, ErrNotExecuted
+	}
+	
+	err = m.scan(m.Address, x.OrderTx.Lt, func(tx *toncenterzp.TransactionDetails) bool {
+		if sameAddress(tx.InMsg.Source, orderAddr) && tx.InMsg.CreatedLt == execute.CreatedLt {
+			x.MultisigTx = tx
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if x.MultisigTx == nil {
+		return nil, ErrNotExecuted
+	}
+	return x, nil
+}
+
+// scan calls fn for the transactions of addr, newest first, down to toLt, until fn
+// returns true
+func (m *Multisig) scan(addr *address.Address, toLt string, fn func(tx *toncenterzp.TransactionDetails) bool) error {
+	pageSize := m.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	
+	req := toncenterzp.GetTransactionsRequest{Address: addr.String(), Limit: pageSize, ToLt: toLt}
+	for {
+		resp, err := m.Client.GetTransactions(req)
+		if err != nil {
+			return err
+		}
+		
+		txs := resp.Result.Transactions
+		if req.Hash != "" && len(txs) > 0 && txs[0].Hash == req.Hash {
+			txs = txs[1:]
+		}
+		for i := range txs {
+			if fn(&txs[i]) {
+				return nil
+			}
+		}
+		if len(txs) == 0 || len(resp.Result.Transactions) < pageSize {
+			return nil
+		}
+		
+		req.Lt = txs[len(txs)-1].Lt
+		req.Hash = txs[len(txs)-1].Hash
+	}
+}
+
+// sameAddress reports whether an address string of the HTTP API equals a
+func sameAddress(s string, a *address.Address) bool {
+	b, err := address.Parse(s)
+	return err == nil && b.Equal(a)
+}
+
+// bodyOp returns the opcode of a message body, 0 if it has none
+func bodyOp(msg toncenterzp.Message) uint32 {
+	if msg.MsgData.Body == "" {
+		return 0
+	}
+	body, err := cell.FromBOCBase64(msg.MsgData.Body)
+	if err != nil {
+		return 0
+	}
+	s := body.BeginParse()
+	op := uint32(s.LoadUInt(32))
+	if s.Err() != nil {
+		return 0
+	}
+	return op
+}
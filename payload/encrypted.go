@@ -0,0 +1,113 @@
+package payload
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/internal/x25519"
+)
+
+// ErrDecrypt is returned for encrypted comments that cannot be decrypted with the given key
+var ErrDecrypt = errors.New("payload: cannot decrypt comment")
+
+// EncryptComment builds an encrypted comment body from the owner of priv, whose wallet
+// is sender, to the owner of peer. Either party can decrypt it. testnet tells whether
+// the comment is sent on testnet.
+//
+// The text is prefixed with 16 to 31 random bytes, the first holding the prefix length,
+// to a multiple of 16 bytes. The message key is the first 16 bytes of
+// hmac_sha512(salt, data) where the salt is the bounceable form of sender, flagged
+// test-only on testnet, as in the reference wallets; it derives the AES-CBC key and IV
+// from the x25519 shared secret of the two keys.
+func EncryptComment(text string, sender *address.Address, testnet bool, priv ed25519.PrivateKey, peer ed25519.PublicKey) (*cell.Cell, error) {
+	pad := 16 + (16-len(text)%16)%16
+	data := make([]byte, pad+len(text))
+	if _, err := rand.Read(data[:pad]); err != nil {
+		return nil, err
+	}
+	data[0] = byte(pad)
+	copy(data[pad:], text)
+	
+	msgKey := commentMsgKey(sender, testnet, data)
+	block, iv, err := commentCipher(priv, peer, msgKey)
+	if err != nil {
+		return nil, err
+	}
+	
+	own := priv.Public().(ed25519.PublicKey)
+	out := make([]byte, 32+16+len(data))
+	for i := 0; i < 32; i++ {
+		out[i] = own[i] ^ peer[i]
+	}
+	copy(out[32:], msgKey)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[48:], data)
+	
+	return cell.BeginCell().StoreUInt(uint64(OpEncryptedComment), 32).StoreBytesSnake(out).EndCell()
+}
+
+// Decrypt returns the text of the comment using the private key of its sender or of
+// its recipient. sender is the wallet address the comment was sent from and testnet
+// tells whether it was sent on testnet; the flags sender was parsed with do not matter.
+func (c *EncryptedComment) Decrypt(sender *address.Address, testnet bool, priv ed25519.PrivateKey) (string, error) {
+	if len(c.Data) < 32+16+16 || (len(c.Data)-48)%16 != 0 {
+		return "", fmt.Errorf("%w: %d bytes of encrypted data", ErrDecrypt, len(c.Data))
+	}
+	
+	own := priv.Public().(ed25519.PublicKey)
+	peer := make(ed25519.PublicKey, 32)
+	for i := range peer {
+		peer[i] = c.Data[i] ^ own[i]
+	}
+	msgKey := c.Data[32:48]
+	
+	block, iv, err := commentCipher(priv, peer, msgKey)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	data := make([]byte, len(c.Data)-48)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(data, c.Data[48:])
+	
+	if !hmac.Equal(commentMsgKey(sender, testnet, data), msgKey) {
+		return "", fmt.Errorf("%w: message key mismatch", ErrDecrypt)
+	}
+	pad := int(data[0])
+	if pad < 16 || pad > len(data) {
+		return "", fmt.Errorf("%w: invalid prefix length %d", ErrDecrypt, pad)
+	}
+	return string(data[pad:]), nil
+}
+
+// commentMsgKey returns the message key of padded comment data
+func commentMsgKey(sender *address.Address, testnet bool, data []byte) []byte {
+	salt := []byte(sender.Format(true, testnet))
+	mac := hmac.New(sha512.New, salt)
+	mac.Write(data)
+	return mac.Sum(nil)[:16]
+}
+
+// commentCipher derives the AES-256 cipher and IV of a comment from the shared secret
+// of a key pair and a peer key
+func commentCipher(priv ed25519.PrivateKey, peer ed25519.PublicKey, msgKey []byte) (cipher.Block, []byte, error) {
+	shared, err := x25519.SharedKey(priv, peer)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	mac := hmac.New(sha512.New, shared)
+	mac.Write(msgKey)
+	x := mac.Sum(nil)
+	
+	block, err := aes.NewCipher(x[:32])
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, x[32:48], nil
+}
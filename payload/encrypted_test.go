@@ -0,0 +1,148 @@
+package payload
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"errors"
+	"strings"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+)
+
+const senderRaw = "0:83dfd552e63729b472fcbcc8c45ebcc6691702558b68ec7527e1ba403a0f31a8"
+
+func keyPair(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pub, priv
+}
+
+// encrypt encrypts text and decodes the body back into an EncryptedComment
+func encrypt(t *testing.T, text string, sender *address.Address, testnet bool, priv ed25519.PrivateKey, peer ed25519.PublicKey) *EncryptedComment {
+	t.Helper()
+	body, err := EncryptComment(text, sender, testnet, priv, peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Decode(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := b.(*EncryptedComment)
+	if !ok {
+		t.Fatalf("decoded %T", b)
+	}
+	return c
+}
+
+func TestEncryptedCommentRoundTrip(t *testing.T) {
+	_, senderKey := keyPair(t)
+	recipientPub, recipientKey := keyPair(t)
+	sender := address.MustParse(senderRaw)
+	
+	long := strings.Repeat("a long comment spanning several cells ", 20)
+	for _, text := range []string{"", "hello", "0123456789abcdef", "привет", long} {
+		c := encrypt(t, text, sender, false, senderKey, recipientPub)
+		if (len(c.Data)-48)%16 != 0 || len(c.Data)-48 < len(text)+16 {
+			t.Errorf("%q: %d bytes of encrypted data", text, len(c.Data))
+		}
+		for name, key := range map[string]ed25519.PrivateKey{"sender": senderKey, "recipient": recipientKey} {
+			got, err := c.Decrypt(sender, false, key)
+			if err != nil {
+				t.Fatalf("%q by %s: %v", text, name, err)
+			}
+			if got != text {
+				t.Errorf("%q by %s: got %q", text, name, got)
+			}
+		}
+	}
+}
+
+func TestEncryptedCommentIsRandomized(t *testing.T) {
+	_, senderKey := keyPair(t)
+	recipientPub, _ := keyPair(t)
+	sender := address.MustParse(senderRaw)
+	
+	a := encrypt(t, "same text", sender, false, senderKey, recipientPub)
+	b := encrypt(t, "same text", sender, false, senderKey, recipientPub)
+	if bytes.Equal(a.Data, b.Data) {
+		t.Error("two encryptions of the same text are equal")
+	}
+}
+
+func TestEncryptedCommentSalt(t *testing.T) {
+	// The reference wallets salt with the bounceable url-safe form of the sender,
+	// with the test-only flag on testnet
+	sender := address.MustParse(senderRaw)
+	data := bytes.Repeat([]byte{7}, 32)
+	for _, tt := range []struct {
+		testnet bool
+		salt    string
+	}{
+		{false, "EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"},
+		{true, "kQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqKYH"},
+	} {
+		mac := hmac.New(sha512.New, []byte(tt.salt))
+		mac.Write(data)
+		if got := commentMsgKey(sender, tt.testnet, data); !bytes.Equal(got, mac.Sum(nil)[:16]) {
+			t.Errorf("testnet %v: message key not salted with %s", tt.testnet, tt.salt)
+		}
+	}
+}
+
+func TestEncryptedCommentSaltIgnoresAddressForm(t *testing.T) {
+	_, senderKey := keyPair(t)
+	recipientPub, recipientKey := keyPair(t)
+	
+	// The comment decrypts whichever form the sender address was parsed from
+	forms := []string{
+		senderRaw,
+		"EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N",
+		"UQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqEBI",
+		"kQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqKYH",
+	}
+	for _, testnet := range []bool{false, true} {
+		c := encrypt(t, "hello", address.MustParse(forms[0]), testnet, senderKey, recipientPub)
+		for _, form := range forms {
+			got, err := c.Decrypt(address.MustParse(form), testnet, recipientKey)
+			if err != nil || got != "hello" {
+				t.Errorf("testnet %v, sender parsed from %s: %q, %v", testnet, form, got, err)
+			}
+		}
+	}
+}
+
+func TestEncryptedCommentDecryptFailures(t *testing.T) {
+	_, senderKey := keyPair(t)
+	recipientPub, recipientKey := keyPair(t)
+	_, otherKey := keyPair(t)
+	sender := address.MustParse(senderRaw)
+	c := encrypt(t, "hello", sender, false, senderKey, recipientPub)
+	
+	if _, err := c.Decrypt(sender, true, recipientKey); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("other network: error %v, want ErrDecrypt", err)
+	}
+	other := address.MustParse("0:0000000000000000000000000000000000000000000000000000000000000001")
+	if _, err := c.Decrypt(other, false, recipientKey); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("other sender: error %v, want ErrDecrypt", err)
+	}
+	if _, err := c.Decrypt(sender, false, otherKey); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("third party key: error %v, want ErrDecrypt", err)
+	}
+	
+	tampered := &EncryptedComment{Data: append([]byte(nil), c.Data...)}
+	tampered.Data[len(tampered.Data)-1] ^= 1
+	if _, err := tampered.Decrypt(sender, false, recipientKey); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("tampered data: error %v, want ErrDecrypt", err)
+	}
+	short := &EncryptedComment{Data: c.Data[:50]}
+	if _, err := short.Decrypt(sender, false, recipientKey); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("short data: error %v, want ErrDecrypt", err)
+	}
+}
@@ -0,0 +1,153 @@
+// Package payload decodes message bodies by opcode.
+//
+// A body starts with a 32-bit opcode, usually followed by a 64-bit query_id. A
+// Registry maps opcodes to decoders that turn the rest of the body into typed
+// values; DefaultRegistry knows the standard schemas (comments, jettons, NFTs,
+// wallet v5 requests and pool stakes) and more can be registered per opcode.
+package payload
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// ErrInvalidBody is returned for bodies that do not match the schema of their opcode
+var ErrInvalidBody = errors.New("payload: invalid body")
+
+// invalidBody returns an ErrInvalidBody error with details
+func invalidBody(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidBody, fmt.Sprintf(format, args...))
+}
+
+// Body is a decoded message body
+type Body interface {
+	// Op returns the opcode of the body
+	Op() uint32
+}
+
+// DecodeFunc decodes a body from a slice positioned after its opcode
+type DecodeFunc func(s *cell.Slice) (Body, error)
+
+// Unknown is a body whose opcode has no decoder
+type Unknown struct {
+	Opcode  uint32
+	QueryID uint64 // zero when the body is shorter than an opcode and a query_id
+	Cell    *cell.Cell
+}
+
+// Op returns the opcode
+func (u *Unknown) Op() uint32 { return u.Opcode }
+
+// Registry maps opcodes to decoders. The zero value has no decoders and is safe
+// for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	decoders map[uint32]DecodeFunc
+}
+
+// NewRegistry returns a registry with the standard decoders
+func NewRegistry() *Registry {
+	r := &Registry{}
+	for op, fn := range standard {
+		r.Register(op, fn)
+	}
+	return r
+}
+
+// DefaultRegistry is the registry used by Decode and Register
+var DefaultRegistry = NewRegistry()
+
+// Register sets the decoder of an opcode, replacing any previous one
+func (r *Registry) Register(op uint32, fn DecodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	if r.decoders == nil {
+		r.decoders = make(map[uint32]DecodeFunc)
+	}
+	r.decoders[op] = fn
+}
+
+// Decode decodes a body. Bodies with an unregistered opcode are returned as Unknown.
+func (r *Registry) Decode(c *cell.Cell) (Body, error) {
+	if c == nil {
+		return nil, invalidBody("missing cell")
+	}
+	if c.Type() == cell.PrunedBranch {
+		return nil, invalidBody("pruned cell")
+	}
+	
+	s := c.BeginParse()
+	if s.BitsLeft() < 32 {
+		return nil, invalidBody("%d bits is too short for an opcode", s.BitsLeft())
+	}
+	op := uint32(s.LoadUInt(32))
+	
+	r.mu.RLock()
+	fn, ok := r.decoders[op]
+	r.mu.RUnlock()
+	if !ok {
+		u := &Unknown{Opcode: op, Cell: c}
+		if s.BitsLeft() >= 64 {
+			u.QueryID = s.LoadUInt(64)
+		}
+		return u, nil
+	}
+	
+	body, err := fn(s)
+	if err == nil {
+		err = s.Err()
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidBody) {
+			return nil, err
+		}
+		return nil, invalidBody("op 0x%08x: %v", op, err)
+	}
+	return body, nil
+}
+
+// DecodeBase64 decodes a body given as a base64 BOC, e.g. Message.MsgData.Body
+func (r *Registry) DecodeBase64(boc string) (Body, error) {
+	c, err := cell.FromBOCBase64(boc)
+	if err != nil {
+		return nil, invalidBody("%v", err)
+	}
+	return r.Decode(c)
+}
+
+// Register sets the decoder of an opcode in DefaultRegistry
+func Register(op uint32, fn DecodeFunc) {
+	DefaultRegistry.Register(op, fn)
+}
+
+// Decode decodes a body with DefaultRegistry
+func Decode(c *cell.Cell) (Body, error) {
+	return DefaultRegistry.Decode(c)
+}
+
+// loadEitherRef reads an (Either Cell ^Cell) field, returning nil when it is empty
+func loadEitherRef(s *cell.Slice) (*cell.Cell, error) {
+	if s.BitsLeft() == 0 && s.RefsLeft() == 0 {
+		return nil, nil
+	}
+	if s.LoadBit() {
+		return s.LoadRef(), s.Err()
+	}
+	if s.BitsLeft() == 0 && s.RefsLeft() == 0 {
+		return nil, s.Err()
+	}
+	c, err := s.ToCell()
+	if err != nil {
+		return nil, err
+	}
+	// the inline payload is the rest of the body
+	s.Skip(s.BitsLeft())
+	for s.RefsLeft() > 0 {
+		s.LoadRef()
+	}
+	return c, nil
+}
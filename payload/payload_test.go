@@ -0,0 +1,198 @@
+package payload
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+func must(c *cell.Cell, err error) *cell.Cell {
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+var testDest = address.New(0, append(make([]byte, 31), 1))
+
+func comment(text string) *cell.Cell {
+	return must(cell.BeginCell().StoreUInt(uint64(OpComment), 32).StoreStringSnake(text).EndCell())
+}
+
+func TestDecodeComment(t *testing.T) {
+	long := strings.Repeat("a comment longer than one cell ", 10)
+	for _, text := range []string{"", "hi", long} {
+		b, err := Decode(comment(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c, ok := b.(*Comment); !ok || c.Text != text {
+			t.Errorf("decoded %+v, want comment %q", b, text)
+		}
+	}
+}
+
+func TestDecodeJettonTransfer(t *testing.T) {
+	body := must(cell.BeginCell().
+		StoreUInt(uint64(OpJettonTransfer), 32).
+		StoreUInt(7, 64).
+		StoreCoins(big.NewInt(1000)).
+		StoreAddress(testDest).
+		StoreAddress(nil).
+		StoreMaybeRef(nil).
+		StoreCoins(big.NewInt(1)).
+		StoreBit(true).
+		StoreRef(comment("hi")).
+		EndCell())
+	b, err := DefaultRegistry.DecodeBase64(body.ToBOCBase64())
+	if err != nil {
+		t.Fatal(err)
+	}
+	jt, ok := b.(*JettonTransfer)
+	if !ok {
+		t.Fatalf("decoded %T", b)
+	}
+	if jt.QueryID != 7 || jt.Amount.Int64() != 1000 || !jt.Destination.Equal(testDest) || jt.ForwardTonAmount.Int64() != 1 {
+		t.Errorf("transfer %+v", jt)
+	}
+	fwd, err := Decode(jt.ForwardPayload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := fwd.(*Comment); !ok || c.Text != "hi" {
+		t.Errorf("forward payload %+v", fwd)
+	}
+	
+	// The forward payload may also be inline
+	inline := must(cell.BeginCell().
+		StoreUInt(uint64(OpJettonTransfer), 32).
+		StoreUInt(7, 64).
+		StoreCoins(big.NewInt(1000)).
+		StoreAddress(testDest).
+		StoreAddress(nil).
+		StoreMaybeRef(nil).
+		StoreCoins(big.NewInt(1)).
+		StoreBit(false).
+		StoreUInt(uint64(OpComment), 32).
+		StoreStringSnake("hi").
+		EndCell())
+	b, err = Decode(inline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fwd, err := Decode(b.(*JettonTransfer).ForwardPayload); err != nil || fwd.(*Comment).Text != "hi" {
+		t.Errorf("inline forward payload %+v, %v", fwd, err)
+	}
+}
+
+// internalMessage returns an internal message of value to testDest
+func internalMessage(value int64) *cell.Cell {
+	return must(cell.BeginCell().
+		StoreUInt(0, 1). // int_msg_info
+		StoreBit(true).  // ihr_disabled
+		StoreBit(true).  // bounce
+		StoreBit(false). // bounced
+		StoreAddress(nil).
+		StoreAddress(testDest).
+		StoreCoins(big.NewInt(value)).
+		StoreBit(false). // other currencies
+		StoreCoins(big.NewInt(0)).
+		StoreCoins(big.NewInt(0)).
+		StoreUInt(0, 64).
+		StoreUInt(0, 32).
+		StoreBit(false). // init
+		StoreBit(false). // inline body
+		StoreUInt(0, 32).
+		EndCell())
+}
+
+func TestDecodeWalletV5(t *testing.T) {
+	// Two sends in an out list, then adding an extension and disallowing signatures
+	empty := must(cell.BeginCell().EndCell())
+	first := must(cell.BeginCell().StoreRef(empty).StoreUInt(tagSendMsg, 32).StoreUInt(3, 8).StoreRef(internalMessage(1)).EndCell())
+	second := must(cell.BeginCell().StoreRef(first).StoreUInt(tagSendMsg, 32).StoreUInt(1, 8).StoreRef(internalMessage(2)).EndCell())
+	next := must(cell.BeginCell().StoreUInt(uint64(SignatureAuthAllowed), 8).StoreBit(false).EndCell())
+	sig := make([]byte, 64)
+	sig[0] = 0xab
+	body := must(cell.BeginCell().
+		StoreUInt(uint64(OpWalletV5ExternalSigned), 32).
+		StoreUInt(698983191, 32).
+		StoreUInt(100, 32).
+		StoreUInt(5, 32).
+		StoreMaybeRef(second).
+		StoreBit(true).
+		StoreUInt(uint64(ExtensionAdd), 8).
+		StoreAddress(testDest).
+		StoreRef(next).
+		StoreBytes(sig).
+		EndCell())
+	
+	b, err := Decode(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := b.(*WalletV5Request)
+	if !ok {
+		t.Fatalf("decoded %T", b)
+	}
+	if r.Op() != OpWalletV5ExternalSigned || r.WalletID != 698983191 || r.ValidUntil != 100 || r.SeqNo != 5 || r.Signature[0] != 0xab {
+		t.Errorf("request %+v", r)
+	}
+	// Actions are in the order they are performed
+	if len(r.Actions) != 2 || r.Actions[0].Mode != 3 || r.Actions[0].Amount.Int64() != 1 || !r.Actions[0].Bounce ||
+		!r.Actions[0].Destination.Equal(testDest) || r.Actions[1].Mode != 1 || r.Actions[1].Amount.Int64() != 2 {
+		t.Errorf("actions %+v", r.Actions)
+	}
+	ext := r.ExtendedActions
+	if len(ext) != 2 || ext[0].Type != ExtensionAdd || !ext[0].Address.Equal(testDest) || ext[1].Type != SignatureAuthAllowed || ext[1].Allowed {
+		t.Errorf("extended actions %+v", ext)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	if _, err := Decode(must(cell.BeginCell().StoreUInt(1, 16).EndCell())); !errors.Is(err, ErrInvalidBody) {
+		t.Errorf("short body: error %v, want ErrInvalidBody", err)
+	}
+	if _, err := Decode(nil); !errors.Is(err, ErrInvalidBody) {
+		t.Errorf("missing body: error %v, want ErrInvalidBody", err)
+	}
+	truncated := must(cell.BeginCell().StoreUInt(uint64(OpJettonTransfer), 32).StoreUInt(7, 64).EndCell())
+	if _, err := Decode(truncated); !errors.Is(err, ErrInvalidBody) {
+		t.Errorf("truncated transfer: error %v, want ErrInvalidBody", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	c := must(cell.BeginCell().StoreUInt(0x12345678, 32).StoreUInt(9, 64).EndCell())
+	
+	b, err := Decode(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, ok := b.(*Unknown); !ok || u.Op() != 0x12345678 || u.QueryID != 9 || u.Cell != c {
+		t.Errorf("unregistered opcode decoded as %+v", b)
+	}
+	
+	r := NewRegistry()
+	r.Register(0x12345678, func(s *cell.Slice) (Body, error) {
+		return &Excesses{QueryID: s.LoadUInt(64)}, nil
+	})
+	b, err = r.Decode(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := b.(*Excesses); !ok || e.QueryID != 9 {
+		t.Errorf("registered opcode decoded as %+v", b)
+	}
+	
+	var empty Registry
+	if b, err := empty.Decode(comment("hi")); err != nil || b.Op() != OpComment {
+		t.Errorf("zero registry: %+v, %v", b, err)
+	} else if _, ok := b.(*Unknown); !ok {
+		t.Errorf("zero registry decoded a comment as %T", b)
+	}
+}
@@ -0,0 +1,309 @@
+package payload
+
+import (
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// Standard opcodes
+const (
+	OpComment                 uint32 = 0x00000000
+	OpEncryptedComment        uint32 = 0x2167da4b
+	OpJettonTransfer          uint32 = 0x0f8a7ea5
+	OpJettonTransferNotify    uint32 = 0x7362d09c
+	OpJettonInternalTransfer  uint32 = 0x178d4519
+	OpJettonBurn              uint32 = 0x595f07bc
+	OpJettonBurnNotification  uint32 = 0x7bdd97de
+	OpExcesses                uint32 = 0xd53276db
+	OpNFTTransfer             uint32 = 0x5fcc3d14
+	OpNFTOwnershipAssigned    uint32 = 0x05138d91
+	OpWalletV5ExternalSigned  uint32 = 0x7369676e
+	OpWalletV5InternalSigned  uint32 = 0x73696e74
+	OpWalletV5ExtensionAction uint32 = 0x6578746e
+	OpStakeDeposit            uint32 = 0x7bcd1fef
+	OpStakeWithdraw           uint32 = 0xda803efd
+)
+
+// standard holds the decoders installed by NewRegistry
+var standard = map[uint32]DecodeFunc{
+	OpComment:                 decodeComment,
+	OpEncryptedComment:        decodeEncryptedComment,
+	OpJettonTransfer:          decodeJettonTransfer,
+	OpJettonTransferNotify:    decodeJettonTransferNotification,
+	OpJettonInternalTransfer:  decodeJettonInternalTransfer,
+	OpJettonBurn:              decodeJettonBurn,
+	OpJettonBurnNotification:  decodeJettonBurnNotification,
+	OpExcesses:                decodeExcesses,
+	OpNFTTransfer:             decodeNFTTransfer,
+	OpNFTOwnershipAssigned:    decodeNFTOwnershipAssigned,
+	OpWalletV5ExternalSigned:  decodeWalletV5Signed(OpWalletV5ExternalSigned),
+	OpWalletV5InternalSigned:  decodeWalletV5Signed(OpWalletV5InternalSigned),
+	OpWalletV5ExtensionAction: decodeWalletV5ExtensionAction,
+	OpStakeDeposit:            decodeStakeDeposit,
+	OpStakeWithdraw:           decodeStakeWithdraw,
+}
+
+// Comment is a text comment
+type Comment struct {
+	Text string
+}
+
+// Op returns OpComment
+func (*Comment) Op() uint32 { return OpComment }
+
+// decodeComment decodes text_comment#00000000 text:SnakeData
+func decodeComment(s *cell.Slice) (Body, error) {
+	return &Comment{Text: s.LoadStringSnake()}, nil
+}
+
+// EncryptedComment is an encrypted comment. Data holds the sender public key
+// XORed with the recipient one, the message key and the ciphertext.
+type EncryptedComment struct {
+	Data []byte
+}
+
+// Op returns OpEncryptedComment
+func (*EncryptedComment) Op() uint32 { return OpEncryptedComment }
+
+// decodeEncryptedComment decodes encrypted_comment#2167da4b data:SnakeData
+func decodeEncryptedComment(s *cell.Slice) (Body, error) {
+	data := s.LoadBytesSnake()
+	if s.Err() == nil && len(data) < 32+16 {
+		return nil, invalidBody("encrypted comment of %d bytes", len(data))
+	}
+	return &EncryptedComment{Data: data}, nil
+}
+
+// JettonTransfer asks a jetton wallet to send jettons (TEP-74)
+type JettonTransfer struct {
+	QueryID             uint64
+	Amount              *big.Int
+	Destination         *address.Address
+	ResponseDestination *address.Address
+	CustomPayload       *cell.Cell
+	ForwardTonAmount    *big.Int
+	ForwardPayload      *cell.Cell
+}
+
+// Op returns OpJettonTransfer
+func (*JettonTransfer) Op() uint32 { return OpJettonTransfer }
+
+// decodeJettonTransfer decodes transfer#0f8a7ea5 query_id:uint64 amount:Coins
+// destination:MsgAddress response_destination:MsgAddress custom_payload:(Maybe ^Cell)
+// forward_ton_amount:Coins forward_payload:(Either Cell ^Cell)
+func decodeJettonTransfer(s *cell.Slice) (Body, error) {
+	t := &JettonTransfer{
+		QueryID:             s.LoadUInt(64),
+		Amount:              s.LoadCoins(),
+		Destination:         s.LoadAddress(),
+		ResponseDestination: s.LoadAddress(),
+		CustomPayload:       s.LoadMaybeRef(),
+		ForwardTonAmount:    s.LoadCoins(),
+	}
+	var err error
+	t.ForwardPayload, err = loadEitherRef(s)
+	return t, err
+}
+
+// JettonTransferNotification tells the owner of a jetton wallet about received jettons
+type JettonTransferNotification struct {
+	QueryID        uint64
+	Amount         *big.Int
+	Sender         *address.Address
+	ForwardPayload *cell.Cell
+}
+
+// Op returns OpJettonTransferNotify
+func (*JettonTransferNotification) Op() uint32 { return OpJettonTransferNotify }
+
+// decodeJettonTransferNotification decodes transfer_notification#7362d09c
+// query_id:uint64 amount:Coins sender:MsgAddress forward_payload:(Either Cell ^Cell)
+func decodeJettonTransferNotification(s *cell.Slice) (Body, error) {
+	n := &JettonTransferNotification{
+		QueryID: s.LoadUInt(64),
+		Amount:  s.LoadCoins(),
+		Sender:  s.LoadAddress(),
+	}
+	var err error
+	n.ForwardPayload, err = loadEitherRef(s)
+	return n, err
+}
+
+// JettonInternalTransfer moves jettons between jetton wallets
+type JettonInternalTransfer struct {
+	QueryID          uint64
+	Amount           *big.Int
+	From             *address.Address
+	ResponseAddress  *address.Address
+	ForwardTonAmount *big.Int
+	ForwardPayload   *cell.Cell
+}
+
+// Op returns OpJettonInternalTransfer
+func (*JettonInternalTransfer) Op() uint32 { return OpJettonInternalTransfer }
+
+// decodeJettonInternalTransfer decodes internal_transfer#178d4519 query_id:uint64
+// amount:Coins from:MsgAddress response_address:MsgAddress forward_ton_amount:Coins
+// forward_payload:(Either Cell ^Cell)
+func decodeJettonInternalTransfer(s *cell.Slice) (Body, error) {
+	t := &JettonInternalTransfer{
+		QueryID:          s.LoadUInt(64),
+		Amount:           s.LoadCoins(),
+		From:             s.LoadAddress(),
+		ResponseAddress:  s.LoadAddress(),
+		ForwardTonAmount: s.LoadCoins(),
+	}
+	var err error
+	t.ForwardPayload, err = loadEitherRef(s)
+	return t, err
+}
+
+// JettonBurn asks a jetton wallet to burn jettons
+type JettonBurn struct {
+	QueryID             uint64
+	Amount              *big.Int
+	ResponseDestination *address.Address
+	CustomPayload       *cell.Cell
+}
+
+// Op returns OpJettonBurn
+func (*JettonBurn) Op() uint32 { return OpJettonBurn }
+
+// decodeJettonBurn decodes burn#595f07bc query_id:uint64 amount:Coins
+// response_destination:MsgAddress custom_payload:(Maybe ^Cell)
+func decodeJettonBurn(s *cell.Slice) (Body, error) {
+	b := &JettonBurn{
+		QueryID:             s.LoadUInt(64),
+		Amount:              s.LoadCoins(),
+		ResponseDestination: s.LoadAddress(),
+	}
+	// custom_payload is often omitted altogether
+	if s.BitsLeft() > 0 {
+		b.CustomPayload = s.LoadMaybeRef()
+	}
+	return b, nil
+}
+
+// JettonBurnNotification tells a jetton minter about burned jettons
+type JettonBurnNotification struct {
+	QueryID             uint64
+	Amount              *big.Int
+	Sender              *address.Address
+	ResponseDestination *address.Address
+}
+
+// Op returns OpJettonBurnNotification
+func (*JettonBurnNotification) Op() uint32 { return OpJettonBurnNotification }
+
+// decodeJettonBurnNotification decodes burn_notification#7bdd97de query_id:uint64
+// amount:Coins sender:MsgAddress response_destination:MsgAddress
+func decodeJettonBurnNotification(s *cell.Slice) (Body, error) {
+	return &JettonBurnNotification{
+		QueryID:             s.LoadUInt(64),
+		Amount:              s.LoadCoins(),
+		Sender:              s.LoadAddress(),
+		ResponseDestination: s.LoadAddress(),
+	}, nil
+}
+
+// Excesses returns the unused TON of an operation
+type Excesses struct {
+	QueryID uint64
+}
+
+// Op returns OpExcesses
+func (*Excesses) Op() uint32 { return OpExcesses }
+
+// decodeExcesses decodes excesses#d53276db query_id:uint64
+func decodeExcesses(s *cell.Slice) (Body, error) {
+	return &Excesses{QueryID: s.LoadUInt(64)}, nil
+}
+
+// NFTTransfer asks an NFT item to change its owner (TEP-62)
+type NFTTransfer struct {
+	QueryID             uint64
+	NewOwner            *address.Address
+	ResponseDestination *address.Address
+	CustomPayload       *cell.Cell
+	ForwardAmount       *big.Int
+	ForwardPayload      *cell.Cell
+}
+
+// Op returns OpNFTTransfer
+func (*NFTTransfer) Op() uint32 { return OpNFTTransfer }
+
+// decodeNFTTransfer decodes transfer#5fcc3d14 query_id:uint64 new_owner:MsgAddress
+// response_destination:MsgAddress custom_payload:(Maybe ^Cell) forward_amount:Coins
+// forward_payload:(Either Cell ^Cell)
+func decodeNFTTransfer(s *cell.Slice) (Body, error) {
+	t := &NFTTransfer{
+		QueryID:             s.LoadUInt(64),
+		NewOwner:            s.LoadAddress(),
+		ResponseDestination: s.LoadAddress(),
+		CustomPayload:       s.LoadMaybeRef(),
+		ForwardAmount:       s.LoadCoins(),
+	}
+	var err error
+	t.ForwardPayload, err = loadEitherRef(s)
+	return t, err
+}
+
+// NFTOwnershipAssigned tells the new owner of an NFT item about the transfer
+type NFTOwnershipAssigned struct {
+	QueryID        uint64
+	PrevOwner      *address.Address
+	ForwardPayload *cell.Cell
+}
+
+// Op returns OpNFTOwnershipAssigned
+func (*NFTOwnershipAssigned) Op() uint32 { return OpNFTOwnershipAssigned }
+
+// decodeNFTOwnershipAssigned decodes ownership_assigned#05138d91 query_id:uint64
+// prev_owner:MsgAddress forward_payload:(Either Cell ^Cell)
+func decodeNFTOwnershipAssigned(s *cell.Slice) (Body, error) {
+	a := &NFTOwnershipAssigned{
+		QueryID:   s.LoadUInt(64),
+		PrevOwner: s.LoadAddress(),
+	}
+	var err error
+	a.ForwardPayload, err = loadEitherRef(s)
+	return a, err
+}
+
+// StakeDeposit deposits the message value into a staking pool
+type StakeDeposit struct {
+	QueryID  uint64
+	GasLimit *big.Int
+}
+
+// Op returns OpStakeDeposit
+func (*StakeDeposit) Op() uint32 { return OpStakeDeposit }
+
+// decodeStakeDeposit decodes stake_deposit#7bcd1fef query_id:uint64 gas_limit:Coins
+func decodeStakeDeposit(s *cell.Slice) (Body, error) {
+	return &StakeDeposit{
+		QueryID:  s.LoadUInt(64),
+		GasLimit: s.LoadCoins(),
+	}, nil
+}
+
+// StakeWithdraw withdraws a stake from a staking pool; a zero Amount withdraws everything
+type StakeWithdraw struct {
+	QueryID  uint64
+	GasLimit *big.Int
+	Amount   *big.Int
+}
+
+// Op returns OpStakeWithdraw
+func (*StakeWithdraw) Op() uint32 { return OpStakeWithdraw }
+
+// decodeStakeWithdraw decodes stake_withdraw#da803efd query_id:uint64 gas_limit:Coins stake:Coins
+func decodeStakeWithdraw(s *cell.Slice) (Body, error) {
+	return &StakeWithdraw{
+		QueryID:  s.LoadUInt(64),
+		GasLimit: s.LoadCoins(),
+		Amount:   s.LoadCoins(),
+	}, nil
+}
@@ -0,0 +1,209 @@
+package payload
+
+import (
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// tagSendMsg is the tag of action_send_msg, the only out action a wallet v5 accepts
+const tagSendMsg = 0x0ec3c86d
+
+// Extended action types of a wallet v5
+const (
+	ExtensionAdd         uint8 = 2
+	ExtensionDelete      uint8 = 3
+	SignatureAuthAllowed uint8 = 4
+)
+
+// WalletV5Request is a request to a wallet v5: a signed external or internal
+// message, or an action from an installed extension
+type WalletV5Request struct {
+	Opcode     uint32
+	WalletID   uint32 // signed requests only
+	ValidUntil uint32 // signed requests only
+	SeqNo      uint32 // signed requests only
+	QueryID    uint64 // extension actions only
+	Signature  []byte // signed requests only
+	
+	Actions         []SendMsg
+	ExtendedActions []ExtendedAction
+}
+
+// Op returns the opcode of the request
+func (r *WalletV5Request) Op() uint32 { return r.Opcode }
+
+// SendMsg is an action sending a message. Destination, Amount, Bounce and Body are
+// decoded from Message for internal messages.
+type SendMsg struct {
+	Mode    uint8
+	Message *cell.Cell
+	
+	Destination *address.Address
+	Amount      *big.Int
+	Bounce      bool
+	Body        *cell.Cell
+}
+
+// ExtendedAction changes the extensions or the signature mode of a wallet v5
+type ExtendedAction struct {
+	Type    uint8
+	Address *address.Address // ExtensionAdd and ExtensionDelete
+	Allowed bool             // SignatureAuthAllowed
+}
+
+// decodeWalletV5Signed returns the decoder of signed_request$_ wallet_id:uint32
+// valid_until:uint32 msg_seqno:uint32 inner:InnerRequest signature:bits512 under op
+func decodeWalletV5Signed(op uint32) DecodeFunc {
+	return func(s *cell.Slice) (Body, error) {
+		if s.BitsLeft() < 96+2+512 {
+			return nil, invalidBody("signed request of %d bits", s.BitsLeft())
+		}
+		sig := s.Copy()
+		sig.Skip(sig.BitsLeft() - 512)
+		
+		r := &WalletV5Request{
+			Opcode:     op,
+			WalletID:   uint32(s.LoadUInt(32)),
+			ValidUntil: uint32(s.LoadUInt(32)),
+			SeqNo:      uint32(s.LoadUInt(32)),
+			Signature:  sig.LoadBytes(64),
+		}
+		if err := r.loadInner(s); err != nil {
+			return nil, err
+		}
+		if s.BitsLeft() != 512 {
+			return nil, invalidBody("signed request has %d bits before its signature", s.BitsLeft()-512)
+		}
+		s.Skip(512)
+		return r, nil
+	}
+}
+
+// decodeWalletV5ExtensionAction decodes extension_action#6578746e query_id:uint64 inner:InnerRequest
+func decodeWalletV5ExtensionAction(s *cell.Slice) (Body, error) {
+	r := &WalletV5Request{
+		Opcode:  OpWalletV5ExtensionAction,
+		QueryID: s.LoadUInt(64),
+	}
+	if err := r.loadInner(s); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// loadInner reads actions$_ out_actions:(Maybe ^OutList) has_other_actions:(## 1)
+// followed by the extended actions, each but the last linking to the next one
+func (r *WalletV5Request) loadInner(s *cell.Slice) error {
+	if out := s.LoadMaybeRef(); out != nil {
+		actions, err := loadOutList(out)
+		if err != nil {
+			return err
+		}
+		r.Actions = actions
+	}
+	if !s.LoadBit() {
+		return s.Err()
+	}
+	
+	for cur := s; ; {
+		a := ExtendedAction{Type: uint8(cur.LoadUInt(8))}
+		switch a.Type {
+		case ExtensionAdd, ExtensionDelete:
+			a.Address = cur.LoadAddress()
+		case SignatureAuthAllowed:
+			a.Allowed = cur.LoadBit()
+		default:
+			if cur.Err() == nil {
+				return invalidBody("unknown extended action %d", a.Type)
+			}
+		}
+		if err := cur.Err(); err != nil {
+			return err
+		}
+		r.ExtendedActions = append(r.ExtendedActions, a)
+		
+		if cur.RefsLeft() == 0 {
+			return nil
+		}
+		cur = cur.LoadRefSlice()
+	}
+}
+
+// loadOutList reads out_list$_ prev:^OutList action:OutAction, which stores the
+// last action at the root, and returns the actions in execution order
+func loadOutList(c *cell.Cell) ([]SendMsg, error) {
+	var actions []SendMsg
+	for c.BitsSize() > 0 || c.RefsNum() > 0 {
+		s := c.BeginParse()
+		prev := s.LoadRef()
+		if tag := s.LoadUInt(32); s.Err() == nil && tag != tagSendMsg {
+			return nil, invalidBody("unsupported out action 0x%08x", tag)
+		}
+		a := SendMsg{Mode: uint8(s.LoadUInt(8)), Message: s.LoadRef()}
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		if err := a.loadMessage(); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+		c = prev
+	}
+	
+	for i, j := 0, len(actions)-1; i < j; i, j = i+1, j-1 {
+		actions[i], actions[j] = actions[j], actions[i]
+	}
+	return actions, nil
+}
+
+// loadMessage decodes the header and body of an internal MessageRelaxed
+func (a *SendMsg) loadMessage() error {
+	s := a.Message.BeginParse()
+	if s.LoadBit() {
+		// ext_out_msg_info$11 carries no value
+		return s.Err()
+	}
+	
+	// int_msg_info$0 ihr_disabled bounce bounced src dest value ihr_fee fwd_fee created_lt created_at
+	s.Skip(1)
+	a.Bounce = s.LoadBool()
+	s.Skip(1)
+	s.LoadAddress()
+	a.Destination = s.LoadAddress()
+	a.Amount = s.LoadCoins()
+	s.LoadMaybeRef()
+	s.LoadCoins()
+	s.LoadCoins()
+	s.Skip(64 + 32)
+	
+	// init:(Maybe (Either StateInit ^StateInit))
+	if s.LoadBit() {
+		if s.LoadBit() {
+			s.LoadRef()
+		} else {
+			skipStateInit(s)
+		}
+	}
+	
+	body, err := loadEitherRef(s)
+	if err != nil {
+		return err
+	}
+	a.Body = body
+	return s.Err()
+}
+
+// skipStateInit skips an inline StateInit
+func skipStateInit(s *cell.Slice) {
+	if s.LoadBit() {
+		s.Skip(5)
+	}
+	if s.LoadBit() {
+		s.Skip(2)
+	}
+	s.LoadMaybeRef()
+	s.LoadMaybeRef()
+	s.LoadMaybeRef()
+}
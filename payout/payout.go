@@ -0,0 +1,463 @@
+// Package payout sends withdrawals from a highload wallet v3 exactly once.
+//
+// An Engine takes payouts identified by idempotency keys, signs queued payouts into
+// batches of one wallet transfer each, broadcasts every batch with
+// SendBocReturnHash until it is valid no more, and reconciles batches by finding
+// their transactions with GetTransactions and matching the outgoing messages to
+// the payouts. Payouts move from queued to signed, sent and finally confirmed,
+// failed or expired; every transition is committed to a Store before the network
+// is told about it. A signed transfer can only be processed once, and a payout is
+// only signed again after its batch provably expired, so restarting the engine at
+// any point never pays twice.
+package payout
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/highload"
+	"github.com/zhaopeng331/toncenterzp/message"
+)
+
+// Payout states
+const (
+	StatusQueued    = "queued"    // waiting for a batch
+	StatusSigned    = "signed"    // in a batch that was not broadcast yet
+	StatusSent      = "sent"      // in a broadcast batch
+	StatusConfirmed = "confirmed" // the wallet sent the message
+	StatusFailed    = "failed"    // the wallet processed the batch without sending the message
+	StatusExpired   = "expired"   // the batch expired unprocessed
+	StatusReview    = "review"    // the batch was processed but its transactions were not found
+)
+
+// Default engine settings
+const (
+	DefaultPollInterval        = 10 * time.Second
+	DefaultRebroadcastInterval = 30 * time.Second
+	DefaultExpiryMargin        = time.Minute
+	DefaultReviewAfter         = time.Hour
+	DefaultPageSize            = 50
+)
+
+// createdAtLag is how far in the past transfers are dated, so that validators with
+// a slower clock accept them
+const createdAtLag = time.Minute
+
+// ErrConflict is returned by Enqueue when the idempotency key is used by a
+// different payout
+var ErrConflict = errors.New("payout: idempotency key reused with different parameters")
+
+// Request is a withdrawal to enqueue
+type Request struct {
+	// ID is the idempotency key: enqueueing the same request again returns the
+	// existing payout
+	ID          string
+	Destination string
+	Amount      *big.Int // nanotons
+	Comment     string
+	Bounce      bool
+}
+
+// Payout is a withdrawal and its state
+type Payout struct {
+	ID          string   `json:"id"`
+	Destination string   `json:"destination"`
+	Amount      *big.Int `json:"amount"`
+	Comment     string   `json:"comment,omitempty"`
+	Bounce      bool     `json:"bounce,omitempty"`
+	Status      string   `json:"status"`
+	BatchID     string   `json:"batch_id,omitempty"`
+	
+	// TxHash is the transaction that sent the message, once confirmed
+	TxHash    string    `json:"tx_hash,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Batch is a signed wallet transfer carrying one or more payouts. It has the
+// status of its payouts until it is reconciled; a confirmed batch may contain
+// failed payouts.
+type Batch struct {
+	ID         string           `json:"id"` // hex hash of the external message
+	QueryID    highload.QueryID `json:"query_id"`
+	PayoutIDs  []string         `json:"payout_ids"`
+	BOC        string           `json:"boc"`
+	CreatedAt  time.Time        `json:"created_at"`
+	ValidUntil time.Time        `json:"valid_until"`
+	
+	// BodyHash and BatchBodyHash are the hex hashes of the transfer bodies, see
+	// highload.Transfer
+	BodyHash      string `json:"body_hash"`
+	BatchBodyHash string `json:"batch_body_hash,omitempty"`
+	
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	SentAt    time.Time `json:"sent_at,omitempty"`
+	TxHash    string    `json:"tx_hash,omitempty"` // transaction of the external message
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Engine batches, sends and reconciles payouts. Only one engine may run per wallet.
+type Engine struct {
+	Client   *toncenterzp.Client
+	Wallet   *highload.Wallet
+	QueryIDs *highload.QueryIDs
+	Store    Store
+	Logger   *slog.Logger
+	
+	PollInterval time.Duration
+	
+	// BatchSize is the maximum number of payouts per batch, at most
+	// highload.MaxMessages
+	BatchSize int
+	
+	// RebroadcastInterval is the delay between broadcasts of a batch
+	RebroadcastInterval time.Duration
+	
+	// ExpiryMargin is how long after its valid_until a batch without a transaction
+	// is checked with the processed? get method and declared expired
+	ExpiryMargin time.Duration
+	
+	// ReviewAfter is how long after its valid_until a processed batch whose
+	// transactions are not found stays pending before it is marked for manual review
+	ReviewAfter time.Duration
+	
+	// PageSize is the GetTransactions page size used by reconciliation
+	PageSize int
+	
+	// Deploy includes the wallet state init in transfers, for a wallet that may
+	// not be deployed yet
+	Deploy bool
+	
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewEngine creates an engine with default settings
+func NewEngine(client *toncenterzp.Client, wallet *highload.Wallet, ids *highload.QueryIDs, store Store) *Engine {
+	return &Engine{
+		Client:              client,
+		Wallet:              wallet,
+		QueryIDs:            ids,
+		Store:               store,
+		PollInterval:        DefaultPollInterval,
+		BatchSize:           highload.MaxMessages,
+		RebroadcastInterval: DefaultRebroadcastInterval,
+		ExpiryMargin:        DefaultExpiryMargin,
+		ReviewAfter:         DefaultReviewAfter,
+		PageSize:            DefaultPageSize,
+		now:                 time.Now,
+	}
+}
+
+// Enqueue queues a payout, or returns the existing payout with the same ID
+func (e *Engine) Enqueue(req Request) (*Payout, error) {
+	if req.ID == "" {
+		return nil, toncenterzp.NewError(toncenterzp.ErrInvalidParams, "payout ID is required", nil)
+	}
+	if req.Amount == nil || req.Amount.Sign() <= 0 {
+		return nil, toncenterzp.NewError(toncenterzp.ErrInvalidParams, "payout amount must be positive", nil)
+	}
+	if _, err := address.Parse(req.Destination); err != nil {
+		return nil, toncenterzp.NewError(toncenterzp.ErrInvalidAddress, "invalid payout destination", err)
+	}
+	
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	
+	existing, err := e.Store.Payout(req.ID)
+	switch {
+	case err == nil:
+		if existing.Destination != req.Destination || existing.Amount.Cmp(req.Amount) != 0 ||
+			existing.Comment != req.Comment || existing.Bounce != req.Bounce {
+			return nil, ErrConflict
+		}
+		return existing, nil
+	case !errors.Is(err, ErrNotFound):
+		return nil, err
+	}
+	
+	now := e.clock().UTC()
+	p := Payout{
+		ID:          req.ID,
+		Destination: req.Destination,
+		Amount:      new(big.Int).Set(req.Amount),
+		Comment:     req.Comment,
+		Bounce:      req.Bounce,
+		Status:      StatusQueued,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := e.Store.Commit(nil, []Payout{p}); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Payout returns a payout by ID
+func (e *Engine) Payout(id string) (*Payout, error) {
+	return e.Store.Payout(id)
+}
+
+// Retry queues a failed or expired payout again
+func (e *Engine) Retry(id string) (*Payout, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	
+	p, err := e.Store.Payout(id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != StatusFailed && p.Status != StatusExpired {
+		return nil, toncenterzp.NewError(toncenterzp.ErrInvalidParams, fmt.Sprintf("payout is %s", p.Status), nil)
+	}
+	p.Status = StatusQueued
+	p.BatchID = ""
+	p.Error = ""
+	p.UpdatedAt = e.clock().UTC()
+	if err := e.Store.Commit(nil, []Payout{*p}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Run processes payouts every PollInterval until ctx is done
+func (e *Engine) Run(ctx context.Context) error {
+	interval := e.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		if err := e.Process(); err != nil {
+			e.log(slog.LevelWarn, "error processing payouts", slog.String("error", err.Error()))
+		}
+		
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process signs queued payouts, broadcasts live batches and reconciles sent ones
+func (e *Engine) Process() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	
+	self, err := e.Wallet.Address()
+	if err != nil {
+		return err
+	}
+	return errors.Join(e.sign(), e.broadcast(), e.reconcile(self))
+}
+
+// sign commits queued payouts into signed batches
+func (e *Engine) sign() error {
+	queued, err := e.Store.Payouts(StatusQueued)
+	if err != nil {
+		return err
+	}
+	
+	size := e.BatchSize
+	if size <= 0 || size > highload.MaxMessages {
+		size = highload.MaxMessages
+	}
+	for len(queued) > 0 {
+		n := min(size, len(queued))
+		if err := e.signBatch(queued[:n]); err != nil {
+			return err
+		}
+		queued = queued[n:]
+	}
+	return nil
+}
+
+// signBatch signs a transfer for payouts and commits it with the payouts. Payouts
+// whose message cannot be built fail right away.
+func (e *Engine) signBatch(payouts []Payout) error {
+	now := e.clock().UTC()
+	var (
+		msgs     []message.Send
+		included []Payout
+		rejected []Payout
+	)
+	for _, p := range payouts {
+		msg, err := payoutMessage(&p)
+		if err != nil {
+			p.Status = StatusFailed
+			p.Error = err.Error()
+			p.UpdatedAt = now
+			rejected = append(rejected, p)
+			continue
+		}
+		msgs = append(msgs, message.Send{Mode: message.ModePayFeesSeparately | message.ModeIgnoreErrors, Message: msg})
+		included = append(included, p)
+	}
+	if len(included) == 0 {
+		return e.Store.Commit(nil, rejected)
+	}
+	
+	q, err := e.QueryIDs.Next()
+	if err != nil {
+		return err
+	}
+	tr, err := e.Wallet.Sign(highload.Request{
+		QueryID:   q,
+		CreatedAt: now.Add(-createdAtLag),
+		Messages:  msgs,
+		Deploy:    e.Deploy,
+	})
+	if err != nil {
+		return err
+	}
+	
+	b := Batch{
+		ID:         hex.EncodeToString(tr.Hash),
+		QueryID:    q,
+		BOC:        tr.BOC(),
+		CreatedAt:  tr.CreatedAt.UTC(),
+		ValidUntil: tr.ValidUntil.UTC(),
+		BodyHash:   hex.EncodeToString(tr.Body.Hash()),
+		Status:     StatusSigned,
+		UpdatedAt:  now,
+	}
+	if tr.BatchBody != nil {
+		b.BatchBodyHash = hex.EncodeToString(tr.BatchBody.Hash())
+	}
+	for i := range included {
+		included[i].Status = StatusSigned
+		included[i].BatchID = b.ID
+		included[i].UpdatedAt = now
+		b.PayoutIDs = append(b.PayoutIDs, included[i].ID)
+	}
+	if err := e.Store.Commit(&b, append(included, rejected...)); err != nil {
+		return err
+	}
+	
+	e.log(slog.LevelInfo, "payout batch signed",
+		slog.String("batch", b.ID),
+		slog.String("query_id", q.String()),
+		slog.Int("payouts", len(included)),
+	)
+	return nil
+}
+
+// payoutMessage builds the internal message of a payout
+func payoutMessage(p *Payout) (*cell.Cell, error) {
+	dest, err := address.Parse(p.Destination)
+	if err != nil {
+		return nil, err
+	}
+	body, err := payoutBody(p)
+	if err != nil {
+		return nil, err
+	}
+	return (&message.Internal{Bounce: p.Bounce, Destination: dest, Amount: p.Amount, Body: body}).ToCell()
+}
+
+// payoutBody returns the comment of a payout, nil without comment
+func payoutBody(p *Payout) (*cell.Cell, error) {
+	if p.Comment == "" {
+		return nil, nil
+	}
+	return message.Comment(p.Comment)
+}
+
+// broadcast sends the batches that are still valid and were not sent recently.
+// Sending a batch again is safe: the wallet processes its query id only once.
+func (e *Engine) broadcast() error {
+	var errs []error
+	for _, status := range []string{StatusSigned, StatusSent} {
+		batches, err := e.Store.Batches(status)
+		if err != nil {
+			return err
+		}
+		for _, b := range batches {
+			if err := e.broadcastBatch(b); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// broadcastBatch sends a batch if it is due
+func (e *Engine) broadcastBatch(b Batch) error {
+	now := e.clock().UTC()
+	interval := e.RebroadcastInterval
+	if interval <= 0 {
+		interval = DefaultRebroadcastInterval
+	}
+	if !now.Before(b.ValidUntil) || (b.Status == StatusSent && now.Sub(b.SentAt) < interval) {
+		return nil
+	}
+	
+	_, err := e.Client.SendBocReturnHash(toncenterzp.SendBocReturnHashRequest{Boc: b.BOC})
+	b.Attempts++
+	b.UpdatedAt = now
+	if err != nil {
+		// The message may still have reached the network; reconciliation decides
+		b.Error = err.Error()
+		e.log(slog.LevelWarn, "error broadcasting payout batch", slog.String("batch", b.ID), slog.String("error", b.Error))
+		return e.Store.Commit(&b, nil)
+	}
+	
+	b.Error = ""
+	b.SentAt = now
+	var payouts []Payout
+	if b.Status == StatusSigned {
+		b.Status = StatusSent
+		if payouts, err = e.batchPayouts(b); err != nil {
+			return err
+		}
+		for i := range payouts {
+			payouts[i].Status = StatusSent
+			payouts[i].UpdatedAt = now
+		}
+	}
+	return e.Store.Commit(&b, payouts)
+}
+
+// batchPayouts returns the payouts of a batch
+func (e *Engine) batchPayouts(b Batch) ([]Payout, error) {
+	payouts := make([]Payout, 0, len(b.PayoutIDs))
+	for _, id := range b.PayoutIDs {
+		p, err := e.Store.Payout(id)
+		if err != nil {
+			return nil, fmt.Errorf("payout %s of batch %s: %w", id, b.ID, err)
+		}
+		payouts = append(payouts, *p)
+	}
+	return payouts, nil
+}
+
+// clock returns the current time
+func (e *Engine) clock() time.Time {
+	if e.now != nil {
+		return e.now()
+	}
+	return time.Now()
+}
+
+// log writes a record if a logger is configured
+func (e *Engine) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	if e.Logger == nil {
+		return
+	}
+	e.Logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
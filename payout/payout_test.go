@@ -0,0 +1,297 @@
+package payout
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/highload"
+	"github.com/zhaopeng331/toncenterzp/message"
+)
+
+// fakeBackend records broadcasts and serves the transactions of the wallet and
+// the result of processed?
+type fakeBackend struct {
+	toncenterzp.UnimplementedBackend
+	sent      []string
+	txs       []toncenterzp.TransactionDetails
+	processed bool
+}
+
+func (f *fakeBackend) SendBocReturnHash(req toncenterzp.SendBocReturnHashRequest) (*toncenterzp.SendBocReturnHashResponse, error) {
+	f.sent = append(f.sent, req.Boc)
+	return &toncenterzp.SendBocReturnHashResponse{OK: true}, nil
+}
+
+func (f *fakeBackend) GetTransactions(req toncenterzp.GetTransactionsRequest) (*toncenterzp.GetTransactionsResponse, error) {
+	r := &toncenterzp.GetTransactionsResponse{OK: true}
+	if req.Hash == "" {
+		r.Result.Transactions = f.txs
+	}
+	return r, nil
+}
+
+func (f *fakeBackend) RunGetMethod(req toncenterzp.RunGetMethodRequest) (*toncenterzp.RunGetMethodResponse, error) {
+	r := &toncenterzp.RunGetMethodResponse{OK: true}
+	v := "0x0"
+	if f.processed {
+		v = "-0x1"
+	}
+	r.Result.Stack = [][]interface{}{{"num", v}}
+	return r, nil
+}
+
+// testEngine returns an engine over a memory store whose clock is *now
+func testEngine(t *testing.T, now *time.Time) (*Engine, *fakeBackend, Store, *address.Address) {
+	t.Helper()
+	w, err := highload.New(ed25519.NewKeyFromSeed(make([]byte, 32)), highload.DefaultSubwalletID, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, _ := w.Address()
+	ids, err := highload.NewQueryIDs(&highload.MemoryQueryIDStore{}, 600*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeBackend{}
+	client := toncenterzp.NewClient("")
+	client.Backend = f
+	store := NewMemoryStore()
+	e := NewEngine(client, w, ids, store)
+	e.now = func() time.Time { return *now }
+	return e, f, store, self
+}
+
+// hexToBase64 converts a hex hash of the store to the base64 form of the API
+func hexToBase64(h string) string {
+	b, _ := hex.DecodeString(h)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func commentHash(comment string) string {
+	var c *cell.Cell
+	if comment == "" {
+		c, _ = cell.BeginCell().EndCell()
+	} else {
+		c, _ = message.Comment(comment)
+	}
+	return base64.StdEncoding.EncodeToString(c.Hash())
+}
+
+func status(t *testing.T, e *Engine, id string) string {
+	t.Helper()
+	p, err := e.Payout(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Status
+}
+
+var (
+	dest1 = address.New(0, make([]byte, 32)).String()
+	dest2 = address.New(0, append(make([]byte, 31), 1)).String()
+)
+
+func TestEnqueueIdempotent(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	e, _, _, _ := testEngine(t, &now)
+	req := Request{ID: "a", Destination: dest1, Amount: big.NewInt(5), Comment: "x"}
+	if _, err := e.Enqueue(req); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := e.Enqueue(req); err != nil || p.Status != StatusQueued {
+		t.Errorf("enqueued again: %v, %v", p, err)
+	}
+	if _, err := e.Enqueue(Request{ID: "a", Destination: dest1, Amount: big.NewInt(6)}); !errors.Is(err, ErrConflict) {
+		t.Errorf("different payout with the same key: error %v, want ErrConflict", err)
+	}
+}
+
+func TestEngineBatch(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	e, f, store, self := testEngine(t, &now)
+	e.Enqueue(Request{ID: "a", Destination: dest1, Amount: big.NewInt(5), Comment: "x"})
+	e.Enqueue(Request{ID: "b", Destination: dest2, Amount: big.NewInt(7)})
+	e.Enqueue(Request{ID: "c", Destination: dest2, Amount: big.NewInt(9)})
+	if err := e.Process(); err != nil {
+		t.Fatal(err)
+	}
+	sent, _ := store.Batches(StatusSent)
+	if len(f.sent) != 1 || len(sent) != 1 || len(sent[0].PayoutIDs) != 3 || sent[0].BatchBodyHash == "" {
+		t.Fatalf("%d broadcasts, sent batches %+v", len(f.sent), sent)
+	}
+	b := sent[0]
+	for _, id := range []string{"a", "b", "c"} {
+		if s := status(t, e, id); s != StatusSent {
+			t.Errorf("payout %s %s, want sent", id, s)
+		}
+	}
+	
+	// The same transfer is broadcast again after the rebroadcast interval only
+	now = now.Add(time.Second)
+	e.Process()
+	if len(f.sent) != 1 {
+		t.Fatal("broadcast again before the rebroadcast interval")
+	}
+	now = now.Add(DefaultRebroadcastInterval)
+	e.Process()
+	if len(f.sent) != 2 || f.sent[1] != f.sent[0] {
+		t.Fatal("not broadcast again after the rebroadcast interval")
+	}
+	
+	// The wallet sends a and b but not c
+	f.txs = []toncenterzp.TransactionDetails{
+		{
+			Hash:  "itx",
+			Now:   int(now.Unix()),
+			InMsg: toncenterzp.Message{Source: self.String(), BodyHash: hexToBase64(b.BatchBodyHash)},
+			OutMsgs: []toncenterzp.Message{
+				{Destination: dest2, Value: "7", BodyHash: commentHash("")},
+				{Destination: dest1, Value: "5", BodyHash: commentHash("x")},
+			},
+		},
+		{
+			Hash:    "etx",
+			Now:     int(now.Unix()),
+			InMsg:   toncenterzp.Message{BodyHash: hexToBase64(b.BodyHash)},
+			OutMsgs: []toncenterzp.Message{{Destination: self.String(), Value: "100000000"}},
+		},
+	}
+	if err := e.Process(); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]string{"a": StatusConfirmed, "b": StatusConfirmed, "c": StatusFailed} {
+		if s := status(t, e, id); s != want {
+			t.Errorf("payout %s %s, want %s", id, s, want)
+		}
+	}
+	if p, _ := e.Payout("a"); p.TxHash != "itx" {
+		t.Errorf("payout tx %q, want itx", p.TxHash)
+	}
+	if bb, _ := store.Batch(b.ID); bb.Status != StatusConfirmed || bb.TxHash != "etx" {
+		t.Errorf("batch %s with tx %q, want confirmed with etx", bb.Status, bb.TxHash)
+	}
+}
+
+func TestEngineExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	e, f, store, _ := testEngine(t, &now)
+	e.Enqueue(Request{ID: "a", Destination: dest1, Amount: big.NewInt(5)})
+	e.Process()
+	sent, _ := store.Batches(StatusSent)
+	if len(sent) != 1 || sent[0].BatchBodyHash != "" {
+		t.Fatalf("sent batches %+v, want one single message transfer", sent)
+	}
+	
+	// Within the expiry margin the batch may still be processed
+	now = sent[0].ValidUntil.Add(DefaultExpiryMargin / 2)
+	e.Process()
+	if s := status(t, e, "a"); s != StatusSent {
+		t.Fatalf("payout %s within the expiry margin", s)
+	}
+	
+	// A transfer the wallet reports processed is not expired, though its
+	// transaction was not found yet
+	now = sent[0].ValidUntil.Add(2 * DefaultExpiryMargin)
+	f.processed = true
+	e.Process()
+	if s := status(t, e, "a"); s != StatusSent {
+		t.Fatalf("processed payout %s", s)
+	}
+	
+	f.processed = false
+	n := len(f.sent)
+	e.Process()
+	if len(f.sent) != n {
+		t.Error("broadcast after valid_until")
+	}
+	if s := status(t, e, "a"); s != StatusExpired {
+		t.Fatalf("payout %s, want expired", s)
+	}
+	
+	// An expired payout can be retried and goes into a new batch
+	if p, err := e.Retry("a"); err != nil || p.Status != StatusQueued {
+		t.Fatalf("Retry = %v, %v", p, err)
+	}
+	e.Process()
+	if sent, _ := store.Batches(StatusSent); len(sent) != 1 || sent[0].ID == "" {
+		t.Errorf("sent batches after retry %+v", sent)
+	}
+	if s := status(t, e, "a"); s != StatusSent {
+		t.Errorf("retried payout %s, want sent", s)
+	}
+}
+
+func TestEngineReview(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	e, f, store, _ := testEngine(t, &now)
+	e.Enqueue(Request{ID: "a", Destination: dest1, Amount: big.NewInt(5)})
+	e.Process()
+	sent, _ := store.Batches(StatusSent)
+	if len(sent) != 1 {
+		t.Fatalf("sent batches %+v", sent)
+	}
+	
+	// A processed batch whose transaction is never found stays pending until
+	// ReviewAfter, then leaves the reconciled batches
+	f.processed = true
+	now = sent[0].ValidUntil.Add(DefaultReviewAfter - time.Second)
+	e.Process()
+	if s := status(t, e, "a"); s != StatusSent {
+		t.Fatalf("payout %s before ReviewAfter", s)
+	}
+	now = sent[0].ValidUntil.Add(DefaultReviewAfter)
+	e.Process()
+	if s := status(t, e, "a"); s != StatusReview {
+		t.Fatalf("payout %s, want review", s)
+	}
+	if b, _ := store.Batch(sent[0].ID); b.Status != StatusReview || b.Error == "" {
+		t.Errorf("batch %s %q, want review with a reason", b.Status, b.Error)
+	}
+	if pending, _ := store.Batches(StatusSent); len(pending) != 0 {
+		t.Errorf("%d batches still pending", len(pending))
+	}
+	
+	// The payout may have been sent, so it cannot be retried
+	if _, err := e.Retry("a"); err == nil {
+		t.Error("payout under review retried")
+	}
+}
+
+func TestEngineReviewMissingBatchTransaction(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	e, f, store, self := testEngine(t, &now)
+	e.Enqueue(Request{ID: "a", Destination: dest1, Amount: big.NewInt(5)})
+	e.Enqueue(Request{ID: "b", Destination: dest2, Amount: big.NewInt(7)})
+	e.Process()
+	sent, _ := store.Batches(StatusSent)
+	if len(sent) != 1 || sent[0].BatchBodyHash == "" {
+		t.Fatalf("sent batches %+v, want one batch transfer", sent)
+	}
+	
+	// The wallet sent the batch message to itself but that transaction is missing
+	f.txs = []toncenterzp.TransactionDetails{{
+		Hash:    "etx",
+		Now:     int(now.Unix()),
+		InMsg:   toncenterzp.Message{BodyHash: hexToBase64(sent[0].BodyHash)},
+		OutMsgs: []toncenterzp.Message{{Destination: self.String(), Value: "100000000"}},
+	}}
+	e.Process()
+	if s := status(t, e, "a"); s != StatusSent {
+		t.Fatalf("payout %s before ReviewAfter", s)
+	}
+	now = sent[0].ValidUntil.Add(DefaultReviewAfter)
+	e.Process()
+	for _, id := range []string{"a", "b"} {
+		if s := status(t, e, id); s != StatusReview {
+			t.Errorf("payout %s %s, want review", id, s)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package payout
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// walletTxs indexes wallet transactions by the hex body hash of their inbound message
+type walletTxs struct {
+	external map[string]*toncenterzp.TransactionDetails
+	internal map[string]*toncenterzp.TransactionDetails // from the wallet itself
+}
+
+// reconcile settles the signed and sent batches whose transaction is found, the
+// batches that expired unprocessed and the processed batches whose transactions
+// are still missing after ReviewAfter, so that the scanned window stays bounded
+func (e *Engine) reconcile(self *address.Address) error {
+	var pending []Batch
+	for _, status := range []string{StatusSigned, StatusSent} {
+		batches, err := e.Store.Batches(status)
+		if err != nil {
+			return err
+		}
+		pending = append(pending, batches...)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	
+	since := pending[0].CreatedAt
+	for _, b := range pending {
+		if b.CreatedAt.Before(since) {
+			since = b.CreatedAt
+		}
+	}
+	txs, err := e.transactions(self, since.Unix())
+	if err != nil {
+		return err
+	}
+	
+	var errs []error
+	for _, b := range pending {
+		if err := e.reconcileBatch(b, txs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// transactions returns the wallet transactions since a unix time, newest first
+func (e *Engine) transactions(self *address.Address, since int64) (*walletTxs, error) {
+	pageSize := e.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	
+	txs := &walletTxs{
+		external: map[string]*toncenterzp.TransactionDetails{},
+		internal: map[string]*toncenterzp.TransactionDetails{},
+	}
+	req := toncenterzp.GetTransactionsRequest{Address: self.String(), Limit: pageSize}
+	for {
+		resp, err := e.Client.GetTransactions(req)
+		if err != nil {
+			return nil, err
+		}
+		
+		page := resp.Result.Transactions
+		if req.Hash != "" && len(page) > 0 && page[0].Hash == req.Hash {
+			page = page[1:]
+		}
+		
+		done := len(page) == 0 || len(resp.Result.Transactions) < pageSize
+		for i := range page {
+			tx := &page[i]
+			if int64(tx.Now) < since {
+				done = true
+				break
+			}
+			hash := hashHex(tx.InMsg.BodyHash)
+			if tx.InMsg.Source == "" {
+				txs.external[hash] = tx
+			} else if src, err := address.Parse(tx.InMsg.Source); err == nil && src.Equal(self) {
+				txs.internal[hash] = tx
+			}
+		}
+		if done {
+			return txs, nil
+		}
+		
+		req.Lt = page[len(page)-1].Lt
+		req.Hash = page[len(page)-1].Hash
+	}
+}
+
+// reconcileBatch settles a batch from the wallet transactions
+func (e *Engine) reconcileBatch(b Batch, txs *walletTxs) error {
+	now := e.clock().UTC()
+	
+	tx, ok := txs.external[b.BodyHash]
+	if !ok {
+		margin := e.ExpiryMargin
+		if margin <= 0 {
+			margin = DefaultExpiryMargin
+		}
+		if now.Before(b.ValidUntil.Add(margin)) {
+			return nil
+		}
+		
+		// The transaction list may lag behind; the wallet has the final say
+		processed, err := e.Wallet.Processed(e.Client, b.QueryID, false)
+		if err != nil {
+			return err
+		}
+		if processed {
+			e.log(slog.LevelWarn, "payout batch processed but its transaction was not found", slog.String("batch", b.ID))
+			return e.review(b, now, "transaction not found")
+		}
+		return e.settle(b, StatusExpired, nil, "transfer expired unprocessed")
+	}
+	
+	b.TxHash = tx.Hash
+	out := tx
+	if b.BatchBodyHash != "" {
+		itx, ok := txs.internal[b.BatchBodyHash]
+		if !ok {
+			if len(tx.OutMsgs) == 0 {
+				return e.settle(b, StatusFailed, nil, "wallet did not send the batch")
+			}
+			return e.review(b, now, "batch transaction not found")
+		}
+		out = itx
+	}
+	return e.settle(b, StatusConfirmed, out, "")
+}
+
+// review marks a processed batch whose transactions are missing for manual review
+// once ReviewAfter passed since its valid_until. Its payouts may have been sent, so
+// they cannot be retried.
+func (e *Engine) review(b Batch, now time.Time, reason string) error {
+	after := e.ReviewAfter
+	if after <= 0 {
+		after = DefaultReviewAfter
+	}
+	if now.Before(b.ValidUntil.Add(after)) {
+		return nil
+	}
+	return e.settle(b, StatusReview, nil, reason)
+}
+
+// settle records the final state of a batch and its payouts. For a confirmed
+// batch, payouts are matched to the outgoing messages of tx: matched payouts are
+// confirmed, the others failed.
+func (e *Engine) settle(b Batch, status string, tx *toncenterzp.TransactionDetails, reason string) error {
+	payouts, err := e.batchPayouts(b)
+	if err != nil {
+		return err
+	}
+	
+	now := e.clock().UTC()
+	var used []bool
+	if tx != nil {
+		used = make([]bool, len(tx.OutMsgs))
+	}
+	for i := range payouts {
+		p := &payouts[i]
+		p.UpdatedAt = now
+		if status != StatusConfirmed {
+			p.Status = status
+			p.Error = reason
+			continue
+		}
+		
+		p.Status = StatusFailed
+		p.Error = "message was not sent"
+		for j, msg := range tx.OutMsgs {
+			if !used[j] && matches(p, msg) {
+				used[j] = true
+				p.Status = StatusConfirmed
+				p.Error = ""
+				p.TxHash = tx.Hash
+				break
+			}
+		}
+	}
+	
+	b.Status = status
+	b.Error = reason
+	b.UpdatedAt = now
+	if err := e.Store.Commit(&b, payouts); err != nil {
+		return err
+	}
+	
+	e.log(slog.LevelInfo, "payout batch settled",
+		slog.String("batch", b.ID),
+		slog.String("status", status),
+		slog.String("tx", b.TxHash),
+	)
+	return nil
+}
+
+// matches reports whether an outgoing message is the message of a payout
+func matches(p *Payout, msg toncenterzp.Message) bool {
+	if msg.Value != p.Amount.String() {
+		return false
+	}
+	dest, err := address.Parse(msg.Destination)
+	if err != nil {
+		return false
+	}
+	want, err := address.Parse(p.Destination)
+	if err != nil || !dest.Equal(want) {
+		return false
+	}
+	
+	body, err := payoutBody(p)
+	if err != nil {
+		return false
+	}
+	if body == nil {
+		if body, err = cell.BeginCell().EndCell(); err != nil {
+			return false
+		}
+	}
+	return hashHex(msg.BodyHash) == hex.EncodeToString(body.Hash())
+}
+
+// hashHex converts a base64 hash of the HTTP API to hex
+func hashHex(s string) string {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		if b, err = base64.URLEncoding.DecodeString(s); err != nil {
+			return ""
+		}
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,292 @@
+package payout
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrNotFound is returned by a Store for unknown payouts or batches
+var ErrNotFound = errors.New("payout: not found")
+
+// Store persists payouts and batches. Implementations must be safe for concurrent use.
+type Store interface {
+	// Payout returns a payout by ID
+	Payout(id string) (*Payout, error)
+	
+	// Payouts returns the payouts with a status, oldest first
+	Payouts(status string) ([]Payout, error)
+	
+	// Batch returns a batch by ID
+	Batch(id string) (*Batch, error)
+	
+	// Batches returns the batches with a status, oldest first
+	Batches(status string) ([]Batch, error)
+	
+	// Commit records a batch and payouts at once: after a crash either all of
+	// them are stored or none. b may be nil.
+	Commit(b *Batch, payouts []Payout) error
+}
+
+// MemoryStore is a Store that keeps everything in memory
+type MemoryStore struct {
+	mu      sync.Mutex
+	payouts map[string]Payout
+	batches map[string]Batch
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		payouts: map[string]Payout{},
+		batches: map[string]Batch{},
+	}
+}
+
+// Payout returns a payout by ID
+func (s *MemoryStore) Payout(id string) (*Payout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	p, ok := s.payouts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &p, nil
+}
+
+// Payouts returns the payouts with a status ordered by creation time
+func (s *MemoryStore) Payouts(status string) ([]Payout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var payouts []Payout
+	for _, p := range s.payouts {
+		if p.Status == status {
+			payouts = append(payouts, p)
+		}
+	}
+	sort.Slice(payouts, func(i, j int) bool {
+		if !payouts[i].CreatedAt.Equal(payouts[j].CreatedAt) {
+			return payouts[i].CreatedAt.Before(payouts[j].CreatedAt)
+		}
+		return payouts[i].ID < payouts[j].ID
+	})
+	return payouts, nil
+}
+
+// Batch returns a batch by ID
+func (s *MemoryStore) Batch(id string) (*Batch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	b, ok := s.batches[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &b, nil
+}
+
+// Batches returns the batches with a status ordered by creation time
+func (s *MemoryStore) Batches(status string) ([]Batch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var batches []Batch
+	for _, b := range s.batches {
+		if b.Status == status {
+			batches = append(batches, b)
+		}
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].CreatedAt.Before(batches[j].CreatedAt) })
+	return batches, nil
+}
+
+// Commit records a batch and payouts
+func (s *MemoryStore) Commit(b *Batch, payouts []Payout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if b != nil {
+		s.batches[b.ID] = *b
+	}
+	for _, p := range payouts {
+		s.payouts[p.ID] = p
+	}
+	return nil
+}
+
+// all returns every batch and payout ordered by ID
+func (s *MemoryStore) all() ([]Batch, []Payout) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	batches := make([]Batch, 0, len(s.batches))
+	for _, b := range s.batches {
+		batches = append(batches, b)
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].ID < batches[j].ID })
+	payouts := make([]Payout, 0, len(s.payouts))
+	for _, p := range s.payouts {
+		payouts = append(payouts, p)
+	}
+	sort.Slice(payouts, func(i, j int) bool { return payouts[i].ID < payouts[j].ID })
+	return batches, payouts
+}
+
+// record is a line of the FileStore log
+type record struct {
+	Batch   *Batch   `json:"batch,omitempty"`
+	Payouts []Payout `json:"payouts,omitempty"`
+}
+
+// FileStore is a Store backed by payouts.jsonl in a directory: every commit is
+// appended and synced as one line, and the log is replayed and compacted on open.
+// Only the last line may be cut short by a crash, which drops the whole commit;
+// any other unreadable line is an error.
+type FileStore struct {
+	mem *MemoryStore
+	
+	mu  sync.Mutex
+	dir string
+	log *os.File
+}
+
+// OpenFileStore opens or creates a file store in dir
+func OpenFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating store directory: %w", err)
+	}
+	
+	s := &FileStore{mem: NewMemoryStore(), dir: dir}
+	
+	data, err := os.ReadFile(s.logPath())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading payout log: %w", err)
+	}
+	// An unterminated last line is a commit cut short by a crash; compaction drops it
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete > 0 {
+		for i, line := range bytes.Split(data[:complete-1], []byte{'\n'}) {
+			var r record
+			if err := json.Unmarshal(line, &r); err != nil {
+				return nil, fmt.Errorf("error parsing payout log line %d: %w", i+1, err)
+			}
+			s.mem.Commit(r.Batch, r.Payouts)
+		}
+	}
+	
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) logPath() string {
+	return filepath.Join(s.dir, "payouts.jsonl")
+}
+
+// Compact rewrites the log with one line per batch and payout, dropping the
+// states they went through
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compact()
+}
+
+// compact atomically replaces the log with the current state and reopens it for
+// appending; the caller must hold the lock
+func (s *FileStore) compact() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	batches, payouts := s.mem.all()
+	for i := range batches {
+		if err := enc.Encode(record{Batch: &batches[i]}); err != nil {
+			return err
+		}
+	}
+	for _, p := range payouts {
+		if err := enc.Encode(record{Payouts: []Payout{p}}); err != nil {
+			return err
+		}
+	}
+	
+	tmp := s.logPath() + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error compacting payout log: %w", err)
+	}
+	_, err = f.Write(buf.Bytes())
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.logPath())
+	}
+	if err != nil {
+		return fmt.Errorf("error compacting payout log: %w", err)
+	}
+	
+	if s.log != nil {
+		s.log.Close()
+	}
+	s.log, err = os.OpenFile(s.logPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening payout log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the payout log
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
+
+// Payout returns a payout by ID
+func (s *FileStore) Payout(id string) (*Payout, error) {
+	return s.mem.Payout(id)
+}
+
+// Payouts returns the payouts with a status ordered by creation time
+func (s *FileStore) Payouts(status string) ([]Payout, error) {
+	return s.mem.Payouts(status)
+}
+
+// Batch returns a batch by ID
+func (s *FileStore) Batch(id string) (*Batch, error) {
+	return s.mem.Batch(id)
+}
+
+// Batches returns the batches with a status ordered by creation time
+func (s *FileStore) Batches(status string) ([]Batch, error) {
+	return s.mem.Batches(status)
+}
+
+// Commit appends a batch and payouts to the log and syncs it
+func (s *FileStore) Commit(b *Batch, payouts []Payout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	line, err := json.Marshal(record{Batch: b, Payouts: payouts})
+	if err != nil {
+		return err
+	}
+	if _, err := s.log.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing payout log: %w", err)
+	}
+	if err := s.log.Sync(); err != nil {
+		return fmt.Errorf("error syncing payout log: %w", err)
+	}
+	return s.mem.Commit(b, payouts)
+}
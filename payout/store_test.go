@@ -0,0 +1,130 @@
+package payout
+
+import (
+	"bytes"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testPayout(id, status string) Payout {
+	return Payout{ID: id, Destination: dest1, Amount: big.NewInt(1), Status: status, CreatedAt: time.Unix(1700000000, 0).UTC()}
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Commit(nil, []Payout{testPayout("a", StatusQueued), testPayout("b", StatusQueued)})
+	s.Commit(&Batch{ID: "batch", PayoutIDs: []string{"a"}, Status: StatusSent}, []Payout{testPayout("a", StatusSent)})
+	s.Close()
+	
+	s, err = OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if p, err := s.Payout("a"); err != nil || p.Status != StatusSent || p.Amount.Int64() != 1 {
+		t.Errorf("payout a %+v, %v", p, err)
+	}
+	if queued, _ := s.Payouts(StatusQueued); len(queued) != 1 || queued[0].ID != "b" {
+		t.Errorf("queued payouts %+v", queued)
+	}
+	if b, err := s.Batch("batch"); err != nil || b.Status != StatusSent {
+		t.Errorf("batch %+v, %v", b, err)
+	}
+}
+
+func TestFileStoreTornLastLine(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := OpenFileStore(dir)
+	s.Commit(nil, []Payout{testPayout("a", StatusQueued)})
+	s.Close()
+	
+	f, err := os.OpenFile(filepath.Join(dir, "payouts.jsonl"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"payouts":[{"id":"b"`)
+	f.Close()
+	
+	s, err = OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Payout("a"); err != nil {
+		t.Error(err)
+	}
+	if _, err := s.Payout("b"); err != ErrNotFound {
+		t.Errorf("payout of the torn line: error %v, want ErrNotFound", err)
+	}
+	
+	// The next commit starts on its own line
+	s.Commit(nil, []Payout{testPayout("c", StatusQueued)})
+	s.Close()
+	s, err = OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.Payout("c"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFileStoreCorruptLine(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := OpenFileStore(dir)
+	s.Commit(nil, []Payout{testPayout("a", StatusQueued)})
+	s.Commit(nil, []Payout{testPayout("b", StatusQueued)})
+	s.Close()
+	
+	path := filepath.Join(dir, "payouts.jsonl")
+	data, _ := os.ReadFile(path)
+	data[1] = '#'
+	os.WriteFile(path, data, 0o600)
+	
+	if _, err := OpenFileStore(dir); err == nil {
+		t.Error("corrupt line before the last one ignored")
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, data) {
+		t.Error("corrupt log rewritten")
+	}
+}
+
+func TestFileStoreCompact(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := OpenFileStore(dir)
+	for _, st := range []string{StatusQueued, StatusSigned, StatusSent, StatusConfirmed} {
+		s.Commit(nil, []Payout{testPayout("a", st)})
+	}
+	path := filepath.Join(dir, "payouts.jsonl")
+	if data, _ := os.ReadFile(path); bytes.Count(data, []byte{'\n'}) != 4 {
+		t.Fatalf("%d lines before compaction", bytes.Count(data, []byte{'\n'}))
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); bytes.Count(data, []byte{'\n'}) != 1 {
+		t.Errorf("%d lines after compaction, want 1", bytes.Count(data, []byte{'\n'}))
+	}
+	
+	// Commits after compaction go to the new log
+	s.Commit(nil, []Payout{testPayout("b", StatusQueued)})
+	s.Close()
+	s, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if p, err := s.Payout("a"); err != nil || p.Status != StatusConfirmed {
+		t.Errorf("payout a %+v, %v", p, err)
+	}
+	if _, err := s.Payout("b"); err != nil {
+		t.Error(err)
+	}
+}
@@ -4,8 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 )
 
+// jsonRPCID is the source of JSON-RPC request IDs shared by all clients
+var jsonRPCID atomic.Int64
+
+// nextJSONRPCID returns a new unique JSON-RPC request ID
+func nextJSONRPCID() int {
+	return int(jsonRPCID.Add(1))
+}
+
 // JSONRPCRequest represents a JSON-RPC request
 type JSONRPCRequest struct {
 	JSONRPC string      `json:"jsonrpc"`
@@ -37,7 +46,7 @@ func (c *Client) JSONRPC(method string, params interface{}) (*JSONRPCResponse, e
 		JSONRPC: "2.0",
 		Method:  method,
 		Params:  params,
-		ID:      1,
+		ID:      nextJSONRPCID(),
 	}
 	
 	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
@@ -73,10 +82,10 @@ type LookupBlockResponse struct {
 }
 
 // LookupBlock looks up a block by various criteria
-func (c *Client) LookupBlock(req LookupBlockRequest) (*LookupBlockResponse, error) {
+func (h *HTTPBackend) LookupBlock(req LookupBlockRequest) (*LookupBlockResponse, error) {
 	endpoint := "/lookupBlock"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -102,10 +111,10 @@ type PackAddressResponse struct {
 }
 
 // PackAddress packs a TON address
-func (c *Client) PackAddress(address string) (*PackAddressResponse, error) {
+func (h *HTTPBackend) PackAddress(address string) (*PackAddressResponse, error) {
 	endpoint := fmt.Sprintf("/packAddress?address=%s", address)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -140,10 +149,10 @@ type RunGetMethodResponse struct {
 }
 
 // RunGetMethod runs a get method on a TON contract
-func (c *Client) RunGetMethod(req RunGetMethodRequest) (*RunGetMethodResponse, error) {
+func (h *HTTPBackend) RunGetMethod(req RunGetMethodRequest) (*RunGetMethodResponse, error) {
 	endpoint := "/runGetMethod"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -21,10 +21,10 @@ type SendBocResponse struct {
 }
 
 // SendBoc sends a bag of cells to the TON network
-func (c *Client) SendBoc(req SendBocRequest) (*SendBocResponse, error) {
+func (h *HTTPBackend) SendBoc(req SendBocRequest) (*SendBocResponse, error) {
 	endpoint := "/sendBoc"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -53,10 +53,10 @@ type SendBocReturnHashResponse struct {
 }
 
 // SendBocReturnHash sends a bag of cells to the TON network and returns the hash
-func (c *Client) SendBocReturnHash(req SendBocReturnHashRequest) (*SendBocReturnHashResponse, error) {
+func (h *HTTPBackend) SendBocReturnHash(req SendBocReturnHashRequest) (*SendBocReturnHashResponse, error) {
 	endpoint := "/sendBocReturnHash"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -90,10 +90,10 @@ type SendQueryResponse struct {
 }
 
 // SendQuery sends a query to the TON network
-func (c *Client) SendQuery(req SendQueryRequest) (*SendQueryResponse, error) {
+func (h *HTTPBackend) SendQuery(req SendQueryRequest) (*SendQueryResponse, error) {
 	endpoint := "/sendQuery"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -125,10 +125,10 @@ type ShardsResponse struct {
 }
 
 // Shards gets the list of shards
-func (c *Client) Shards(seqNo int) (*ShardsResponse, error) {
+func (h *HTTPBackend) Shards(seqNo int) (*ShardsResponse, error) {
 	endpoint := fmt.Sprintf("/shards?seqno=%d", seqNo)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -158,10 +158,10 @@ type UnpackAddressResponse struct {
 }
 
 // UnpackAddress unpacks a TON address
-func (c *Client) UnpackAddress(address string) (*UnpackAddressResponse, error) {
+func (h *HTTPBackend) UnpackAddress(address string) (*UnpackAddressResponse, error) {
 	endpoint := fmt.Sprintf("/unpackAddress?address=%s", address)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
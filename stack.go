@@ -0,0 +1,122 @@
+package toncenterzp
+
+import (
+	"fmt"
+	"math/big"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// StackNum returns a number argument for RunGetMethodRequest.Stack
+func StackNum(v *big.Int) []interface{} {
+	if v.Sign() < 0 {
+		return []interface{}{"num", "-0x" + new(big.Int).Neg(v).Text(16)}
+	}
+	return []interface{}{"num", "0x" + v.Text(16)}
+}
+
+// StackCell returns a cell argument for RunGetMethodRequest.Stack
+func StackCell(c *cell.Cell) []interface{} {
+	return []interface{}{"tvm.Cell", c.ToBOCBase64()}
+}
+
+// StackSlice returns a slice argument for RunGetMethodRequest.Stack
+func StackSlice(c *cell.Cell) []interface{} {
+	return []interface{}{"tvm.Slice", c.ToBOCBase64()}
+}
+
+// stackEntry returns entry i of the result stack after checking the exit code
+func (r *RunGetMethodResponse) stackEntry(i int) (string, interface{}, error) {
+	if code := r.Result.ExitCode; code != 0 && code != 1 {
+		return "", nil, NewError(ErrInvalidResponse, fmt.Sprintf("get method failed with exit code %d", code), nil)
+	}
+	if i < 0 || i >= len(r.Result.Stack) {
+		return "", nil, NewError(ErrInvalidResponse, fmt.Sprintf("stack has no entry %d", i), nil)
+	}
+	entry := r.Result.Stack[i]
+	if len(entry) == 0 {
+		return "", nil, NewError(ErrInvalidResponse, fmt.Sprintf("empty stack entry %d", i), nil)
+	}
+	kind, _ := entry[0].(string)
+	var value interface{}
+	if len(entry) > 1 {
+		value = entry[1]
+	}
+	return kind, value, nil
+}
+
+// StackNum returns the number at entry i of the result stack
+func (r *RunGetMethodResponse) StackNum(i int) (*big.Int, error) {
+	kind, value, err := r.stackEntry(i)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := value.(string)
+	if kind != "num" || !ok {
+		return nil, NewError(ErrInvalidResponse, fmt.Sprintf("stack entry %d is %s, not a number", i, kind), nil)
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, NewError(ErrInvalidResponse, fmt.Sprintf("invalid stack number %q", s), nil)
+	}
+	return n, nil
+}
+
+// StackCell returns the cell or slice at entry i of the result stack
+func (r *RunGetMethodResponse) StackCell(i int) (*cell.Cell, error) {
+	kind, value, err := r.stackEntry(i)
+	if err != nil {
+		return nil, err
+	}
+	boc, ok := value.(string)
+	if m, isMap := value.(map[string]interface{}); isMap {
+		boc, ok = m["bytes"].(string)
+	}
+	switch kind {
+	case "cell", "slice", "tvm.Cell", "tvm.Slice":
+	default:
+		ok = false
+	}
+	if !ok {
+		return nil, NewError(ErrInvalidResponse, fmt.Sprintf("stack entry %d is %s, not a cell", i, kind), nil)
+	}
+	c, err := cell.FromBOCBase64(boc)
+	if err != nil {
+		return nil, NewError(ErrInvalidResponse, "invalid stack cell", err)
+	}
+	return c, nil
+}
+
+// StackIsNull reports whether entry i of the result stack is null
+func (r *RunGetMethodResponse) StackIsNull(i int) (bool, error) {
+	kind, _, err := r.stackEntry(i)
+	if err != nil {
+		return false, err
+	}
+	return kind == "null", nil
+}
+
+// StackMaybeCell returns the cell at entry i of the result stack, nil if the entry is
+// null, e.g. an empty dictionary
+func (r *RunGetMethodResponse) StackMaybeCell(i int) (*cell.Cell, error) {
+	null, err := r.StackIsNull(i)
+	if err != nil || null {
+		return nil, err
+	}
+	return r.StackCell(i)
+}
+
+// StackAddress returns the address stored in the slice at entry i of the result stack
+func (r *RunGetMethodResponse) StackAddress(i int) (*address.Address, error) {
+	c, err := r.StackCell(i)
+	if err != nil {
+		return nil, err
+	}
+	s := c.BeginParse()
+	a := s.LoadAddress()
+	if err := s.Err(); err != nil {
+		return nil, NewError(ErrInvalidResponse, "invalid stack address", err)
+	}
+	return a, nil
+}
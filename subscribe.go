@@ -0,0 +1,640 @@
+package toncenterzp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp/internal/websocket"
+)
+
+// Default subscription settings
+const (
+	DefaultSubscribeBuffer       = 64
+	DefaultMinPollInterval       = 2 * time.Second
+	DefaultMaxPollInterval       = 30 * time.Second
+	DefaultReconnectDelay        = time.Second
+	DefaultMaxReconnectDelay     = 30 * time.Second
+	DefaultMaxReconnects         = 5
+	DefaultSubscribePollPageSize = 16
+	DefaultPingInterval          = 20 * time.Second
+	DefaultStreamTimeout         = 60 * time.Second
+)
+
+// Event sources reported in subscription events
+const (
+	EventSourceStream = "stream"
+	EventSourcePoll   = "poll"
+)
+
+// SubscribeOptions configures SubscribeBlocks and SubscribeAccount. Zero values
+// select the defaults above.
+//
+// When StreamURL is set the subscription first uses the provider's streaming
+// endpoint: a ws:// or wss:// URL is used as a WebSocket, an http:// or https://
+// URL as a server-sent events stream. After MaxReconnects consecutive failed
+// connections it falls back to polling. Without StreamURL it polls from the start.
+// Every time a stream connects the subscription polls once, so that data produced
+// while it was disconnected is delivered before the stream resumes.
+//
+// The streaming protocol is JSON. Over WebSocket the client sends
+//
+//	{"operation": "subscribe", "id": "1", "types": ["blocks"]}
+//	{"operation": "subscribe", "id": "1", "types": ["transactions"], "addresses": ["EQ..."]}
+//
+// and over SSE the same fields are passed as query parameters ("types",
+// "addresses"). The server sends messages of the form
+//
+//	{"type": "blocks", "blocks": [{"workchain": -1, "shard": "...", "seqno": 1, ...}]}
+//	{"type": "transactions", "transactions": [{"hash": "...", "lt": "...", ...}]}
+//
+// ("block" with a single object is accepted as well). Other messages are ignored.
+type SubscribeOptions struct {
+	StreamURL string
+	
+	// Buffer is the capacity of the event channel. When it is full the subscription
+	// stops reading from the stream or polling until the consumer catches up.
+	Buffer int
+	
+	// MinPollInterval and MaxPollInterval bound the adaptive polling interval, which
+	// shrinks to the minimum whenever new data arrives and grows while nothing changes
+	MinPollInterval time.Duration
+	MaxPollInterval time.Duration
+	
+	// ReconnectDelay is the initial delay before reconnecting a dropped stream; it
+	// doubles with every consecutive failure up to MaxReconnectDelay
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+	MaxReconnects     int
+	
+	// PingInterval is the interval of WebSocket pings. A stream on which nothing,
+	// not even a pong, arrives for StreamTimeout is considered dead and reconnected.
+	// PingInterval is lowered to half of StreamTimeout if it is not shorter.
+	PingInterval  time.Duration
+	StreamTimeout time.Duration
+	
+	// PollPageSize is the number of transactions fetched per poll of an account
+	PollPageSize int
+}
+
+// withDefaults returns a copy of the options with zero values replaced by defaults
+func (o *SubscribeOptions) withDefaults() SubscribeOptions {
+	var opts SubscribeOptions
+	if o != nil {
+		opts = *o
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = DefaultSubscribeBuffer
+	}
+	if opts.MinPollInterval <= 0 {
+		opts.MinPollInterval = DefaultMinPollInterval
+	}
+	if opts.MaxPollInterval < opts.MinPollInterval {
+		opts.MaxPollInterval = DefaultMaxPollInterval
+		if opts.MaxPollInterval < opts.MinPollInterval {
+			opts.MaxPollInterval = opts.MinPollInterval
+		}
+	}
+	if opts.ReconnectDelay <= 0 {
+		opts.ReconnectDelay = DefaultReconnectDelay
+	}
+	if opts.MaxReconnectDelay < opts.ReconnectDelay {
+		opts.MaxReconnectDelay = DefaultMaxReconnectDelay
+		if opts.MaxReconnectDelay < opts.ReconnectDelay {
+			opts.MaxReconnectDelay = opts.ReconnectDelay
+		}
+	}
+	if opts.MaxReconnects <= 0 {
+		opts.MaxReconnects = DefaultMaxReconnects
+	}
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = DefaultPingInterval
+	}
+	if opts.StreamTimeout <= 0 {
+		opts.StreamTimeout = DefaultStreamTimeout
+	}
+	if opts.PingInterval >= opts.StreamTimeout {
+		opts.PingInterval = opts.StreamTimeout / 2
+	}
+	if opts.PollPageSize <= 0 {
+		opts.PollPageSize = DefaultSubscribePollPageSize
+	}
+	return opts
+}
+
+// BlockEvent reports a new masterchain block
+type BlockEvent struct {
+	Block  BlockID
+	Source string
+}
+
+// AccountEvent reports a new transaction of a subscribed account. Raw holds the
+// transaction exactly as received, which for streams may carry more fields than
+// TransactionDetails.
+type AccountEvent struct {
+	Address     string
+	Transaction TransactionDetails
+	Raw         json.RawMessage
+	Source      string
+}
+
+// BlockSubscription delivers new masterchain blocks in order
+type BlockSubscription struct {
+	events chan BlockEvent
+	subscription
+}
+
+// Events returns the channel of new blocks. It is closed after Close.
+func (s *BlockSubscription) Events() <-chan BlockEvent {
+	return s.events
+}
+
+// AccountSubscription delivers new transactions of an account in logical time order
+type AccountSubscription struct {
+	events chan AccountEvent
+	subscription
+}
+
+// Events returns the channel of new transactions. It is closed after Close.
+func (s *AccountSubscription) Events() <-chan AccountEvent {
+	return s.events
+}
+
+// subscription is the lifecycle shared by all subscriptions
+type subscription struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Close stops the subscription and waits until its event channel is closed
+func (s *subscription) Close() {
+	s.once.Do(s.cancel)
+	<-s.done
+}
+
+// streamMessage is a message received from a streaming endpoint
+type streamMessage struct {
+	Type         string            `json:"type"`
+	Block        *BlockID          `json:"block"`
+	Blocks       []BlockID         `json:"blocks"`
+	Transactions []json.RawMessage `json:"transactions"`
+}
+
+// SubscribeBlocks follows new masterchain blocks until ctx is done or Close is called.
+// Blocks produced before the call are not reported.
+func (c *Client) SubscribeBlocks(ctx context.Context, opts *SubscribeOptions) (*BlockSubscription, error) {
+	o := opts.withDefaults()
+	
+	info, err := c.GetMasterchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	last := info.Result.LastBlockID.SeqNo
+	
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &BlockSubscription{
+		events:       make(chan BlockEvent, o.Buffer),
+		subscription: subscription{cancel: cancel, done: make(chan struct{})},
+	}
+	
+	emit := func(block BlockID, source string) bool {
+		if block.SeqNo <= last {
+			return true
+		}
+		select {
+		case sub.events <- BlockEvent{Block: block, Source: source}:
+			last = block.SeqNo
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	
+	onMessage := func(msg streamMessage) bool {
+		blocks := msg.Blocks
+		if msg.Block != nil {
+			blocks = append(blocks, *msg.Block)
+		}
+		for _, block := range blocks {
+			if block.Workchain != -1 {
+				continue
+			}
+			if !emit(block, EventSourceStream) {
+				return false
+			}
+		}
+		return true
+	}
+	
+	poll := func() (bool, error) {
+		info, err := c.GetMasterchainInfo()
+		if err != nil {
+			return false, err
+		}
+		
+		head := info.Result.LastBlockID
+		if head.SeqNo <= last {
+			return false, nil
+		}
+		
+		for seqNo := last + 1; seqNo < head.SeqNo; seqNo++ {
+			block, err := c.LookupBlock(LookupBlockRequest{Workchain: head.Workchain, Shard: head.Shard, SeqNo: seqNo})
+			if err != nil {
+				return true, err
+			}
+			if !emit(block.Result, EventSourcePoll) {
+				return true, ctx.Err()
+			}
+		}
+		
+		emit(BlockID{
+			Workchain: head.Workchain,
+			Shard:     head.Shard,
+			SeqNo:     head.SeqNo,
+			RootHash:  head.RootHash,
+			FileHash:  head.FileHash,
+		}, EventSourcePoll)
+		return true, ctx.Err()
+	}
+	
+	go func() {
+		defer close(sub.done)
+		defer close(sub.events)
+		c.runSubscription(ctx, o, url.Values{"types": {"blocks"}}, onMessage, poll)
+	}()
+	
+	return sub, nil
+}
+
+// SubscribeAccount follows new transactions of an address until ctx is done or Close
+// is called. Transactions that happened before the call are not reported.
+func (c *Client) SubscribeAccount(ctx context.Context, address string, opts *SubscribeOptions) (*AccountSubscription, error) {
+	if address == "" {
+		return nil, NewError(ErrInvalidParams, "address is required", nil)
+	}
+	o := opts.withDefaults()
+	
+	latest, err := c.GetTransactions(GetTransactionsRequest{Address: address, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	var lastLt uint64
+	if len(latest.Result.Transactions) > 0 {
+		lastLt, _ = strconv.ParseUint(latest.Result.Transactions[0].Lt, 10, 64)
+	}
+	
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &AccountSubscription{
+		events:       make(chan AccountEvent, o.Buffer),
+		subscription: subscription{cancel: cancel, done: make(chan struct{})},
+	}
+	
+	emit := func(tx TransactionDetails, raw json.RawMessage, source string) bool {
+		lt, err := strconv.ParseUint(tx.Lt, 10, 64)
+		if err != nil || lt <= lastLt {
+			return true
+		}
+		select {
+		case sub.events <- AccountEvent{Address: address, Transaction: tx, Raw: raw, Source: source}:
+			lastLt = lt
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	
+	onMessage := func(msg streamMessage) bool {
+		for _, raw := range msg.Transactions {
+			var tx TransactionDetails
+			if err := json.Unmarshal(raw, &tx); err != nil {
+				c.log(slog.LevelWarn, "invalid transaction in stream", slog.String("error", err.Error()))
+				continue
+			}
+			if !emit(tx, raw, EventSourceStream) {
+				return false
+			}
+		}
+		return true
+	}
+	
+	poll := func() (bool, error) {
+		// Collect everything newer than lastLt, newest first, then deliver oldest first
+		var pending []TransactionDetails
+		req := GetTransactionsRequest{Address: address, Limit: o.PollPageSize}
+		for {
+			resp, err := c.GetTransactions(req)
+			if err != nil {
+				return false, err
+			}
+			
+			txs := resp.Result.Transactions
+			if req.Hash != "" && len(txs) > 0 && txs[0].Hash == req.Hash {
+				txs = txs[1:]
+			}
+			
+			reachedKnown := len(txs) == 0
+			for _, tx := range txs {
+				lt, err := strconv.ParseUint(tx.Lt, 10, 64)
+				if err != nil || lt <= lastLt {
+					reachedKnown = true
+					break
+				}
+				pending = append(pending, tx)
+			}
+			if reachedKnown || len(resp.Result.Transactions) < req.Limit {
+				break
+			}
+			
+			req.Lt = txs[len(txs)-1].Lt
+			req.Hash = txs[len(txs)-1].Hash
+		}
+		
+		for i := len(pending) - 1; i >= 0; i-- {
+			raw, _ := json.Marshal(pending[i])
+			if !emit(pending[i], raw, EventSourcePoll) {
+				return true, ctx.Err()
+			}
+		}
+		return len(pending) > 0, nil
+	}
+	
+	go func() {
+		defer close(sub.done)
+		defer close(sub.events)
+		params := url.Values{"types": {"transactions"}, "addresses": {address}}
+		c.runSubscription(ctx, o, params, onMessage, poll)
+	}()
+	
+	return sub, nil
+}
+
+// runSubscription consumes the stream while it works and polls otherwise. onMessage
+// returns false when the subscription was cancelled while delivering; poll reports
+// whether it found new data. poll also runs once on every stream connection to
+// catch up with what was missed while disconnected.
+func (c *Client) runSubscription(ctx context.Context, opts SubscribeOptions, params url.Values, onMessage func(streamMessage) bool, poll func() (bool, error)) {
+	if opts.StreamURL != "" {
+		failures := 0
+		delay := opts.ReconnectDelay
+		for failures < opts.MaxReconnects {
+			received, err := c.consumeStream(ctx, opts, params, onMessage, poll)
+			if ctx.Err() != nil {
+				return
+			}
+			if received {
+				failures = 0
+				delay = opts.ReconnectDelay
+			}
+			failures++
+			
+			c.log(slog.LevelWarn, "stream disconnected",
+				slog.String("url", c.redact(opts.StreamURL)),
+				slog.Int("failures", failures),
+				slog.Duration("reconnect_in", delay),
+				slog.String("error", c.redact(fmt.Sprint(err))),
+			)
+			if !sleepContext(ctx, delay) {
+				return
+			}
+			delay *= 2
+			if delay > opts.MaxReconnectDelay {
+				delay = opts.MaxReconnectDelay
+			}
+		}
+		c.log(slog.LevelWarn, "stream unavailable, falling back to polling",
+			slog.String("url", c.redact(opts.StreamURL)),
+		)
+	}
+	
+	interval := opts.MinPollInterval
+	for {
+		found, err := poll()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.log(slog.LevelWarn, "subscription poll failed", slog.String("error", c.redact(err.Error())))
+		}
+		
+		if found {
+			interval = opts.MinPollInterval
+		} else {
+			interval += interval / 2
+			if interval > opts.MaxPollInterval {
+				interval = opts.MaxPollInterval
+			}
+		}
+		
+		if !sleepContext(ctx, interval) {
+			return
+		}
+	}
+}
+
+// consumeStream reads one streaming connection until it fails or ctx is done and
+// reports whether any message was received. Once connected it calls poll, and gives
+// up the connection if poll fails, since stream data would then skip a gap.
+func (c *Client) consumeStream(ctx context.Context, opts SubscribeOptions, params url.Values, onMessage func(streamMessage) bool, poll func() (bool, error)) (bool, error) {
+	catchUp := func() error {
+		_, err := poll()
+		return err
+	}
+	if strings.HasPrefix(opts.StreamURL, "ws://") || strings.HasPrefix(opts.StreamURL, "wss://") {
+		return c.consumeWebSocket(ctx, opts, params, onMessage, catchUp)
+	}
+	return c.consumeSSE(ctx, opts, params, onMessage, catchUp)
+}
+
+// consumeWebSocket subscribes over a WebSocket connection, pinging it every
+// PingInterval
+func (c *Client) consumeWebSocket(ctx context.Context, opts SubscribeOptions, params url.Values, onMessage func(streamMessage) bool, catchUp func() error) (bool, error) {
+	streamURL := opts.StreamURL
+	header := http.Header{}
+	if c.APIKey != "" {
+		header.Set("x-api-key", c.APIKey)
+	}
+	
+	conn, err := websocket.Dial(ctx, streamURL, header)
+	if err != nil {
+		return false, err
+	}
+	
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	defer conn.Close()
+	conn.SetIdleTimeout(opts.StreamTimeout)
+	
+	request := map[string]interface{}{
+		"operation": "subscribe",
+		"id":        strconv.Itoa(nextJSONRPCID()),
+		"types":     params["types"],
+	}
+	if addresses := params["addresses"]; len(addresses) > 0 {
+		request["addresses"] = addresses
+	}
+	subscribe, err := json.Marshal(request)
+	if err != nil {
+		return false, err
+	}
+	if err := conn.WriteText(subscribe); err != nil {
+		return false, err
+	}
+	
+	c.log(slog.LevelDebug, "stream connected", slog.String("url", c.redact(streamURL)))
+	
+	pingDone := make(chan struct{})
+	defer close(pingDone)
+	go func() {
+		ticker := time.NewTicker(opts.PingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if conn.Ping() != nil {
+					conn.Close()
+					return
+				}
+			case <-pingDone:
+				return
+			}
+		}
+	}()
+	
+	if err := catchUp(); err != nil {
+		return false, err
+	}
+	
+	received := false
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		
+		var msg streamMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if !onMessage(msg) {
+			return received, ctx.Err()
+		}
+	}
+}
+
+// consumeSSE subscribes over a server-sent events stream. SSE has no pings, so the
+// stream is dropped when no line, including keepalive comments, arrives for
+// StreamTimeout.
+func (c *Client) consumeSSE(ctx context.Context, opts SubscribeOptions, params url.Values, onMessage func(streamMessage) bool, catchUp func() error) (bool, error) {
+	streamURL := opts.StreamURL
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return false, err
+	}
+	query := u.Query()
+	for key, values := range params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := time.AfterFunc(opts.StreamTimeout, cancel)
+	defer idle.Stop()
+	
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if c.APIKey != "" {
+		req.Header.Set("x-api-key", c.APIKey)
+	}
+	
+	// The client timeout would cut long-lived streams, so only its transport is reused
+	httpClient := &http.Client{Transport: c.HTTPClient.Transport}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, sseError(ctx, streamCtx, opts, err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("stream responded with status %d", resp.StatusCode)
+	}
+	
+	c.log(slog.LevelDebug, "stream connected", slog.String("url", c.redact(streamURL)))
+	
+	// The timer only measures the stream, not catching up or delivering
+	idle.Stop()
+	if err := catchUp(); err != nil {
+		return false, err
+	}
+	idle.Reset(opts.StreamTimeout)
+	
+	received := false
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), websocket.MaxMessageSize)
+	var data []string
+	for scanner.Scan() {
+		idle.Reset(opts.StreamTimeout)
+		line := scanner.Text()
+		if line == "" {
+			if len(data) == 0 {
+				continue
+			}
+			received = true
+			
+			var msg streamMessage
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &msg); err == nil {
+				idle.Stop()
+				if !onMessage(msg) {
+					return received, ctx.Err()
+				}
+				idle.Reset(opts.StreamTimeout)
+			}
+			data = data[:0]
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+	
+	if err := scanner.Err(); err != nil {
+		return received, sseError(ctx, streamCtx, opts, err)
+	}
+	return received, fmt.Errorf("stream closed by server")
+}
+
+// sseError reports a stream cancelled by its idle timer as a timeout rather than
+// a cancellation
+func sseError(ctx, streamCtx context.Context, opts SubscribeOptions, err error) error {
+	if ctx.Err() == nil && streamCtx.Err() != nil {
+		return fmt.Errorf("stream idle for %s", opts.StreamTimeout)
+	}
+	return err
+}
+
+// sleepContext waits for d and reports false if ctx was cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
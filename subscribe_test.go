@@ -0,0 +1,291 @@
+package toncenterzp
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeChain is a backend whose masterchain head can be moved by the test
+type fakeChain struct {
+	UnimplementedBackend
+	
+	mu   sync.Mutex
+	head int
+}
+
+func (f *fakeChain) setHead(seqNo int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.head = seqNo
+}
+
+func (f *fakeChain) GetMasterchainInfo() (*GetMasterchainInfoResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &GetMasterchainInfoResponse{OK: true}
+	resp.Result.LastBlockID.Workchain = -1
+	resp.Result.LastBlockID.SeqNo = f.head
+	return resp, nil
+}
+
+func (f *fakeChain) LookupBlock(req LookupBlockRequest) (*LookupBlockResponse, error) {
+	return &LookupBlockResponse{OK: true, Result: BlockID{Workchain: -1, SeqNo: req.SeqNo}}, nil
+}
+
+// wsPeer is the server side of a test WebSocket connection
+type wsPeer struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// wsServer serves WebSocket connections with handle, called with the number of the
+// connection starting at 1
+func wsServer(t *testing.T, handle func(n int, p *wsPeer)) string {
+	t.Helper()
+	var conns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+			base64.StdEncoding.EncodeToString(sum[:]))
+		handle(int(atomic.AddInt32(&conns, 1)), &wsPeer{conn: conn, br: brw.Reader})
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// read returns the opcode and payload of the next frame from the client
+func (p *wsPeer) read() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(p.br, head[:]); err != nil {
+		return 0, nil, err
+	}
+	length := int(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(p.br, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		return 0, nil, fmt.Errorf("frame too large")
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(p.br, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(p.br, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return head[0] & 0x0F, payload, nil
+}
+
+// write sends an unmasked frame
+func (p *wsPeer) write(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode, byte(len(payload))}
+	_, err := p.conn.Write(append(frame, payload...))
+	return err
+}
+
+// sendBlock sends a blocks message with one masterchain block
+func (p *wsPeer) sendBlock(seqNo int) error {
+	return p.write(0x1, []byte(fmt.Sprintf(`{"type":"blocks","blocks":[{"workchain":-1,"seqno":%d}]}`, seqNo)))
+}
+
+// serve answers pings, if pong is set, until the client goes away
+func (p *wsPeer) serve(pong bool, pings *int32) {
+	for {
+		op, payload, err := p.read()
+		if err != nil {
+			return
+		}
+		if op == 0x9 {
+			atomic.AddInt32(pings, 1)
+			if pong {
+				p.write(0xA, payload)
+			}
+		}
+	}
+}
+
+// nextBlock returns the next block event or fails after a timeout
+func nextBlock(t *testing.T, sub *BlockSubscription) BlockEvent {
+	t.Helper()
+	select {
+	case ev := <-sub.Events():
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block event")
+		return BlockEvent{}
+	}
+}
+
+func TestSubscribeBlocksCatchesUpAfterReconnect(t *testing.T) {
+	chain := &fakeChain{head: 10}
+	url := wsServer(t, func(n int, p *wsPeer) {
+		if n == 2 {
+			// Blocks 12 to 14 were produced while the client was disconnected
+			chain.setHead(14)
+		}
+		if _, _, err := p.read(); err != nil {
+			return
+		}
+		if n == 1 {
+			p.sendBlock(11)
+			return
+		}
+		p.sendBlock(15)
+		var pings int32
+		p.serve(true, &pings)
+	})
+	
+	c := NewClient("")
+	c.Backend = chain
+	sub, err := c.SubscribeBlocks(context.Background(), &SubscribeOptions{StreamURL: url, ReconnectDelay: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	
+	want := []struct {
+		seqNo  int
+		source string
+	}{
+		{11, EventSourceStream},
+		{12, EventSourcePoll},
+		{13, EventSourcePoll},
+		{14, EventSourcePoll},
+		{15, EventSourceStream},
+	}
+	for _, w := range want {
+		ev := nextBlock(t, sub)
+		if ev.Block.SeqNo != w.seqNo || ev.Source != w.source {
+			t.Fatalf("got block %d from %s, want %d from %s", ev.Block.SeqNo, ev.Source, w.seqNo, w.source)
+		}
+	}
+}
+
+func TestSubscribeBlocksPingKeepsStreamAlive(t *testing.T) {
+	var conns, pings int32
+	url := wsServer(t, func(n int, p *wsPeer) {
+		atomic.AddInt32(&conns, 1)
+		if _, _, err := p.read(); err != nil {
+			return
+		}
+		go func() {
+			// Silent for several stream timeouts, kept alive by pongs only
+			time.Sleep(300 * time.Millisecond)
+			p.sendBlock(11)
+		}()
+		p.serve(true, &pings)
+	})
+	
+	c := NewClient("")
+	c.Backend = &fakeChain{head: 10}
+	sub, err := c.SubscribeBlocks(context.Background(), &SubscribeOptions{
+		StreamURL:      url,
+		PingInterval:   20 * time.Millisecond,
+		StreamTimeout:  60 * time.Millisecond,
+		ReconnectDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	
+	if ev := nextBlock(t, sub); ev.Block.SeqNo != 11 || ev.Source != EventSourceStream {
+		t.Fatalf("got block %d from %s", ev.Block.SeqNo, ev.Source)
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("%d connections, want 1", n)
+	}
+	if atomic.LoadInt32(&pings) == 0 {
+		t.Error("client never pinged")
+	}
+}
+
+func TestSubscribeBlocksReconnectsSilentWebSocket(t *testing.T) {
+	url := wsServer(t, func(n int, p *wsPeer) {
+		if _, _, err := p.read(); err != nil {
+			return
+		}
+		if n > 1 {
+			p.sendBlock(11)
+		}
+		// Pings are swallowed, as by a dead intermediary
+		var pings int32
+		p.serve(false, &pings)
+	})
+	
+	c := NewClient("")
+	c.Backend = &fakeChain{head: 10}
+	sub, err := c.SubscribeBlocks(context.Background(), &SubscribeOptions{
+		StreamURL:      url,
+		PingInterval:   20 * time.Millisecond,
+		StreamTimeout:  100 * time.Millisecond,
+		ReconnectDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	
+	if ev := nextBlock(t, sub); ev.Block.SeqNo != 11 {
+		t.Fatalf("got block %d", ev.Block.SeqNo)
+	}
+}
+
+func TestSubscribeBlocksReconnectsSilentSSE(t *testing.T) {
+	var conns int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if atomic.AddInt32(&conns, 1) > 1 {
+			fmt.Fprint(w, "data: {\"type\":\"blocks\",\"blocks\":[{\"workchain\":-1,\"seqno\":11}]}\n\n")
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	
+	c := NewClient("")
+	c.Backend = &fakeChain{head: 10}
+	sub, err := c.SubscribeBlocks(context.Background(), &SubscribeOptions{
+		StreamURL:      srv.URL,
+		StreamTimeout:  100 * time.Millisecond,
+		ReconnectDelay: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	
+	if ev := nextBlock(t, sub); ev.Block.SeqNo != 11 || ev.Source != EventSourceStream {
+		t.Fatalf("got block %d from %s", ev.Block.SeqNo, ev.Source)
+	}
+}
@@ -0,0 +1,638 @@
+package tlb
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fieldType describes how a TL-B type maps to Go: the Go type and the statements
+// that load it from a slice and store it into a builder
+type fieldType struct {
+	goType   string
+	nullable bool // nil is a valid value, so Maybe needs no extra pointer
+	rest     bool // an inline Cell or Any, which takes the rest of the slice
+	
+	load  func(s, dst string) string
+	store func(b, v, name string) string
+}
+
+// generator holds the state of one Generate call
+type generator struct {
+	schema   *Schema
+	structs  map[*Constructor]string // Go struct of each constructor
+	multi    map[string]bool         // types with several constructors
+	declared map[string]bool
+	tmp      int
+}
+
+var sizedRe = regexp.MustCompile(`^(uint|int|bits)([0-9]+)$`)
+
+// Generate returns Go source declaring the types of a schema in package pkg. A type
+// with one constructor becomes a struct named after the type; a type with several
+// becomes an interface implemented by one struct per constructor and read by a
+// Load<Type> function that picks the constructor by tag.
+func Generate(schema *Schema, pkg string) ([]byte, error) {
+	g := &generator{
+		schema:   schema,
+		structs:  map[*Constructor]string{},
+		multi:    map[string]bool{},
+		declared: map[string]bool{},
+	}
+	if err := g.name(); err != nil {
+		return nil, err
+	}
+	
+	var body bytes.Buffer
+	for _, typ := range schema.Types() {
+		ctors := schema.ConstructorsOf(typ)
+		if g.multi[typ] {
+			g.genInterface(&body, typ, ctors)
+		}
+		for _, c := range ctors {
+			if err := g.genStruct(&body, c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by tlbgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	src := body.String()
+	for _, imp := range []struct{ use, path string }{
+		{"fmt.", "fmt"},
+		{"big.", "math/big"},
+		{"address.", "github.com/zhaopeng331/toncenterzp/address"},
+		{"cell.", "github.com/zhaopeng331/toncenterzp/cell"},
+		{"tlb.", "github.com/zhaopeng331/toncenterzp/tlb"},
+	} {
+		if strings.Contains(src, imp.use) {
+			fmt.Fprintf(&out, "%q\n", imp.path)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.WriteString(src)
+	
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("tlb: formatting generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// name assigns Go names to types and constructors
+func (g *generator) name() error {
+	used := map[string]string{}
+	claim := func(name, what string) error {
+		if prev, ok := used[name]; ok {
+			return fmt.Errorf("tlb: %s and %s both map to Go name %s", prev, what, name)
+		}
+		used[name] = what
+		return nil
+	}
+	
+	for _, typ := range g.schema.Types() {
+		g.declared[typ] = true
+		ctors := g.schema.ConstructorsOf(typ)
+		if len(ctors) == 1 {
+			g.structs[ctors[0]] = goName(typ)
+			if err := claim(goName(typ), "type "+typ); err != nil {
+				return err
+			}
+			continue
+		}
+		
+		g.multi[typ] = true
+		if err := claim(goName(typ), "type "+typ); err != nil {
+			return err
+		}
+		for _, c := range ctors {
+			g.structs[c] = goName(c.Name)
+			if err := claim(goName(c.Name), "constructor "+c.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// goName converts a TL-B identifier to an exported Go identifier
+func goName(s string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(s, "_") {
+		if part == "" {
+			continue
+		}
+		if part == "id" {
+			b.WriteString("ID")
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	if b.Len() == 0 {
+		return "X"
+	}
+	return b.String()
+}
+
+// fieldName returns the Go name of the i-th field of a constructor
+func fieldName(f *Field, i int) string {
+	if f.Name == "" {
+		return "Field" + strconv.Itoa(i)
+	}
+	return goName(f.Name)
+}
+
+// temp returns a fresh local variable name
+func (g *generator) temp(prefix string) string {
+	g.tmp++
+	return prefix + strconv.Itoa(g.tmp)
+}
+
+// genInterface writes the interface of a type with several constructors and its loader
+func (g *generator) genInterface(w *bytes.Buffer, typ string, ctors []*Constructor) {
+	name := goName(typ)
+	var names []string
+	for _, c := range ctors {
+		names = append(names, g.structs[c])
+	}
+	fmt.Fprintf(w, "// %s is one of %s\ntype %s interface {\n\ttlb.Storer\n\tis%s()\n}\n\n",
+		name, strings.Join(names, ", "), name, name)
+	for _, n := range names {
+		fmt.Fprintf(w, "func (*%s) is%s() {}\n\n", n, name)
+	}
+	
+	fmt.Fprintf(w, "// Load%s reads a %s, choosing the constructor by its tag\n", name, name)
+	fmt.Fprintf(w, "func Load%s(s *cell.Slice) (%s, error) {\n\tswitch {\n", name, name)
+	var untagged *Constructor
+	for _, c := range ctors {
+		if c.TagBits == 0 {
+			if untagged == nil {
+				untagged = c
+			}
+			continue
+		}
+		fmt.Fprintf(w, "\tcase tlb.HasTag(s, %d, %#x):\n", c.TagBits, c.Tag)
+		fmt.Fprintf(w, "\t\tv := new(%s)\n\t\treturn v, v.LoadFromCell(s)\n", g.structs[c])
+	}
+	if untagged != nil {
+		fmt.Fprintf(w, "\tdefault:\n\t\tv := new(%s)\n\t\treturn v, v.LoadFromCell(s)\n", g.structs[untagged])
+	}
+	fmt.Fprintf(w, "\t}\n\tif err := s.Err(); err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(w, "\treturn nil, fmt.Errorf(\"%%w: no constructor of %s matches\", tlb.ErrInvalidTag)\n}\n\n", typ)
+}
+
+// genStruct writes the struct of a constructor and its methods
+func (g *generator) genStruct(w *bytes.Buffer, c *Constructor) error {
+	name := g.structs[c]
+	types := make([]*fieldType, len(c.Fields))
+	byName := map[string]int{}
+	for i, f := range c.Fields {
+		t, err := g.resolve(f.Type)
+		if err != nil {
+			return fmt.Errorf("%w (field %s of %s)", err, fieldName(f, i), c.Name)
+		}
+		if t.rest && i != len(c.Fields)-1 {
+			return fmt.Errorf("tlb: field %s of %s: inline %s must be the last field", fieldName(f, i), c.Name, f.Type)
+		}
+		if f.CondField != "" {
+			flag := types[byName[f.CondField]]
+			if flag.goType == "bool" && f.CondBit >= 0 || flag.goType != "bool" && !strings.HasPrefix(flag.goType, "uint") {
+				return fmt.Errorf("tlb: field %s of %s: %s cannot be used as a condition", fieldName(f, i), c.Name, f.CondField)
+			}
+			t = g.optional(t)
+		}
+		types[i] = t
+		if f.Name != "" {
+			byName[f.Name] = i
+		}
+	}
+	
+	cond := func(f *Field) string {
+		flag := "v." + fieldName(c.Fields[byName[f.CondField]], byName[f.CondField])
+		switch {
+		case types[byName[f.CondField]].goType == "bool":
+			return flag
+		case f.CondBit >= 0:
+			return fmt.Sprintf("%s>>%d&1 == 1", flag, f.CondBit)
+		}
+		return flag + " != 0"
+	}
+	
+	tag := ""
+	if c.TagBits > 0 {
+		tag = fmt.Sprintf("#%0*x", c.TagBits/4, c.Tag)
+		if c.TagBits%4 != 0 {
+			tag = fmt.Sprintf("$%0*b", c.TagBits, c.Tag)
+		}
+	}
+	fmt.Fprintf(w, "// %s is the TL-B constructor %s%s of %s\ntype %s struct {\n", name, c.Name, tag, c.Type, name)
+	for i, f := range c.Fields {
+		fmt.Fprintf(w, "\t%s %s // %s\n", fieldName(f, i), types[i].goType, f.Type)
+	}
+	w.WriteString("}\n\n")
+	
+	fmt.Fprintf(w, "// LoadFromCell reads a %s from s\nfunc (v *%s) LoadFromCell(s *cell.Slice) error {\n", name, name)
+	if c.TagBits > 0 {
+		fmt.Fprintf(w, "\tif err := tlb.CheckTag(s, %d, %#x, %q); err != nil {\n\t\treturn err\n\t}\n", c.TagBits, c.Tag, c.Name)
+	}
+	for i, f := range c.Fields {
+		dst := "v." + fieldName(f, i)
+		code := types[i].load("s", dst)
+		if f.CondField != "" {
+			code = fmt.Sprintf("if %s {\n%s}\n", cond(f), code)
+		}
+		w.WriteString(code)
+	}
+	w.WriteString("\treturn s.Err()\n}\n\n")
+	
+	fmt.Fprintf(w, "// Store writes v to b\nfunc (v *%s) Store(b *cell.Builder) error {\n", name)
+	if c.TagBits > 0 {
+		fmt.Fprintf(w, "\tb.StoreUInt(%#x, %d)\n", c.Tag, c.TagBits)
+	}
+	for i, f := range c.Fields {
+		field := name + "." + fieldName(f, i)
+		val := "v." + fieldName(f, i)
+		code := types[i].store("b", val, field)
+		if f.CondField != "" {
+			code = fmt.Sprintf("if %s {\n%s}\n", cond(f), code)
+		}
+		w.WriteString(code)
+	}
+	w.WriteString("\treturn b.Err()\n}\n\n")
+	
+	fmt.Fprintf(w, "// ToCell serializes v into a new cell\nfunc (v *%s) ToCell() (*cell.Cell, error) {\n", name)
+	w.WriteString("\tb := cell.BeginCell()\n\tif err := v.Store(b); err != nil {\n\t\treturn nil, err\n\t}\n\treturn b.EndCell()\n}\n\n")
+	return nil
+}
+
+// missing returns a statement failing for a nil field
+func missing(name string) string {
+	return fmt.Sprintf("return fmt.Errorf(\"%%w: %s\", tlb.ErrMissingField)\n", name)
+}
+
+// num returns the numeric argument i of an expression
+func num(t *TypeExpr, i int) (int, error) {
+	if len(t.Args) <= i || !t.Args[i].IsNum {
+		return 0, fmt.Errorf("tlb: %s expects a number", t.Name)
+	}
+	return t.Args[i].Num, nil
+}
+
+// resolve maps a type expression to its Go representation
+func (g *generator) resolve(t *TypeExpr) (*fieldType, error) {
+	if t.IsNum {
+		return nil, fmt.Errorf("tlb: number %d used as a type", t.Num)
+	}
+	
+	if m := sizedRe.FindStringSubmatch(t.Name); m != nil && len(t.Args) == 0 {
+		n, _ := strconv.Atoi(m[2])
+		switch {
+		case n == 0 || n > 1023:
+			return nil, fmt.Errorf("tlb: invalid size in %s", t.Name)
+		case m[1] == "uint":
+			return uintType(n), nil
+		case m[1] == "int":
+			return intType(n), nil
+		}
+		return bitsType(n), nil
+	}
+	
+	switch t.Name {
+	case "#":
+		return uintType(32), nil
+	case "##":
+		n, err := num(t, 0)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 || n > 1023 {
+			return nil, fmt.Errorf("tlb: invalid size in (## %d)", n)
+		}
+		return uintType(n), nil
+	case "Bool":
+		return simpleType("bool", false, "%s = %s.LoadBool()\n", "%s.StoreBool(%s)\n"), nil
+	case "Coins", "Grams":
+		return varUIntType(4), nil
+	case "VarUInteger":
+		n, err := num(t, 0)
+		if err != nil {
+			return nil, err
+		}
+		if n < 2 || n > 32 {
+			return nil, fmt.Errorf("tlb: unsupported (VarUInteger %d)", n)
+		}
+		return varUIntType(bits.Len(uint(n - 1))), nil
+	case "MsgAddress", "MsgAddressInt":
+		return simpleType("*address.Address", true, "%s = %s.LoadAddress()\n", "%s.StoreAddress(%s)\n"), nil
+	case "Cell", "Any":
+		return g.restType(), nil
+	case "^":
+		return g.refType(t.Args[0])
+	case "Maybe":
+		if len(t.Args) != 1 {
+			return nil, fmt.Errorf("tlb: Maybe expects one argument")
+		}
+		elem, err := g.resolve(t.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		return g.maybeType(elem), nil
+	case "Either":
+		return g.eitherType(t)
+	case "HashmapE":
+		return g.hashmapType(t)
+	}
+	
+	if g.declared[t.Name] && len(t.Args) == 0 {
+		return g.userType(t.Name), nil
+	}
+	return nil, fmt.Errorf("tlb: unsupported type %s", t)
+}
+
+// simpleType returns a type loaded and stored by one method call each
+func simpleType(goType string, nullable bool, load, store string) *fieldType {
+	return &fieldType{
+		goType:   goType,
+		nullable: nullable,
+		load: func(s, dst string) string {
+			return fmt.Sprintf(load, dst, s)
+		},
+		store: func(b, v, name string) string {
+			return fmt.Sprintf(store, b, v)
+		},
+	}
+}
+
+// uintType returns an unsigned integer of n bits
+func uintType(n int) *fieldType {
+	if n > 64 {
+		return &fieldType{
+			goType:   "*big.Int",
+			nullable: true,
+			load: func(s, dst string) string {
+				return fmt.Sprintf("%s = %s.LoadBigUInt(%d)\n", dst, s, n)
+			},
+			store: func(b, v, name string) string {
+				return fmt.Sprintf("%s.StoreBigUInt(%s, %d)\n", b, v, n)
+			},
+		}
+	}
+	goType := intGoType("uint", n)
+	return &fieldType{
+		goType: goType,
+		load: func(s, dst string) string {
+			if goType == "uint64" {
+				return fmt.Sprintf("%s = %s.LoadUInt(%d)\n", dst, s, n)
+			}
+			return fmt.Sprintf("%s = %s(%s.LoadUInt(%d))\n", dst, goType, s, n)
+		},
+		store: func(b, v, name string) string {
+			return fmt.Sprintf("%s.StoreUInt(uint64(%s), %d)\n", b, v, n)
+		},
+	}
+}
+
+// intType returns a signed integer of n bits
+func intType(n int) *fieldType {
+	if n > 64 {
+		return &fieldType{
+			goType:   "*big.Int",
+			nullable: true,
+			load: func(s, dst string) string {
+				return fmt.Sprintf("%s = %s.LoadBigInt(%d)\n", dst, s, n)
+			},
+			store: func(b, v, name string) string {
+				return fmt.Sprintf("%s.StoreBigInt(%s, %d)\n", b, v, n)
+			},
+		}
+	}
+	goType := intGoType("int", n)
+	return &fieldType{
+		goType: goType,
+		load: func(s, dst string) string {
+			if goType == "int64" {
+				return fmt.Sprintf("%s = %s.LoadInt(%d)\n", dst, s, n)
+			}
+			return fmt.Sprintf("%s = %s(%s.LoadInt(%d))\n", dst, goType, s, n)
+		},
+		store: func(b, v, name string) string {
+			return fmt.Sprintf("%s.StoreInt(int64(%s), %d)\n", b, v, n)
+		},
+	}
+}
+
+// intGoType returns the smallest Go integer type holding n bits
+func intGoType(prefix string, n int) string {
+	for _, size := range []int{8, 16, 32} {
+		if n <= size {
+			return prefix + strconv.Itoa(size)
+		}
+	}
+	return prefix + "64"
+}
+
+// bitsType returns a bit string of n bits
+func bitsType(n int) *fieldType {
+	return &fieldType{
+		goType:   "[]byte",
+		nullable: true,
+		load: func(s, dst string) string {
+			return fmt.Sprintf("%s = %s.LoadBits(%d)\n", dst, s, n)
+		},
+		store: func(b, v, name string) string {
+			return fmt.Sprintf("if len(%s)*8 < %d {\nreturn fmt.Errorf(\"%%w: %s needs %d bits\", tlb.ErrMissingField)\n}\n%s.StoreBits(%s, %d)\n",
+				v, n, name, n, b, v, n)
+		},
+	}
+}
+
+// varUIntType returns a VarUInteger with a length of lenBits bits
+func varUIntType(lenBits int) *fieldType {
+	return &fieldType{
+		goType:   "*big.Int",
+		nullable: true,
+		load: func(s, dst string) string {
+			return fmt.Sprintf("%s = %s.LoadVarUInt(%d)\n", dst, s, lenBits)
+		},
+		store: func(b, v, name string) string {
+			return fmt.Sprintf("%s.StoreVarUInt(%s, %d)\n", b, v, lenBits)
+		},
+	}
+}
+
+// restType returns an inline Cell taking the rest of the slice
+func (g *generator) restType() *fieldType {
+	return &fieldType{
+		goType:   "*cell.Cell",
+		nullable: true,
+		rest:     true,
+		load: func(s, dst string) string {
+			c := g.temp("c")
+			return fmt.Sprintf("%s, err := tlb.LoadRest(%s)\nif err != nil {\nreturn err\n}\n%s = %s\n", c, s, dst, c)
+		},
+		store: func(b, v, name string) string {
+			return fmt.Sprintf("if %s != nil {\n%s.StoreSlice(%s.BeginParse())\n}\n", v, b, v)
+		},
+	}
+}
+
+// refType returns ^X
+func (g *generator) refType(t *TypeExpr) (*fieldType, error) {
+	elem, err := g.resolve(t)
+	if err != nil {
+		return nil, err
+	}
+	if elem.rest {
+		return &fieldType{
+			goType:   "*cell.Cell",
+			nullable: true,
+			load: func(s, dst string) string {
+				return fmt.Sprintf("%s = %s.LoadRef()\n", dst, s)
+			},
+			store: func(b, v, name string) string {
+				return fmt.Sprintf("if %s == nil {\n%s}\n%s.StoreRef(%s)\n", v, missing(name), b, v)
+			},
+		}, nil
+	}
+	
+	return &fieldType{
+		goType:   elem.goType,
+		nullable: elem.nullable,
+		load: func(s, dst string) string {
+			r, rs := g.temp("r"), g.temp("rs")
+			return fmt.Sprintf("if %s := %s.LoadRef(); %s != nil {\n%s := %s.BeginParse()\n%sif err := %s.Err(); err != nil {\nreturn err\n}\n}\n",
+				r, s, r, rs, r, elem.load(rs, dst), rs)
+		},
+		store: func(b, v, name string) string {
+			rb, r := g.temp("rb"), g.temp("r")
+			return fmt.Sprintf("{\n%s := cell.BeginCell()\n%s%s, err := %s.EndCell()\nif err != nil {\nreturn err\n}\n%s.StoreRef(%s)\n}\n",
+				rb, elem.store(rb, v, name), r, rb, b, r)
+		},
+	}, nil
+}
+
+// optional returns a type holding nil when the value is absent
+func (g *generator) optional(elem *fieldType) *fieldType {
+	if elem.nullable {
+		return elem
+	}
+	return &fieldType{
+		goType:   "*" + elem.goType,
+		nullable: true,
+		load: func(s, dst string) string {
+			x := g.temp("x")
+			return fmt.Sprintf("var %s %s\n%s%s = &%s\n", x, elem.goType, elem.load(s, x), dst, x)
+		},
+		store: func(b, v, name string) string {
+			return fmt.Sprintf("if %s == nil {\n%s}\n%s", v, missing(name), elem.store(b, "(*"+v+")", name))
+		},
+	}
+}
+
+// maybeType returns Maybe X
+func (g *generator) maybeType(elem *fieldType) *fieldType {
+	opt := g.optional(elem)
+	return &fieldType{
+		goType:   opt.goType,
+		nullable: true,
+		load: func(s, dst string) string {
+			return fmt.Sprintf("if %s.LoadBit() {\n%s}\n", s, opt.load(s, dst))
+		},
+		store: func(b, v, name string) string {
+			return fmt.Sprintf("%s.StoreBit(%s != nil)\nif %s != nil {\n%s}\n", b, v, v, opt.store(b, v, name))
+		},
+	}
+}
+
+// eitherType returns (Either X ^X), stored as a reference
+func (g *generator) eitherType(t *TypeExpr) (*fieldType, error) {
+	if len(t.Args) != 2 {
+		return nil, fmt.Errorf("tlb: Either expects two arguments")
+	}
+	left, right := t.Args[0], t.Args[1]
+	if right.Name != "^" || right.Args[0].String() != left.String() {
+		return nil, fmt.Errorf("tlb: unsupported %s, only (Either X ^X) is supported", t)
+	}
+	
+	inline, err := g.resolve(left)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := g.refType(left)
+	if err != nil {
+		return nil, err
+	}
+	return &fieldType{
+		goType:   inline.goType,
+		nullable: inline.nullable,
+		rest:     inline.rest,
+		load: func(s, dst string) string {
+			return fmt.Sprintf("if %s.LoadBit() {\n%s} else {\n%s}\n", s, ref.load(s, dst), inline.load(s, dst))
+		},
+		store: func(b, v, name string) string {
+			if inline.rest {
+				return fmt.Sprintf("%s.StoreBit(%s != nil)\nif %s != nil {\n%s.StoreRef(%s)\n}\n", b, v, v, b, v)
+			}
+			return fmt.Sprintf("%s.StoreBit(true)\n%s", b, ref.store(b, v, name))
+		},
+	}, nil
+}
+
+// hashmapType returns (HashmapE N X) as a map keyed by unsigned integers
+func (g *generator) hashmapType(t *TypeExpr) (*fieldType, error) {
+	n, err := num(t, 0)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 || n > 64 || len(t.Args) != 2 {
+		return nil, fmt.Errorf("tlb: unsupported %s, keys must be 1 to 64 bits", t)
+	}
+	elem, err := g.resolve(t.Args[1])
+	if err != nil {
+		return nil, err
+	}
+	
+	goType := "map[uint64]" + elem.goType
+	return &fieldType{
+		goType:   goType,
+		nullable: true,
+		load: func(s, dst string) string {
+			m, vs, x := g.temp("m"), g.temp("vs"), g.temp("x")
+			return fmt.Sprintf("%s := %s{}\nif err := tlb.LoadHashmapE(%s, %d, func(key uint64, %s *cell.Slice) error {\nvar %s %s\n%s%s[key] = %s\nreturn %s.Err()\n}); err != nil {\nreturn err\n}\n%s = %s\n",
+				m, goType, s, n, vs, x, elem.goType, elem.load(vs, x), m, x, vs, dst, m)
+		},
+		store: func(b, v, name string) string {
+			keys, vb := g.temp("keys"), g.temp("vb")
+			return fmt.Sprintf("%s := make([]uint64, 0, len(%s))\nfor key := range %s {\n%s = append(%s, key)\n}\nif err := tlb.StoreHashmapE(%s, %d, %s, func(key uint64, %s *cell.Builder) error {\n%sreturn %s.Err()\n}); err != nil {\nreturn err\n}\n",
+				keys, v, v, keys, keys, b, n, keys, vb, elem.store(vb, v+"[key]", name), vb)
+		},
+	}, nil
+}
+
+// userType returns a type declared in the schema
+func (g *generator) userType(typ string) *fieldType {
+	name := goName(typ)
+	goType := "*" + name
+	if g.multi[typ] {
+		goType = name
+	}
+	return &fieldType{
+		goType:   goType,
+		nullable: true,
+		load: func(s, dst string) string {
+			x := g.temp("x")
+			if g.multi[typ] {
+				return fmt.Sprintf("%s, err := Load%s(%s)\nif err != nil {\nreturn err\n}\n%s = %s\n", x, name, s, dst, x)
+			}
+			return fmt.Sprintf("%s := new(%s)\nif err := %s.LoadFromCell(%s); err != nil {\nreturn err\n}\n%s = %s\n", x, name, x, s, dst, x)
+		},
+		store: func(b, v, name string) string {
+			return fmt.Sprintf("if %s == nil {\n%s}\nif err := %s.Store(%s); err != nil {\nreturn err\n}\n", v, missing(name), v, b)
+		},
+	}
+}
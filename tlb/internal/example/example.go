@@ -0,0 +1,5 @@
+// Package example holds types generated from messages.tlb, which cover the field
+// types supported by tlbgen
+package example
+
+//go:generate go run github.com/zhaopeng331/toncenterzp/cmd/tlbgen messages.tlb
@@ -0,0 +1,96 @@
+package example
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/payload"
+	"github.com/zhaopeng331/toncenterzp/tlb"
+)
+
+func TestTransferMatchesPayload(t *testing.T) {
+	// A generated jetton transfer is read by the handwritten decoder
+	fwd, err := cell.BeginCell().StoreUInt(0, 32).StoreStringSnake("hi").EndCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &Transfer{
+		QueryID:          5,
+		Amount:           big.NewInt(100),
+		Destination:      address.New(0, make([]byte, 32)),
+		ForwardTonAmount: big.NewInt(1),
+		ForwardPayload:   fwd,
+	}
+	c, err := tr.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := payload.Decode(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jt, ok := b.(*payload.JettonTransfer)
+	if !ok || jt.QueryID != 5 || jt.Amount.Int64() != 100 {
+		t.Fatalf("decoded %+v", b)
+	}
+	
+	m, err := LoadInternalMsgBody(c.BeginParse())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := m.(*Transfer); !ok || got.QueryID != 5 || got.ForwardTonAmount.Int64() != 1 {
+		t.Errorf("loaded %+v", m)
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	names, _ := cell.BeginCell().StoreUInt(7, 8).EndCell()
+	z := uint32(9)
+	st := &State{
+		Points: map[uint64]*Point{
+			1:  {X: -3, Y: 4, Flags: 2, Z: &z, B: true, W: &Point2{X: 7}},
+			77: {X: 1},
+			5:  {},
+		},
+		Names: map[uint64]*cell.Cell{3: names},
+		Seq:   8,
+		Big:   big.NewInt(12345),
+		Key:   make([]byte, 32),
+		Last:  &Point{Y: 2},
+	}
+	c, err := st.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	var got State
+	if err := got.LoadFromCell(c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	again, err := got.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c.Hash(), again.Hash()) {
+		t.Error("stored again differently")
+	}
+	p := got.Points[1]
+	if len(got.Points) != 3 || p.X != -3 || p.Z == nil || *p.Z != 9 || p.W == nil || p.W.X != 7 {
+		t.Errorf("points %+v, point 1 %+v", got.Points, p)
+	}
+	// z is absent when bit 1 of flags is clear, and w when b is false
+	if p := got.Points[77]; p.Z != nil || p.W != nil {
+		t.Errorf("point 77 %+v", p)
+	}
+	if got.Seq != 8 || got.Big.Int64() != 12345 || got.Last == nil || got.Last.Y != 2 || got.Names[3] == nil {
+		t.Errorf("state %+v", got)
+	}
+	
+	if _, err := LoadInternalMsgBody(c.BeginParse()); !errors.Is(err, tlb.ErrInvalidTag) {
+		t.Errorf("state read as a message body: error %v, want ErrInvalidTag", err)
+	}
+}
@@ -0,0 +1,350 @@
+// Code generated by tlbgen. DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+	"github.com/zhaopeng331/toncenterzp/address"
+	"github.com/zhaopeng331/toncenterzp/cell"
+	"github.com/zhaopeng331/toncenterzp/tlb"
+	"math/big"
+)
+
+// InternalMsgBody is one of Transfer, Excesses
+type InternalMsgBody interface {
+	tlb.Storer
+	isInternalMsgBody()
+}
+
+func (*Transfer) isInternalMsgBody() {}
+
+func (*Excesses) isInternalMsgBody() {}
+
+// LoadInternalMsgBody reads a InternalMsgBody, choosing the constructor by its tag
+func LoadInternalMsgBody(s *cell.Slice) (InternalMsgBody, error) {
+	switch {
+	case tlb.HasTag(s, 32, 0xf8a7ea5):
+		v := new(Transfer)
+		return v, v.LoadFromCell(s)
+	case tlb.HasTag(s, 32, 0xd53276db):
+		v := new(Excesses)
+		return v, v.LoadFromCell(s)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: no constructor of InternalMsgBody matches", tlb.ErrInvalidTag)
+}
+
+// Transfer is the TL-B constructor transfer#0f8a7ea5 of InternalMsgBody
+type Transfer struct {
+	QueryID             uint64           // uint64
+	Amount              *big.Int         // (VarUInteger 16)
+	Destination         *address.Address // MsgAddress
+	ResponseDestination *address.Address // MsgAddress
+	CustomPayload       *cell.Cell       // (Maybe ^Cell)
+	ForwardTonAmount    *big.Int         // (VarUInteger 16)
+	ForwardPayload      *cell.Cell       // (Either Cell ^Cell)
+}
+
+// LoadFromCell reads a Transfer from s
+func (v *Transfer) LoadFromCell(s *cell.Slice) error {
+	if err := tlb.CheckTag(s, 32, 0xf8a7ea5, "transfer"); err != nil {
+		return err
+	}
+	v.QueryID = s.LoadUInt(64)
+	v.Amount = s.LoadVarUInt(4)
+	v.Destination = s.LoadAddress()
+	v.ResponseDestination = s.LoadAddress()
+	if s.LoadBit() {
+		v.CustomPayload = s.LoadRef()
+	}
+	v.ForwardTonAmount = s.LoadVarUInt(4)
+	if s.LoadBit() {
+		v.ForwardPayload = s.LoadRef()
+	} else {
+		c1, err := tlb.LoadRest(s)
+		if err != nil {
+			return err
+		}
+		v.ForwardPayload = c1
+	}
+	return s.Err()
+}
+
+// Store writes v to b
+func (v *Transfer) Store(b *cell.Builder) error {
+	b.StoreUInt(0xf8a7ea5, 32)
+	b.StoreUInt(uint64(v.QueryID), 64)
+	b.StoreVarUInt(v.Amount, 4)
+	b.StoreAddress(v.Destination)
+	b.StoreAddress(v.ResponseDestination)
+	b.StoreBit(v.CustomPayload != nil)
+	if v.CustomPayload != nil {
+		if v.CustomPayload == nil {
+			return fmt.Errorf("%w: Transfer.CustomPayload", tlb.ErrMissingField)
+		}
+		b.StoreRef(v.CustomPayload)
+	}
+	b.StoreVarUInt(v.ForwardTonAmount, 4)
+	b.StoreBit(v.ForwardPayload != nil)
+	if v.ForwardPayload != nil {
+		b.StoreRef(v.ForwardPayload)
+	}
+	return b.Err()
+}
+
+// ToCell serializes v into a new cell
+func (v *Transfer) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := v.Store(b); err != nil {
+		return nil, err
+	}
+	return b.EndCell()
+}
+
+// Excesses is the TL-B constructor excesses#d53276db of InternalMsgBody
+type Excesses struct {
+	QueryID uint64 // uint64
+}
+
+// LoadFromCell reads a Excesses from s
+func (v *Excesses) LoadFromCell(s *cell.Slice) error {
+	if err := tlb.CheckTag(s, 32, 0xd53276db, "excesses"); err != nil {
+		return err
+	}
+	v.QueryID = s.LoadUInt(64)
+	return s.Err()
+}
+
+// Store writes v to b
+func (v *Excesses) Store(b *cell.Builder) error {
+	b.StoreUInt(0xd53276db, 32)
+	b.StoreUInt(uint64(v.QueryID), 64)
+	return b.Err()
+}
+
+// ToCell serializes v into a new cell
+func (v *Excesses) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := v.Store(b); err != nil {
+		return nil, err
+	}
+	return b.EndCell()
+}
+
+// Point is the TL-B constructor point of Point
+type Point struct {
+	X     int16   // int16
+	Y     int16   // int16
+	Flags uint8   // (## 8)
+	Z     *uint32 // uint32
+	B     bool    // Bool
+	W     *Point2 // ^Point2
+}
+
+// LoadFromCell reads a Point from s
+func (v *Point) LoadFromCell(s *cell.Slice) error {
+	v.X = int16(s.LoadInt(16))
+	v.Y = int16(s.LoadInt(16))
+	v.Flags = uint8(s.LoadUInt(8))
+	if v.Flags>>1&1 == 1 {
+		var x2 uint32
+		x2 = uint32(s.LoadUInt(32))
+		v.Z = &x2
+	}
+	v.B = s.LoadBool()
+	if v.B {
+		if r3 := s.LoadRef(); r3 != nil {
+			rs4 := r3.BeginParse()
+			x5 := new(Point2)
+			if err := x5.LoadFromCell(rs4); err != nil {
+				return err
+			}
+			v.W = x5
+			if err := rs4.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return s.Err()
+}
+
+// Store writes v to b
+func (v *Point) Store(b *cell.Builder) error {
+	b.StoreInt(int64(v.X), 16)
+	b.StoreInt(int64(v.Y), 16)
+	b.StoreUInt(uint64(v.Flags), 8)
+	if v.Flags>>1&1 == 1 {
+		if v.Z == nil {
+			return fmt.Errorf("%w: Point.Z", tlb.ErrMissingField)
+		}
+		b.StoreUInt(uint64((*v.Z)), 32)
+	}
+	b.StoreBool(v.B)
+	if v.B {
+		{
+			rb6 := cell.BeginCell()
+			if v.W == nil {
+				return fmt.Errorf("%w: Point.W", tlb.ErrMissingField)
+			}
+			if err := v.W.Store(rb6); err != nil {
+				return err
+			}
+			r7, err := rb6.EndCell()
+			if err != nil {
+				return err
+			}
+			b.StoreRef(r7)
+		}
+	}
+	return b.Err()
+}
+
+// ToCell serializes v into a new cell
+func (v *Point) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := v.Store(b); err != nil {
+		return nil, err
+	}
+	return b.EndCell()
+}
+
+// Point2 is the TL-B constructor point2 of Point2
+type Point2 struct {
+	X uint8 // uint8
+}
+
+// LoadFromCell reads a Point2 from s
+func (v *Point2) LoadFromCell(s *cell.Slice) error {
+	v.X = uint8(s.LoadUInt(8))
+	return s.Err()
+}
+
+// Store writes v to b
+func (v *Point2) Store(b *cell.Builder) error {
+	b.StoreUInt(uint64(v.X), 8)
+	return b.Err()
+}
+
+// ToCell serializes v into a new cell
+func (v *Point2) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := v.Store(b); err != nil {
+		return nil, err
+	}
+	return b.EndCell()
+}
+
+// State is the TL-B constructor state#01 of State
+type State struct {
+	Owner  *address.Address      // MsgAddress
+	Points map[uint64]*Point     // (HashmapE 32 Point)
+	Names  map[uint64]*cell.Cell // (HashmapE 8 ^Cell)
+	Seq    uint32                // #
+	Big    *big.Int              // uint128
+	Key    []byte                // bits256
+	Last   *Point                // (Maybe Point)
+}
+
+// LoadFromCell reads a State from s
+func (v *State) LoadFromCell(s *cell.Slice) error {
+	if err := tlb.CheckTag(s, 8, 0x1, "state"); err != nil {
+		return err
+	}
+	v.Owner = s.LoadAddress()
+	m8 := map[uint64]*Point{}
+	if err := tlb.LoadHashmapE(s, 32, func(key uint64, vs9 *cell.Slice) error {
+		var x10 *Point
+		x11 := new(Point)
+		if err := x11.LoadFromCell(vs9); err != nil {
+			return err
+		}
+		x10 = x11
+		m8[key] = x10
+		return vs9.Err()
+	}); err != nil {
+		return err
+	}
+	v.Points = m8
+	m12 := map[uint64]*cell.Cell{}
+	if err := tlb.LoadHashmapE(s, 8, func(key uint64, vs13 *cell.Slice) error {
+		var x14 *cell.Cell
+		x14 = vs13.LoadRef()
+		m12[key] = x14
+		return vs13.Err()
+	}); err != nil {
+		return err
+	}
+	v.Names = m12
+	v.Seq = uint32(s.LoadUInt(32))
+	v.Big = s.LoadBigUInt(128)
+	v.Key = s.LoadBits(256)
+	if s.LoadBit() {
+		x15 := new(Point)
+		if err := x15.LoadFromCell(s); err != nil {
+			return err
+		}
+		v.Last = x15
+	}
+	return s.Err()
+}
+
+// Store writes v to b
+func (v *State) Store(b *cell.Builder) error {
+	b.StoreUInt(0x1, 8)
+	b.StoreAddress(v.Owner)
+	keys16 := make([]uint64, 0, len(v.Points))
+	for key := range v.Points {
+		keys16 = append(keys16, key)
+	}
+	if err := tlb.StoreHashmapE(b, 32, keys16, func(key uint64, vb17 *cell.Builder) error {
+		if v.Points[key] == nil {
+			return fmt.Errorf("%w: State.Points", tlb.ErrMissingField)
+		}
+		if err := v.Points[key].Store(vb17); err != nil {
+			return err
+		}
+		return vb17.Err()
+	}); err != nil {
+		return err
+	}
+	keys18 := make([]uint64, 0, len(v.Names))
+	for key := range v.Names {
+		keys18 = append(keys18, key)
+	}
+	if err := tlb.StoreHashmapE(b, 8, keys18, func(key uint64, vb19 *cell.Builder) error {
+		if v.Names[key] == nil {
+			return fmt.Errorf("%w: State.Names", tlb.ErrMissingField)
+		}
+		vb19.StoreRef(v.Names[key])
+		return vb19.Err()
+	}); err != nil {
+		return err
+	}
+	b.StoreUInt(uint64(v.Seq), 32)
+	b.StoreBigUInt(v.Big, 128)
+	if len(v.Key)*8 < 256 {
+		return fmt.Errorf("%w: State.Key needs 256 bits", tlb.ErrMissingField)
+	}
+	b.StoreBits(v.Key, 256)
+	b.StoreBit(v.Last != nil)
+	if v.Last != nil {
+		if v.Last == nil {
+			return fmt.Errorf("%w: State.Last", tlb.ErrMissingField)
+		}
+		if err := v.Last.Store(b); err != nil {
+			return err
+		}
+	}
+	return b.Err()
+}
+
+// ToCell serializes v into a new cell
+func (v *State) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := v.Store(b); err != nil {
+		return nil, err
+	}
+	return b.EndCell()
+}
@@ -0,0 +1,121 @@
+package tlb
+
+import (
+	"errors"
+	"fmt"
+	
+	"github.com/zhaopeng331/toncenterzp/cell"
+)
+
+// ErrInvalidTag is returned when a cell does not start with the tag of the expected constructor
+var ErrInvalidTag = errors.New("tlb: invalid tag")
+
+// ErrMissingField is returned when storing a value whose required field is nil
+var ErrMissingField = errors.New("tlb: missing field")
+
+// Loader is implemented by generated types
+type Loader interface {
+	LoadFromCell(s *cell.Slice) error
+}
+
+// Storer is implemented by generated types
+type Storer interface {
+	Store(b *cell.Builder) error
+	ToCell() (*cell.Cell, error)
+}
+
+// UnmarshalBOCBase64 decodes a base64 BOC into v, e.g. a cell returned by RunGetMethod
+func UnmarshalBOCBase64(boc string, v Loader) error {
+	c, err := cell.FromBOCBase64(boc)
+	if err != nil {
+		return err
+	}
+	return v.LoadFromCell(c.BeginParse())
+}
+
+// MarshalBOCBase64 encodes v as a base64 BOC, e.g. a message body for SendBoc
+func MarshalBOCBase64(v Storer) (string, error) {
+	c, err := v.ToCell()
+	if err != nil {
+		return "", err
+	}
+	return c.ToBOCBase64(), nil
+}
+
+// CheckTag reads a constructor tag and fails unless it equals tag
+func CheckTag(s *cell.Slice, n int, tag uint64, name string) error {
+	if n == 0 {
+		return nil
+	}
+	got := s.LoadUInt(n)
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if got != tag {
+		return fmt.Errorf("%w: %s expects %#x, got %#x", ErrInvalidTag, name, tag, got)
+	}
+	return nil
+}
+
+// HasTag reports whether the slice starts with a tag without reading it
+func HasTag(s *cell.Slice, n int, tag uint64) bool {
+	return s.Err() == nil && s.BitsLeft() >= n && s.PreloadUInt(n) == tag
+}
+
+// LoadRest reads the rest of a slice into a cell, as for an inline Cell or Any field
+func LoadRest(s *cell.Slice) (*cell.Cell, error) {
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	c, err := s.ToCell()
+	if err != nil {
+		return nil, err
+	}
+	s.Skip(s.BitsLeft())
+	for s.RefsLeft() > 0 {
+		s.LoadRef()
+	}
+	return c, nil
+}
+
+// LoadHashmapE reads a HashmapE with keys of keyLen <= 64 bits and calls fn for every
+// value in key order
+func LoadHashmapE(s *cell.Slice, keyLen int, fn func(key uint64, value *cell.Slice) error) error {
+	d := s.LoadDict(keyLen)
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return d.ForEach(func(key []byte, value *cell.Slice) error {
+		if err := fn(cell.KeyUint(key, keyLen), value); err != nil {
+			return err
+		}
+		return value.Err()
+	})
+}
+
+// StoreHashmapE writes a HashmapE with keys of keyLen <= 64 bits, calling fn to store
+// the value of every key
+func StoreHashmapE(b *cell.Builder, keyLen int, keys []uint64, fn func(key uint64, b *cell.Builder) error) error {
+	d := cell.NewDict(keyLen)
+	for _, k := range keys {
+		if keyLen < 64 && k>>keyLen != 0 {
+			return fmt.Errorf("tlb: dictionary key %d does not fit in %d bits", k, keyLen)
+		}
+		if v, _ := d.Get(cell.UintKey(k, keyLen)); v != nil {
+			return fmt.Errorf("tlb: duplicate dictionary key %d", k)
+		}
+		vb := cell.BeginCell()
+		if err := fn(k, vb); err != nil {
+			return err
+		}
+		v, err := vb.EndCell()
+		if err != nil {
+			return err
+		}
+		if err := d.Set(cell.UintKey(k, keyLen), v); err != nil {
+			return err
+		}
+	}
+	b.StoreDict(d)
+	return b.Err()
+}
@@ -0,0 +1,353 @@
+// Package tlb parses TL-B schemas and generates Go types that read and write them.
+//
+// Parse reads the constructors of a .tlb file; Generate turns them into Go source
+// with a struct per constructor and LoadFromCell, Store and ToCell methods. The
+// tlbgen command wraps both for use with go generate:
+//
+//	//go:generate go run github.com/zhaopeng331/toncenterzp/cmd/tlbgen -pkg wallet -out messages_tlb.go messages.tlb
+//
+// Supported field types are uintN, intN, bitsN, (## N), #, Bool, Coins, Grams,
+// (VarUInteger N), MsgAddress, MsgAddressInt, Cell, Any, ^X, (Maybe X),
+// (Either X ^X), (HashmapE N X) with N <= 64, conditional fields (flag?X and
+// flag.N?X) and other types of the schema. Type parameters, implicit fields and
+// constraints ({...}) are not supported.
+package tlb
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrSyntax is returned for schemas that cannot be parsed
+var ErrSyntax = errors.New("tlb: syntax error")
+
+// syntaxError returns an ErrSyntax error with details
+func syntaxError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrSyntax, fmt.Sprintf(format, args...))
+}
+
+// Schema is a parsed TL-B schema
+type Schema struct {
+	Constructors []*Constructor
+}
+
+// Constructor is a TL-B constructor declaration
+type Constructor struct {
+	Name    string
+	TagBits int
+	Tag     uint64
+	Fields  []*Field
+	Type    string // the type the constructor builds
+}
+
+// Field is a field of a constructor
+type Field struct {
+	Name string // empty for anonymous fields
+	Type *TypeExpr
+	
+	// CondField is the field a conditional field (flag?X or flag.N?X) depends on;
+	// CondBit is N, or -1 for the whole flag
+	CondField string
+	CondBit   int
+}
+
+// TypeExpr is a type expression: a name applied to arguments, a reference (Name "^"
+// with one argument) or a number
+type TypeExpr struct {
+	Name  string
+	Args  []*TypeExpr
+	Num   int
+	IsNum bool
+}
+
+// String formats the expression in TL-B syntax
+func (t *TypeExpr) String() string {
+	switch {
+	case t.IsNum:
+		return strconv.Itoa(t.Num)
+	case t.Name == "^":
+		return "^" + t.Args[0].String()
+	case len(t.Args) == 0:
+		return t.Name
+	}
+	parts := []string{t.Name}
+	for _, a := range t.Args {
+		parts = append(parts, a.String())
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// Types returns the names of the types built by the schema's constructors, in order
+// of first appearance
+func (s *Schema) Types() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, c := range s.Constructors {
+		if !seen[c.Type] {
+			seen[c.Type] = true
+			names = append(names, c.Type)
+		}
+	}
+	return names
+}
+
+// ConstructorsOf returns the constructors of a type
+func (s *Schema) ConstructorsOf(typ string) []*Constructor {
+	var out []*Constructor
+	for _, c := range s.Constructors {
+		if c.Type == typ {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+var (
+	commentRe = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+	headerRe  = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(#[0-9a-fA-F]*|#_|\$[01]*|\$_)?$`)
+	tokenRe   = regexp.MustCompile(`\s*(##|[A-Za-z_][A-Za-z0-9_]*|[0-9]+|[#^():?.=\[\]{}~<>$,*+-])`)
+)
+
+// Parse parses a TL-B schema. Every constructor needs an explicit tag: #hex, $bits,
+// or #_ / $_ for none.
+func Parse(src string) (*Schema, error) {
+	src = commentRe.ReplaceAllString(src, " ")
+	
+	schema := &Schema{}
+	decls := strings.Split(src, ";")
+	if strings.TrimSpace(decls[len(decls)-1]) != "" {
+		return nil, syntaxError("missing ; after %q", strings.TrimSpace(decls[len(decls)-1]))
+	}
+	for _, decl := range decls[:len(decls)-1] {
+		decl = strings.TrimSpace(decl)
+		if decl == "" {
+			continue
+		}
+		c, err := parseConstructor(decl)
+		if err != nil {
+			return nil, err
+		}
+		schema.Constructors = append(schema.Constructors, c)
+	}
+	return schema, nil
+}
+
+// parseConstructor parses one declaration without its trailing semicolon
+func parseConstructor(decl string) (*Constructor, error) {
+	head := strings.Fields(decl)[0]
+	m := headerRe.FindStringSubmatch(head)
+	if m == nil {
+		return nil, syntaxError("invalid constructor %q", head)
+	}
+	c := &Constructor{Name: m[1]}
+	switch tag := m[2]; {
+	case tag == "":
+		return nil, syntaxError("constructor %s has no tag; use $_ for none", c.Name)
+	case tag == "#_" || tag == "$_":
+	case tag[0] == '#':
+		if len(tag) > 17 {
+			return nil, syntaxError("tag of %s is longer than 64 bits", c.Name)
+		}
+		c.TagBits = 4 * (len(tag) - 1)
+		c.Tag, _ = strconv.ParseUint(tag[1:], 16, 64)
+	default:
+		if len(tag) > 65 {
+			return nil, syntaxError("tag of %s is longer than 64 bits", c.Name)
+		}
+		c.TagBits = len(tag) - 1
+		c.Tag, _ = strconv.ParseUint(tag[1:], 2, 64)
+	}
+	
+	toks, err := tokenize(strings.TrimSpace(decl[len(head):]))
+	if err != nil {
+		return nil, fmt.Errorf("%w (in %s)", err, c.Name)
+	}
+	p := &parser{toks: toks}
+	for !p.done() && p.peek() != "=" {
+		f, err := p.field()
+		if err != nil {
+			return nil, fmt.Errorf("%w (in %s)", err, c.Name)
+		}
+		c.Fields = append(c.Fields, f)
+	}
+	if !p.accept("=") {
+		return nil, syntaxError("constructor %s has no result type", c.Name)
+	}
+	if p.done() || !isIdent(p.peek()) {
+		return nil, syntaxError("constructor %s has no result type", c.Name)
+	}
+	c.Type = p.next()
+	if !p.done() {
+		return nil, syntaxError("type %s: type parameters are not supported", c.Type)
+	}
+	
+	if err := checkFields(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// checkFields checks that field names are unique and that conditions refer to earlier fields
+func checkFields(c *Constructor) error {
+	seen := map[string]bool{}
+	for _, f := range c.Fields {
+		if f.CondField != "" && !seen[f.CondField] {
+			return syntaxError("%s: condition %s? does not refer to an earlier field", c.Name, f.CondField)
+		}
+		if f.Name == "" {
+			continue
+		}
+		if seen[f.Name] {
+			return syntaxError("%s: duplicate field %s", c.Name, f.Name)
+		}
+		seen[f.Name] = true
+	}
+	return nil
+}
+
+// tokenize splits the fields and result of a declaration into tokens
+func tokenize(s string) ([]string, error) {
+	var toks []string
+	for len(strings.TrimSpace(s)) > 0 {
+		m := tokenRe.FindStringSubmatchIndex(s)
+		if m == nil || m[0] != 0 {
+			return nil, syntaxError("unexpected %q", strings.TrimSpace(s))
+		}
+		toks = append(toks, s[m[2]:m[3]])
+		s = s[m[1]:]
+	}
+	return toks, nil
+}
+
+// isIdent reports whether a token is an identifier
+func isIdent(tok string) bool {
+	c := tok[0]
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// parser reads fields and type expressions from tokens
+type parser struct {
+	toks []string
+	pos  int
+}
+
+// done reports whether all tokens were read
+func (p *parser) done() bool {
+	return p.pos >= len(p.toks)
+}
+
+// peek returns the next token without reading it
+func (p *parser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+// peekAt returns the token i positions ahead
+func (p *parser) peekAt(i int) string {
+	if p.pos+i >= len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos+i]
+}
+
+// next reads a token
+func (p *parser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// accept reads the next token if it equals tok
+func (p *parser) accept(tok string) bool {
+	if p.peek() == tok {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// field reads name:type, name:flag?type, name:flag.N?type or an anonymous type
+func (p *parser) field() (*Field, error) {
+	switch p.peek() {
+	case "{":
+		return nil, syntaxError("implicit fields and constraints are not supported")
+	case "[":
+		return nil, syntaxError("anonymous constructors are not supported")
+	}
+	
+	f := &Field{CondBit: -1}
+	if isIdent(p.peek()) && p.peekAt(1) == ":" {
+		f.Name = p.next()
+		if f.Name == "_" {
+			f.Name = ""
+		}
+		p.next()
+		
+		if isIdent(p.peek()) && (p.peekAt(1) == "?" || p.peekAt(1) == ".") {
+			f.CondField = p.next()
+			if p.accept(".") {
+				n, err := strconv.Atoi(p.next())
+				if err != nil || n > 63 {
+					return nil, syntaxError("invalid condition bit in field %s", f.Name)
+				}
+				f.CondBit = n
+			}
+			if !p.accept("?") {
+				return nil, syntaxError("expected ? in field %s", f.Name)
+			}
+		}
+	}
+	
+	t, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	f.Type = t
+	return f, nil
+}
+
+// term reads ^term, (expr), a name or a number
+func (p *parser) term() (*TypeExpr, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, syntaxError("unexpected end of declaration")
+	case tok == "^":
+		t, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		return &TypeExpr{Name: "^", Args: []*TypeExpr{t}}, nil
+	case tok == "(":
+		head, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		for p.peek() != ")" {
+			arg, err := p.term()
+			if err != nil {
+				return nil, err
+			}
+			if head.Name == "^" || head.IsNum {
+				return nil, syntaxError("cannot apply %s to arguments", head)
+			}
+			head.Args = append(head.Args, arg)
+		}
+		p.next()
+		return head, nil
+	case tok == "#" || tok == "##" || isIdent(tok):
+		return &TypeExpr{Name: tok}, nil
+	case tok[0] >= '0' && tok[0] <= '9':
+		n, err := strconv.Atoi(tok)
+		if err != nil {
+			return nil, syntaxError("invalid number %s", tok)
+		}
+		return &TypeExpr{Num: n, IsNum: true}, nil
+	}
+	return nil, syntaxError("unexpected %q", tok)
+}
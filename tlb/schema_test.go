@@ -0,0 +1,93 @@
+package tlb
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	s, err := Parse(`
+		// comment
+		a#0f8a7ea5 x:uint64 ^Cell = T;
+		b$10 flags:(## 8) y:flags.3?(Maybe ^T) /* comment */ z:flags?Bool = T;
+		c$_ = U;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Constructors) != 3 {
+		t.Fatalf("%d constructors, want 3", len(s.Constructors))
+	}
+	if types := s.Types(); len(types) != 2 || types[0] != "T" || types[1] != "U" || len(s.ConstructorsOf("T")) != 2 {
+		t.Errorf("types %v", types)
+	}
+	
+	a, b, c := s.Constructors[0], s.Constructors[1], s.Constructors[2]
+	if a.Tag != 0x0f8a7ea5 || a.TagBits != 32 || b.Tag != 0b10 || b.TagBits != 2 || c.TagBits != 0 {
+		t.Errorf("tags %#x/%d, %#b/%d, %d bits", a.Tag, a.TagBits, b.Tag, b.TagBits, c.TagBits)
+	}
+	if len(a.Fields) != 2 || a.Fields[1].Name != "" || a.Fields[1].Type.String() != "^Cell" {
+		t.Errorf("fields of a %+v", a.Fields)
+	}
+	y, z := b.Fields[1], b.Fields[2]
+	if y.CondField != "flags" || y.CondBit != 3 || y.Type.String() != "(Maybe ^T)" {
+		t.Errorf("field y %+v", y)
+	}
+	if z.CondField != "flags" || z.CondBit != -1 || z.Type.String() != "Bool" {
+		t.Errorf("field z %+v", z)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"a#01 x:uint8 = T",
+		"a x:uint8 = T;",
+		"a#01 x:uint8;",
+		"a#01 x:uint8 x:uint8 = T;",
+		"a#01 y:x?uint8 x:# = T;",
+		"a#01 x:uint8 = T X;",
+		"a#01 {n:#} x:uint8 = T;",
+		"a#0123456789abcdef01 = T;",
+		"a#01 x:(^Cell 5) = T;",
+	} {
+		if _, err := Parse(src); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: error %v, want ErrSyntax", src, err)
+		}
+	}
+}
+
+func TestGenerateExample(t *testing.T) {
+	// internal/example holds the code generated from its schema; its tests check
+	// the generated types
+	src, err := os.ReadFile("internal/example/messages.tlb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Parse(string(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := Generate(s, "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("internal/example/messages_tlb.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(code, want) {
+		t.Error("internal/example/messages_tlb.go is out of date; run go generate ./tlb/...")
+	}
+}
+
+func TestGenerateUnknownType(t *testing.T) {
+	s, err := Parse("a#01 x:Unknown = T;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Generate(s, "example"); err == nil {
+		t.Error("unknown field type accepted")
+	}
+}
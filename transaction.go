@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	
+	"github.com/zhaopeng331/toncenterzp/payload"
 )
 
 // GetTransactionsRequest represents the request for the /getTransactions endpoint
@@ -56,10 +58,15 @@ type Message struct {
 	BodyHash    string `json:"body_hash"`
 	MsgType     string `json:"msg_type"`
 	MsgData     MessageData `json:"msg_data"`
+	
+	// Decoded is the body decoded by opcode, set on GetTransactions results;
+	// nil when the message has no body or it fails to decode
+	Decoded payload.Body `json:"-"`
 }
 
 // MessageData represents the data of a message
 type MessageData struct {
+	Type     string `json:"@type"`
 	Text     string `json:"text"`
 	InitState string `json:"init_state"`
 	Body     string `json:"body"`
@@ -124,10 +131,10 @@ type GetTransactionsResponse struct {
 }
 
 // GetTransactions gets transactions for a TON address
-func (c *Client) GetTransactions(req GetTransactionsRequest) (*GetTransactionsResponse, error) {
+func (h *HTTPBackend) GetTransactions(req GetTransactionsRequest) (*GetTransactionsResponse, error) {
 	endpoint := "/getTransactions"
 	
-	respBody, err := c.doRequest(http.MethodPost, endpoint, req)
+	respBody, err := h.client.doRequest(http.MethodPost, endpoint, req)
 	if err != nil {
 		return nil, err
 	}
@@ -161,10 +168,10 @@ type GetWalletInformationResponse struct {
 }
 
 // GetWalletInformation gets information about a TON wallet
-func (c *Client) GetWalletInformation(address string) (*GetWalletInformationResponse, error) {
+func (h *HTTPBackend) GetWalletInformation(address string) (*GetWalletInformationResponse, error) {
 	endpoint := fmt.Sprintf("/getWalletInformation?address=%s", address)
 	
-	respBody, err := c.doRequest(http.MethodGet, endpoint, nil)
+	respBody, err := h.client.doRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
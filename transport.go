@@ -0,0 +1,156 @@
+package toncenterzp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// TransportMode selects how typed client methods reach the API
+type TransportMode int
+
+const (
+	// TransportREST calls every method through its own HTTP endpoint (the default)
+	TransportREST TransportMode = iota
+	
+	// TransportJSONRPC routes every method through the /jsonRPC endpoint, for
+	// providers that only expose JSON-RPC. Requests and responses keep the same structs.
+	TransportJSONRPC
+)
+
+// String returns the name of the transport mode
+func (m TransportMode) String() string {
+	switch m {
+	case TransportREST:
+		return "rest"
+	case TransportJSONRPC:
+		return "jsonrpc"
+	default:
+		return fmt.Sprintf("TransportMode(%d)", int(m))
+	}
+}
+
+// endpointPath strips the query string from an endpoint
+func endpointPath(endpoint string) string {
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		return endpoint[:i]
+	}
+	return endpoint
+}
+
+// execute sends a request over the transport selected for the client
+func (c *Client) execute(method, endpoint string, jsonBody []byte) ([]byte, error) {
+	if c.Transport == TransportJSONRPC && endpointPath(endpoint) != EndpointJSONRPC {
+		return c.executeJSONRPC(endpoint, jsonBody)
+	}
+	return c.send(method, c.BaseURL, endpoint, jsonBody)
+}
+
+// executeJSONRPC translates a REST request into a JSON-RPC call and the JSON-RPC
+// answer back into the {"ok": true, "result": ...} shape of the REST endpoints
+func (c *Client) executeJSONRPC(endpoint string, jsonBody []byte) ([]byte, error) {
+	method, params, err := jsonRPCParams(endpoint, jsonBody)
+	if err != nil {
+		return nil, err
+	}
+	
+	reqBody, err := json.Marshal(JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      nextJSONRPCID(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request body: %w", err)
+	}
+	
+	respBody, err := c.send(http.MethodPost, c.BaseURL, EndpointJSONRPC, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	
+	var response jsonRPCEnvelope
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshaling response: %w (code: %d)", err, ErrInvalidResponse)
+	}
+	
+	if rpcErr := response.rpcError(); rpcErr != nil {
+		return nil, fmt.Errorf("JSON-RPC error: %s (code: %d)", rpcErr.Message, rpcErr.Code)
+	}
+	
+	if response.OK != nil && !*response.OK {
+		return nil, fmt.Errorf("API returned non-OK status (code: %d)", ErrAPIError)
+	}
+	
+	result := response.Result
+	if len(result) == 0 {
+		result = json.RawMessage("null")
+	}
+	
+	return json.Marshal(struct {
+		OK     bool            `json:"ok"`
+		Result json.RawMessage `json:"result"`
+	}{OK: true, Result: result})
+}
+
+// jsonRPCEnvelope is a JSON-RPC response as toncenter sends it: besides the standard
+// fields it carries an ok flag, and a failed call may report its error as a plain
+// string next to a top-level code instead of an error object
+type jsonRPCEnvelope struct {
+	OK     *bool           `json:"ok"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+	Code   int             `json:"code"`
+	ID     *int            `json:"id"`
+}
+
+// rpcError returns the error of the response in either form, nil if it has none
+func (r *jsonRPCEnvelope) rpcError() *JSONRPCError {
+	if len(r.Error) == 0 || bytes.Equal(r.Error, []byte("null")) {
+		return nil
+	}
+	var rpcErr JSONRPCError
+	if err := json.Unmarshal(r.Error, &rpcErr); err != nil {
+		var message string
+		if json.Unmarshal(r.Error, &message) != nil {
+			message = string(r.Error)
+		}
+		rpcErr = JSONRPCError{Code: r.Code, Message: message}
+	}
+	return &rpcErr
+}
+
+// jsonRPCParams derives the JSON-RPC method name and params from a REST endpoint and body.
+// Query parameters that look like integers are sent as numbers.
+func jsonRPCParams(endpoint string, jsonBody []byte) (string, interface{}, error) {
+	path := endpointPath(endpoint)
+	method := strings.TrimPrefix(path, "/")
+	
+	if len(jsonBody) > 0 && !bytes.Equal(jsonBody, []byte("null")) {
+		return method, json.RawMessage(jsonBody), nil
+	}
+	
+	params := map[string]interface{}{}
+	if len(path) < len(endpoint) {
+		query, err := url.ParseQuery(endpoint[len(path)+1:])
+		if err != nil {
+			return "", nil, NewError(ErrInvalidParams, "invalid query parameters", err)
+		}
+		for key, values := range query {
+			if len(values) == 0 {
+				continue
+			}
+			if n, err := strconv.ParseInt(values[0], 10, 64); err == nil {
+				params[key] = n
+			} else {
+				params[key] = values[0]
+			}
+		}
+	}
+	
+	return method, params, nil
+}
@@ -0,0 +1,117 @@
+package toncenterzp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// jsonRPCServer serves /jsonRPC with the response built by answer from each call and
+// fails any other path
+func jsonRPCServer(t *testing.T, answer func(req JSONRPCRequest) string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, EndpointJSONRPC) || r.Method != http.MethodPost {
+			t.Errorf("%s %s called in JSON-RPC mode", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var req JSONRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding call: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(answer(req)))
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClientWithOptions("", srv.URL, DefaultTimeout)
+	c.Transport = TransportJSONRPC
+	return c
+}
+
+func TestJSONRPCTransportQueryParams(t *testing.T) {
+	var calls []JSONRPCRequest
+	c := jsonRPCServer(t, func(req JSONRPCRequest) string {
+		calls = append(calls, req)
+		if req.Method == "shards" {
+			return `{"ok":true,"result":{"shards":[{"workchain":0,"seqno":7}]},"jsonrpc":"2.0","id":1}`
+		}
+		return `{"ok":true,"result":"1500","jsonrpc":"2.0","id":1}`
+	})
+	
+	balance, err := c.GetAddressBalance("EQabc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Result != "1500" {
+		t.Errorf("balance %q, want 1500", balance.Result)
+	}
+	shards, err := c.Shards(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shards.Result.Shards) != 1 || shards.Result.Shards[0].SeqNo != 7 {
+		t.Errorf("shards %+v", shards.Result.Shards)
+	}
+	
+	// Query parameters become params, integers as numbers
+	if len(calls) != 2 {
+		t.Fatalf("%d calls, want 2", len(calls))
+	}
+	params, _ := json.Marshal(calls[0].Params)
+	if calls[0].Method != "getAddressBalance" || string(params) != `{"address":"EQabc"}` {
+		t.Errorf("call %s %s", calls[0].Method, params)
+	}
+	params, _ = json.Marshal(calls[1].Params)
+	if calls[1].Method != "shards" || string(params) != `{"seqno":42}` {
+		t.Errorf("call %s %s", calls[1].Method, params)
+	}
+}
+
+func TestJSONRPCTransportBody(t *testing.T) {
+	var call JSONRPCRequest
+	c := jsonRPCServer(t, func(req JSONRPCRequest) string {
+		call = req
+		return `{"ok":true,"result":{"transactions":[]},"jsonrpc":"2.0","id":1}`
+	})
+	if _, err := c.GetTransactions(GetTransactionsRequest{Address: "EQabc", Limit: 5}); err != nil {
+		t.Fatal(err)
+	}
+	
+	// A request body is sent as the params unchanged
+	var params map[string]interface{}
+	raw, _ := json.Marshal(call.Params)
+	json.Unmarshal(raw, &params)
+	if call.Method != "getTransactions" || params["address"] != "EQabc" || params["limit"] != float64(5) {
+		t.Errorf("call %s %s", call.Method, raw)
+	}
+}
+
+func TestJSONRPCTransportErrors(t *testing.T) {
+	for name, resp := range map[string]string{
+		"error object": `{"jsonrpc":"2.0","error":{"code":-32601,"message":"method not found"},"id":1}`,
+		"string error": `{"ok":false,"error":"LITE_SERVER_UNKNOWN: cannot load block","code":500,"id":1}`,
+		"not ok":       `{"ok":false,"result":null,"id":1}`,
+	} {
+		c := jsonRPCServer(t, func(JSONRPCRequest) string { return resp })
+		if _, err := c.GetAddressBalance("EQabc"); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	
+	c := jsonRPCServer(t, func(JSONRPCRequest) string {
+		return `{"ok":false,"error":"LITE_SERVER_UNKNOWN: cannot load block","code":500,"id":1}`
+	})
+	if _, err := c.GetAddressBalance("EQabc"); err == nil || !strings.Contains(err.Error(), "cannot load block") {
+		t.Errorf("string error reported as %v", err)
+	}
+}
+
+func TestTransportModeString(t *testing.T) {
+	if TransportREST.String() != "rest" || TransportJSONRPC.String() != "jsonrpc" {
+		t.Errorf("names %s, %s", TransportREST, TransportJSONRPC)
+	}
+}
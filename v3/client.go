@@ -0,0 +1,180 @@
+// Package v3 is a client for the toncenter v3 indexer API (/api/v3). It shares
+// the transport, authentication and logging of a toncenterzp.Client.
+package v3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	
+	"github.com/zhaopeng331/toncenterzp"
+)
+
+// DefaultBaseURL is the default base URL of the v3 API
+const DefaultBaseURL = "https://toncenter.com/api/v3"
+
+// Constants for API endpoints
+const (
+	EndpointTransactions    = "/transactions"
+	EndpointMessages        = "/messages"
+	EndpointJettonTransfers = "/jetton/transfers"
+	EndpointNFTItems        = "/nft/items"
+	EndpointTraces          = "/traces"
+	EndpointActions         = "/actions"
+	EndpointAddressBook     = "/addressBook"
+	EndpointEmulate         = "/emulate"
+)
+
+// DefaultLimit is the page size used by iterators when no limit is given
+const DefaultLimit = 100
+
+// Client is a v3 API client
+type Client struct {
+	BaseURL string
+	
+	client *toncenterzp.Client
+}
+
+// New creates a v3 client sharing the transport of c. The base URL is derived from
+// c.BaseURL when it points at a v2 API path ("/api/v2"), otherwise DefaultBaseURL is used.
+func New(c *toncenterzp.Client) *Client {
+	baseURL := DefaultBaseURL
+	if i := strings.Index(c.BaseURL, "/api/v2"); i >= 0 {
+		baseURL = c.BaseURL[:i] + "/api/v3"
+	}
+	return NewWithBaseURL(c, baseURL)
+}
+
+// NewWithBaseURL creates a v3 client sharing the transport of c with an explicit base URL
+func NewWithBaseURL(c *toncenterzp.Client, baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  c,
+	}
+}
+
+// get performs a GET request with query parameters taken from params and decodes the response into out
+func (c *Client) get(endpoint string, params interface{}, out interface{}) error {
+	if query := encodeQuery(params); query != "" {
+		endpoint += "?" + query
+	}
+	
+	respBody, err := c.client.RawRequest(http.MethodGet, c.BaseURL, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("error unmarshaling response: %w (code: %d)", err, toncenterzp.ErrInvalidResponse)
+	}
+	
+	return nil
+}
+
+// post performs a POST request with a JSON body and decodes the response into out
+func (c *Client) post(endpoint string, body interface{}, out interface{}) error {
+	respBody, err := c.client.RawRequest(http.MethodPost, c.BaseURL, endpoint, body)
+	if err != nil {
+		return err
+	}
+	
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("error unmarshaling response: %w (code: %d)", err, toncenterzp.ErrInvalidResponse)
+	}
+	
+	return nil
+}
+
+// encodeQuery encodes the fields of a request struct tagged with `url:"name"`.
+// Zero values are omitted; slices repeat the parameter once per element.
+func encodeQuery(params interface{}) string {
+	if params == nil {
+		return ""
+	}
+	
+	v := reflect.ValueOf(params)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	
+	values := url.Values{}
+	addQueryFields(values, v)
+	return values.Encode()
+}
+
+// addQueryFields adds the tagged fields of struct value v, descending into embedded structs
+func addQueryFields(values url.Values, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if t.Field(i).Anonymous && field.Kind() == reflect.Struct {
+			addQueryFields(values, field)
+			continue
+		}
+		
+		name := t.Field(i).Tag.Get("url")
+		if name == "" || field.IsZero() {
+			continue
+		}
+		if field.Kind() == reflect.Pointer {
+			field = field.Elem()
+		}
+		
+		switch field.Kind() {
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				values.Add(name, fmt.Sprint(field.Index(j).Interface()))
+			}
+		case reflect.Bool:
+			values.Set(name, strconv.FormatBool(field.Bool()))
+		default:
+			values.Set(name, fmt.Sprint(field.Interface()))
+		}
+	}
+}
+
+// Page holds the limit/offset pagination parameters shared by list endpoints
+type Page struct {
+	Limit  int    `url:"limit"`
+	Offset int    `url:"offset"`
+	Sort   string `url:"sort"`
+}
+
+// Next returns the page following a page that returned n items
+func (p Page) Next(n int) Page {
+	p.Offset += n
+	return p
+}
+
+// AddressBookEntry is the user-friendly form of a raw address in an address book
+type AddressBookEntry struct {
+	UserFriendly string `json:"user_friendly"`
+	Domain       string `json:"domain,omitempty"`
+}
+
+// AddressBook maps raw addresses to their user-friendly forms
+type AddressBook map[string]AddressBookEntry
+
+// GetAddressBook returns the user-friendly forms of the given addresses
+func (c *Client) GetAddressBook(addresses []string) (AddressBook, error) {
+	if len(addresses) == 0 {
+		return AddressBook{}, nil
+	}
+	
+	params := struct {
+		Address []string `url:"address"`
+	}{Address: addresses}
+	
+	var book AddressBook
+	if err := c.get(EndpointAddressBook, params, &book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
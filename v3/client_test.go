@@ -0,0 +1,145 @@
+package v3
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp"
+)
+
+func TestNewBaseURL(t *testing.T) {
+	for base, want := range map[string]string{
+		"https://toncenter.com/api/v2":           "https://toncenter.com/api/v3",
+		"https://testnet.toncenter.com/api/v2/":  "https://testnet.toncenter.com/api/v3",
+		"http://localhost:8081/proxy/api/v2/x":   "http://localhost:8081/proxy/api/v3",
+		"https://provider.example/jsonrpc-only/": DefaultBaseURL,
+	} {
+		c := toncenterzp.NewClientWithOptions("", base, toncenterzp.DefaultTimeout)
+		if got := New(c).BaseURL; got != want {
+			t.Errorf("%s: base URL %s, want %s", base, got, want)
+		}
+	}
+	c := toncenterzp.NewClient("")
+	if got := NewWithBaseURL(c, "https://example.com/v3/").BaseURL; got != "https://example.com/v3" {
+		t.Errorf("explicit base URL %s", got)
+	}
+}
+
+func TestEncodeQuery(t *testing.T) {
+	workchain := 0
+	q, err := url.ParseQuery(encodeQuery(GetTransactionsRequest{
+		Account:   []string{"a", "b"},
+		Workchain: &workchain,
+		Page:      Page{Limit: 10, Sort: "desc"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{
+		"account":   {"a", "b"},
+		"workchain": {"0"},
+		"limit":     {"10"},
+		"sort":      {"desc"},
+	}
+	if q.Encode() != want.Encode() {
+		t.Errorf("query %s, want %s", q.Encode(), want.Encode())
+	}
+	if got := encodeQuery(nil); got != "" {
+		t.Errorf("nil params encoded as %q", got)
+	}
+	if got := encodeQuery(&GetTransactionsRequest{}); got != "" {
+		t.Errorf("zero params encoded as %q", got)
+	}
+}
+
+// transactionServer serves the transactions with the given logical times newest
+// first, honouring end_lt, offset and limit, and records the queries
+func transactionServer(t *testing.T, lts []int) (*Client, *[]url.Values) {
+	t.Helper()
+	var queries []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		queries = append(queries, q)
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		end, err := strconv.Atoi(q.Get("end_lt"))
+		if err != nil {
+			end = lts[0]
+		}
+		var txs []string
+		for i, lt := range lts {
+			if lt > end {
+				continue
+			}
+			if offset > 0 {
+				offset--
+				continue
+			}
+			if len(txs) == limit {
+				break
+			}
+			txs = append(txs, fmt.Sprintf(`{"hash":"h%d","lt":"%d"}`, i, lt))
+		}
+		fmt.Fprintf(w, `{"transactions":[%s]}`, strings.Join(txs, ","))
+	}))
+	t.Cleanup(srv.Close)
+	c := toncenterzp.NewClientWithOptions("", srv.URL+"/api/v2", toncenterzp.DefaultTimeout)
+	return New(c), &queries
+}
+
+// iterate collects the hashes of all transactions returned by it
+func iterate(t *testing.T, it *TransactionIterator) []string {
+	t.Helper()
+	var hashes []string
+	for {
+		txs, err := it.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(txs) == 0 {
+			return hashes
+		}
+		for _, tx := range txs {
+			hashes = append(hashes, tx.Hash)
+		}
+	}
+}
+
+func TestIterateTransactions(t *testing.T) {
+	// Transactions with logical times 10 down to 1
+	c, queries := transactionServer(t, []int{10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	it := c.IterateTransactions(GetTransactionsRequest{Account: []string{"a"}, Page: Page{Limit: 4, Offset: 3}})
+	if got := strings.Join(iterate(t, it), " "); got != "h0 h1 h2 h3 h4 h5 h6 h7 h8 h9" {
+		t.Errorf("hashes %v", got)
+	}
+	
+	// The cursor is the logical time of the last transaction; the offset only skips
+	// the transactions at that time already returned. The last short page ends the
+	// iteration without another request.
+	if len(*queries) != 3 {
+		t.Fatalf("%d requests, want 3", len(*queries))
+	}
+	for i, want := range [][2]string{{"", ""}, {"7", "1"}, {"3", "1"}} {
+		q := (*queries)[i]
+		if q.Get("end_lt") != want[0] || q.Get("offset") != want[1] || q.Get("sort") != "desc" || q.Get("account") != "a" {
+			t.Errorf("request %d: %s", i, q.Encode())
+		}
+	}
+}
+
+func TestIterateTransactionsSameLt(t *testing.T) {
+	// Pages of 2 split the transactions at 8 and, twice, the run at 5
+	c, queries := transactionServer(t, []int{9, 8, 8, 7, 5, 5, 5, 5, 5, 2})
+	it := c.IterateTransactions(GetTransactionsRequest{Page: Page{Limit: 2}})
+	if got := strings.Join(iterate(t, it), " "); got != "h0 h1 h2 h3 h4 h5 h6 h7 h8 h9" {
+		t.Errorf("hashes %v", got)
+	}
+	if len(*queries) != 6 {
+		t.Errorf("%d requests, want 6", len(*queries))
+	}
+}
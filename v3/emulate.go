@@ -0,0 +1,170 @@
+package v3
+
+import (
+	"encoding/json"
+	"fmt"
+	
+	"github.com/zhaopeng331/toncenterzp"
+)
+
+// EmulateRequest is the body of the /emulate endpoint
+type EmulateRequest struct {
+	Boc             string `json:"boc"`
+	IgnoreChksig    bool   `json:"ignore_chksig,omitempty"`
+	WithActions     bool   `json:"with_actions,omitempty"`
+	IncludeCodeData bool   `json:"include_code_data,omitempty"`
+}
+
+// EmulateTrace executes a message against the latest state of the network without
+// broadcasting it and returns the predicted trace. Transaction hashes in the trace are
+// those the transactions would have if the message were sent now.
+func (c *Client) EmulateTrace(req EmulateRequest) (*Trace, error) {
+	var trace Trace
+	if err := c.post(EndpointEmulate, req, &trace); err != nil {
+		return nil, err
+	}
+	if trace.Trace == nil {
+		return nil, toncenterzp.NewError(toncenterzp.ErrInvalidResponse, "emulation returned no transactions", nil)
+	}
+	return &trace, nil
+}
+
+// Emulate implements toncenterzp.Emulator with EmulateTrace
+func (c *Client) Emulate(req toncenterzp.EmulateRequest) (*toncenterzp.EmulateResult, error) {
+	trace, err := c.EmulateTrace(EmulateRequest{Boc: req.Boc, IgnoreChksig: req.IgnoreSignature})
+	if err != nil {
+		return nil, err
+	}
+	return emulateResult(trace, *trace.Trace)
+}
+
+// emulateResult converts a trace node and its children to the v2 transaction shape
+func emulateResult(trace *Trace, node TraceNode) (*toncenterzp.EmulateResult, error) {
+	tx, ok := trace.Transactions[node.TxHash]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s missing from trace (code: %d)", node.TxHash, toncenterzp.ErrInvalidResponse)
+	}
+	
+	details, err := transactionDetails(tx)
+	if err != nil {
+		return nil, err
+	}
+	
+	result := &toncenterzp.EmulateResult{Transaction: *details}
+	for _, child := range node.Children {
+		r, err := emulateResult(trace, child)
+		if err != nil {
+			return nil, err
+		}
+		result.Children = append(result.Children, *r)
+	}
+	return result, nil
+}
+
+// transactionDescription holds the phases of an ordinary transaction description
+type transactionDescription struct {
+	Type         string                    `json:"type"`
+	StoragePhase *toncenterzp.StoragePhase `json:"storage_ph"`
+	CreditPhase  *toncenterzp.CreditPhase  `json:"credit_ph"`
+	ComputePhase *struct {
+		toncenterzp.ComputePhase
+		Skipped bool   `json:"skipped"`
+		Reason  string `json:"reason"`
+		GasFees string `json:"gas_fees"`
+	} `json:"compute_ph"`
+	ActionPhase *toncenterzp.ActionPhase `json:"action"`
+	BouncePhase *struct {
+		Type string `json:"type"`
+		toncenterzp.BouncePhase
+	} `json:"bounce"`
+}
+
+// transactionDetails converts a v3 transaction to the v2 transaction shape
+func transactionDetails(tx Transaction) (*toncenterzp.TransactionDetails, error) {
+	details := &toncenterzp.TransactionDetails{
+		Fee:           tx.TotalFees,
+		TotalFees:     tx.TotalFees,
+		Now:           int(tx.Now),
+		OrigStatus:    tx.OrigStatus,
+		EndStatus:     tx.EndStatus,
+		AccountAddr:   tx.Account,
+		Lt:            tx.Lt,
+		Hash:          tx.Hash,
+		PrevTransHash: tx.PrevTransHash,
+		PrevTransLt:   tx.PrevTransLt,
+		OutMsgsCount:  len(tx.OutMsgs),
+		Description:   string(tx.Description),
+		BlockID: toncenterzp.BlockID{
+			Workchain: tx.BlockRef.Workchain,
+			Shard:     tx.BlockRef.Shard,
+			SeqNo:     tx.BlockRef.SeqNo,
+		},
+	}
+	if tx.InMsg != nil {
+		details.InMsg = message(*tx.InMsg)
+	}
+	for _, m := range tx.OutMsgs {
+		details.OutMsgs = append(details.OutMsgs, message(m))
+	}
+	
+	if len(tx.Description) == 0 {
+		return details, nil
+	}
+	var desc transactionDescription
+	if err := json.Unmarshal(tx.Description, &desc); err != nil {
+		return nil, fmt.Errorf("error unmarshaling transaction description: %w (code: %d)", err, toncenterzp.ErrInvalidResponse)
+	}
+	if desc.StoragePhase != nil {
+		details.StoragePhase = *desc.StoragePhase
+		details.StorageFee = desc.StoragePhase.StorageFeesCollected
+	}
+	if desc.CreditPhase != nil {
+		details.CreditPhase = *desc.CreditPhase
+	}
+	if desc.ComputePhase != nil {
+		details.ComputePhase = desc.ComputePhase.ComputePhase
+		if desc.ComputePhase.Skipped {
+			details.ComputePhase.SkippedReason = desc.ComputePhase.Reason
+		}
+		details.GasFee = desc.ComputePhase.GasFees
+	}
+	if desc.ActionPhase != nil {
+		details.ActionPhase = *desc.ActionPhase
+		details.FwdFee = desc.ActionPhase.TotalFwdFees
+	}
+	if desc.BouncePhase != nil {
+		details.BouncePhase = desc.BouncePhase.BouncePhase
+		details.BouncePhase.BounceType = desc.BouncePhase.Type
+	}
+	return details, nil
+}
+
+// message converts a v3 message to the v2 message shape
+func message(m Message) toncenterzp.Message {
+	msg := toncenterzp.Message{
+		Source:      m.Source,
+		Destination: m.Destination,
+		Value:       m.Value,
+		FwdFee:      m.FwdFee,
+		IhrFee:      m.IhrFee,
+		CreatedLt:   m.CreatedLt,
+	}
+	if m.Source == "" {
+		msg.MsgType = "ext_in_msg"
+	} else if m.Destination == "" {
+		msg.MsgType = "ext_out_msg"
+	} else {
+		msg.MsgType = "int_msg"
+	}
+	if m.MessageContent != nil {
+		msg.BodyHash = m.MessageContent.Hash
+		msg.MsgData.Body = m.MessageContent.Body
+		if d := m.MessageContent.Decoded; d != nil && d.Type == "text_comment" {
+			msg.MsgData.Text = d.Comment
+		}
+	}
+	if m.InitState != nil {
+		msg.MsgData.InitState = m.InitState.Body
+	}
+	return msg
+}
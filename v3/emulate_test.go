@@ -0,0 +1,121 @@
+package v3
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"github.com/zhaopeng331/toncenterzp"
+)
+
+// emulateTrace is an /emulate response: an ordinary transaction sending one internal
+// message whose destination has no state
+const emulateTrace = `{
+	"trace": {"tx_hash": "a", "children": [{"tx_hash": "b", "children": []}]},
+	"transactions": {
+		"a": {
+			"hash": "a",
+			"total_fees": "100",
+			"description": {
+				"type": "ord",
+				"storage_ph": {"storage_fees_collected": "5"},
+				"compute_ph": {"skipped": false, "success": true, "gas_fees": "60", "gas_used": "1500", "exit_code": 0},
+				"action": {"success": true, "valid": true, "total_fwd_fees": "35", "tot_actions": 1}
+			},
+			"out_msgs": [{"source": "x", "destination": "y", "value": "1000"}]
+		},
+		"b": {
+			"hash": "b",
+			"total_fees": "1",
+			"description": {"type": "ord", "compute_ph": {"skipped": true, "reason": "no_state"}}
+		}
+	}
+}`
+
+// emulateServer serves emulateTrace at /api/v3/emulate and records the requests
+func emulateServer(t *testing.T, requests *[]EmulateRequest) *toncenterzp.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3"+EndpointEmulate {
+			http.NotFound(w, r)
+			return
+		}
+		var req EmulateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		*requests = append(*requests, req)
+		w.Write([]byte(emulateTrace))
+	}))
+	t.Cleanup(srv.Close)
+	return toncenterzp.NewClientWithOptions("", srv.URL+"/api/v2", toncenterzp.DefaultTimeout)
+}
+
+func TestEmulate(t *testing.T) {
+	var requests []EmulateRequest
+	c := emulateServer(t, &requests)
+	c.Emulator = New(c)
+	
+	r, err := c.DryRun(toncenterzp.EmulateRequest{Boc: "te6cc", IgnoreSignature: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || requests[0].Boc != "te6cc" || !requests[0].IgnoreChksig {
+		t.Errorf("requests %+v", requests)
+	}
+	
+	tx := r.Transaction
+	if !r.Success() || tx.Hash != "a" || tx.TotalFees != "100" {
+		t.Errorf("transaction %s: success %v, fees %s", tx.Hash, r.Success(), tx.TotalFees)
+	}
+	if tx.GasFee != "60" || tx.FwdFee != "35" || tx.StorageFee != "5" || tx.ComputePhase.GasUsed != "1500" {
+		t.Errorf("gas fee %s, forward fee %s, storage fee %s, gas used %s", tx.GasFee, tx.FwdFee, tx.StorageFee, tx.ComputePhase.GasUsed)
+	}
+	if len(tx.OutMsgs) != 1 || tx.OutMsgs[0].MsgType != "int_msg" || tx.OutMsgs[0].Value != "1000" {
+		t.Errorf("outgoing messages %+v", tx.OutMsgs)
+	}
+	
+	if len(r.Children) != 1 {
+		t.Fatalf("%d children, want 1", len(r.Children))
+	}
+	child := r.Children[0]
+	if child.Transaction.Hash != "b" || child.Transaction.ComputePhase.SkippedReason != "no_state" || child.Success() {
+		t.Errorf("child %+v", child.Transaction)
+	}
+}
+
+func TestEmulateFallback(t *testing.T) {
+	var requests []EmulateRequest
+	c := emulateServer(t, &requests)
+	
+	// The first emulator has no endpoint, so the second one answers
+	c.Emulator = toncenterzp.Emulators{NewWithBaseURL(c, c.BaseURL+"/missing"), New(c)}
+	r, err := c.DryRun(toncenterzp.EmulateRequest{Boc: "te6cc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Transaction.Hash != "a" || len(requests) != 1 {
+		t.Errorf("transaction %s after %d requests", r.Transaction.Hash, len(requests))
+	}
+	
+	c.Emulator = toncenterzp.Emulators{NewWithBaseURL(c, c.BaseURL+"/missing")}
+	if _, err := c.DryRun(toncenterzp.EmulateRequest{Boc: "te6cc"}); err == nil {
+		t.Error("no error when every emulator fails")
+	}
+	c.Emulator = nil
+	if _, err := c.DryRun(toncenterzp.EmulateRequest{Boc: "te6cc"}); err == nil {
+		t.Error("no error without an emulator")
+	}
+}
+
+func TestEmulateMissingTransaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"trace": {"tx_hash": "a", "children": []}, "transactions": {}}`))
+	}))
+	defer srv.Close()
+	c := toncenterzp.NewClientWithOptions("", srv.URL+"/api/v2", toncenterzp.DefaultTimeout)
+	if _, err := New(c).Emulate(toncenterzp.EmulateRequest{Boc: "te6cc"}); err == nil {
+		t.Error("trace without its transaction accepted")
+	}
+}
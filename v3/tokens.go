@@ -0,0 +1,99 @@
+package v3
+
+import "encoding/json"
+
+// JettonTransfer is a jetton transfer as indexed by the v3 API
+type JettonTransfer struct {
+	QueryID             string `json:"query_id"`
+	Source              string `json:"source"`
+	Destination         string `json:"destination"`
+	Amount              string `json:"amount"`
+	SourceWallet        string `json:"source_wallet"`
+	JettonMaster        string `json:"jetton_master"`
+	TransactionHash     string `json:"transaction_hash"`
+	TransactionLt       string `json:"transaction_lt"`
+	TransactionNow      int64  `json:"transaction_now"`
+	TransactionAborted  bool   `json:"transaction_aborted"`
+	ResponseDestination string `json:"response_destination"`
+	CustomPayload       string `json:"custom_payload"`
+	ForwardTonAmount    string `json:"forward_ton_amount"`
+	ForwardPayload      string `json:"forward_payload"`
+	TraceID             string `json:"trace_id"`
+}
+
+// GetJettonTransfersRequest filters the /jetton/transfers endpoint
+type GetJettonTransfersRequest struct {
+	OwnerAddress []string `url:"owner_address"`
+	JettonWallet []string `url:"jetton_wallet"`
+	JettonMaster string   `url:"jetton_master"`
+	Direction    string   `url:"direction"`
+	StartUtime   int64    `url:"start_utime"`
+	EndUtime     int64    `url:"end_utime"`
+	StartLt      string   `url:"start_lt"`
+	EndLt        string   `url:"end_lt"`
+	Page
+}
+
+// GetJettonTransfersResponse is the response of the /jetton/transfers endpoint
+type GetJettonTransfersResponse struct {
+	JettonTransfers []JettonTransfer `json:"jetton_transfers"`
+	AddressBook     AddressBook      `json:"address_book"`
+}
+
+// GetJettonTransfers returns jetton transfers matching req
+func (c *Client) GetJettonTransfers(req GetJettonTransfersRequest) (*GetJettonTransfersResponse, error) {
+	var response GetJettonTransfersResponse
+	if err := c.get(EndpointJettonTransfers, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// NFTCollection is the collection an NFT item belongs to
+type NFTCollection struct {
+	Address           string          `json:"address"`
+	OwnerAddress      string          `json:"owner_address"`
+	LastTransactionLt string          `json:"last_transaction_lt"`
+	NextItemIndex     string          `json:"next_item_index"`
+	CollectionContent json.RawMessage `json:"collection_content"`
+	CodeHash          string          `json:"code_hash"`
+	DataHash          string          `json:"data_hash"`
+}
+
+// NFTItem is an NFT item as indexed by the v3 API
+type NFTItem struct {
+	Address           string          `json:"address"`
+	Init              bool            `json:"init"`
+	Index             string          `json:"index"`
+	CollectionAddress string          `json:"collection_address"`
+	OwnerAddress      string          `json:"owner_address"`
+	Content           json.RawMessage `json:"content"`
+	LastTransactionLt string          `json:"last_transaction_lt"`
+	CodeHash          string          `json:"code_hash"`
+	DataHash          string          `json:"data_hash"`
+	Collection        *NFTCollection  `json:"collection"`
+}
+
+// GetNFTItemsRequest filters the /nft/items endpoint
+type GetNFTItemsRequest struct {
+	Address           []string `url:"address"`
+	OwnerAddress      []string `url:"owner_address"`
+	CollectionAddress []string `url:"collection_address"`
+	Index             []string `url:"index"`
+	Page
+}
+
+// GetNFTItemsResponse is the response of the /nft/items endpoint
+type GetNFTItemsResponse struct {
+	NFTItems    []NFTItem   `json:"nft_items"`
+	AddressBook AddressBook `json:"address_book"`
+}
+
+// GetNFTItems returns NFT items matching req
+func (c *Client) GetNFTItems(req GetNFTItemsRequest) (*GetNFTItemsResponse, error) {
+	var response GetNFTItemsResponse
+	if err := c.get(EndpointNFTItems, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,301 @@
+package v3
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// BlockRef identifies the block a transaction belongs to
+type BlockRef struct {
+	Workchain int    `json:"workchain"`
+	Shard     string `json:"shard"`
+	SeqNo     int    `json:"seqno"`
+}
+
+// AccountState is a snapshot of an account before or after a transaction
+type AccountState struct {
+	Hash          string `json:"hash"`
+	Balance       string `json:"balance"`
+	AccountStatus string `json:"account_status"`
+	FrozenHash    string `json:"frozen_hash"`
+	CodeHash      string `json:"code_hash"`
+	DataHash      string `json:"data_hash"`
+}
+
+// MessageContent is the body or init state of a message
+type MessageContent struct {
+	Hash    string `json:"hash"`
+	Body    string `json:"body"`
+	Decoded *struct {
+		Type    string `json:"type"`
+		Comment string `json:"comment"`
+	} `json:"decoded"`
+}
+
+// Message is a message as indexed by the v3 API
+type Message struct {
+	Hash           string          `json:"hash"`
+	Source         string          `json:"source"`
+	Destination    string          `json:"destination"`
+	Value          string          `json:"value"`
+	FwdFee         string          `json:"fwd_fee"`
+	IhrFee         string          `json:"ihr_fee"`
+	CreatedLt      string          `json:"created_lt"`
+	CreatedAt      string          `json:"created_at"`
+	Opcode         string          `json:"opcode"`
+	IhrDisabled    bool            `json:"ihr_disabled"`
+	Bounce         bool            `json:"bounce"`
+	Bounced        bool            `json:"bounced"`
+	ImportFee      string          `json:"import_fee"`
+	MessageContent *MessageContent `json:"message_content"`
+	InitState      *MessageContent `json:"init_state"`
+}
+
+// Transaction is a transaction as indexed by the v3 API
+type Transaction struct {
+	Account            string          `json:"account"`
+	Hash               string          `json:"hash"`
+	Lt                 string          `json:"lt"`
+	Now                int64           `json:"now"`
+	McBlockSeqNo       int             `json:"mc_block_seqno"`
+	TraceID            string          `json:"trace_id"`
+	PrevTransHash      string          `json:"prev_trans_hash"`
+	PrevTransLt        string          `json:"prev_trans_lt"`
+	OrigStatus         string          `json:"orig_status"`
+	EndStatus          string          `json:"end_status"`
+	TotalFees          string          `json:"total_fees"`
+	Description        json.RawMessage `json:"description"`
+	BlockRef           BlockRef        `json:"block_ref"`
+	InMsg              *Message        `json:"in_msg"`
+	OutMsgs            []Message       `json:"out_msgs"`
+	AccountStateBefore *AccountState   `json:"account_state_before"`
+	AccountStateAfter  *AccountState   `json:"account_state_after"`
+}
+
+// GetTransactionsRequest filters the /transactions endpoint
+type GetTransactionsRequest struct {
+	Account        []string `url:"account"`
+	ExcludeAccount []string `url:"exclude_account"`
+	Hash           string   `url:"hash"`
+	Lt             string   `url:"lt"`
+	StartUtime     int64    `url:"start_utime"`
+	EndUtime       int64    `url:"end_utime"`
+	StartLt        string   `url:"start_lt"`
+	EndLt          string   `url:"end_lt"`
+	Workchain      *int     `url:"workchain"`
+	Shard          string   `url:"shard"`
+	SeqNo          int      `url:"seqno"`
+	McSeqNo        int      `url:"mc_seqno"`
+	Page
+}
+
+// GetTransactionsResponse is the response of the /transactions endpoint
+type GetTransactionsResponse struct {
+	Transactions []Transaction `json:"transactions"`
+	AddressBook  AddressBook   `json:"address_book"`
+}
+
+// GetTransactions returns transactions matching req
+func (c *Client) GetTransactions(req GetTransactionsRequest) (*GetTransactionsResponse, error) {
+	var response GetTransactionsResponse
+	if err := c.get(EndpointTransactions, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// TransactionIterator pages through /transactions newest first using the logical
+// time of the last returned transaction as a cursor, so transactions arriving while
+// iterating do not shift the pages. Transactions sharing that logical time are
+// skipped with an offset counting the ones already returned.
+type TransactionIterator struct {
+	client *Client
+	req    GetTransactionsRequest
+	done   bool
+}
+
+// IterateTransactions returns an iterator over transactions matching req. The Page
+// fields of req are managed by the iterator except for Limit.
+func (c *Client) IterateTransactions(req GetTransactionsRequest) *TransactionIterator {
+	if req.Limit <= 0 {
+		req.Limit = DefaultLimit
+	}
+	req.Offset = 0
+	req.Sort = "desc"
+	return &TransactionIterator{client: c, req: req}
+}
+
+// Next returns the next page of transactions; an empty page means the iteration is over
+func (it *TransactionIterator) Next() ([]Transaction, error) {
+	if it.done {
+		return nil, nil
+	}
+	
+	resp, err := it.client.GetTransactions(it.req)
+	if err != nil {
+		return nil, err
+	}
+	
+	txs := resp.Transactions
+	if len(txs) < it.req.Limit {
+		it.done = true
+	}
+	if len(txs) == 0 {
+		return nil, nil
+	}
+	
+	last := txs[len(txs)-1].Lt
+	if _, err := strconv.ParseUint(last, 10, 64); err != nil {
+		it.done = true
+		return txs, nil
+	}
+	
+	// Several transactions, of different accounts or split across pages, may share
+	// the last logical time; the next page starts after the ones returned so far
+	seen := 0
+	for _, tx := range txs {
+		if tx.Lt == last {
+			seen++
+		}
+	}
+	if last == it.req.EndLt {
+		it.req.Offset += seen
+	} else {
+		it.req.EndLt = last
+		it.req.Offset = seen
+	}
+	
+	return txs, nil
+}
+
+// GetMessagesRequest filters the /messages endpoint
+type GetMessagesRequest struct {
+	Hash        string `url:"msg_hash"`
+	BodyHash    string `url:"body_hash"`
+	Source      string `url:"source"`
+	Destination string `url:"destination"`
+	Opcode      string `url:"opcode"`
+	StartUtime  int64  `url:"start_utime"`
+	EndUtime    int64  `url:"end_utime"`
+	StartLt     string `url:"start_lt"`
+	EndLt       string `url:"end_lt"`
+	Direction   string `url:"direction"`
+	Page
+}
+
+// GetMessagesResponse is the response of the /messages endpoint
+type GetMessagesResponse struct {
+	Messages    []Message   `json:"messages"`
+	AddressBook AddressBook `json:"address_book"`
+}
+
+// GetMessages returns messages matching req
+func (c *Client) GetMessages(req GetMessagesRequest) (*GetMessagesResponse, error) {
+	var response GetMessagesResponse
+	if err := c.get(EndpointMessages, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// TraceNode is a node of the transaction tree of a trace
+type TraceNode struct {
+	TxHash    string      `json:"tx_hash"`
+	InMsgHash string      `json:"in_msg_hash"`
+	Children  []TraceNode `json:"children"`
+}
+
+// Trace is the tree of transactions caused by one external message
+type Trace struct {
+	TraceID           string                 `json:"trace_id"`
+	ExternalHash      string                 `json:"external_hash"`
+	McSeqNoStart      string                 `json:"mc_seqno_start"`
+	McSeqNoEnd        string                 `json:"mc_seqno_end"`
+	StartLt           string                 `json:"start_lt"`
+	StartUtime        int64                  `json:"start_utime"`
+	EndLt             string                 `json:"end_lt"`
+	EndUtime          int64                  `json:"end_utime"`
+	IsIncomplete      bool                   `json:"is_incomplete"`
+	TraceInfo         json.RawMessage        `json:"trace_info"`
+	Trace             *TraceNode             `json:"trace"`
+	TransactionsOrder []string               `json:"transactions_order"`
+	Transactions      map[string]Transaction `json:"transactions"`
+	Actions           []Action               `json:"actions"`
+}
+
+// GetTracesRequest filters the /traces endpoint
+type GetTracesRequest struct {
+	Account        string   `url:"account"`
+	TraceID        []string `url:"trace_id"`
+	TxHash         []string `url:"tx_hash"`
+	MsgHash        []string `url:"msg_hash"`
+	McSeqNo        int      `url:"mc_seqno"`
+	StartUtime     int64    `url:"start_utime"`
+	EndUtime       int64    `url:"end_utime"`
+	StartLt        string   `url:"start_lt"`
+	EndLt          string   `url:"end_lt"`
+	IncludeActions bool     `url:"include_actions"`
+	Page
+}
+
+// GetTracesResponse is the response of the /traces endpoint
+type GetTracesResponse struct {
+	Traces      []Trace     `json:"traces"`
+	AddressBook AddressBook `json:"address_book"`
+}
+
+// GetTraces returns traces matching req
+func (c *Client) GetTraces(req GetTracesRequest) (*GetTracesResponse, error) {
+	var response GetTracesResponse
+	if err := c.get(EndpointTraces, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Action is a high-level operation (transfer, swap, ...) recognized in a trace.
+// Details depend on Type and are left undecoded.
+type Action struct {
+	ActionID          string          `json:"action_id"`
+	Type              string          `json:"type"`
+	Success           bool            `json:"success"`
+	StartLt           string          `json:"start_lt"`
+	EndLt             string          `json:"end_lt"`
+	StartUtime        int64           `json:"start_utime"`
+	EndUtime          int64           `json:"end_utime"`
+	TraceID           string          `json:"trace_id"`
+	TraceExternalHash string          `json:"trace_external_hash"`
+	Transactions      []string        `json:"transactions"`
+	Details           json.RawMessage `json:"details"`
+}
+
+// GetActionsRequest filters the /actions endpoint
+type GetActionsRequest struct {
+	Account    string   `url:"account"`
+	TxHash     []string `url:"tx_hash"`
+	MsgHash    []string `url:"msg_hash"`
+	ActionID   []string `url:"action_id"`
+	TraceID    []string `url:"trace_id"`
+	McSeqNo    int      `url:"mc_seqno"`
+	StartUtime int64    `url:"start_utime"`
+	EndUtime   int64    `url:"end_utime"`
+	StartLt    string   `url:"start_lt"`
+	EndLt      string   `url:"end_lt"`
+	ActionType []string `url:"action_type"`
+	Page
+}
+
+// GetActionsResponse is the response of the /actions endpoint
+type GetActionsResponse struct {
+	Actions     []Action    `json:"actions"`
+	AddressBook AddressBook `json:"address_book"`
+}
+
+// GetActions returns actions matching req
+func (c *Client) GetActions(req GetActionsRequest) (*GetActionsResponse, error) {
+	var response GetActionsResponse
+	if err := c.get(EndpointActions, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,163 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// AdminHandler returns the admin HTTP API:
+//
+//	GET    /watches                   list watches
+//	POST   /watches                   add a watch: {"address": "...", "url": "...", "secret": "..."}
+//	GET    /watches/{id}              get a watch
+//	DELETE /watches/{id}              remove a watch
+//	GET    /watches/{id}/deliveries   recent deliveries of a watch (?limit=N, default 50)
+//
+// Every request must carry AdminToken as a bearer token; without an AdminToken all
+// requests are rejected. Secrets are only returned by POST /watches.
+func (d *Dispatcher) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.AdminToken == "" {
+			writeError(w, http.StatusForbidden, "admin token not configured")
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(d.AdminToken)) != 1 {
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) == 0 || parts[0] != "watches" {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		
+		switch {
+		case len(parts) == 1 && r.Method == http.MethodGet:
+			d.listWatches(w)
+		case len(parts) == 1 && r.Method == http.MethodPost:
+			d.createWatch(w, r)
+		case len(parts) == 2 && r.Method == http.MethodGet:
+			d.getWatch(w, parts[1])
+		case len(parts) == 2 && r.Method == http.MethodDelete:
+			d.deleteWatch(w, parts[1])
+		case len(parts) == 3 && parts[2] == "deliveries" && r.Method == http.MethodGet:
+			d.listDeliveries(w, r, parts[1])
+		case len(parts) <= 3:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+		}
+	})
+}
+
+// listWatches handles GET /watches
+func (d *Dispatcher) listWatches(w http.ResponseWriter) {
+	watches, err := d.Store.Watches()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i := range watches {
+		watches[i].Secret = ""
+	}
+	writeJSON(w, http.StatusOK, watches)
+}
+
+// createWatch handles POST /watches
+func (d *Dispatcher) createWatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Address string `json:"address"`
+		URL     string `json:"url"`
+		Secret  string `json:"secret"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Address == "" || req.URL == "" {
+		writeError(w, http.StatusBadRequest, "address and url are required")
+		return
+	}
+	
+	if err := validateURL(req.URL); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	
+	watch, err := d.AddWatch(req.Address, req.URL, req.Secret)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, watch)
+}
+
+// getWatch handles GET /watches/{id}
+func (d *Dispatcher) getWatch(w http.ResponseWriter, id string) {
+	watch, err := d.Store.Watch(id)
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, "watch not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	watch.Secret = ""
+	writeJSON(w, http.StatusOK, watch)
+}
+
+// deleteWatch handles DELETE /watches/{id}
+func (d *Dispatcher) deleteWatch(w http.ResponseWriter, id string) {
+	err := d.RemoveWatch(id)
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, "watch not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listDeliveries handles GET /watches/{id}/deliveries
+func (d *Dispatcher) listDeliveries(w http.ResponseWriter, r *http.Request, id string) {
+	limit := 50
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+	
+	deliveries, err := d.Store.Deliveries(id, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if deliveries == nil {
+		deliveries = []Delivery{}
+	}
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
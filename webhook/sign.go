@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every webhook request
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+// Sign computes the signature of a payload: "sha256=" followed by the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the watch secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a received signature and rejects timestamps further than tolerance
+// from now, for use by webhook receivers. A zero tolerance disables the time check.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if tolerance > 0 {
+		diff := time.Since(time.Unix(ts, 0))
+		if diff > tolerance || diff < -tolerance {
+			return false
+		}
+	}
+	
+	expected := Sign(secret, ts, body)
+	return hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature)))
+}
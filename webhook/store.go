@@ -0,0 +1,371 @@
+package webhook
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a Store for unknown watches or deliveries
+var ErrNotFound = errors.New("webhook: not found")
+
+// Watch is a watched address and the URL its transactions are delivered to
+type Watch struct {
+	ID        string    `json:"id"`
+	Address   string    `json:"address"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	
+	// LastLt and LastHash identify the newest transaction already dispatched
+	LastLt   string `json:"last_lt,omitempty"`
+	LastHash string `json:"last_hash,omitempty"`
+}
+
+// Delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery is one webhook call and the outcome of its attempts
+type Delivery struct {
+	ID          string          `json:"id"`
+	WatchID     string          `json:"watch_id"`
+	URL         string          `json:"url"`
+	TxHash      string          `json:"tx_hash"`
+	TxLt        string          `json:"tx_lt"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	StatusCode  int             `json:"status_code,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	NextAttempt time.Time       `json:"next_attempt,omitempty"`
+}
+
+// Store persists watches and the delivery log. Implementations must be safe for concurrent use.
+type Store interface {
+	// Watches returns all watches
+	Watches() ([]Watch, error)
+	
+	// Watch returns a watch by ID
+	Watch(id string) (*Watch, error)
+	
+	// PutWatch creates or replaces a watch
+	PutWatch(w Watch) error
+	
+	// AdvanceWatch moves the cursor of a watch to a transaction. Unlike PutWatch it
+	// does not recreate a deleted watch but returns ErrNotFound.
+	AdvanceWatch(id, lt, hash string) error
+	
+	// DeleteWatch removes a watch
+	DeleteWatch(id string) error
+	
+	// Delivery returns a delivery by ID
+	Delivery(id string) (*Delivery, error)
+	
+	// PutDelivery creates or updates a delivery
+	PutDelivery(d Delivery) error
+	
+	// Deliveries returns the most recent deliveries of a watch, newest first;
+	// limit <= 0 returns all of them
+	Deliveries(watchID string, limit int) ([]Delivery, error)
+	
+	// PendingDeliveries returns deliveries that still need to be attempted
+	PendingDeliveries() ([]Delivery, error)
+}
+
+// MemoryStore is a Store that keeps everything in memory
+type MemoryStore struct {
+	mu         sync.Mutex
+	watches    map[string]Watch
+	deliveries map[string]Delivery
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		watches:    map[string]Watch{},
+		deliveries: map[string]Delivery{},
+	}
+}
+
+// Watches returns all watches ordered by creation time
+func (s *MemoryStore) Watches() ([]Watch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	watches := make([]Watch, 0, len(s.watches))
+	for _, w := range s.watches {
+		watches = append(watches, w)
+	}
+	sort.Slice(watches, func(i, j int) bool { return watches[i].CreatedAt.Before(watches[j].CreatedAt) })
+	return watches, nil
+}
+
+// Watch returns a watch by ID
+func (s *MemoryStore) Watch(id string) (*Watch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	w, ok := s.watches[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &w, nil
+}
+
+// PutWatch creates or replaces a watch
+func (s *MemoryStore) PutWatch(w Watch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watches[w.ID] = w
+	return nil
+}
+
+// AdvanceWatch moves the cursor of a watch
+func (s *MemoryStore) AdvanceWatch(id, lt, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	w, ok := s.watches[id]
+	if !ok {
+		return ErrNotFound
+	}
+	w.LastLt = lt
+	w.LastHash = hash
+	s.watches[id] = w
+	return nil
+}
+
+// DeleteWatch removes a watch
+func (s *MemoryStore) DeleteWatch(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if _, ok := s.watches[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.watches, id)
+	return nil
+}
+
+// Delivery returns a delivery by ID
+func (s *MemoryStore) Delivery(id string) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	d, ok := s.deliveries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &d, nil
+}
+
+// PutDelivery creates or updates a delivery
+func (s *MemoryStore) PutDelivery(d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+// Deliveries returns the most recent deliveries of a watch
+func (s *MemoryStore) Deliveries(watchID string, limit int) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var deliveries []Delivery
+	for _, d := range s.deliveries {
+		if d.WatchID == watchID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return newestFirst(deliveries, limit), nil
+}
+
+// PendingDeliveries returns deliveries that still need to be attempted
+func (s *MemoryStore) PendingDeliveries() ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	var deliveries []Delivery
+	for _, d := range s.deliveries {
+		if d.Status == DeliveryPending {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt) })
+	return deliveries, nil
+}
+
+// newestFirst sorts deliveries by creation time, newest first, and truncates to limit
+func newestFirst(deliveries []Delivery, limit int) []Delivery {
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt) })
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries
+}
+
+// FileStore is a Store backed by a directory: watches are kept in watches.json and
+// every delivery state change is appended to deliveries.jsonl, which doubles as an
+// audit log. The log is replayed on open.
+type FileStore struct {
+	mem *MemoryStore
+	dir string
+	
+	mu  sync.Mutex
+	log *os.File
+}
+
+// OpenFileStore opens or creates a file store in dir
+func OpenFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating store directory: %w", err)
+	}
+	
+	s := &FileStore{mem: NewMemoryStore(), dir: dir}
+	
+	data, err := os.ReadFile(filepath.Join(dir, "watches.json"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("error reading watches: %w", err)
+	default:
+		var watches []Watch
+		if err := json.Unmarshal(data, &watches); err != nil {
+			return nil, fmt.Errorf("error parsing watches: %w", err)
+		}
+		for _, w := range watches {
+			s.mem.watches[w.ID] = w
+		}
+	}
+	
+	logPath := filepath.Join(dir, "deliveries.jsonl")
+	if f, err := os.Open(logPath); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64<<10), 16<<20)
+		for scanner.Scan() {
+			var d Delivery
+			if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+				continue
+			}
+			s.mem.deliveries[d.ID] = d
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("error reading delivery log: %w", err)
+		}
+	}
+	
+	s.log, err = os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening delivery log: %w", err)
+	}
+	
+	return s, nil
+}
+
+// Close closes the delivery log
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.log.Close()
+}
+
+// Watches returns all watches ordered by creation time
+func (s *FileStore) Watches() ([]Watch, error) {
+	return s.mem.Watches()
+}
+
+// Watch returns a watch by ID
+func (s *FileStore) Watch(id string) (*Watch, error) {
+	return s.mem.Watch(id)
+}
+
+// PutWatch creates or replaces a watch and rewrites watches.json
+func (s *FileStore) PutWatch(w Watch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	s.mem.PutWatch(w)
+	return s.saveWatches()
+}
+
+// AdvanceWatch moves the cursor of a watch and rewrites watches.json
+func (s *FileStore) AdvanceWatch(id, lt, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if err := s.mem.AdvanceWatch(id, lt, hash); err != nil {
+		return err
+	}
+	return s.saveWatches()
+}
+
+// DeleteWatch removes a watch and rewrites watches.json
+func (s *FileStore) DeleteWatch(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if err := s.mem.DeleteWatch(id); err != nil {
+		return err
+	}
+	return s.saveWatches()
+}
+
+// Delivery returns a delivery by ID
+func (s *FileStore) Delivery(id string) (*Delivery, error) {
+	return s.mem.Delivery(id)
+}
+
+// PutDelivery records a delivery state and appends it to the delivery log
+func (s *FileStore) PutDelivery(d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	line, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if _, err := s.log.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing delivery log: %w", err)
+	}
+	return s.mem.PutDelivery(d)
+}
+
+// Deliveries returns the most recent deliveries of a watch
+func (s *FileStore) Deliveries(watchID string, limit int) ([]Delivery, error) {
+	return s.mem.Deliveries(watchID, limit)
+}
+
+// PendingDeliveries returns deliveries that still need to be attempted
+func (s *FileStore) PendingDeliveries() ([]Delivery, error) {
+	return s.mem.PendingDeliveries()
+}
+
+// saveWatches atomically rewrites watches.json; the caller must hold the lock
+func (s *FileStore) saveWatches() error {
+	watches, _ := s.mem.Watches()
+	data, err := json.MarshalIndent(watches, "", "  ")
+	if err != nil {
+		return err
+	}
+	
+	tmp := filepath.Join(s.dir, "watches.json.tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("error writing watches: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, "watches.json"))
+}
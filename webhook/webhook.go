@@ -0,0 +1,494 @@
+// Package webhook calls HTTP endpoints when watched addresses get new transactions.
+//
+// A Dispatcher polls GetTransactions for every watch, persists a pending delivery
+// for each new transaction, and posts a signed JSON payload to the watch URL,
+// retrying with exponential backoff. Watches, cursors and the delivery log live in
+// a Store, so a restarted dispatcher resumes where it stopped. AdminHandler exposes
+// a small HTTP API to manage watches.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"sync"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp"
+)
+
+// Default dispatcher settings
+const (
+	DefaultPollInterval   = 10 * time.Second
+	DefaultPageSize       = 50
+	DefaultMaxAttempts    = 8
+	DefaultInitialBackoff = 2 * time.Second
+	DefaultMaxBackoff     = 10 * time.Minute
+	DefaultWorkers        = 4
+)
+
+// EventTransaction is the event type of payloads for new transactions
+const EventTransaction = "transaction"
+
+// Payload is the JSON body posted to a watch URL
+type Payload struct {
+	DeliveryID  string                         `json:"delivery_id"`
+	WatchID     string                         `json:"watch_id"`
+	Event       string                         `json:"event"`
+	Address     string                         `json:"address"`
+	Transaction toncenterzp.TransactionDetails `json:"transaction"`
+}
+
+// Dispatcher detects new transactions of watched addresses and delivers webhooks
+type Dispatcher struct {
+	Client     *toncenterzp.Client
+	Store      Store
+	HTTPClient *http.Client
+	Logger     *slog.Logger
+	
+	PollInterval   time.Duration
+	PageSize       int
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Workers        int
+	
+	// AdminToken must be sent as a bearer token to the admin API, which rejects
+	// every request while it is empty
+	AdminToken string
+	
+	queue chan string
+	
+	mu       sync.Mutex
+	inFlight map[string]bool
+}
+
+// NewDispatcher creates a dispatcher with default settings
+func NewDispatcher(client *toncenterzp.Client, store Store) *Dispatcher {
+	return &Dispatcher{
+		Client:         client,
+		Store:          store,
+		HTTPClient:     &http.Client{Timeout: 15 * time.Second},
+		PollInterval:   DefaultPollInterval,
+		PageSize:       DefaultPageSize,
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Workers:        DefaultWorkers,
+	}
+}
+
+// AddWatch starts watching an address. Only transactions after the call are delivered.
+// url must be an absolute http or https URL. A random secret is generated when secret
+// is empty.
+func (d *Dispatcher) AddWatch(address, url, secret string) (*Watch, error) {
+	if address == "" || url == "" {
+		return nil, toncenterzp.NewError(toncenterzp.ErrInvalidParams, "address and url are required", nil)
+	}
+	if err := validateURL(url); err != nil {
+		return nil, err
+	}
+	if secret == "" {
+		secret = randomID()
+	}
+	
+	w := Watch{
+		ID:        randomID(),
+		Address:   address,
+		URL:       url,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}
+	
+	latest, err := d.Client.GetTransactions(toncenterzp.GetTransactionsRequest{Address: address, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if txs := latest.Result.Transactions; len(txs) > 0 {
+		w.LastLt = txs[0].Lt
+		w.LastHash = txs[0].Hash
+	}
+	
+	if err := d.Store.PutWatch(w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// RemoveWatch stops watching; pending deliveries of the watch are marked failed
+// instead of being sent
+func (d *Dispatcher) RemoveWatch(id string) error {
+	return d.Store.DeleteWatch(id)
+}
+
+// validateURL checks that a watch URL is an absolute http or https URL
+func validateURL(raw string) error {
+	u, err := neturl.Parse(raw)
+	if err != nil {
+		return toncenterzp.NewError(toncenterzp.ErrInvalidParams, "invalid url", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return toncenterzp.NewError(toncenterzp.ErrInvalidParams, "url must be an absolute http or https URL", nil)
+	}
+	return nil
+}
+
+// Run polls the watches and delivers webhooks until ctx is done or the pending
+// deliveries cannot be loaded. The workers have stopped when it returns.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	workers := d.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	
+	d.mu.Lock()
+	d.queue = make(chan string, 1024)
+	d.inFlight = map[string]bool{}
+	d.mu.Unlock()
+	
+	// The workers stop with ctx, or when Run fails before that
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.worker(ctx)
+		}()
+	}
+	
+	// Resume deliveries that were pending when the dispatcher last stopped
+	pending, err := d.Store.PendingDeliveries()
+	if err != nil {
+		return err
+	}
+	for _, delivery := range pending {
+		d.schedule(ctx, delivery.ID, time.Until(delivery.NextAttempt))
+	}
+	
+	interval := d.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		d.pollAll(ctx)
+		
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollAll checks every watch for new transactions
+func (d *Dispatcher) pollAll(ctx context.Context) {
+	watches, err := d.Store.Watches()
+	if err != nil {
+		d.log(slog.LevelError, "error listing watches", slog.String("error", err.Error()))
+		return
+	}
+	
+	for _, w := range watches {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := d.pollWatch(ctx, w); err != nil {
+			d.log(slog.LevelWarn, "error polling watch",
+				slog.String("watch", w.ID),
+				slog.String("address", w.Address),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// pollWatch creates deliveries for the transactions of a watch newer than its cursor
+func (d *Dispatcher) pollWatch(ctx context.Context, w Watch) error {
+	lastLt, _ := strconv.ParseUint(w.LastLt, 10, 64)
+	pageSize := d.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	
+	var fresh []toncenterzp.TransactionDetails
+	req := toncenterzp.GetTransactionsRequest{Address: w.Address, Limit: pageSize}
+	for {
+		resp, err := d.Client.GetTransactions(req)
+		if err != nil {
+			return err
+		}
+		
+		txs := resp.Result.Transactions
+		if req.Hash != "" && len(txs) > 0 && txs[0].Hash == req.Hash {
+			txs = txs[1:]
+		}
+		
+		done := len(txs) == 0 || len(resp.Result.Transactions) < pageSize
+		for _, tx := range txs {
+			lt, err := strconv.ParseUint(tx.Lt, 10, 64)
+			if err != nil || lt <= lastLt {
+				done = true
+				break
+			}
+			fresh = append(fresh, tx)
+		}
+		if done {
+			break
+		}
+		
+		req.Lt = txs[len(txs)-1].Lt
+		req.Hash = txs[len(txs)-1].Hash
+	}
+	
+	// Deliver oldest first and advance the cursor after every persisted delivery
+	for i := len(fresh) - 1; i >= 0; i-- {
+		tx := fresh[i]
+		id := w.ID + ":" + tx.Hash
+		
+		if _, err := d.Store.Delivery(id); errors.Is(err, ErrNotFound) {
+			payload, err := json.Marshal(Payload{
+				DeliveryID:  id,
+				WatchID:     w.ID,
+				Event:       EventTransaction,
+				Address:     w.Address,
+				Transaction: tx,
+			})
+			if err != nil {
+				return err
+			}
+			
+			now := time.Now().UTC()
+			delivery := Delivery{
+				ID:        id,
+				WatchID:   w.ID,
+				URL:       w.URL,
+				TxHash:    tx.Hash,
+				TxLt:      tx.Lt,
+				Payload:   payload,
+				Status:    DeliveryPending,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := d.Store.PutDelivery(delivery); err != nil {
+				return err
+			}
+			d.schedule(ctx, id, 0)
+		}
+		
+		// The watch may have been removed while polling; it must stay removed
+		err := d.Store.AdvanceWatch(w.ID, tx.Lt, tx.Hash)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// schedule queues a delivery attempt after delay
+func (d *Dispatcher) schedule(ctx context.Context, id string, delay time.Duration) {
+	enqueue := func() {
+		d.mu.Lock()
+		if d.inFlight[id] {
+			d.mu.Unlock()
+			return
+		}
+		d.inFlight[id] = true
+		d.mu.Unlock()
+		
+		select {
+		case d.queue <- id:
+		case <-ctx.Done():
+		}
+	}
+	
+	if delay <= 0 {
+		go enqueue()
+		return
+	}
+	time.AfterFunc(delay, func() {
+		if ctx.Err() == nil {
+			enqueue()
+		}
+	})
+}
+
+// worker performs queued delivery attempts
+func (d *Dispatcher) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-d.queue:
+			retry, delay := d.attempt(ctx, id)
+			
+			d.mu.Lock()
+			delete(d.inFlight, id)
+			d.mu.Unlock()
+			
+			if retry {
+				d.schedule(ctx, id, delay)
+			}
+		}
+	}
+}
+
+// attempt posts a delivery once, records the outcome and reports whether and when to retry
+func (d *Dispatcher) attempt(ctx context.Context, id string) (bool, time.Duration) {
+	delivery, err := d.Store.Delivery(id)
+	if err != nil || delivery.Status != DeliveryPending {
+		return false, 0
+	}
+	
+	// A delivery is never sent unsigned: without its watch there is no secret
+	w, err := d.Store.Watch(delivery.WatchID)
+	if errors.Is(err, ErrNotFound) {
+		delivery.Status = DeliveryFailed
+		delivery.Error = "watch removed"
+		delivery.NextAttempt = time.Time{}
+		delivery.UpdatedAt = time.Now().UTC()
+		if err := d.Store.PutDelivery(*delivery); err != nil {
+			d.log(slog.LevelError, "error recording delivery", slog.String("delivery", id), slog.String("error", err.Error()))
+		}
+		return false, 0
+	}
+	if err != nil {
+		d.log(slog.LevelError, "error loading watch", slog.String("delivery", id), slog.String("error", err.Error()))
+		return true, d.backoff(delivery.Attempts + 1)
+	}
+	
+	status, err := d.post(ctx, delivery, w.Secret)
+	if ctx.Err() != nil {
+		return false, 0
+	}
+	
+	delivery.Attempts++
+	delivery.StatusCode = status
+	delivery.UpdatedAt = time.Now().UTC()
+	delivery.Error = ""
+	
+	switch {
+	case err == nil:
+		delivery.Status = DeliveryDelivered
+		delivery.NextAttempt = time.Time{}
+	case delivery.Attempts >= d.maxAttempts():
+		delivery.Status = DeliveryFailed
+		delivery.Error = err.Error()
+		delivery.NextAttempt = time.Time{}
+	default:
+		delivery.Error = err.Error()
+		delivery.NextAttempt = delivery.UpdatedAt.Add(d.backoff(delivery.Attempts))
+	}
+	
+	if err := d.Store.PutDelivery(*delivery); err != nil {
+		d.log(slog.LevelError, "error recording delivery", slog.String("delivery", id), slog.String("error", err.Error()))
+		return false, 0
+	}
+	
+	level := slog.LevelDebug
+	if delivery.Status != DeliveryDelivered {
+		level = slog.LevelWarn
+	}
+	d.log(level, "webhook attempt",
+		slog.String("delivery", id),
+		slog.String("status", delivery.Status),
+		slog.Int("attempts", delivery.Attempts),
+		slog.Int("status_code", status),
+		slog.String("error", delivery.Error),
+	)
+	
+	return delivery.Status == DeliveryPending, time.Until(delivery.NextAttempt)
+}
+
+// post sends the signed payload of a delivery and returns the response status
+func (d *Dispatcher) post(ctx context.Context, delivery *Delivery, secret string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, delivery.Payload))
+	
+	httpClient := d.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// maxAttempts returns the configured attempt limit
+func (d *Dispatcher) maxAttempts() int {
+	if d.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return d.MaxAttempts
+}
+
+// backoff returns the delay before the attempt following the given number of attempts
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.InitialBackoff
+	if delay <= 0 {
+		delay = DefaultInitialBackoff
+	}
+	maxDelay := d.MaxBackoff
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxBackoff
+	}
+	
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// log writes a record if a logger is configured
+func (d *Dispatcher) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	if d.Logger == nil {
+		return
+	}
+	d.Logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// randomID returns a random 128-bit hex identifier
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
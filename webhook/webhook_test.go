@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	
+	"github.com/zhaopeng331/toncenterzp"
+)
+
+// chain is a backend returning fixed transactions, newest first, and running
+// onRequest before answering
+type chain struct {
+	toncenterzp.UnimplementedBackend
+	
+	txs       []toncenterzp.TransactionDetails
+	onRequest func()
+}
+
+func (c *chain) GetTransactions(req toncenterzp.GetTransactionsRequest) (*toncenterzp.GetTransactionsResponse, error) {
+	if c.onRequest != nil {
+		c.onRequest()
+	}
+	resp := &toncenterzp.GetTransactionsResponse{OK: true}
+	resp.Result.Transactions = c.txs
+	return resp, nil
+}
+
+func newDispatcher(backend toncenterzp.Backend, store Store) *Dispatcher {
+	client := toncenterzp.NewClient("")
+	client.Backend = backend
+	return NewDispatcher(client, store)
+}
+
+func TestPollWatchKeepsRemovedWatchRemoved(t *testing.T) {
+	store := NewMemoryStore()
+	w := Watch{ID: "w1", Address: "EQ...", URL: "http://localhost/hook", Secret: "s", LastLt: "1"}
+	store.PutWatch(w)
+	
+	backend := &chain{txs: []toncenterzp.TransactionDetails{{Lt: "3", Hash: "h3"}, {Lt: "2", Hash: "h2"}}}
+	d := newDispatcher(backend, store)
+	backend.onRequest = func() { d.RemoveWatch(w.ID) }
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.queue = make(chan string, 16)
+	d.inFlight = map[string]bool{}
+	if err := d.pollWatch(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Watch(w.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("watch after poll: %v, want ErrNotFound", err)
+	}
+}
+
+func TestPollWatchAdvancesCursor(t *testing.T) {
+	store := NewMemoryStore()
+	w := Watch{ID: "w1", Address: "EQ...", URL: "http://localhost/hook", Secret: "s", LastLt: "1"}
+	store.PutWatch(w)
+	
+	d := newDispatcher(&chain{txs: []toncenterzp.TransactionDetails{{Lt: "3", Hash: "h3"}, {Lt: "2", Hash: "h2"}}}, store)
+	d.queue = make(chan string, 16)
+	d.inFlight = map[string]bool{}
+	if err := d.pollWatch(context.Background(), w); err != nil {
+		t.Fatal(err)
+	}
+	
+	got, err := store.Watch(w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LastLt != "3" || got.LastHash != "h3" {
+		t.Errorf("cursor %s/%s, want 3/h3", got.LastLt, got.LastHash)
+	}
+	pending, _ := store.PendingDeliveries()
+	if len(pending) != 2 {
+		t.Errorf("%d pending deliveries, want 2", len(pending))
+	}
+}
+
+func TestAttemptSignsWithWatchSecret(t *testing.T) {
+	var verified int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if Verify("secret", r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body, time.Minute) {
+			atomic.AddInt32(&verified, 1)
+		}
+	}))
+	defer target.Close()
+	
+	store := NewMemoryStore()
+	store.PutWatch(Watch{ID: "w1", URL: target.URL, Secret: "secret"})
+	store.PutDelivery(Delivery{ID: "w1:h", WatchID: "w1", URL: target.URL, Payload: []byte(`{}`), Status: DeliveryPending})
+	
+	d := newDispatcher(&chain{}, store)
+	if retry, _ := d.attempt(context.Background(), "w1:h"); retry {
+		t.Fatal("delivery retried")
+	}
+	if atomic.LoadInt32(&verified) != 1 {
+		t.Error("receiver could not verify the signature")
+	}
+	if got, _ := store.Delivery("w1:h"); got.Status != DeliveryDelivered {
+		t.Errorf("status %s, want %s", got.Status, DeliveryDelivered)
+	}
+}
+
+func TestAttemptSkipsRemovedWatch(t *testing.T) {
+	var requests int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer target.Close()
+	
+	store := NewMemoryStore()
+	store.PutDelivery(Delivery{ID: "w1:h", WatchID: "w1", URL: target.URL, Payload: []byte(`{}`), Status: DeliveryPending})
+	
+	d := newDispatcher(&chain{}, store)
+	if retry, _ := d.attempt(context.Background(), "w1:h"); retry {
+		t.Fatal("delivery retried")
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("%d requests sent for a removed watch", n)
+	}
+	if got, _ := store.Delivery("w1:h"); got.Status != DeliveryFailed {
+		t.Errorf("status %s, want %s", got.Status, DeliveryFailed)
+	}
+}
+
+// brokenStore fails to list pending deliveries and counts the deliveries read
+type brokenStore struct {
+	*MemoryStore
+	reads int32
+}
+
+func (s *brokenStore) PendingDeliveries() ([]Delivery, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func (s *brokenStore) Delivery(id string) (*Delivery, error) {
+	atomic.AddInt32(&s.reads, 1)
+	return s.MemoryStore.Delivery(id)
+}
+
+func TestRunStopsWorkersOnStoreError(t *testing.T) {
+	store := &brokenStore{MemoryStore: NewMemoryStore()}
+	d := newDispatcher(&chain{}, store)
+	if err := d.Run(context.Background()); err == nil {
+		t.Fatal("no error")
+	}
+	
+	// No worker is left to pick up queued deliveries
+	d.queue <- "w1:h"
+	time.Sleep(50 * time.Millisecond)
+	if n := atomic.LoadInt32(&store.reads); n != 0 || len(d.queue) != 1 {
+		t.Errorf("delivery picked up after Run returned: %d reads, %d queued", n, len(d.queue))
+	}
+}
+
+func TestAdminHandlerAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{"no admin token", "", "", http.StatusForbidden},
+		{"no admin token, empty bearer", "", "Bearer ", http.StatusForbidden},
+		{"missing header", "t0ken", "", http.StatusUnauthorized},
+		{"wrong token", "t0ken", "Bearer nope", http.StatusUnauthorized},
+		{"token without scheme", "t0ken", "t0ken", http.StatusUnauthorized},
+		{"valid token", "t0ken", "Bearer t0ken", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDispatcher(&chain{}, NewMemoryStore())
+			d.AdminToken = tt.token
+			
+			req := httptest.NewRequest(http.MethodGet, "/watches", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			d.AdminHandler().ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("status %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestAdminHandlerRejectsInvalidURL(t *testing.T) {
+	d := newDispatcher(&chain{}, NewMemoryStore())
+	d.AdminToken = "t0ken"
+	
+	for _, url := range []string{"file:///etc/passwd", "/relative", "http://"} {
+		req := httptest.NewRequest(http.MethodPost, "/watches", strings.NewReader(`{"address":"EQ...","url":"`+url+`"}`))
+		req.Header.Set("Authorization", "Bearer t0ken")
+		rec := httptest.NewRecorder()
+		d.AdminHandler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", url, rec.Code, http.StatusBadRequest)
+		}
+	}
+}